@@ -2,10 +2,13 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -17,7 +20,9 @@ import (
 	"github.com/xernobyl/formbricks_worktrial/internal/config"
 	"github.com/xernobyl/formbricks_worktrial/internal/repository"
 	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/blob"
 	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
 )
 
 // @title Formbricks Hub API
@@ -50,19 +55,111 @@ func main() {
 	}
 	defer db.Close()
 
+	// The PII vault keeps masked originals of value_text encrypted
+	var vault *encryption.Cipher
+	if cfg.PIIVaultKey != "" {
+		vault, err = encryption.NewCipherFromBase64(cfg.PIIVaultKey)
+		if err != nil {
+			slog.Error("Invalid PII_VAULT_KEY", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Encrypting sources seal their records with the keyring
+	var keys *encryption.Keyring
+	if cfg.EncryptionKeys != "" {
+		keys, err = encryption.ParseKeyring(cfg.EncryptionKeys, cfg.EncryptionKeyVersion, cfg.EncryptionIndexKey)
+		if err != nil {
+			slog.Error("Invalid encryption keys", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Pseudonymizing sources store user identifiers as keyed hashes
+	var pseudonyms *encryption.Pseudonymizer
+	if cfg.PseudonymKey != "" {
+		pseudonyms, err = encryption.NewPseudonymizerFromBase64(cfg.PseudonymKey)
+		if err != nil {
+			slog.Error("Invalid PSEUDONYM_KEY", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Exports are kept in a blob store; download URLs of a local store are signed
+	// by the API, with a key that only lasts until restart unless configured
+	signingKey, err := base64.StdEncoding.DecodeString(cfg.ExportSigningKey)
+	if err != nil {
+		slog.Error("Invalid EXPORT_SIGNING_KEY", "error", err)
+		os.Exit(1)
+	}
+	if len(signingKey) == 0 && cfg.ExportStore != "s3" {
+		slog.Warn("EXPORT_SIGNING_KEY is not set, download URLs stop working when the API restarts")
+		signingKey = make([]byte, 32)
+		if _, err := rand.Read(signingKey); err != nil {
+			slog.Error("Failed to generate export signing key", "error", err)
+			os.Exit(1)
+		}
+	}
+	exportStore, err := blob.New(blob.Config{
+		Kind:       cfg.ExportStore,
+		Dir:        cfg.ExportDir,
+		BaseURL:    strings.TrimSuffix(cfg.PublicURL, "/") + "/v1/downloads",
+		SigningKey: signingKey,
+		S3: blob.S3Config{
+			Endpoint:        cfg.ExportS3Endpoint,
+			Region:          cfg.ExportS3Region,
+			Bucket:          cfg.ExportS3Bucket,
+			AccessKeyID:     cfg.ExportS3AccessKeyID,
+			SecretAccessKey: cfg.ExportS3SecretAccessKey,
+			PathStyle:       cfg.ExportS3PathStyle,
+		},
+	})
+	if err != nil {
+		slog.Error("Invalid export store", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize repository, service, and handler layers
 	experienceRepo := repository.NewExperienceRepository(db)
-	experienceService := service.NewExperienceService(experienceRepo)
+	sourceRepo := repository.NewSourceRepository(db)
+	experienceService := service.NewExperienceService(experienceRepo, sourceRepo, vault, keys, pseudonyms)
 	experienceHandler := handlers.NewExperienceHandler(experienceService)
+	sourceService := service.NewSourceService(sourceRepo, vault, keys, pseudonyms)
+	sourceHandler := handlers.NewSourceHandler(sourceService)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, keys, pseudonyms)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	responseRepo := repository.NewResponseRepository(db)
+	responseService := service.NewResponseService(responseRepo, keys, pseudonyms)
+	responseHandler := handlers.NewResponseHandler(responseService)
+	dataSubjectRepo := repository.NewDataSubjectRepository(db)
+	dataSubjectService := service.NewDataSubjectService(dataSubjectRepo, keys, pseudonyms)
+	dataSubjectHandler := handlers.NewDataSubjectHandler(dataSubjectService)
+	retentionRepo := repository.NewRetentionRepository(db)
+	retentionService := service.NewRetentionService(retentionRepo)
+	retentionHandler := handlers.NewRetentionHandler(retentionService)
+	bulkJobRepo := repository.NewBulkJobRepository(db)
+	bulkService := service.NewBulkService(experienceRepo, sourceRepo, bulkJobRepo, keys, pseudonyms)
+	bulkHandler := handlers.NewBulkHandler(bulkService)
+	exportRepo := repository.NewExportRepository(db)
+	exportURLTTL := time.Duration(cfg.ExportURLTTLMinutes) * time.Minute
+	exportService := service.NewExportService(experienceRepo, exportRepo, exportStore, keys, pseudonyms, exportURLTTL)
+	fileStore, _ := exportStore.(*blob.FileStore)
+	exportHandler := handlers.NewExportHandler(exportService, fileStore)
 	healthHandler := handlers.NewHealthHandler()
 
 	// Initialize API key repository for authentication
 	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	idempotencyTTL := time.Duration(cfg.IdempotencyTTLHours) * time.Hour
 
 	// Set up public endpoints (no authentication required)
 	publicMux := http.NewServeMux()
 	publicMux.HandleFunc("GET /health", healthHandler.Check)
 	publicMux.HandleFunc("GET /swagger/", httpSwagger.WrapHandler)
+	if fileStore != nil {
+		publicMux.HandleFunc("GET /v1/downloads/{key...}", exportHandler.Download) // Authenticated by the signature of the URL
+	}
 
 	// Apply middleware to public endpoints
 	var publicHandler http.Handler = publicMux
@@ -71,21 +168,63 @@ func main() {
 	// Set up protected endpoints (authentication required)
 	protectedMux := http.NewServeMux()
 	protectedMux.HandleFunc("POST /v1/experiences", experienceHandler.Create)
+	protectedMux.HandleFunc("PUT /v1/experiences", experienceHandler.Upsert)
+	protectedMux.HandleFunc("POST /v1/experiences/lookup", experienceHandler.Lookup)
 	protectedMux.HandleFunc("GET /v1/experiences", experienceHandler.List)
 	protectedMux.HandleFunc("GET /v1/experiences/{id}", experienceHandler.Get)
 	protectedMux.HandleFunc("PATCH /v1/experiences/{id}", experienceHandler.Update)
 	protectedMux.HandleFunc("DELETE /v1/experiences/{id}", experienceHandler.Delete)
+	protectedMux.HandleFunc("POST /v1/experiences/{id}/restore", experienceHandler.Restore)
+	protectedMux.HandleFunc("GET /v1/experiences/trash", experienceHandler.Trash)
+	protectedMux.HandleFunc("GET /v1/experiences/{id}/history", experienceHandler.History)
+	protectedMux.HandleFunc("GET /v1/experiences/{id}/vault", experienceHandler.Vault)
+	protectedMux.HandleFunc("POST /v1/experiences/bulk-update", bulkHandler.Update)
+	protectedMux.HandleFunc("POST /v1/experiences/bulk-delete", bulkHandler.Delete)
+	protectedMux.HandleFunc("GET /v1/bulk-jobs/{id}", bulkHandler.GetJob)
+
+	protectedMux.HandleFunc("POST /v1/exports", exportHandler.Create)
+	protectedMux.HandleFunc("GET /v1/exports/{id}", exportHandler.Get)
 
 	protectedMux.HandleFunc("GET /v1/experiences/search", experienceHandler.Search)
 
+	protectedMux.HandleFunc("GET /v1/analytics/metrics", analyticsHandler.Metrics)
+	protectedMux.HandleFunc("GET /v1/analytics/timeseries", analyticsHandler.TimeSeries)
+	protectedMux.HandleFunc("GET /v1/analytics/crosstab", analyticsHandler.Crosstab)
+
+	protectedMux.HandleFunc("GET /v1/responses", responseHandler.List)
+	protectedMux.HandleFunc("GET /v1/responses/{id}", responseHandler.Get)
+
+	protectedMux.HandleFunc("GET /v1/data-subjects/{user_identifier}/export", dataSubjectHandler.Export)
+	protectedMux.HandleFunc("DELETE /v1/data-subjects/{user_identifier}", dataSubjectHandler.Erase)
+	protectedMux.HandleFunc("GET /v1/erasure-receipts/{id}", dataSubjectHandler.GetErasureReceipt)
+
+	protectedMux.HandleFunc("POST /v1/retention-policies", retentionHandler.Create)
+	protectedMux.HandleFunc("GET /v1/retention-policies", retentionHandler.List)
+	protectedMux.HandleFunc("GET /v1/retention-policies/{id}", retentionHandler.Get)
+	protectedMux.HandleFunc("PATCH /v1/retention-policies/{id}", retentionHandler.Update)
+	protectedMux.HandleFunc("DELETE /v1/retention-policies/{id}", retentionHandler.Delete)
+	protectedMux.HandleFunc("POST /v1/retention-policies/{id}/dry-run", retentionHandler.DryRun)
+
+	protectedMux.HandleFunc("POST /v1/sources", sourceHandler.Create)
+	protectedMux.HandleFunc("GET /v1/sources", sourceHandler.List)
+	protectedMux.HandleFunc("GET /v1/sources/{id}", sourceHandler.Get)
+	protectedMux.HandleFunc("PATCH /v1/sources/{id}", sourceHandler.Update)
+	protectedMux.HandleFunc("DELETE /v1/sources/{id}", sourceHandler.Delete)
+	protectedMux.HandleFunc("GET /v1/sources/{id}/fields", sourceHandler.ListFields)
+	protectedMux.HandleFunc("PUT /v1/sources/{id}/fields/{field_id}", sourceHandler.PutField)
+	protectedMux.HandleFunc("DELETE /v1/sources/{id}/fields/{field_id}", sourceHandler.DeleteField)
+	protectedMux.HandleFunc("POST /v1/sources/import/formbricks", sourceHandler.ImportFormbricks)
+
 	// Apply middleware to protected endpoints
 	var protectedHandler http.Handler = protectedMux
+	protectedHandler = middleware.Idempotency(idempotencyRepo, keys, idempotencyTTL)(protectedHandler) // Runs after Auth, keys are scoped to the API key
 	protectedHandler = middleware.Auth(apiKeyRepo)(protectedHandler)
 	// protectedHandler = middleware.CORS(protectedHandler)	// CORS disabled
 
 	// Combine both handlers
 	mainMux := http.NewServeMux()
 	mainMux.Handle("/v1/", protectedHandler)
+	mainMux.Handle("/v1/downloads/", publicHandler)
 	mainMux.Handle("/", publicHandler) // Catch-all for public routes (/health, /swagger/, etc.)
 
 	// Apply logging to all requests
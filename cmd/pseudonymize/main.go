@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// pseudonymize replaces the user_identifier of existing records of sources with
+// pseudonymize enabled, and of their change history, by its pseudonym
+// It is safe to run repeatedly, records that are already converted are skipped.
+func main() {
+	ctx := context.Background()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if cfg.PseudonymKey == "" {
+		slog.Error("PSEUDONYM_KEY must be set")
+		os.Exit(1)
+	}
+	pseudonyms, err := encryption.NewPseudonymizerFromBase64(cfg.PseudonymKey)
+	if err != nil {
+		slog.Error("Invalid PSEUDONYM_KEY", "error", err)
+		os.Exit(1)
+	}
+
+	// Encrypted records are opened to convert their user_identifier
+	var keys *encryption.Keyring
+	if cfg.EncryptionKeys != "" {
+		keys, err = encryption.ParseKeyring(cfg.EncryptionKeys, cfg.EncryptionKeyVersion, cfg.EncryptionIndexKey)
+		if err != nil {
+			slog.Error("Invalid encryption keys", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Initialize database connection
+	db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	experienceService := service.NewExperienceService(
+		repository.NewExperienceRepository(db), repository.NewSourceRepository(db), nil, keys, pseudonyms)
+
+	converted, err := experienceService.PseudonymizeRecords(ctx)
+	if err != nil {
+		slog.Error("Pseudonymization failed", "converted", converted, "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("Pseudonymized user identifiers", "converted", converted)
+}
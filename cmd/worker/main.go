@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/blob"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// job is a unit of background work run on every tick of its loop
+type job struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Load configuration
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize database connection
+	db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+	if err != nil {
+		slog.Error("Failed to connect to database", "error", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	// Re-encrypting records rotated to a new key version and exporting encrypted records need the keyring
+	var keys *encryption.Keyring
+	if cfg.EncryptionKeys != "" {
+		keys, err = encryption.ParseKeyring(cfg.EncryptionKeys, cfg.EncryptionKeyVersion, cfg.EncryptionIndexKey)
+		if err != nil {
+			slog.Error("Invalid encryption keys", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	// Bulk jobs and exports filtering by user_identifier match its pseudonyms
+	var pseudonyms *encryption.Pseudonymizer
+	if cfg.PseudonymKey != "" {
+		pseudonyms, err = encryption.NewPseudonymizerFromBase64(cfg.PseudonymKey)
+		if err != nil {
+			slog.Error("Invalid PSEUDONYM_KEY", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	experienceRepo := repository.NewExperienceRepository(db)
+	sourceRepo := repository.NewSourceRepository(db)
+	experienceService := service.NewExperienceService(experienceRepo, sourceRepo, nil, keys, nil) // Jobs neither scan value_text nor pseudonymize
+	retentionRepo := repository.NewRetentionRepository(db)
+	retentionService := service.NewRetentionService(retentionRepo)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
+	bulkService := service.NewBulkService(experienceRepo, sourceRepo, repository.NewBulkJobRepository(db), keys, pseudonyms)
+
+	// The worker writes export files, which the API signs download URLs for
+	exportStore, err := blob.New(blob.Config{
+		Kind: cfg.ExportStore,
+		Dir:  cfg.ExportDir,
+		S3: blob.S3Config{
+			Endpoint:        cfg.ExportS3Endpoint,
+			Region:          cfg.ExportS3Region,
+			Bucket:          cfg.ExportS3Bucket,
+			AccessKeyID:     cfg.ExportS3AccessKeyID,
+			SecretAccessKey: cfg.ExportS3SecretAccessKey,
+			PathStyle:       cfg.ExportS3PathStyle,
+		},
+	})
+	if err != nil {
+		slog.Error("Invalid export store", "error", err)
+		os.Exit(1)
+	}
+	exportService := service.NewExportService(experienceRepo, repository.NewExportRepository(db), exportStore, keys, pseudonyms, 0) // Only the API signs download URLs
+
+	trashRetention := time.Duration(cfg.TrashRetentionDays) * 24 * time.Hour
+	exportRetention := time.Duration(cfg.ExportRetentionHours) * time.Hour
+
+	// Bulk jobs and exports are polled for on a short interval of their own, so they
+	// start soon after being created and do not wait for the maintenance jobs
+	bulkJobs := []job{
+		{
+			name: "run_bulk_jobs",
+			run: func(ctx context.Context) error {
+				finished, err := bulkService.RunJobs(ctx)
+				for _, job := range finished {
+					slog.Info("Finished bulk job", "job_id", job.ID, "action", job.Action, "status", job.Status,
+						"matched", job.Matched, "affected", job.Affected)
+				}
+				return err
+			},
+		},
+	}
+
+	// Exports are polled for in a loop of their own, so a large export does not hold up bulk jobs
+	exportJobs := []job{
+		{
+			name: "run_exports",
+			run: func(ctx context.Context) error {
+				finished, err := exportService.RunJobs(ctx, exportRetention)
+				for _, exp := range finished {
+					slog.Info("Finished export", "export_id", exp.ID, "format", exp.Format, "status", exp.Status,
+						"rows", exp.Rows, "size", exp.Size)
+				}
+				return err
+			},
+		},
+	}
+
+	jobs := []job{
+		{
+			name: "purge_trash",
+			run: func(ctx context.Context) error {
+				purged, err := experienceService.PurgeTrash(ctx, trashRetention)
+				if purged > 0 {
+					slog.Info("Purged deleted experiences", "count", purged, "retention_days", cfg.TrashRetentionDays)
+				}
+				return err
+			},
+		},
+		{
+			name: "enforce_retention",
+			run: func(ctx context.Context) error {
+				runs, err := retentionService.EnforcePolicies(ctx, cfg.RetentionDryRun)
+				for _, run := range runs {
+					if run.Affected > 0 || run.DryRun {
+						slog.Info("Enforced retention policy", "policy_id", run.PolicyID, "action", run.Action,
+							"cutoff", run.Cutoff, "affected", run.Affected, "dry_run", run.DryRun)
+					}
+				}
+				return err
+			},
+		},
+		{
+			name: "purge_idempotency_keys",
+			run: func(ctx context.Context) error {
+				purged, err := idempotencyRepo.DeleteExpired(ctx, time.Now())
+				if purged > 0 {
+					slog.Info("Purged expired idempotency keys", "count", purged)
+				}
+				return err
+			},
+		},
+		{
+			name: "expire_exports",
+			run: func(ctx context.Context) error {
+				expired, err := exportService.ExpireExports(ctx)
+				if expired > 0 {
+					slog.Info("Deleted expired exports", "count", expired, "retention_hours", cfg.ExportRetentionHours)
+				}
+				return err
+			},
+		},
+		{
+			name: "reencrypt",
+			run: func(ctx context.Context) error {
+				reencrypted, err := experienceService.ReencryptRecords(ctx)
+				if reencrypted > 0 {
+					slog.Info("Re-encrypted experiences", "count", reencrypted, "key_version", keys.CurrentVersion())
+				}
+				return err
+			},
+		},
+	}
+
+	interval := time.Duration(cfg.WorkerIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		slog.Error("WORKER_INTERVAL_MINUTES must be positive", "value", cfg.WorkerIntervalMinutes)
+		os.Exit(1)
+	}
+	pollInterval := time.Duration(cfg.JobPollIntervalSeconds) * time.Second
+	if pollInterval <= 0 {
+		slog.Error("JOB_POLL_INTERVAL_SECONDS must be positive", "value", cfg.JobPollIntervalSeconds)
+		os.Exit(1)
+	}
+
+	slog.Info("Starting worker", "interval", interval.String(), "poll_interval", pollInterval.String())
+
+	// Every loop runs on its own, so a long job only delays the jobs of its loop
+	var wg sync.WaitGroup
+	start := func(interval time.Duration, jobs []job) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			runEvery(ctx, interval, jobs)
+		}()
+	}
+	start(pollInterval, bulkJobs)
+	start(pollInterval, exportJobs)
+	start(interval, jobs)
+
+	wg.Wait()
+	slog.Info("Worker exited")
+}
+
+// runEvery runs jobs right away and then on every tick of interval until ctx is done
+func runEvery(ctx context.Context, interval time.Duration, jobs []job) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runJobs(ctx, jobs)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runJobs runs every job once, logging failures without stopping the others
+func runJobs(ctx context.Context, jobs []job) {
+	for _, j := range jobs {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := j.run(ctx); err != nil {
+			slog.Error("Job failed", "job", j.name, "error", err)
+		}
+	}
+}
@@ -38,22 +38,53 @@ const docTemplate = `{
                 }
             }
         },
-        "/v1/experiences": {
+        "/v1/analytics/crosstab": {
             "get": {
-                "security": [
-                    {
-                        "BearerAuth": []
-                    }
-                ],
-                "description": "Retrieve a list of experience data records with optional filters",
+                "description": "Count the responses for every pair of answers to two fields given in the same response (joined on response_id),\nwith row percentages and Pearson's chi-square test of independence. Each field may have at most 100 distinct answers.\nFilters restrict the answers that are paired; when a field was answered more than once in a response the latest answer is used.\nEncrypted text and JSON answers cannot be counted; encrypted_not_counted reports how many were left out.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "experiences"
+                    "analytics"
                 ],
-                "summary": "List experience data",
+                "summary": "Cross-tabulate two fields",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Field ID whose answers label the rows",
+                        "name": "row_field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field ID whose answers label the columns",
+                        "name": "column_field",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search query (supports quoted phrases, -negation, prefix* and OR)",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fulltext",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "description": "Search mode for query: fulltext (default) or fuzzy",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)",
+                        "name": "min_similarity",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Filter by source type",
@@ -68,26 +99,32 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Filter by field ID",
-                        "name": "field_id",
+                        "description": "Filter by user identifier",
+                        "name": "user_identifier",
                         "in": "query"
                     },
                     {
+                        "enum": [
+                            "email",
+                            "phone",
+                            "iban",
+                            "card"
+                        ],
                         "type": "string",
-                        "description": "Filter by user identifier",
-                        "name": "user_identifier",
+                        "description": "Filter by a type of personal data detected in value_text",
+                        "name": "pii_type",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Maximum number of records to return",
-                        "name": "limit",
+                        "type": "string",
+                        "description": "Filter by collected_at \u003e= start_date (RFC3339 format)",
+                        "name": "start_date",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of records to skip",
-                        "name": "offset",
+                        "type": "string",
+                        "description": "Filter by collected_at \u003c= end_date (RFC3339 format)",
+                        "name": "end_date",
                         "in": "query"
                     }
                 ],
@@ -95,10 +132,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.ExperienceData"
-                            }
+                            "$ref": "#/definitions/models.CrosstabResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
                     "401": {
@@ -113,45 +153,135 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            },
-            "post": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Create a new experience data record",
-                "consumes": [
-                    "application/json"
-                ],
+                ]
+            }
+        },
+        "/v1/analytics/metrics": {
+            "get": {
+                "description": "Compute count, mean, median, percentiles and the value distribution of value_number for a field.\nFields with more than 100 distinct values get a distribution of 20 equal-width ranges instead, flagged by distribution_binned.\nOptionally computes NPS (promoters 9-10, passives 7-8, detractors 0-6), CSAT (top-2-box) or CES (average effort).",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "experiences"
+                    "analytics"
                 ],
-                "summary": "Create experience data",
+                "summary": "Compute metrics for a numeric field",
                 "parameters": [
                     {
-                        "description": "Experience data to create",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/models.CreateExperienceRequest"
-                        }
+                        "type": "string",
+                        "description": "Field ID to compute metrics for",
+                        "name": "field_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "nps",
+                            "csat",
+                            "ces"
+                        ],
+                        "type": "string",
+                        "description": "Survey metric to compute",
+                        "name": "metric",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Highest answer on the rating scale (default 5 for csat, 7 for ces)",
+                        "name": "scale_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Full-text search query (supports quoted phrases, -negation, prefix* and OR)",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fulltext",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "description": "Search mode for query: fulltext (default) or fuzzy",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)",
+                        "name": "min_similarity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source type",
+                        "name": "source_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source ID",
+                        "name": "source_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by field type",
+                        "name": "field_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user identifier",
+                        "name": "user_identifier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by response ID",
+                        "name": "response_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "email",
+                            "phone",
+                            "iban",
+                            "card"
+                        ],
+                        "type": "string",
+                        "description": "Filter by a type of personal data detected in value_text",
+                        "name": "pii_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by collected_at \u003e= start_date (RFC3339 format)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by collected_at \u003c= end_date (RFC3339 format)",
+                        "name": "end_date",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ExperienceData"
+                            "$ref": "#/definitions/models.MetricsResponse"
                         }
                     },
                     "400": {
-                        "description": "Bad Request",
+                        "description": "Invalid request parameters",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -161,32 +291,101 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     }
-                }
-            }
-        },
-        "/v1/experiences/search": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Search experience data with advanced filters, full-text search, and pagination",
+                ]
+            }
+        },
+        "/v1/analytics/timeseries": {
+            "get": {
+                "description": "Bucket experience data by collected_at into hours, days, weeks (starting Monday) or months in the given time zone and compute a metric per bucket.\nGaps are filled with empty buckets. Grouping by source_type, field_id or metadata.\u003ckey\u003e returns one series per group (at most 50, largest first).\nWithout start_date the range covers the last 48 hours, 30 days, 26 weeks or 12 months, depending on the interval.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "experiences"
+                    "analytics"
                 ],
-                "summary": "Search experience data",
+                "summary": "Compute a metric over time",
                 "parameters": [
+                    {
+                        "enum": [
+                            "hour",
+                            "day",
+                            "week",
+                            "month"
+                        ],
+                        "type": "string",
+                        "description": "Bucket size",
+                        "name": "interval",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "IANA time zone buckets are aligned to (default UTC)",
+                        "name": "time_zone",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "count",
+                            "mean",
+                            "sum",
+                            "min",
+                            "max",
+                            "nps",
+                            "csat",
+                            "ces"
+                        ],
+                        "type": "string",
+                        "description": "Metric per bucket (default count)",
+                        "name": "metric",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Highest answer on the rating scale for csat (default 5)",
+                        "name": "scale_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Split into one series per source_type, field_id or metadata.\u003ckey\u003e",
+                        "name": "group_by",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Full-text search query",
+                        "description": "Full-text search query (supports quoted phrases, -negation, prefix* and OR)",
                         "name": "query",
                         "in": "query"
                     },
+                    {
+                        "enum": [
+                            "fulltext",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "description": "Search mode for query: fulltext (default) or fuzzy",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)",
+                        "name": "min_similarity",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Filter by source type",
@@ -219,26 +418,32 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "Filter by collected_at \u003e= start_date (RFC3339 format)",
-                        "name": "start_date",
+                        "description": "Filter by response ID",
+                        "name": "response_id",
                         "in": "query"
                     },
                     {
+                        "enum": [
+                            "email",
+                            "phone",
+                            "iban",
+                            "card"
+                        ],
                         "type": "string",
-                        "description": "Filter by collected_at \u003c= end_date (RFC3339 format)",
-                        "name": "end_date",
+                        "description": "Filter by a type of personal data detected in value_text",
+                        "name": "pii_type",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Number of results per page (default 20, max 40)",
-                        "name": "pageSize",
+                        "type": "string",
+                        "description": "Filter by collected_at \u003e= start_date (RFC3339 format)",
+                        "name": "start_date",
                         "in": "query"
                     },
                     {
-                        "type": "integer",
-                        "description": "Page number (starts at 0, default 0)",
-                        "name": "page",
+                        "type": "string",
+                        "description": "Filter by collected_at \u003c= end_date (RFC3339 format, default now)",
+                        "name": "end_date",
                         "in": "query"
                     }
                 ],
@@ -246,7 +451,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.SearchExperiencesResponse"
+                            "$ref": "#/definitions/models.TimeSeriesResponse"
                         }
                     },
                     "400": {
@@ -267,28 +472,28 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            }
-        },
-        "/v1/experiences/{id}": {
-            "get": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
-                ],
-                "description": "Retrieve a single experience data record by its UUID",
+                ]
+            }
+        },
+        "/v1/bulk-jobs/{id}": {
+            "get": {
+                "description": "Report the progress of a bulk update or delete run by the worker. A failed job keeps the changes made before it stopped.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "experiences"
                 ],
-                "summary": "Get experience data by ID",
+                "summary": "Get a bulk job",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Experience ID (UUID)",
+                        "description": "Bulk job ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -298,7 +503,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.ExperienceData"
+                            "$ref": "#/definitions/models.BulkJob"
                         }
                     },
                     "400": {
@@ -314,39 +519,57 @@ const docTemplate = `{
                         }
                     },
                     "404": {
-                        "description": "Experience not found",
+                        "description": "Bulk job not found",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            },
-            "delete": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
+                ]
+            }
+        },
+        "/v1/data-subjects/{user_identifier}": {
+            "delete": {
+                "description": "Delete or anonymize every experience data record of a person, including records in the trash, and delete their change history.\nAnonymizing keeps the records for aggregates, clearing user_identifier, metadata (including enrichment outputs) and the value_text of text and file answers.\nReturns the stored erasure receipt, which holds a keyed HMAC of the user identifier instead of the identifier itself, omitted without encryption keys.",
+                "produces": [
+                    "application/json"
                 ],
-                "description": "Delete an experience data record by ID",
                 "tags": [
-                    "experiences"
+                    "data-subjects"
                 ],
-                "summary": "Delete experience data",
+                "summary": "Erase a data subject",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Experience ID (UUID)",
-                        "name": "id",
+                        "description": "User identifier (URL encoded)",
+                        "name": "user_identifier",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "delete",
+                            "anonymize"
+                        ],
+                        "type": "string",
+                        "description": "Erasure mode (default delete)",
+                        "name": "mode",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content - Successfully deleted"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErasureReceipt"
+                        }
                     },
                     "400": {
-                        "description": "Invalid UUID format",
+                        "description": "Invalid request parameters",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
@@ -357,149 +580,3896 @@ const docTemplate = `{
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "Experience not found",
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
                             "$ref": "#/definitions/handlers.ErrorResponse"
                         }
                     }
-                }
-            },
-            "patch": {
+                },
                 "security": [
                     {
                         "BearerAuth": []
                     }
+                ]
+            }
+        },
+        "/v1/data-subjects/{user_identifier}/export": {
+            "get": {
+                "description": "Download every experience data record of a person, including records in the trash, with their change history.\nThe export is a JSON document, or a ZIP archive holding it with format=zip.",
+                "produces": [
+                    "application/json",
+                    "application/zip"
                 ],
-                "description": "Update an existing experience data record",
-                "consumes": [
-                    "application/json"
+                "tags": [
+                    "data-subjects"
+                ],
+                "summary": "Export a data subject",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User identifier (URL encoded)",
+                        "name": "user_identifier",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "zip"
+                        ],
+                        "type": "string",
+                        "description": "Download format (default json)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.DataSubjectExport"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No records for the user identifier",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/downloads/{key}": {
+            "get": {
+                "description": "Serve a file of the local blob store from a signed download URL, as returned in the download_url of a completed export.\nNo API key is needed; the URL is signed instead and stops working when it expires. Range requests are supported.",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Download an exported file",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Key of the file, e.g. exports/{id}.parquet",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Expiry time of the URL (Unix seconds)",
+                        "name": "expires",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Signature of the key and expiry time",
+                        "name": "signature",
+                        "in": "query",
+                        "required": true
+                    }
                 ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "403": {
+                        "description": "Invalid or expired signature",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "File not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/erasure-receipts/{id}": {
+            "get": {
+                "description": "Retrieve the receipt of a data subject erasure",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "experiences"
+                    "data-subjects"
                 ],
-                "summary": "Update experience data",
+                "summary": "Get an erasure receipt",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Experience ID (UUID)",
+                        "description": "Erasure receipt ID (UUID)",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErasureReceipt"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
                     },
+                    "404": {
+                        "description": "Erasure receipt not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
                     {
-                        "description": "Fields to update",
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences": {
+            "get": {
+                "description": "Retrieve a list of experience data records with optional filters\nWith Accept: text/csv or application/x-ndjson, records are streamed as CSV rows or JSON lines instead, and limit is optional and not capped.\nCSV columns follow the field order of experience data, with metadata flattened into a metadata.\u003ckey\u003e column for each of its keys, sorted.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "List experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by source type",
+                        "name": "source_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source ID",
+                        "name": "source_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by field ID",
+                        "name": "field_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user identifier",
+                        "name": "user_identifier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by response ID",
+                        "name": "response_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "email",
+                            "phone",
+                            "iban",
+                            "card"
+                        ],
+                        "type": "string",
+                        "description": "Filter by a type of personal data detected in value_text",
+                        "name": "pii_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of records to return (default 100, max 1000 for JSON)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,value_number; all when not set (id is always returned)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/json (default), text/csv or application/x-ndjson",
+                        "name": "Accept",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExperienceData"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid fields parameter",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "put": {
+                "description": "Create records, or replace the live record with the same natural key, so re-syncing the same answers does not duplicate them.\nThe natural key combines the fields given by key, source_type,source_id,field_id,response_id by default, which every record must set.\nOnly records upserted on the same key are matched, records created with POST never are. Records are validated like those created with POST\nand written all or none; validation errors name the offending record, e.g. [2].value_number.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Upsert experience data",
+                "parameters": [
+                    {
+                        "description": "Records to upsert (at most 1000)",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.UpdateExperienceRequest"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.CreateExperienceRequest"
+                            }
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "default": "source_type,source_id,field_id,response_id",
+                        "description": "Comma separated natural key fields, including source_type and field_id",
+                        "name": "key",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Reject records whose source or field is not registered, or whose field_type differs from the registration",
+                        "name": "strict",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.UpsertExperiencesResponse"
                         }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "A record misses a natural key value, its values do not match the field type, value_text holds personal data the source rejects, or user_identifier starts with hmac: without being a pseudonym",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Create a new experience data record\nThe value column required by field_type must be set: value_text for text, single_choice and file (http(s) URL),\nvalue_number for number, rating (1-10) and nps (0-10), value_boolean for boolean, value_date for date,\nvalue_json for multi_choice (array of strings) and matrix (object).\nWhen the field is registered for the source, a missing field_label is filled from the registration. Sources in strict mode always validate like strict=true.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Create experience data",
+                "parameters": [
+                    {
+                        "description": "Experience data to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateExperienceRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Reject records whose source or field is not registered, or whose field_type differs from the registration",
+                        "name": "strict",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExperienceData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Values do not match the field type, value_text holds personal data the source rejects, or user_identifier starts with hmac: without being a pseudonym",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/bulk-delete": {
+            "post": {
+                "description": "Move every record matching the filters to the trash, e.g. to clean up a spam source. Filters take the same fields as search.\nAt least one filter is required. dry_run only counts the matching records. When more records match than max_affected (default 10000) nothing is deleted.\nUp to 1000 matching records are deleted right away, all or none; larger sets, or any with async, are deleted by a job in the worker (202),\nwhose progress is reported by GET /v1/bulk-jobs/{id}. Deleted records stay restorable until the trash is purged.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Bulk delete experience data",
+                "parameters": [
+                    {
+                        "description": "Filters selecting the records to delete",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkExperiencesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkResult"
+                        }
+                    },
+                    "202": {
+                        "description": "Records are deleted by a job",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "More records match than max_affected",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/bulk-update": {
+            "post": {
+                "description": "Apply a patch to every record matching the filters, which take the same fields as search, e.g. to rename a mislabelled field_id.\nAt least one filter is required. dry_run only counts the matching records. When more records match than max_affected (default 10000) nothing is changed.\nUp to 1000 matching records are updated right away, all or none; larger sets, or any with async, are updated by a job in the worker (202),\nwhose progress is reported by GET /v1/bulk-jobs/{id}. metadata is merged into the stored metadata as a JSON merge patch.\nRecords cannot be moved into encrypting or pseudonymizing sources or sources scanning value_text with another pii_mode, and upserted records get the natural key of their new source and field.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Bulk update experience data",
+                "parameters": [
+                    {
+                        "description": "Filters selecting the records and the patch to apply",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkExperiencesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkResult"
+                        }
+                    },
+                    "202": {
+                        "description": "Records are updated by a job",
+                        "schema": {
+                            "$ref": "#/definitions/models.BulkResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A record would get the natural key of another, or Idempotency-Key reused",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "More records match than max_affected, or a record cannot move to the patched source",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/lookup": {
+            "post": {
+                "description": "Retrieve up to 500 records by ID in a single query, in the order of the requested IDs.\nIDs without a live record are listed in missing. fields limits the returned fields, id is always returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Look up experience data by IDs",
+                "parameters": [
+                    {
+                        "description": "IDs of the records and the fields to return",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.LookupExperiencesRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.LookupExperiencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request, no IDs, more than 500 IDs or unknown fields",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/search": {
+            "get": {
+                "description": "Search experience data with advanced filters, full-text search, and pagination.\nText matches are stemmed using each record's language, ordered by relevance and include a highlighted snippet.\nEncrypted records cannot match a query; encrypted_not_searched counts those matching the other filters.\nWith Accept: text/csv or application/x-ndjson, all matching results are streamed as CSV rows or JSON lines instead, without pagination, counts or facets.",
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Search experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Full-text search query (supports quoted phrases, -negation, prefix* and OR)",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "fulltext",
+                            "fuzzy"
+                        ],
+                        "type": "string",
+                        "description": "Search mode for query: fulltext (default) or fuzzy (typo-tolerant trigram similarity)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)",
+                        "name": "min_similarity",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source type",
+                        "name": "source_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source ID",
+                        "name": "source_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by field ID",
+                        "name": "field_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by field type",
+                        "name": "field_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user identifier",
+                        "name": "user_identifier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by response ID",
+                        "name": "response_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "email",
+                            "phone",
+                            "iban",
+                            "card"
+                        ],
+                        "type": "string",
+                        "description": "Filter by a type of personal data detected in value_text",
+                        "name": "pii_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by collected_at \u003e= start_date (RFC3339 format)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by collected_at \u003c= end_date (RFC3339 format)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of results per page (default 20, max 40)",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page number (starts at 0, default 0)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma-separated facets to count under the current filters: source_type, source_id, field_id, field_type, language, sentiment",
+                        "name": "facets",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields of each result to return, e.g. id,value_number; all when not set (id, rank, highlight and similarity are always returned)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "application/json (default), text/csv or application/x-ndjson",
+                        "name": "Accept",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SearchExperiencesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/trash": {
+            "get": {
+                "description": "Retrieve deleted experience data records, most recently deleted first.\nDeleted records are hidden from all other endpoints until they are restored, and purged permanently once the trash retention period has passed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "List deleted experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by source type",
+                        "name": "source_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source ID",
+                        "name": "source_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by field ID",
+                        "name": "field_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user identifier",
+                        "name": "user_identifier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by response ID",
+                        "name": "response_id",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "email",
+                            "phone",
+                            "iban",
+                            "card"
+                        ],
+                        "type": "string",
+                        "description": "Filter by a type of personal data detected in value_text",
+                        "name": "pii_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of records to return",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of records to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExperienceData"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/{id}": {
+            "get": {
+                "description": "Retrieve a single experience data record by its UUID\nThe ETag header holds the quoted version of the record, for If-None-Match and for If-Match on updates and deletes.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Get experience data by ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Experience ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated fields to return, e.g. id,value_number; all when not set (id is always returned)",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a cached copy; 304 is returned while the record is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExperienceData"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted version of the record"
+                            }
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified - The record still matches If-None-Match"
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Experience not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Move an experience data record to the trash. It can be restored until the trash retention period has passed.\nWith If-Match, the record is only deleted while it is at the version of the given ETag.",
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Delete experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Experience ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the record must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - Successfully deleted"
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Experience not found, unless If-Match is sent",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "The record changed since the version of If-Match, or does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "patch": {
+                "description": "Update an existing experience data record\nWith If-Match, the update only applies while the record is at the version of the given ETag.\nSent as application/json, fields that are null or missing are left unchanged and metadata is replaced.\nSent as application/merge-patch+json (RFC 7396), null clears a field and metadata is merged recursively, where null removes a key. Fields that cannot be cleared reject null with 400.",
+                "consumes": [
+                    "application/json",
+                    "application/merge-patch+json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Update experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Experience ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateExperienceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag the record must still have",
+                        "name": "If-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExperienceData"
+                        },
+                        "headers": {
+                            "ETag": {
+                                "type": "string",
+                                "description": "Quoted version of the updated record"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Experience not found, unless If-Match is sent",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "An upserted record would take the natural key of another live record, or the record kept changing during the update",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "412": {
+                        "description": "The record changed since the version of If-Match, or does not exist",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Values do not match the field type, value_text holds personal data the source rejects, or user_identifier starts with hmac: without being a pseudonym",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/{id}/history": {
+            "get": {
+                "description": "Retrieve every create, update, delete and restore of an experience data record, oldest first,\nwith the record before and after the change and the ID of the API key that made it.\nRecords in the trash keep their history until they are purged.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Get the change history of experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Experience ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ExperienceHistoryEntry"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Experience not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/{id}/restore": {
+            "post": {
+                "description": "Move a deleted experience data record out of the trash",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Restore deleted experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Experience ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExperienceData"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Experience not found in the trash",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A live record has the same natural key, or Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/experiences/{id}/vault": {
+            "get": {
+                "description": "Decrypt the original value_text of a record whose personal data was masked by a source keeping a PII vault",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "experiences"
+                ],
+                "summary": "Get the original value_text of masked experience data",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Experience ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ExperienceVault"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Experience not found or without a vaulted value",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/exports": {
+            "post": {
+                "description": "Create an export of every record matching the filters, which take the same fields as search, to a csv, ndjson or parquet file.\nWithout filters every live record is exported. The worker writes the file from a single consistent snapshot of the data,\nso poll GET /v1/exports/{id} until its status is completed and fetch the file from its download_url.\nCSV files flatten metadata into a metadata.\u003ckey\u003e column per key; Parquet files store timestamps in microseconds and arrays and objects as JSON.\nSearches with a query also export rank and highlight, or similarity in fuzzy mode. Files are deleted once the export expires.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Export experience data to a file",
+                "parameters": [
+                    {
+                        "description": "Filters selecting the records, the file format and the fields to export",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateExportRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "The export is waiting for the worker",
+                        "schema": {
+                            "$ref": "#/definitions/models.Export"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/exports/{id}": {
+            "get": {
+                "description": "Report the status of an export. Completed exports have a signed download_url, valid until download_expires_at without an API key;\nfetch the export again for a fresh one. Failed exports report their error, expired ones no longer have a file.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "exports"
+                ],
+                "summary": "Get an export",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Export ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Export"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Export not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/responses": {
+            "get": {
+                "description": "List submissions with their answer values pivoted into one column per field_id.\nIf a field was answered more than once in a response the latest answer is returned.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "responses"
+                ],
+                "summary": "List responses",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by source type",
+                        "name": "source_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by source ID",
+                        "name": "source_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by user identifier",
+                        "name": "user_identifier",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by collected_at \u003e= start_date (RFC3339 format)",
+                        "name": "start_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by collected_at \u003c= end_date (RFC3339 format)",
+                        "name": "end_date",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of responses to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of responses to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ListResponsesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request parameters",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/responses/{id}": {
+            "get": {
+                "description": "Retrieve all answers of a single submission sharing the response_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "responses"
+                ],
+                "summary": "Get a response",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Response ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Response not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/retention-policies": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "retention"
+                ],
+                "summary": "List retention policies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.RetentionPolicy"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Limit how long experience data of a source_type (and optionally a single source_id) is kept.\nThe worker deletes or anonymizes records collected more than max_age_days ago, including records in the trash.\nAnonymizing clears user_identifier, metadata and the value_text of text and file answers; the change history of affected records is deleted.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Create a retention policy",
+                "parameters": [
+                    {
+                        "description": "Retention policy to create",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateRetentionPolicyRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/retention-policies/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Get a retention policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Retention policy ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Retention policy not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Delete a retention policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Retention policy ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - Successfully deleted"
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Retention policy not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "patch": {
+                "description": "Change the age, action or enabled state of a retention policy",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Update a retention policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Retention policy ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateRetentionPolicyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Retention policy not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/retention-policies/{id}/dry-run": {
+            "post": {
+                "description": "Count the records the policy would delete or anonymize if it ran now, without changing anything.\nDisabled policies can be dry-run as well.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Dry-run a retention policy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Retention policy ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.RetentionRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Retention policy not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/sources": {
+            "get": {
+                "description": "Retrieve registered sources, most recently created first",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "List sources",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by source type",
+                        "name": "source_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of sources to return (default 100, max 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of sources to skip",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Source"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "post": {
+                "description": "Register a source of experience data, such as a survey. Records belong to the source when their source_type and source_id match its source_type and external_id.\npii_mode scans value_text of the source's records for email addresses, phone numbers, IBANs and card numbers: off (default), flag, mask or reject.\nWith pii_vault, masked originals are kept encrypted and can be read from /v1/experiences/{id}/vault.\nWith encrypt, value_text, value_json and user_identifier of the source's records are stored encrypted and cannot be searched.\nWith pseudonymize, user_identifier of the source's records is stored as a keyed hash; user_identifier filters hash their input.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Register a source",
+                "parameters": [
+                    {
+                        "description": "Source to register",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.CreateSourceRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/models.Source"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A source with the same source_type and external_id exists, or Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unknown pii_mode, or pii_vault, encrypt or pseudonymize without its key configured",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/sources/import/formbricks": {
+            "post": {
+                "description": "Register a Formbricks survey definition as a source (source_type formbricks, external_id the survey ID) and its questions as fields.\nThe definition may be wrapped in {\"data\": ...} as returned by the Formbricks management API. Re-importing updates the registration.\nQuestions without a matching field type (e.g. cta, address) are skipped and listed in the response.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Import a Formbricks survey",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Enable strict mode for the source",
+                        "name": "strict",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Formbricks survey definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Retry safely: repeating the request with the same key replays the original response",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.ImportSurveyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid survey definition",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Idempotency-Key reused for a different request or still in progress",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/sources/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Get a source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Source"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "description": "Delete a source and its registered fields. Experience records of the source are kept.",
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Delete a source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - Successfully deleted"
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "patch": {
+                "description": "Rename a source, switch strict mode on or off or change how personal data in value_text is handled.\nSwitching encrypt on encrypts records created or updated afterwards; encrypted records stay encrypted when it is switched off.\nSwitching pseudonymize on applies to records created afterwards; existing records are converted with the pseudonymize command.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Update a source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Fields to update",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.UpdateSourceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.Source"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unknown pii_mode, or pii_vault, encrypt or pseudonymize without its key configured",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/sources/{id}/fields": {
+            "get": {
+                "description": "Retrieve the fields registered for a source, ordered by position",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "List the fields of a source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.SourceField"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        },
+        "/v1/sources/{id}/fields/{field_id}": {
+            "put": {
+                "description": "Register a field with its label, type, choices and translated labels, replacing an existing registration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Register a field of a source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field ID",
+                        "name": "field_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Field definition",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.PutSourceFieldRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SourceField"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request or UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Source not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Unknown field type or invalid choices",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            },
+            "delete": {
+                "tags": [
+                    "sources"
+                ],
+                "summary": "Delete a field of a source",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Source ID (UUID)",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Field ID",
+                        "name": "field_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content - Successfully deleted"
+                    },
+                    "400": {
+                        "description": "Invalid UUID format",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized - Invalid or missing API key",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Field not found",
+                        "schema": {
+                            "$ref": "#/definitions/handlers.ErrorResponse"
+                        }
+                    }
+                },
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ]
+            }
+        }
+    },
+    "definitions": {
+        "handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "Explanation of this occurrence",
+                    "type": "string"
+                },
+                "details": {
+                    "description": "Per-field reasons for validation errors",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldError"
+                    }
+                },
+                "error": {
+                    "description": "Machine readable error type, e.g. not_found",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "HTTP status code",
+                    "type": "integer"
+                },
+                "title": {
+                    "description": "HTTP status text",
+                    "type": "string"
+                },
+                "type": {
+                    "description": "Problem type URI, about:blank as errors are identified by status and error",
+                    "type": "string"
+                }
+            }
+        },
+        "models.BulkExperiencePatch": {
+            "type": "object",
+            "properties": {
+                "field_id": {
+                    "type": "string"
+                },
+                "field_label": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "description": "Merged into the stored metadata as a JSON merge patch (RFC 7396)",
+                    "type": "object"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.BulkExperiencesRequest": {
+            "type": "object",
+            "properties": {
+                "async": {
+                    "description": "Run as a job even when few records match",
+                    "type": "boolean"
+                },
+                "dry_run": {
+                    "description": "Only count the matching records",
+                    "type": "boolean"
+                },
+                "end_date": {
+                    "description": "Filter by collected_at \u003c= end_date",
+                    "type": "string"
+                },
+                "field_id": {
+                    "description": "Filter by field ID",
+                    "type": "string"
+                },
+                "field_type": {
+                    "description": "Filter by field type",
+                    "type": "string"
+                },
+                "max_affected": {
+                    "description": "Fail instead when more records match (default 10000)",
+                    "type": "integer"
+                },
+                "min_similarity": {
+                    "description": "Fuzzy mode similarity threshold between 0 and 1 (default 0.4)",
+                    "type": "number"
+                },
+                "mode": {
+                    "description": "Search mode for query: fulltext (default) or fuzzy",
+                    "type": "string"
+                },
+                "patch": {
+                    "description": "Fields to set, required by bulk updates",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BulkExperiencePatch"
+                        }
+                    ]
+                },
+                "pii_type": {
+                    "description": "Filter by a type of detected personal data",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Full-text search query (supports \"phrases\", -negation, prefix* and OR)",
+                    "type": "string"
+                },
+                "response_id": {
+                    "description": "Filter by response ID",
+                    "type": "string"
+                },
+                "source_id": {
+                    "description": "Filter by source ID",
+                    "type": "string"
+                },
+                "source_type": {
+                    "description": "Filter by source type",
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "Filter by collected_at \u003e= start_date",
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "description": "Filter by user identifier",
+                    "type": "string"
+                }
+            }
+        },
+        "models.BulkJob": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "update or delete",
+                    "type": "string"
+                },
+                "affected": {
+                    "description": "Records updated or deleted so far",
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "filters": {
+                    "$ref": "#/definitions/models.ExperienceFilters"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "matched": {
+                    "description": "Records matching the filters when the job was created",
+                    "type": "integer"
+                },
+                "max_affected": {
+                    "description": "The job fails instead of changing more records",
+                    "type": "integer"
+                },
+                "patch": {
+                    "$ref": "#/definitions/models.BulkExperiencePatch"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "pending, running, completed or failed",
+                    "type": "string"
+                }
+            }
+        },
+        "models.BulkResult": {
+            "type": "object",
+            "properties": {
+                "affected": {
+                    "description": "Records updated or deleted, 0 in a dry run or when a job was created",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "Nothing was changed",
+                    "type": "boolean"
+                },
+                "job": {
+                    "description": "The job processing the records, when the request runs as a job",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.BulkJob"
+                        }
+                    ]
+                },
+                "matched": {
+                    "description": "Records matching the filters",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CESResult": {
+            "type": "object",
+            "properties": {
+                "low_effort": {
+                    "type": "integer"
+                },
+                "low_effort_percentage": {
+                    "type": "number"
+                },
+                "scale_max": {
+                    "type": "number"
+                },
+                "score": {
+                    "description": "Average answer",
+                    "type": "number"
+                }
+            }
+        },
+        "models.CSATResult": {
+            "type": "object",
+            "properties": {
+                "satisfied": {
+                    "type": "integer"
+                },
+                "scale_max": {
+                    "type": "number"
+                },
+                "score": {
+                    "description": "Percentage of satisfied answers (0-100)",
+                    "type": "number"
+                }
+            }
+        },
+        "models.ChiSquareResult": {
+            "type": "object",
+            "properties": {
+                "degrees_of_freedom": {
+                    "type": "integer"
+                },
+                "low_expected_cells": {
+                    "description": "Cells with an expected count below 5, the test is unreliable when this exceeds 20% of cells",
+                    "type": "integer"
+                },
+                "p_value": {
+                    "type": "number"
+                },
+                "significant": {
+                    "description": "p_value \u003c 0.05",
+                    "type": "boolean"
+                },
+                "statistic": {
+                    "type": "number"
+                }
+            }
+        },
+        "models.CreateExperienceRequest": {
+            "type": "object",
+            "properties": {
+                "collected_at": {
+                    "type": "string"
+                },
+                "field_id": {
+                    "type": "string"
+                },
+                "field_label": {
+                    "type": "string"
+                },
+                "field_type": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "response_id": {
+                    "description": "Groups the answers of one submission",
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "type": "string"
+                },
+                "value_boolean": {
+                    "type": "boolean"
+                },
+                "value_date": {
+                    "type": "string"
+                },
+                "value_json": {
+                    "type": "object"
+                },
+                "value_number": {
+                    "type": "number"
+                },
+                "value_text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateExportRequest": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "description": "Filter by collected_at \u003c= end_date",
+                    "type": "string"
+                },
+                "field_id": {
+                    "description": "Filter by field ID",
+                    "type": "string"
+                },
+                "field_type": {
+                    "description": "Filter by field type",
+                    "type": "string"
+                },
+                "fields": {
+                    "description": "Sparse fieldset, see ExperienceFieldNames; all fields when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "format": {
+                    "description": "csv, ndjson or parquet",
+                    "type": "string"
+                },
+                "min_similarity": {
+                    "description": "Fuzzy mode similarity threshold between 0 and 1 (default 0.4)",
+                    "type": "number"
+                },
+                "mode": {
+                    "description": "Search mode for query: fulltext (default) or fuzzy",
+                    "type": "string"
+                },
+                "pii_type": {
+                    "description": "Filter by a type of detected personal data",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Full-text search query (supports \"phrases\", -negation, prefix* and OR)",
+                    "type": "string"
+                },
+                "response_id": {
+                    "description": "Filter by response ID",
+                    "type": "string"
+                },
+                "source_id": {
+                    "description": "Filter by source ID",
+                    "type": "string"
+                },
+                "source_type": {
+                    "description": "Filter by source type",
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "Filter by collected_at \u003e= start_date",
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "description": "Filter by user identifier",
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateRetentionPolicyRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "description": "Defaults to true",
+                    "type": "boolean"
+                },
+                "max_age_days": {
+                    "type": "integer"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CreateSourceRequest": {
+            "type": "object",
+            "properties": {
+                "encrypt": {
+                    "type": "boolean"
+                },
+                "external_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pii_mode": {
+                    "description": "Default off",
+                    "type": "string"
+                },
+                "pii_vault": {
+                    "type": "boolean"
+                },
+                "pseudonymize": {
+                    "type": "boolean"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "strict": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "models.CrosstabCell": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "row_percentage": {
+                    "description": "Share of the row total (0-100)",
+                    "type": "number"
+                }
+            }
+        },
+        "models.CrosstabColumn": {
+            "type": "object",
+            "properties": {
+                "total": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.CrosstabResponse": {
+            "type": "object",
+            "properties": {
+                "chi_square": {
+                    "description": "Test of independence, omitted for tables with fewer than 2 rows or columns",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ChiSquareResult"
+                        }
+                    ]
+                },
+                "column_field": {
+                    "type": "string"
+                },
+                "columns": {
+                    "description": "Column answers, in the order of CrosstabRow.Cells",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CrosstabColumn"
+                    }
+                },
+                "encrypted_not_counted": {
+                    "description": "EncryptedNotCounted counts the answers to either field left out because\ntheir value_text or value_json is encrypted",
+                    "type": "integer"
+                },
+                "row_field": {
+                    "type": "string"
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CrosstabRow"
+                    }
+                },
+                "total": {
+                    "description": "Number of responses counted",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.CrosstabRow": {
+            "type": "object",
+            "properties": {
+                "cells": {
+                    "description": "One cell per column",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.CrosstabCell"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DataSubjectExport": {
+            "type": "object",
+            "properties": {
+                "experiences": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExperienceData"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "history": {
+                    "description": "Changes of the exported records",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExperienceHistoryEntry"
+                    }
+                },
+                "user_identifier": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.DistributionBucket": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "percentage": {
+                    "description": "Share of all counted records (0-100)",
+                    "type": "number"
+                },
+                "upper_bound": {
+                    "description": "Exclusive upper bound of a range, inclusive for the last range",
+                    "type": "number"
+                },
+                "value": {
+                    "description": "The value, or the lower bound of a range",
+                    "type": "number"
+                }
+            }
+        },
+        "models.ErasureReceipt": {
+            "type": "object",
+            "properties": {
+                "api_key_id": {
+                    "description": "API key that requested the erasure",
+                    "type": "string"
+                },
+                "erased_at": {
+                    "type": "string"
+                },
+                "experiences_affected": {
+                    "type": "integer"
+                },
+                "history_entries_deleted": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "delete or anonymize",
+                    "type": "string"
+                },
+                "user_identifier_hash": {
+                    "description": "Hex encoded HMAC-SHA256 of the user identifier under ENCRYPTION_INDEX_KEY, omitted without encryption keys",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExperienceData": {
+            "type": "object",
+            "properties": {
+                "collected_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Set while the record is in the trash",
+                    "type": "string"
+                },
+                "encrypted": {
+                    "description": "value_text, value_json and user_identifier are stored encrypted",
+                    "type": "boolean"
+                },
+                "field_id": {
+                    "type": "string"
+                },
+                "field_label": {
+                    "type": "string"
+                },
+                "field_type": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "natural_key": {
+                    "description": "Fields of the natural key the record was upserted on",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "pii_types": {
+                    "description": "Types of personal data detected in value_text",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "response_id": {
+                    "description": "Groups the answers of one submission",
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "type": "string"
+                },
+                "value_boolean": {
+                    "type": "boolean"
+                },
+                "value_date": {
+                    "type": "string"
+                },
+                "value_json": {
+                    "type": "object"
+                },
+                "value_number": {
+                    "type": "number"
+                },
+                "value_text": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Incremented by every change; the ETag of the record is the quoted version",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ExperienceFilters": {
+            "type": "object",
+            "properties": {
+                "end_date": {
+                    "description": "Filter by collected_at \u003c= end_date",
+                    "type": "string"
+                },
+                "field_id": {
+                    "description": "Filter by field ID",
+                    "type": "string"
+                },
+                "field_type": {
+                    "description": "Filter by field type",
+                    "type": "string"
+                },
+                "min_similarity": {
+                    "description": "Fuzzy mode similarity threshold between 0 and 1 (default 0.4)",
+                    "type": "number"
+                },
+                "mode": {
+                    "description": "Search mode for query: fulltext (default) or fuzzy",
+                    "type": "string"
+                },
+                "pii_type": {
+                    "description": "Filter by a type of detected personal data",
+                    "type": "string"
+                },
+                "query": {
+                    "description": "Full-text search query (supports \"phrases\", -negation, prefix* and OR)",
+                    "type": "string"
+                },
+                "response_id": {
+                    "description": "Filter by response ID",
+                    "type": "string"
+                },
+                "source_id": {
+                    "description": "Filter by source ID",
+                    "type": "string"
+                },
+                "source_type": {
+                    "description": "Filter by source type",
+                    "type": "string"
+                },
+                "start_date": {
+                    "description": "Filter by collected_at \u003e= start_date",
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "description": "Filter by user identifier",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExperienceHistoryEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "create, update, delete or restore",
+                    "type": "string"
+                },
+                "after": {
+                    "description": "Record after the change",
+                    "type": "object"
+                },
+                "api_key_id": {
+                    "description": "API key that made the change",
+                    "type": "string"
+                },
+                "before": {
+                    "description": "Record before the change, absent for create",
+                    "type": "object"
+                },
+                "changed_at": {
+                    "type": "string"
+                },
+                "experience_id": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ExperienceSearchResult": {
+            "type": "object",
+            "properties": {
+                "collected_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Set while the record is in the trash",
+                    "type": "string"
+                },
+                "encrypted": {
+                    "description": "value_text, value_json and user_identifier are stored encrypted",
+                    "type": "boolean"
+                },
+                "field_id": {
+                    "type": "string"
+                },
+                "field_label": {
+                    "type": "string"
+                },
+                "field_type": {
+                    "type": "string"
+                },
+                "highlight": {
+                    "description": "value_text snippet with matches wrapped in \u003cmark\u003e\u003c/mark\u003e",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "language": {
+                    "type": "string"
+                },
+                "metadata": {
+                    "type": "object"
+                },
+                "natural_key": {
+                    "description": "Fields of the natural key the record was upserted on",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "pii_types": {
+                    "description": "Types of personal data detected in value_text",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rank": {
+                    "description": "ts_rank relevance of the match",
+                    "type": "number"
+                },
+                "response_id": {
+                    "description": "Groups the answers of one submission",
+                    "type": "string"
+                },
+                "similarity": {
+                    "description": "Trigram word similarity of the match (0-1)",
+                    "type": "number"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_name": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "type": "string"
+                },
+                "value_boolean": {
+                    "type": "boolean"
+                },
+                "value_date": {
+                    "type": "string"
+                },
+                "value_json": {
+                    "type": "object"
+                },
+                "value_number": {
+                    "type": "number"
+                },
+                "value_text": {
+                    "type": "string"
+                },
+                "version": {
+                    "description": "Incremented by every change; the ETag of the record is the quoted version",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.ExperienceVault": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "value_text": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Export": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "download_expires_at": {
+                    "description": "When download_url stops working",
+                    "type": "string"
+                },
+                "download_url": {
+                    "description": "Signed URL of the file, set while it is completed",
+                    "type": "string"
+                },
+                "error": {
+                    "description": "Why a failed export stopped",
+                    "type": "string"
+                },
+                "expires_at": {
+                    "description": "When the file is deleted",
+                    "type": "string"
+                },
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "filters": {
+                    "$ref": "#/definitions/models.ExperienceFilters"
+                },
+                "finished_at": {
+                    "type": "string"
+                },
+                "format": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "rows": {
+                    "description": "Records written so far",
+                    "type": "integer"
+                },
+                "size": {
+                    "description": "Size of the file in bytes, once completed",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.FacetValue": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "value": {
+                    "description": "null counts records without a value",
+                    "type": "string"
+                }
+            }
+        },
+        "models.FieldChoice": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "translations": {
+                    "description": "Label keyed by language code",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Machine readable reason, see the Validation* constants",
+                    "type": "string"
+                },
+                "field": {
+                    "description": "JSON name of the offending field, e.g. value_number",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Human readable explanation",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ImportSurveyResponse": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SourceField"
+                    }
+                },
+                "skipped": {
+                    "description": "Questions without a matching field type",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SkippedQuestion"
+                    }
+                },
+                "source": {
+                    "$ref": "#/definitions/models.Source"
+                }
+            }
+        },
+        "models.ListResponsesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Most recent responses first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ResponseRow"
+                    }
+                },
+                "fields": {
+                    "description": "field_id of every column present in data, sorted",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.LookupExperiencesRequest": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "description": "Sparse fieldset, see ExperienceFieldNames; all fields when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "ids": {
+                    "description": "At most 500 record IDs",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.LookupExperiencesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "description": "Found records, in the order of the requested IDs",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExperienceData"
+                    }
+                },
+                "missing": {
+                    "description": "Requested IDs without a live record",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.MetricsResponse": {
+            "type": "object",
+            "properties": {
+                "ces": {
+                    "$ref": "#/definitions/models.CESResult"
+                },
+                "count": {
+                    "description": "Number of records with a numeric value",
+                    "type": "integer"
+                },
+                "csat": {
+                    "$ref": "#/definitions/models.CSATResult"
+                },
+                "distribution": {
+                    "description": "Count of each distinct value, ascending by value",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DistributionBucket"
+                    }
+                },
+                "distribution_binned": {
+                    "description": "Set when more than 100 distinct values are counted in 20 equal-width ranges instead",
+                    "type": "boolean"
+                },
+                "field_id": {
+                    "type": "string"
+                },
+                "max": {
+                    "description": "Highest value",
+                    "type": "number"
+                },
+                "mean": {
+                    "description": "Average value",
+                    "type": "number"
+                },
+                "median": {
+                    "description": "50th percentile",
+                    "type": "number"
+                },
+                "min": {
+                    "description": "Lowest value",
+                    "type": "number"
+                },
+                "nps": {
+                    "$ref": "#/definitions/models.NPSResult"
+                },
+                "percentiles": {
+                    "description": "Continuous percentiles keyed p5, p10, p25, p50, p75, p90, p95, p99",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                },
+                "std_dev": {
+                    "description": "Sample standard deviation",
+                    "type": "number"
+                }
+            }
+        },
+        "models.NPSResult": {
+            "type": "object",
+            "properties": {
+                "detractors": {
+                    "type": "integer"
+                },
+                "detractors_percentage": {
+                    "type": "number"
+                },
+                "passives": {
+                    "type": "integer"
+                },
+                "passives_percentage": {
+                    "type": "number"
+                },
+                "promoters": {
+                    "type": "integer"
+                },
+                "promoters_percentage": {
+                    "type": "number"
+                },
+                "score": {
+                    "description": "% promoters - % detractors (-100 to 100)",
+                    "type": "number"
+                }
+            }
+        },
+        "models.PutSourceFieldRequest": {
+            "type": "object",
+            "properties": {
+                "choices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChoice"
+                    }
+                },
+                "field_type": {
+                    "type": "string"
+                },
+                "label": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "translations": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "models.Response": {
+            "type": "object",
+            "properties": {
+                "answers": {
+                    "description": "Ordered by collected_at, then field_id",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExperienceData"
+                    }
+                },
+                "collected_at": {
+                    "description": "collected_at of the earliest answer",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ResponseRow": {
+            "type": "object",
+            "properties": {
+                "collected_at": {
+                    "description": "collected_at of the earliest answer",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "source_id": {
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "user_identifier": {
+                    "type": "string"
+                },
+                "values": {
+                    "description": "Answer value keyed by field_id",
+                    "type": "object"
+                }
+            }
+        },
+        "models.RetentionPolicy": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "delete or anonymize",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_run_affected": {
+                    "description": "Records deleted or anonymized by the last run",
+                    "type": "integer"
+                },
+                "last_run_at": {
+                    "type": "string"
+                },
+                "max_age_days": {
+                    "description": "Age by collected_at after which records expire",
+                    "type": "integer"
+                },
+                "source_id": {
+                    "description": "Restricts the policy to one source",
+                    "type": "string"
+                },
+                "source_type": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.RetentionRun": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "affected": {
+                    "description": "Records deleted or anonymized, or that would be in a dry run",
+                    "type": "integer"
+                },
+                "cutoff": {
+                    "description": "Records collected before the cutoff are expired",
+                    "type": "string"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "policy_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.SearchExperiencesResponse": {
+            "type": "object",
+            "properties": {
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ExperienceSearchResult"
                     }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/models.ExperienceData"
-                        }
-                    },
-                    "400": {
-                        "description": "Invalid request or UUID format",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
-                        }
-                    },
-                    "401": {
-                        "description": "Unauthorized - Invalid or missing API key",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Experience not found",
-                        "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                },
+                "encrypted_not_searched": {
+                    "description": "EncryptedNotSearched counts the encrypted records matching every filter but\nquery, whose value_text the query could not search",
+                    "type": "integer"
+                },
+                "facets": {
+                    "description": "Value counts per requested facet, most frequent first",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/models.FacetValue"
                         }
                     }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "page_size": {
+                    "type": "integer"
+                },
+                "total_count": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
                 }
             }
-        }
-    },
-    "definitions": {
-        "handlers.ErrorResponse": {
+        },
+        "models.SkippedQuestion": {
             "type": "object",
             "properties": {
-                "error": {
+                "id": {
                     "type": "string"
                 },
-                "message": {
+                "reason": {
+                    "type": "string"
+                },
+                "type": {
                     "type": "string"
                 }
             }
         },
-        "models.CreateExperienceRequest": {
+        "models.Source": {
             "type": "object",
             "properties": {
-                "collected_at": {
+                "created_at": {
                     "type": "string"
                 },
-                "field_id": {
+                "encrypt": {
+                    "description": "Encrypt value_text, value_json and user_identifier of records",
+                    "type": "boolean"
+                },
+                "external_id": {
+                    "description": "Matches source_id of the source's records",
                     "type": "string"
                 },
-                "field_label": {
+                "id": {
                     "type": "string"
                 },
-                "field_type": {
+                "name": {
                     "type": "string"
                 },
-                "language": {
+                "pii_mode": {
+                    "description": "How personal data in value_text is handled: off, flag, mask or reject",
                     "type": "string"
                 },
-                "metadata": {
-                    "type": "object"
+                "pii_vault": {
+                    "description": "Keep the original of masked value_text encrypted",
+                    "type": "boolean"
                 },
-                "source_id": {
+                "pseudonymize": {
+                    "description": "Store user_identifier of records only as a keyed hash",
+                    "type": "boolean"
+                },
+                "source_type": {
                     "type": "string"
                 },
-                "source_name": {
+                "strict": {
+                    "description": "Reject records for fields that are not registered",
+                    "type": "boolean"
+                },
+                "updated_at": {
                     "type": "string"
+                }
+            }
+        },
+        "models.SourceField": {
+            "type": "object",
+            "properties": {
+                "choices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.FieldChoice"
+                    }
                 },
-                "source_type": {
+                "created_at": {
                     "type": "string"
                 },
-                "user_identifier": {
+                "field_id": {
+                    "description": "Matches field_id of the field's records",
                     "type": "string"
                 },
-                "value_boolean": {
-                    "type": "boolean"
+                "field_type": {
+                    "type": "string"
                 },
-                "value_date": {
+                "label": {
                     "type": "string"
                 },
-                "value_json": {
-                    "type": "object"
+                "position": {
+                    "description": "Order of the field within the source",
+                    "type": "integer"
                 },
-                "value_number": {
-                    "type": "number"
+                "source_id": {
+                    "type": "string"
                 },
-                "value_text": {
+                "translations": {
+                    "description": "Label keyed by language code",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
                     "type": "string"
                 }
             }
         },
-        "models.ExperienceData": {
+        "models.TimeSeries": {
             "type": "object",
             "properties": {
-                "collected_at": {
+                "group": {
+                    "description": "Group value, null without grouping or for records missing the value",
                     "type": "string"
                 },
-                "created_at": {
+                "points": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimeSeriesPoint"
+                    }
+                }
+            }
+        },
+        "models.TimeSeriesPoint": {
+            "type": "object",
+            "properties": {
+                "bucket": {
+                    "description": "Start of the bucket in the requested time zone",
+                    "type": "string"
+                },
+                "count": {
+                    "description": "Number of matching records in the bucket, only those with a value_number for metrics other than count",
+                    "type": "integer"
+                },
+                "value": {
+                    "description": "Metric value, null if it cannot be computed for the bucket",
+                    "type": "number"
+                }
+            }
+        },
+        "models.TimeSeriesResponse": {
+            "type": "object",
+            "properties": {
+                "group_by": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "string"
+                },
+                "metric": {
                     "type": "string"
                 },
+                "series": {
+                    "description": "One series per group, a single series without grouping",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TimeSeries"
+                    }
+                },
+                "time_zone": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.UpdateExperienceRequest": {
+            "type": "object",
+            "properties": {
                 "field_id": {
                     "type": "string"
                 },
@@ -509,15 +4479,16 @@ const docTemplate = `{
                 "field_type": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
-                },
                 "language": {
                     "type": "string"
                 },
                 "metadata": {
                     "type": "object"
                 },
+                "response_id": {
+                    "description": "Groups the answers of one submission",
+                    "type": "string"
+                },
                 "source_id": {
                     "type": "string"
                 },
@@ -527,9 +4498,6 @@ const docTemplate = `{
                 "source_type": {
                     "type": "string"
                 },
-                "updated_at": {
-                    "type": "string"
-                },
                 "user_identifier": {
                     "type": "string"
                 },
@@ -550,32 +4518,62 @@ const docTemplate = `{
                 }
             }
         },
-        "models.SearchExperiencesResponse": {
+        "models.UpdateRetentionPolicyRequest": {
             "type": "object",
             "properties": {
-                "data": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.ExperienceData"
-                    }
+                "action": {
+                    "type": "string"
                 },
-                "page": {
-                    "type": "integer"
+                "enabled": {
+                    "type": "boolean"
                 },
-                "page_size": {
+                "max_age_days": {
                     "type": "integer"
+                }
+            }
+        },
+        "models.UpdateSourceRequest": {
+            "type": "object",
+            "properties": {
+                "encrypt": {
+                    "description": "Applies to records created or updated afterwards",
+                    "type": "boolean"
                 },
-                "total_count": {
-                    "type": "integer"
+                "name": {
+                    "type": "string"
                 },
-                "total_pages": {
-                    "type": "integer"
+                "pii_mode": {
+                    "type": "string"
+                },
+                "pii_vault": {
+                    "type": "boolean"
+                },
+                "pseudonymize": {
+                    "description": "Applies to records created or updated afterwards, see cmd/pseudonymize for existing ones",
+                    "type": "boolean"
+                },
+                "strict": {
+                    "type": "boolean"
                 }
             }
         },
-        "models.UpdateExperienceRequest": {
+        "models.UpsertExperienceResult": {
             "type": "object",
             "properties": {
+                "collected_at": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "description": "Set while the record is in the trash",
+                    "type": "string"
+                },
+                "encrypted": {
+                    "description": "value_text, value_json and user_identifier are stored encrypted",
+                    "type": "boolean"
+                },
                 "field_id": {
                     "type": "string"
                 },
@@ -585,12 +4583,40 @@ const docTemplate = `{
                 "field_type": {
                     "type": "string"
                 },
+                "id": {
+                    "type": "string"
+                },
                 "language": {
                     "type": "string"
                 },
                 "metadata": {
                     "type": "object"
                 },
+                "natural_key": {
+                    "description": "Fields of the natural key the record was upserted on",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "pii_types": {
+                    "description": "Types of personal data detected in value_text",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "response_id": {
+                    "description": "Groups the answers of one submission",
+                    "type": "string"
+                },
+                "result": {
+                    "type": "string",
+                    "enum": [
+                        "created",
+                        "updated"
+                    ]
+                },
                 "source_id": {
                     "type": "string"
                 },
@@ -600,6 +4626,9 @@ const docTemplate = `{
                 "source_type": {
                     "type": "string"
                 },
+                "updated_at": {
+                    "type": "string"
+                },
                 "user_identifier": {
                     "type": "string"
                 },
@@ -617,6 +4646,29 @@ const docTemplate = `{
                 },
                 "value_text": {
                     "type": "string"
+                },
+                "version": {
+                    "description": "Incremented by every change; the ETag of the record is the quoted version",
+                    "type": "integer"
+                }
+            }
+        },
+        "models.UpsertExperiencesResponse": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "description": "Number of records created",
+                    "type": "integer"
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.UpsertExperienceResult"
+                    }
+                },
+                "updated": {
+                    "description": "Number of records updated",
+                    "type": "integer"
                 }
             }
         }
@@ -0,0 +1,258 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embed the IANA time zone database so time_zone works without system tzdata
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+// AnalyticsHandler handles HTTP requests for experience analytics
+type AnalyticsHandler struct {
+	service *service.AnalyticsService
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(service *service.AnalyticsService) *AnalyticsHandler {
+	return &AnalyticsHandler{service: service}
+}
+
+// Metrics handles GET /v1/analytics/metrics
+// @Summary Compute metrics for a numeric field
+// @Description Compute count, mean, median, percentiles and the value distribution of value_number for a field.
+// @Description Fields with more than 100 distinct values get a distribution of 20 equal-width ranges instead, flagged by distribution_binned.
+// @Description Optionally computes NPS (promoters 9-10, passives 7-8, detractors 0-6), CSAT (top-2-box) or CES (average effort).
+// @Tags analytics
+// @Produce json
+// @Param field_id query string true "Field ID to compute metrics for"
+// @Param metric query string false "Survey metric to compute" Enums(nps, csat, ces)
+// @Param scale_max query number false "Highest answer on the rating scale (default 5 for csat, 7 for ces)"
+// @Param query query string false "Full-text search query (supports quoted phrases, -negation, prefix* and OR)"
+// @Param mode query string false "Search mode for query: fulltext (default) or fuzzy" Enums(fulltext, fuzzy)
+// @Param min_similarity query number false "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)"
+// @Param source_type query string false "Filter by source type"
+// @Param source_id query string false "Filter by source ID"
+// @Param field_type query string false "Filter by field type"
+// @Param user_identifier query string false "Filter by user identifier"
+// @Param response_id query string false "Filter by response ID"
+// @Param pii_type query string false "Filter by a type of personal data detected in value_text" Enums(email, phone, iban, card)
+// @Param start_date query string false "Filter by collected_at >= start_date (RFC3339 format)"
+// @Param end_date query string false "Filter by collected_at <= end_date (RFC3339 format)"
+// @Success 200 {object} models.MetricsResponse
+// @Failure 400 {object} ErrorResponse "Invalid request parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/analytics/metrics [get]
+func (h *AnalyticsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters, paramErr := parseExperienceFilters(query)
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+
+	if filters.FieldID == nil {
+		RespondError(w, http.StatusBadRequest, "invalid_parameter", "field_id is required")
+		return
+	}
+
+	req := &models.MetricsRequest{ExperienceFilters: filters}
+
+	if metric := query.Get("metric"); metric != "" {
+		if metric != models.MetricNPS && metric != models.MetricCSAT && metric != models.MetricCES {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid metric parameter, use nps, csat or ces")
+			return
+		}
+		req.Metric = metric
+	}
+
+	if scaleMaxStr := query.Get("scale_max"); scaleMaxStr != "" {
+		scaleMax, err := strconv.ParseFloat(scaleMaxStr, 64)
+		if err != nil || scaleMax <= 0 {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid scale_max parameter")
+			return
+		}
+		req.ScaleMax = &scaleMax
+	}
+
+	metrics, err := h.service.GetMetrics(r.Context(), req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, metrics)
+}
+
+// TimeSeries handles GET /v1/analytics/timeseries
+// @Summary Compute a metric over time
+// @Description Bucket experience data by collected_at into hours, days, weeks (starting Monday) or months in the given time zone and compute a metric per bucket.
+// @Description Gaps are filled with empty buckets. Grouping by source_type, field_id or metadata.<key> returns one series per group (at most 50, largest first).
+// @Description Without start_date the range covers the last 48 hours, 30 days, 26 weeks or 12 months, depending on the interval.
+// @Tags analytics
+// @Produce json
+// @Param interval query string true "Bucket size" Enums(hour, day, week, month)
+// @Param time_zone query string false "IANA time zone buckets are aligned to (default UTC)"
+// @Param metric query string false "Metric per bucket (default count)" Enums(count, mean, sum, min, max, nps, csat, ces)
+// @Param scale_max query number false "Highest answer on the rating scale for csat (default 5)"
+// @Param group_by query string false "Split into one series per source_type, field_id or metadata.<key>"
+// @Param query query string false "Full-text search query (supports quoted phrases, -negation, prefix* and OR)"
+// @Param mode query string false "Search mode for query: fulltext (default) or fuzzy" Enums(fulltext, fuzzy)
+// @Param min_similarity query number false "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)"
+// @Param source_type query string false "Filter by source type"
+// @Param source_id query string false "Filter by source ID"
+// @Param field_id query string false "Filter by field ID"
+// @Param field_type query string false "Filter by field type"
+// @Param user_identifier query string false "Filter by user identifier"
+// @Param response_id query string false "Filter by response ID"
+// @Param pii_type query string false "Filter by a type of personal data detected in value_text" Enums(email, phone, iban, card)
+// @Param start_date query string false "Filter by collected_at >= start_date (RFC3339 format)"
+// @Param end_date query string false "Filter by collected_at <= end_date (RFC3339 format, default now)"
+// @Success 200 {object} models.TimeSeriesResponse
+// @Failure 400 {object} ErrorResponse "Invalid request parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/analytics/timeseries [get]
+func (h *AnalyticsHandler) TimeSeries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters, paramErr := parseExperienceFilters(query)
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+
+	req := &models.TimeSeriesRequest{ExperienceFilters: filters}
+
+	switch interval := query.Get("interval"); interval {
+	case models.IntervalHour, models.IntervalDay, models.IntervalWeek, models.IntervalMonth:
+		req.Interval = interval
+	default:
+		RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid interval parameter, use hour, day, week or month")
+		return
+	}
+
+	if timeZone := query.Get("time_zone"); timeZone != "" {
+		loc, err := time.LoadLocation(timeZone)
+		if err != nil || timeZone == "Local" {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid time_zone parameter, use an IANA time zone such as Europe/Berlin")
+			return
+		}
+		req.Location = loc
+	}
+
+	if metric := query.Get("metric"); metric != "" {
+		switch metric {
+		case models.MetricCount, models.MetricMean, models.MetricSum, models.MetricMin, models.MetricMax,
+			models.MetricNPS, models.MetricCSAT, models.MetricCES:
+			req.Metric = metric
+		default:
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid metric parameter, use count, mean, sum, min, max, nps, csat or ces")
+			return
+		}
+	}
+
+	if scaleMaxStr := query.Get("scale_max"); scaleMaxStr != "" {
+		scaleMax, err := strconv.ParseFloat(scaleMaxStr, 64)
+		if err != nil || scaleMax <= 0 {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid scale_max parameter")
+			return
+		}
+		req.ScaleMax = &scaleMax
+	}
+
+	if groupBy := query.Get("group_by"); groupBy != "" {
+		key, isMetadata := strings.CutPrefix(groupBy, "metadata.")
+		if (isMetadata && key == "") || (!isMetadata && groupBy != "source_type" && groupBy != "field_id") {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid group_by parameter, use source_type, field_id or metadata.<key>")
+			return
+		}
+		req.GroupBy = &groupBy
+	}
+
+	if req.StartDate != nil && req.EndDate != nil && req.StartDate.After(*req.EndDate) {
+		RespondError(w, http.StatusBadRequest, "invalid_date", "start_date must not be after end_date")
+		return
+	}
+
+	result, err := h.service.GetTimeSeries(r.Context(), req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, result)
+}
+
+// Crosstab handles GET /v1/analytics/crosstab
+// @Summary Cross-tabulate two fields
+// @Description Count the responses for every pair of answers to two fields given in the same response (joined on response_id),
+// @Description with row percentages and Pearson's chi-square test of independence. Each field may have at most 100 distinct answers.
+// @Description Filters restrict the answers that are paired; when a field was answered more than once in a response the latest answer is used.
+// @Description Encrypted text and JSON answers cannot be counted; encrypted_not_counted reports how many were left out.
+// @Tags analytics
+// @Produce json
+// @Param row_field query string true "Field ID whose answers label the rows"
+// @Param column_field query string true "Field ID whose answers label the columns"
+// @Param query query string false "Full-text search query (supports quoted phrases, -negation, prefix* and OR)"
+// @Param mode query string false "Search mode for query: fulltext (default) or fuzzy" Enums(fulltext, fuzzy)
+// @Param min_similarity query number false "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)"
+// @Param source_type query string false "Filter by source type"
+// @Param source_id query string false "Filter by source ID"
+// @Param user_identifier query string false "Filter by user identifier"
+// @Param pii_type query string false "Filter by a type of personal data detected in value_text" Enums(email, phone, iban, card)
+// @Param start_date query string false "Filter by collected_at >= start_date (RFC3339 format)"
+// @Param end_date query string false "Filter by collected_at <= end_date (RFC3339 format)"
+// @Success 200 {object} models.CrosstabResponse
+// @Failure 400 {object} ErrorResponse "Invalid request parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/analytics/crosstab [get]
+func (h *AnalyticsHandler) Crosstab(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters, paramErr := parseExperienceFilters(query)
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+
+	// The fields are chosen by row_field and column_field, and a single response
+	// has no second answer to pair with
+	if filters.FieldID != nil || filters.ResponseID != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_parameter", "field_id and response_id filters are not supported, use row_field and column_field")
+		return
+	}
+
+	req := &models.CrosstabRequest{
+		ExperienceFilters: filters,
+		RowField:          query.Get("row_field"),
+		ColumnField:       query.Get("column_field"),
+	}
+
+	if req.RowField == "" || req.ColumnField == "" {
+		RespondError(w, http.StatusBadRequest, "invalid_parameter", "row_field and column_field are required")
+		return
+	}
+
+	if req.RowField == req.ColumnField {
+		RespondError(w, http.StatusBadRequest, "invalid_parameter", "row_field and column_field must be different fields")
+		return
+	}
+
+	result, err := h.service.GetCrosstab(r.Context(), req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, result)
+}
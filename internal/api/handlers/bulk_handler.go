@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+// BulkHandler handles HTTP requests for bulk updates and deletes of experience data
+type BulkHandler struct {
+	service *service.BulkService
+}
+
+// NewBulkHandler creates a new bulk handler
+func NewBulkHandler(service *service.BulkService) *BulkHandler {
+	return &BulkHandler{service: service}
+}
+
+// respondBulkResult writes the result of a bulk request, 202 Accepted when it runs as a job
+func respondBulkResult(w http.ResponseWriter, result *models.BulkResult) {
+	if result.Job != nil {
+		RespondSuccess(w, http.StatusAccepted, result)
+		return
+	}
+	RespondSuccess(w, http.StatusOK, result)
+}
+
+// Update handles POST /v1/experiences/bulk-update
+// @Summary Bulk update experience data
+// @Description Apply a patch to every record matching the filters, which take the same fields as search, e.g. to rename a mislabelled field_id.
+// @Description At least one filter is required. dry_run only counts the matching records. When more records match than max_affected (default 10000) nothing is changed.
+// @Description Up to 1000 matching records are updated right away, all or none; larger sets, or any with async, are updated by a job in the worker (202),
+// @Description whose progress is reported by GET /v1/bulk-jobs/{id}. metadata is merged into the stored metadata as a JSON merge patch.
+// @Description Records cannot be moved into encrypting or pseudonymizing sources or sources scanning value_text with another pii_mode, and upserted records get the natural key of their new source and field.
+// @Tags experiences
+// @Accept json
+// @Produce json
+// @Param request body models.BulkExperiencesRequest true "Filters selecting the records and the patch to apply"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 200 {object} models.BulkResult
+// @Success 202 {object} models.BulkResult "Records are updated by a job"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 409 {object} ErrorResponse "A record would get the natural key of another, or Idempotency-Key reused"
+// @Failure 422 {object} ErrorResponse "More records match than max_affected, or a record cannot move to the patched source"
+// @Security BearerAuth
+// @Router /v1/experiences/bulk-update [post]
+func (h *BulkHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkExperiencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	result, err := h.service.BulkUpdate(r.Context(), &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	respondBulkResult(w, result)
+}
+
+// Delete handles POST /v1/experiences/bulk-delete
+// @Summary Bulk delete experience data
+// @Description Move every record matching the filters to the trash, e.g. to clean up a spam source. Filters take the same fields as search.
+// @Description At least one filter is required. dry_run only counts the matching records. When more records match than max_affected (default 10000) nothing is deleted.
+// @Description Up to 1000 matching records are deleted right away, all or none; larger sets, or any with async, are deleted by a job in the worker (202),
+// @Description whose progress is reported by GET /v1/bulk-jobs/{id}. Deleted records stay restorable until the trash is purged.
+// @Tags experiences
+// @Accept json
+// @Produce json
+// @Param request body models.BulkExperiencesRequest true "Filters selecting the records to delete"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 200 {object} models.BulkResult
+// @Success 202 {object} models.BulkResult "Records are deleted by a job"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 409 {object} ErrorResponse "Idempotency-Key reused for a different request or still in progress"
+// @Failure 422 {object} ErrorResponse "More records match than max_affected"
+// @Security BearerAuth
+// @Router /v1/experiences/bulk-delete [post]
+func (h *BulkHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkExperiencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	result, err := h.service.BulkDelete(r.Context(), &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	respondBulkResult(w, result)
+}
+
+// GetJob handles GET /v1/bulk-jobs/{id}
+// @Summary Get a bulk job
+// @Description Report the progress of a bulk update or delete run by the worker. A failed job keeps the changes made before it stopped.
+// @Tags experiences
+// @Produce json
+// @Param id path string true "Bulk job ID (UUID)"
+// @Success 200 {object} models.BulkJob
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Bulk job not found"
+// @Security BearerAuth
+// @Router /v1/bulk-jobs/{id} [get]
+func (h *BulkHandler) GetJob(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return
+	}
+
+	job, err := h.service.GetJob(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, job)
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+// exportFileName is the name of the downloaded export, and of the file within the ZIP archive
+const exportFileName = "data-subject-export.json"
+
+// DataSubjectHandler handles HTTP requests for data subjects, the people
+// identified by the user_identifier of experience data
+type DataSubjectHandler struct {
+	service *service.DataSubjectService
+}
+
+// NewDataSubjectHandler creates a new data subject handler
+func NewDataSubjectHandler(service *service.DataSubjectService) *DataSubjectHandler {
+	return &DataSubjectHandler{service: service}
+}
+
+// Export handles GET /v1/data-subjects/{user_identifier}/export
+// @Summary Export a data subject
+// @Description Download every experience data record of a person, including records in the trash, with their change history.
+// @Description The export is a JSON document, or a ZIP archive holding it with format=zip.
+// @Tags data-subjects
+// @Produce json,application/zip
+// @Param user_identifier path string true "User identifier (URL encoded)"
+// @Param format query string false "Download format (default json)" Enums(json, zip)
+// @Success 200 {object} models.DataSubjectExport
+// @Failure 400 {object} ErrorResponse "Invalid request parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "No records for the user identifier"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/data-subjects/{user_identifier}/export [get]
+func (h *DataSubjectHandler) Export(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "zip" {
+		RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid format parameter, use json or zip")
+		return
+	}
+
+	export, err := h.service.ExportDataSubject(r.Context(), r.PathValue("user_identifier"))
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	if format != "zip" {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+exportFileName+`"`)
+		json.NewEncoder(w).Encode(export)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="data-subject-export.zip"`)
+
+	// Headers are sent once the archive is written to, so failures can only be logged
+	archive := zip.NewWriter(w)
+	file, err := archive.Create(exportFileName)
+	if err == nil {
+		err = json.NewEncoder(file).Encode(export)
+	}
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		slog.Error("Failed to write data subject export", "error", err)
+	}
+}
+
+// Erase handles DELETE /v1/data-subjects/{user_identifier}
+// @Summary Erase a data subject
+// @Description Delete or anonymize every experience data record of a person, including records in the trash, and delete their change history.
+// @Description Anonymizing keeps the records for aggregates, clearing user_identifier, metadata (including enrichment outputs) and the value_text of text and file answers.
+// @Description Returns the stored erasure receipt, which holds a keyed HMAC of the user identifier instead of the identifier itself, omitted without encryption keys.
+// @Tags data-subjects
+// @Produce json
+// @Param user_identifier path string true "User identifier (URL encoded)"
+// @Param mode query string false "Erasure mode (default delete)" Enums(delete, anonymize)
+// @Success 200 {object} models.ErasureReceipt
+// @Failure 400 {object} ErrorResponse "Invalid request parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/data-subjects/{user_identifier} [delete]
+func (h *DataSubjectHandler) Erase(w http.ResponseWriter, r *http.Request) {
+	req := &models.EraseDataSubjectRequest{
+		UserIdentifier: r.PathValue("user_identifier"),
+		Mode:           r.URL.Query().Get("mode"),
+	}
+
+	receipt, err := h.service.EraseDataSubject(r.Context(), req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, receipt)
+}
+
+// GetErasureReceipt handles GET /v1/erasure-receipts/{id}
+// @Summary Get an erasure receipt
+// @Description Retrieve the receipt of a data subject erasure
+// @Tags data-subjects
+// @Produce json
+// @Param id path string true "Erasure receipt ID (UUID)"
+// @Success 200 {object} models.ErasureReceipt
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Erasure receipt not found"
+// @Security BearerAuth
+// @Router /v1/erasure-receipts/{id} [get]
+func (h *DataSubjectHandler) GetErasureReceipt(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return
+	}
+
+	receipt, err := h.service.GetErasureReceipt(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, receipt)
+}
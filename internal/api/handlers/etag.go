@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// etag returns the entity tag of a record at version, the quoted version
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseETags splits a list of entity tags as sent in If-Match and If-None-Match
+// into the versions they name. Weak tags are only read when weak is set, tags
+// that do not name a version are skipped. wildcard reports a "*" tag.
+func parseETags(header string, weak bool) (versions []int, wildcard bool) {
+	versions = []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return versions, true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		if version, err := strconv.Atoi(tag[1 : len(tag)-1]); err == nil {
+			versions = append(versions, version)
+		}
+	}
+	return versions, false
+}
+
+// ifMatchVersions reads the If-Match header of r as the versions a change of a
+// record is conditional on. It returns nil when the change is unconditional,
+// without If-Match or with "*", which any existing record matches.
+// Entity tags are compared strongly, so weak tags never match.
+func ifMatchVersions(r *http.Request) []int {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return nil
+	}
+	versions, wildcard := parseETags(header, false)
+	if wildcard {
+		return nil
+	}
+	return versions
+}
+
+// ifMatchError returns the error of a change conditional on the If-Match header
+// of r. A missing record matches no entity tag, not even "*", so with If-Match
+// its not-found error becomes a failed precondition (RFC 9110 section 13.1.1).
+func ifMatchError(r *http.Request, err error) error {
+	if r.Header.Get("If-Match") != "" && errors.Is(err, models.ErrNotFound) {
+		return fmt.Errorf("no current record matches If-Match: %w", models.ErrPreconditionFailed)
+	}
+	return err
+}
+
+// notModified reports whether the If-None-Match header of r matches a record
+// at version, in which case it should get 304 Not Modified instead of the record.
+// Entity tags are compared weakly.
+func notModified(r *http.Request, version int) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	versions, wildcard := parseETags(header, true)
+	if wildcard {
+		return true
+	}
+	return slices.Contains(versions, version)
+}
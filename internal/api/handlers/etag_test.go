@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestIfMatchVersions(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []int
+	}{
+		{name: "no header", header: "", want: nil},
+		{name: "wildcard", header: "*", want: nil},
+		{name: "single tag", header: `"3"`, want: []int{3}},
+		{name: "list of tags", header: `"3", "4"`, want: []int{3, 4}},
+		{name: "weak tags never match", header: `W/"3"`, want: []int{}},
+		{name: "foreign tags never match", header: `"abc"`, want: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PATCH", "/v1/experiences/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+			assert.Equal(t, tt.want, ifMatchVersions(r))
+		})
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   bool
+	}{
+		{name: "no header", header: "", want: false},
+		{name: "current version", header: `"2"`, want: true},
+		{name: "weak current version", header: `W/"2"`, want: true},
+		{name: "old version", header: `"1"`, want: false},
+		{name: "list with current version", header: `"1", "2"`, want: true},
+		{name: "wildcard", header: "*", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/v1/experiences/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-None-Match", tt.header)
+			}
+			assert.Equal(t, tt.want, notModified(r, 2))
+		})
+	}
+}
+
+func TestIfMatchError(t *testing.T) {
+	notFound := fmt.Errorf("experience %w", models.ErrNotFound)
+
+	r := httptest.NewRequest("DELETE", "/v1/experiences/1", nil)
+	assert.ErrorIs(t, ifMatchError(r, notFound), models.ErrNotFound)
+
+	for _, header := range []string{"*", `"3"`} {
+		r.Header.Set("If-Match", header)
+		err := ifMatchError(r, notFound)
+		assert.ErrorIs(t, err, models.ErrPreconditionFailed, header)
+		assert.NotErrorIs(t, err, models.ErrNotFound, header)
+	}
+
+	// Other errors are kept
+	conflict := fmt.Errorf("experience %w", models.ErrConflict)
+	assert.Equal(t, conflict, ifMatchError(r, conflict))
+	assert.NoError(t, ifMatchError(r, nil))
+}
@@ -3,10 +3,13 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
-	"time"
+	"strings"
 
 	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/export"
 	"github.com/xernobyl/formbricks_worktrial/internal/models"
 	"github.com/xernobyl/formbricks_worktrial/internal/service"
 )
@@ -24,13 +27,21 @@ func NewExperienceHandler(service *service.ExperienceService) *ExperienceHandler
 // Create handles POST /v1/experiences
 // @Summary Create experience data
 // @Description Create a new experience data record
+// @Description The value column required by field_type must be set: value_text for text, single_choice and file (http(s) URL),
+// @Description value_number for number, rating (1-10) and nps (0-10), value_boolean for boolean, value_date for date,
+// @Description value_json for multi_choice (array of strings) and matrix (object).
+// @Description When the field is registered for the source, a missing field_label is filled from the registration. Sources in strict mode always validate like strict=true.
 // @Tags experiences
 // @Accept json
 // @Produce json
 // @Param request body models.CreateExperienceRequest true "Experience data to create"
+// @Param strict query bool false "Reject records whose source or field is not registered, or whose field_type differs from the registration"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
 // @Success 201 {object} models.ExperienceData
 // @Failure 400 {object} ErrorResponse
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 422 {object} ErrorResponse "Values do not match the field type, value_text holds personal data the source rejects, or user_identifier starts with hmac: without being a pseudonym"
+// @Failure 409 {object} ErrorResponse "Idempotency-Key reused for a different request or still in progress"
 // @Security BearerAuth
 // @Router /v1/experiences [post]
 func (h *ExperienceHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -40,22 +51,134 @@ func (h *ExperienceHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if strictStr := r.URL.Query().Get("strict"); strictStr != "" {
+		strict, err := strconv.ParseBool(strictStr)
+		if err != nil {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid strict parameter, use true or false")
+			return
+		}
+		req.Strict = strict
+	}
+
 	exp, err := h.service.CreateExperience(r.Context(), &req)
 	if err != nil {
-		RespondError(w, http.StatusBadRequest, "creation_failed", err.Error())
+		RespondServiceError(w, err)
 		return
 	}
 
+	w.Header().Set("ETag", etag(exp.Version))
 	RespondSuccess(w, http.StatusCreated, exp)
 }
 
+// Upsert handles PUT /v1/experiences
+// @Summary Upsert experience data
+// @Description Create records, or replace the live record with the same natural key, so re-syncing the same answers does not duplicate them.
+// @Description The natural key combines the fields given by key, source_type,source_id,field_id,response_id by default, which every record must set.
+// @Description Only records upserted on the same key are matched, records created with POST never are. Records are validated like those created with POST
+// @Description and written all or none; validation errors name the offending record, e.g. [2].value_number.
+// @Tags experiences
+// @Accept json
+// @Produce json
+// @Param request body []models.CreateExperienceRequest true "Records to upsert (at most 1000)"
+// @Param key query string false "Comma separated natural key fields, including source_type and field_id" default(source_type,source_id,field_id,response_id)
+// @Param strict query bool false "Reject records whose source or field is not registered, or whose field_type differs from the registration"
+// @Success 200 {object} models.UpsertExperiencesResponse
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 422 {object} ErrorResponse "A record misses a natural key value, its values do not match the field type, value_text holds personal data the source rejects, or user_identifier starts with hmac: without being a pseudonym"
+// @Security BearerAuth
+// @Router /v1/experiences [put]
+func (h *ExperienceHandler) Upsert(w http.ResponseWriter, r *http.Request) {
+	req := &models.UpsertExperiencesRequest{}
+	if err := json.NewDecoder(r.Body).Decode(&req.Records); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body, expected an array of records")
+		return
+	}
+
+	query := r.URL.Query()
+	if keyStr := query.Get("key"); keyStr != "" {
+		for _, field := range strings.Split(keyStr, ",") {
+			field = strings.TrimSpace(field)
+			if field != "" && !slices.Contains(req.Key, field) {
+				req.Key = append(req.Key, field)
+			}
+		}
+	}
+
+	if strictStr := query.Get("strict"); strictStr != "" {
+		strict, err := strconv.ParseBool(strictStr)
+		if err != nil {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid strict parameter, use true or false")
+			return
+		}
+		req.Strict = strict
+	}
+
+	result, err := h.service.UpsertExperiences(r.Context(), req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, result)
+}
+
+// Lookup handles POST /v1/experiences/lookup
+// @Summary Look up experience data by IDs
+// @Description Retrieve up to 500 records by ID in a single query, in the order of the requested IDs.
+// @Description IDs without a live record are listed in missing. fields limits the returned fields, id is always returned.
+// @Tags experiences
+// @Accept json
+// @Produce json
+// @Param request body models.LookupExperiencesRequest true "IDs of the records and the fields to return"
+// @Success 200 {object} models.LookupExperiencesResponse
+// @Failure 400 {object} ErrorResponse "Invalid request, no IDs, more than 500 IDs or unknown fields"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Security BearerAuth
+// @Router /v1/experiences/lookup [post]
+func (h *ExperienceHandler) Lookup(w http.ResponseWriter, r *http.Request) {
+	var req models.LookupExperiencesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body, expected ids as an array of UUIDs")
+		return
+	}
+	if paramErr := checkFields(req.Fields); paramErr != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", paramErr.message)
+		return
+	}
+
+	result, err := h.service.LookupExperiences(r.Context(), &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	if len(req.Fields) > 0 {
+		sparse := sparseLookupResponse{LookupExperiencesResponse: *result}
+		sparse.Data, err = sparseRecords(result.Data, req.Fields)
+		if err != nil {
+			RespondServiceError(w, err)
+			return
+		}
+		RespondSuccess(w, http.StatusOK, sparse)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, result)
+}
+
 // Get handles GET /v1/experiences/{id}
 // @Summary Get experience data by ID
 // @Description Retrieve a single experience data record by its UUID
+// @Description The ETag header holds the quoted version of the record, for If-None-Match and for If-Match on updates and deletes.
 // @Tags experiences
 // @Produce json
 // @Param id path string true "Experience ID (UUID)"
+// @Param fields query string false "Comma separated fields to return, e.g. id,value_number; all when not set (id is always returned)"
+// @Param If-None-Match header string false "ETag of a cached copy; 304 is returned while the record is unchanged"
 // @Success 200 {object} models.ExperienceData
+// @Header 200 {string} ETag "Quoted version of the record"
+// @Success 304 "Not Modified - The record still matches If-None-Match"
 // @Failure 400 {object} ErrorResponse "Invalid UUID format"
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
 // @Failure 404 {object} ErrorResponse "Experience not found"
@@ -74,9 +197,31 @@ func (h *ExperienceHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fields, paramErr := parseFields(r.URL.Query())
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+
 	exp, err := h.service.GetExperience(r.Context(), id)
 	if err != nil {
-		RespondError(w, http.StatusNotFound, "not_found", err.Error())
+		RespondServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(exp.Version))
+	if notModified(r, exp.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	if fields != nil {
+		sparse, err := export.Object(exp, fields)
+		if err != nil {
+			RespondServiceError(w, err)
+			return
+		}
+		RespondSuccess(w, http.StatusOK, sparse)
 		return
 	}
 
@@ -86,22 +231,97 @@ func (h *ExperienceHandler) Get(w http.ResponseWriter, r *http.Request) {
 // List handles GET /v1/experiences
 // @Summary List experience data
 // @Description Retrieve a list of experience data records with optional filters
+// @Description With Accept: text/csv or application/x-ndjson, records are streamed as CSV rows or JSON lines instead, and limit is optional and not capped.
+// @Description CSV columns follow the field order of experience data, with metadata flattened into a metadata.<key> column for each of its keys, sorted.
 // @Tags experiences
-// @Produce json
+// @Produce json,text/csv,application/x-ndjson
 // @Param source_type query string false "Filter by source type"
 // @Param source_id query string false "Filter by source ID"
 // @Param field_id query string false "Filter by field ID"
 // @Param user_identifier query string false "Filter by user identifier"
-// @Param limit query int false "Maximum number of records to return"
+// @Param response_id query string false "Filter by response ID"
+// @Param pii_type query string false "Filter by a type of personal data detected in value_text" Enums(email, phone, iban, card)
+// @Param limit query int false "Maximum number of records to return (default 100, max 1000 for JSON)"
 // @Param offset query int false "Number of records to skip"
+// @Param fields query string false "Comma separated fields to return, e.g. id,value_number; all when not set (id is always returned)"
+// @Param Accept header string false "application/json (default), text/csv or application/x-ndjson"
 // @Success 200 {array} models.ExperienceData
+// @Failure 400 {object} ErrorResponse "Invalid fields parameter"
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Security BearerAuth
 // @Router /v1/experiences [get]
 func (h *ExperienceHandler) List(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
+	filters := parseListFilters(query)
+
+	fields, paramErr := parseFields(query)
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+	filters.Fields = fields
+
+	w.Header().Add("Vary", "Accept")
+	if format := streamFormat(r); format != "" {
+		stream := newRecordStream(w, format, fields)
+		stream.close(h.service.StreamExperiences(r.Context(), filters, stream.metadataKeys(), func(exp *models.ExperienceData) error {
+			return stream.write(exp)
+		}))
+		return
+	}
+
+	experiences, err := h.service.ListExperiences(r.Context(), filters)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	if fields != nil {
+		sparse, err := sparseRecords(experiences, fields)
+		if err != nil {
+			RespondServiceError(w, err)
+			return
+		}
+		RespondSuccess(w, http.StatusOK, sparse)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, experiences)
+}
 
+// Trash handles GET /v1/experiences/trash
+// @Summary List deleted experience data
+// @Description Retrieve deleted experience data records, most recently deleted first.
+// @Description Deleted records are hidden from all other endpoints until they are restored, and purged permanently once the trash retention period has passed.
+// @Tags experiences
+// @Produce json
+// @Param source_type query string false "Filter by source type"
+// @Param source_id query string false "Filter by source ID"
+// @Param field_id query string false "Filter by field ID"
+// @Param user_identifier query string false "Filter by user identifier"
+// @Param response_id query string false "Filter by response ID"
+// @Param pii_type query string false "Filter by a type of personal data detected in value_text" Enums(email, phone, iban, card)
+// @Param limit query int false "Maximum number of records to return"
+// @Param offset query int false "Number of records to skip"
+// @Success 200 {array} models.ExperienceData
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/experiences/trash [get]
+func (h *ExperienceHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	experiences, err := h.service.ListTrash(r.Context(), parseListFilters(r.URL.Query()))
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, experiences)
+}
+
+// parseListFilters reads the filters shared by List and Trash
+// Invalid limit and offset values are ignored
+func parseListFilters(query url.Values) *models.ListExperiencesFilters {
 	filters := &models.ListExperiencesFilters{}
 
 	if sourceType := query.Get("source_type"); sourceType != "" {
@@ -120,6 +340,14 @@ func (h *ExperienceHandler) List(w http.ResponseWriter, r *http.Request) {
 		filters.UserIdentifier = &userIdentifier
 	}
 
+	if responseID := query.Get("response_id"); responseID != "" {
+		filters.ResponseID = &responseID
+	}
+
+	if piiType := query.Get("pii_type"); piiType != "" {
+		filters.PIIType = &piiType
+	}
+
 	if limitStr := query.Get("limit"); limitStr != "" {
 		limit, err := strconv.Atoi(limitStr)
 		if err == nil && limit > 0 {
@@ -134,27 +362,29 @@ func (h *ExperienceHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	experiences, err := h.service.ListExperiences(r.Context(), filters)
-	if err != nil {
-		RespondError(w, http.StatusInternalServerError, "list_failed", err.Error())
-		return
-	}
-
-	RespondSuccess(w, http.StatusOK, experiences)
+	return filters
 }
 
 // Update handles PATCH /v1/experiences/{id}
 // @Summary Update experience data
 // @Description Update an existing experience data record
+// @Description With If-Match, the update only applies while the record is at the version of the given ETag.
+// @Description Sent as application/json, fields that are null or missing are left unchanged and metadata is replaced.
+// @Description Sent as application/merge-patch+json (RFC 7396), null clears a field and metadata is merged recursively, where null removes a key. Fields that cannot be cleared reject null with 400.
 // @Tags experiences
-// @Accept json
+// @Accept json,application/merge-patch+json
 // @Produce json
 // @Param id path string true "Experience ID (UUID)"
 // @Param request body models.UpdateExperienceRequest true "Fields to update"
+// @Param If-Match header string false "ETag the record must still have"
 // @Success 200 {object} models.ExperienceData
+// @Header 200 {string} ETag "Quoted version of the updated record"
 // @Failure 400 {object} ErrorResponse "Invalid request or UUID format"
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
-// @Failure 404 {object} ErrorResponse "Experience not found"
+// @Failure 404 {object} ErrorResponse "Experience not found, unless If-Match is sent"
+// @Failure 409 {object} ErrorResponse "An upserted record would take the natural key of another live record, or the record kept changing during the update"
+// @Failure 412 {object} ErrorResponse "The record changed since the version of If-Match, or does not exist"
+// @Failure 422 {object} ErrorResponse "Values do not match the field type, value_text holds personal data the source rejects, or user_identifier starts with hmac: without being a pseudonym"
 // @Security BearerAuth
 // @Router /v1/experiences/{id} [patch]
 func (h *ExperienceHandler) Update(w http.ResponseWriter, r *http.Request) {
@@ -171,29 +401,40 @@ func (h *ExperienceHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req models.UpdateExperienceRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+	if isMergePatch(r) {
+		if err := decodeMergePatch(r.Body, &req); err != nil {
+			RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body, expected a JSON merge patch object")
+			return
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
 		return
 	}
 
+	req.IfMatch = ifMatchVersions(r)
+
 	exp, err := h.service.UpdateExperience(r.Context(), id, &req)
 	if err != nil {
-		RespondError(w, http.StatusBadRequest, "update_failed", err.Error())
+		RespondServiceError(w, ifMatchError(r, err))
 		return
 	}
 
+	w.Header().Set("ETag", etag(exp.Version))
 	RespondSuccess(w, http.StatusOK, exp)
 }
 
 // Delete handles DELETE /v1/experiences/{id}
 // @Summary Delete experience data
-// @Description Delete an experience data record by ID
+// @Description Move an experience data record to the trash. It can be restored until the trash retention period has passed.
+// @Description With If-Match, the record is only deleted while it is at the version of the given ETag.
 // @Tags experiences
 // @Param id path string true "Experience ID (UUID)"
+// @Param If-Match header string false "ETag the record must still have"
 // @Success 204 "No Content - Successfully deleted"
 // @Failure 400 {object} ErrorResponse "Invalid UUID format"
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
-// @Failure 404 {object} ErrorResponse "Experience not found"
+// @Failure 404 {object} ErrorResponse "Experience not found, unless If-Match is sent"
+// @Failure 412 {object} ErrorResponse "The record changed since the version of If-Match, or does not exist"
 // @Security BearerAuth
 // @Router /v1/experiences/{id} [delete]
 func (h *ExperienceHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -209,29 +450,140 @@ func (h *ExperienceHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.DeleteExperience(r.Context(), id); err != nil {
-		RespondError(w, http.StatusNotFound, "delete_failed", err.Error())
+	if err := h.service.DeleteExperience(r.Context(), id, ifMatchVersions(r)); err != nil {
+		RespondServiceError(w, ifMatchError(r, err))
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// Restore handles POST /v1/experiences/{id}/restore
+// @Summary Restore deleted experience data
+// @Description Move a deleted experience data record out of the trash
+// @Tags experiences
+// @Produce json
+// @Param id path string true "Experience ID (UUID)"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 200 {object} models.ExperienceData
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Experience not found in the trash"
+// @Failure 409 {object} ErrorResponse "A live record has the same natural key, or Idempotency-Key reused for a different request or still in progress"
+// @Security BearerAuth
+// @Router /v1/experiences/{id}/restore [post]
+func (h *ExperienceHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Experience ID is required")
+		return
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return
+	}
+
+	exp, err := h.service.RestoreExperience(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(exp.Version))
+	RespondSuccess(w, http.StatusOK, exp)
+}
+
+// History handles GET /v1/experiences/{id}/history
+// @Summary Get the change history of experience data
+// @Description Retrieve every create, update, delete and restore of an experience data record, oldest first,
+// @Description with the record before and after the change and the ID of the API key that made it.
+// @Description Records in the trash keep their history until they are purged.
+// @Tags experiences
+// @Produce json
+// @Param id path string true "Experience ID (UUID)"
+// @Success 200 {array} models.ExperienceHistoryEntry
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Experience not found"
+// @Security BearerAuth
+// @Router /v1/experiences/{id}/history [get]
+func (h *ExperienceHandler) History(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	if idStr == "" {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Experience ID is required")
+		return
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return
+	}
+
+	history, err := h.service.GetExperienceHistory(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, history)
+}
+
+// Vault handles GET /v1/experiences/{id}/vault
+// @Summary Get the original value_text of masked experience data
+// @Description Decrypt the original value_text of a record whose personal data was masked by a source keeping a PII vault
+// @Tags experiences
+// @Produce json
+// @Param id path string true "Experience ID (UUID)"
+// @Success 200 {object} models.ExperienceVault
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Experience not found or without a vaulted value"
+// @Security BearerAuth
+// @Router /v1/experiences/{id}/vault [get]
+func (h *ExperienceHandler) Vault(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return
+	}
+
+	vault, err := h.service.GetExperienceVault(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, vault)
+}
+
 // Search handles GET /v1/experiences/search
 // @Summary Search experience data
-// @Description Search experience data with advanced filters, full-text search, and pagination
+// @Description Search experience data with advanced filters, full-text search, and pagination.
+// @Description Text matches are stemmed using each record's language, ordered by relevance and include a highlighted snippet.
+// @Description Encrypted records cannot match a query; encrypted_not_searched counts those matching the other filters.
+// @Description With Accept: text/csv or application/x-ndjson, all matching results are streamed as CSV rows or JSON lines instead, without pagination, counts or facets.
 // @Tags experiences
-// @Produce json
-// @Param query query string false "Full-text search query"
+// @Produce json,text/csv,application/x-ndjson
+// @Param query query string false "Full-text search query (supports quoted phrases, -negation, prefix* and OR)"
+// @Param mode query string false "Search mode for query: fulltext (default) or fuzzy (typo-tolerant trigram similarity)" Enums(fulltext, fuzzy)
+// @Param min_similarity query number false "Fuzzy mode similarity threshold, greater than 0 and at most 1 (default 0.4)"
 // @Param source_type query string false "Filter by source type"
 // @Param source_id query string false "Filter by source ID"
 // @Param field_id query string false "Filter by field ID"
 // @Param field_type query string false "Filter by field type"
 // @Param user_identifier query string false "Filter by user identifier"
+// @Param response_id query string false "Filter by response ID"
+// @Param pii_type query string false "Filter by a type of personal data detected in value_text" Enums(email, phone, iban, card)
 // @Param start_date query string false "Filter by collected_at >= start_date (RFC3339 format)"
 // @Param end_date query string false "Filter by collected_at <= end_date (RFC3339 format)"
 // @Param pageSize query int false "Number of results per page (default 20, max 40)"
 // @Param page query int false "Page number (starts at 0, default 0)"
+// @Param facets query string false "Comma-separated facets to count under the current filters: source_type, source_id, field_id, field_type, language, sentiment"
+// @Param fields query string false "Comma separated fields of each result to return, e.g. id,value_number; all when not set (id, rank, highlight and similarity are always returned)"
+// @Param Accept header string false "application/json (default), text/csv or application/x-ndjson"
 // @Success 200 {object} models.SearchExperiencesResponse
 // @Failure 400 {object} ErrorResponse "Invalid request parameters"
 // @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
@@ -241,52 +593,13 @@ func (h *ExperienceHandler) Delete(w http.ResponseWriter, r *http.Request) {
 func (h *ExperienceHandler) Search(w http.ResponseWriter, r *http.Request) {
 	query := r.URL.Query()
 
-	req := &models.SearchExperiencesRequest{}
-
-	// Parse full-text search query
-	if q := query.Get("query"); q != "" {
-		req.Query = &q
-	}
-
-	// Parse filters
-	if sourceType := query.Get("source_type"); sourceType != "" {
-		req.SourceType = &sourceType
-	}
-
-	if sourceID := query.Get("source_id"); sourceID != "" {
-		req.SourceID = &sourceID
-	}
-
-	if fieldID := query.Get("field_id"); fieldID != "" {
-		req.FieldID = &fieldID
-	}
-
-	if fieldType := query.Get("field_type"); fieldType != "" {
-		req.FieldType = &fieldType
-	}
-
-	if userIdentifier := query.Get("user_identifier"); userIdentifier != "" {
-		req.UserIdentifier = &userIdentifier
-	}
-
-	// Parse date range
-	if startDateStr := query.Get("start_date"); startDateStr != "" {
-		startDate, err := time.Parse(time.RFC3339, startDateStr)
-		if err != nil {
-			RespondError(w, http.StatusBadRequest, "invalid_date", "Invalid start_date format, use RFC3339")
-			return
-		}
-		req.StartDate = &startDate
+	filters, paramErr := parseExperienceFilters(query)
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
 	}
 
-	if endDateStr := query.Get("end_date"); endDateStr != "" {
-		endDate, err := time.Parse(time.RFC3339, endDateStr)
-		if err != nil {
-			RespondError(w, http.StatusBadRequest, "invalid_date", "Invalid end_date format, use RFC3339")
-			return
-		}
-		req.EndDate = &endDate
-	}
+	req := &models.SearchExperiencesRequest{ExperienceFilters: filters}
 
 	// Parse pagination parameters
 	// pageSize defaults to 20, max 40 (enforced in service layer)
@@ -309,10 +622,52 @@ func (h *ExperienceHandler) Search(w http.ResponseWriter, r *http.Request) {
 		req.Page = page
 	}
 
+	// Parse requested facets
+	if facetsStr := query.Get("facets"); facetsStr != "" {
+		for _, facet := range strings.Split(facetsStr, ",") {
+			facet = strings.TrimSpace(facet)
+			if facet == "" || slices.Contains(req.Facets, facet) {
+				continue
+			}
+			if !slices.Contains(models.SearchFacetNames, facet) {
+				RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid facet: "+facet)
+				return
+			}
+			req.Facets = append(req.Facets, facet)
+		}
+	}
+
+	fields, paramErr := parseFields(query)
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+	req.Fields = fields
+
+	w.Header().Add("Vary", "Accept")
+	if format := streamFormat(r); format != "" {
+		stream := newRecordStream(w, format, fields, "rank", "highlight", "similarity")
+		stream.close(h.service.StreamSearch(r.Context(), req, stream.metadataKeys(), func(res *models.ExperienceSearchResult) error {
+			return stream.write(res)
+		}))
+		return
+	}
+
 	// Call service to search
 	result, err := h.service.SearchExperiences(r.Context(), req)
 	if err != nil {
-		RespondError(w, http.StatusInternalServerError, "search_failed", err.Error())
+		RespondServiceError(w, err)
+		return
+	}
+
+	if fields != nil {
+		sparse := sparseSearchResponse{SearchExperiencesResponse: *result}
+		sparse.Data, err = sparseRecords(result.Data, fields, "rank", "highlight", "similarity")
+		if err != nil {
+			RespondServiceError(w, err)
+			return
+		}
+		RespondSuccess(w, http.StatusOK, sparse)
 		return
 	}
 
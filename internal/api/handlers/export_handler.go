@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/export"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/blob"
+)
+
+// ExportHandler handles HTTP requests for exports of experience data to files
+type ExportHandler struct {
+	service *service.ExportService
+	files   *blob.FileStore // Serves downloads of a local store, nil when files are kept elsewhere
+}
+
+// NewExportHandler creates a new export handler
+func NewExportHandler(service *service.ExportService, files *blob.FileStore) *ExportHandler {
+	return &ExportHandler{service: service, files: files}
+}
+
+// Create handles POST /v1/exports
+// @Summary Export experience data to a file
+// @Description Create an export of every record matching the filters, which take the same fields as search, to a csv, ndjson or parquet file.
+// @Description Without filters every live record is exported. The worker writes the file from a single consistent snapshot of the data,
+// @Description so poll GET /v1/exports/{id} until its status is completed and fetch the file from its download_url.
+// @Description CSV files flatten metadata into a metadata.<key> column per key; Parquet files store timestamps in microseconds and arrays and objects as JSON.
+// @Description Searches with a query also export rank and highlight, or similarity in fuzzy mode. Files are deleted once the export expires.
+// @Tags exports
+// @Accept json
+// @Produce json
+// @Param request body models.CreateExportRequest true "Filters selecting the records, the file format and the fields to export"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 202 {object} models.Export "The export is waiting for the worker"
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 409 {object} ErrorResponse "Idempotency-Key reused for a different request or still in progress"
+// @Security BearerAuth
+// @Router /v1/exports [post]
+func (h *ExportHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	exp, err := h.service.CreateExport(r.Context(), &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusAccepted, exp)
+}
+
+// Get handles GET /v1/exports/{id}
+// @Summary Get an export
+// @Description Report the status of an export. Completed exports have a signed download_url, valid until download_expires_at without an API key;
+// @Description fetch the export again for a fresh one. Failed exports report their error, expired ones no longer have a file.
+// @Tags exports
+// @Produce json
+// @Param id path string true "Export ID (UUID)"
+// @Success 200 {object} models.Export
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Export not found"
+// @Security BearerAuth
+// @Router /v1/exports/{id} [get]
+func (h *ExportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return
+	}
+
+	exp, err := h.service.GetExport(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, exp)
+}
+
+// Download handles GET /v1/downloads/{key}
+// @Summary Download an exported file
+// @Description Serve a file of the local blob store from a signed download URL, as returned in the download_url of a completed export.
+// @Description No API key is needed; the URL is signed instead and stops working when it expires. Range requests are supported.
+// @Tags exports
+// @Produce octet-stream
+// @Param key path string true "Key of the file, e.g. exports/{id}.parquet"
+// @Param expires query int true "Expiry time of the URL (Unix seconds)"
+// @Param signature query string true "Signature of the key and expiry time"
+// @Success 200 {file} file
+// @Failure 403 {object} ErrorResponse "Invalid or expired signature"
+// @Failure 404 {object} ErrorResponse "File not found"
+// @Router /v1/downloads/{key} [get]
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	query := r.URL.Query()
+
+	file, err := h.files.Open(key, query.Get("expires"), query.Get("signature"), time.Now())
+	switch {
+	case errors.Is(err, blob.ErrInvalidSignature):
+		RespondError(w, http.StatusForbidden, "forbidden", "Invalid or expired download URL")
+		return
+	case errors.Is(err, blob.ErrNotFound):
+		RespondError(w, http.StatusNotFound, "not_found", "File not found")
+		return
+	case err != nil:
+		RespondServiceError(w, err)
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	// Large files outlast the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Debug("Write deadline not cleared for download", "error", err)
+	}
+
+	name := path.Base(key)
+	w.Header().Set("Content-Type", export.ContentType(strings.TrimPrefix(path.Ext(name), ".")))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	w.Header().Set("Cache-Control", "private, no-store")
+	http.ServeContent(w, r, name, info.ModTime(), file)
+}
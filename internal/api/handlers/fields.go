@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/export"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// checkFields returns an error naming the first of fields that experience
+// data does not have
+func checkFields(fields []string) *paramError {
+	for _, field := range fields {
+		if !slices.Contains(models.ExperienceFieldNames, field) {
+			return &paramError{"invalid_parameter", "Invalid field: " + field}
+		}
+	}
+	return nil
+}
+
+// parseFields reads the comma separated sparse fieldset of the fields query
+// parameter, nil when it is not set
+func parseFields(query url.Values) ([]string, *paramError) {
+	fieldsStr := query.Get("fields")
+	if fieldsStr == "" {
+		return nil, nil
+	}
+
+	var fields []string
+	for _, field := range strings.Split(fieldsStr, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" && !slices.Contains(fields, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, checkFields(fields)
+}
+
+// sparseRecords encodes every record as a JSON object holding only its id,
+// the given fields and the members named by keep
+func sparseRecords[T any](records []T, fields []string, keep ...string) ([]map[string]json.RawMessage, error) {
+	objects := make([]map[string]json.RawMessage, 0, len(records))
+	for i := range records {
+		object, err := export.Object(&records[i], fields, keep...)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+// sparseSearchResponse is a search response whose results hold a sparse fieldset
+type sparseSearchResponse struct {
+	models.SearchExperiencesResponse
+	Data []map[string]json.RawMessage `json:"data"`
+}
+
+// sparseLookupResponse is a lookup response whose records hold a sparse fieldset
+type sparseLookupResponse struct {
+	models.LookupExperiencesResponse
+	Data []map[string]json.RawMessage `json:"data"`
+}
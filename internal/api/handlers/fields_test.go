@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestParseFields(t *testing.T) {
+	fields, err := parseFields(url.Values{})
+	assert.Nil(t, err)
+	assert.Nil(t, fields)
+
+	fields, err = parseFields(url.Values{"fields": {"id, value_number,,value_number"}})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id", "value_number"}, fields)
+
+	_, err = parseFields(url.Values{"fields": {"id,value_blob"}})
+	require.NotNil(t, err)
+	assert.Contains(t, err.message, "value_blob")
+}
+
+func TestSparseRecords(t *testing.T) {
+	score := 9.0
+	rank := 0.5
+	results := []models.ExperienceSearchResult{{
+		ExperienceData: models.ExperienceData{
+			ID:          uuid.New(),
+			FieldID:     "nps",
+			ValueNumber: &score,
+			Metadata:    json.RawMessage(`{"large": true}`),
+		},
+		Rank: &rank,
+	}}
+
+	sparse, err := sparseRecords(results, []string{"value_number"}, "rank")
+	require.NoError(t, err)
+	require.Len(t, sparse, 1)
+
+	keys := make([]string, 0, len(sparse[0]))
+	for key := range sparse[0] {
+		keys = append(keys, key)
+	}
+	assert.ElementsMatch(t, []string{"id", "value_number", "rank"}, keys)
+	assert.JSONEq(t, "9", string(sparse[0]["value_number"]))
+}
+
+func TestSparseSearchResponse(t *testing.T) {
+	resp := sparseSearchResponse{
+		SearchExperiencesResponse: models.SearchExperiencesResponse{TotalCount: 1},
+		Data:                      []map[string]json.RawMessage{{"id": json.RawMessage(`"a"`)}},
+	}
+
+	encoded, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"data": [{"id": "a"}], "page": 0, "page_size": 0, "total_count": 1, "total_pages": 0}`, string(encoded))
+}
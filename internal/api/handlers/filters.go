@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// paramError describes a query parameter that could not be parsed
+type paramError struct {
+	errorType string
+	message   string
+}
+
+func (e *paramError) Error() string {
+	return e.message
+}
+
+// respondParamError writes a 400 response for a paramError
+func respondParamError(w http.ResponseWriter, err *paramError) {
+	RespondError(w, http.StatusBadRequest, err.errorType, err.message)
+}
+
+// parseExperienceFilters reads the experience filters shared by search and
+// analytics endpoints from query parameters
+func parseExperienceFilters(query url.Values) (models.ExperienceFilters, *paramError) {
+	var filters models.ExperienceFilters
+
+	// Parse full-text search query
+	if q := query.Get("query"); q != "" {
+		filters.Query = &q
+	}
+
+	// Parse search mode, fulltext is the default (enforced in service layer)
+	if mode := query.Get("mode"); mode != "" {
+		if mode != models.SearchModeFullText && mode != models.SearchModeFuzzy {
+			return filters, &paramError{"invalid_parameter", "Invalid mode parameter, use fulltext or fuzzy"}
+		}
+		filters.Mode = mode
+	}
+
+	if minSimilarityStr := query.Get("min_similarity"); minSimilarityStr != "" {
+		minSimilarity, err := strconv.ParseFloat(minSimilarityStr, 64)
+		if err != nil || minSimilarity <= 0 || minSimilarity > 1 {
+			return filters, &paramError{"invalid_parameter", "Invalid min_similarity parameter, must be greater than 0 and at most 1"}
+		}
+		filters.MinSimilarity = minSimilarity
+	}
+
+	// Parse filters
+	if sourceType := query.Get("source_type"); sourceType != "" {
+		filters.SourceType = &sourceType
+	}
+
+	if sourceID := query.Get("source_id"); sourceID != "" {
+		filters.SourceID = &sourceID
+	}
+
+	if fieldID := query.Get("field_id"); fieldID != "" {
+		filters.FieldID = &fieldID
+	}
+
+	if fieldType := query.Get("field_type"); fieldType != "" {
+		filters.FieldType = &fieldType
+	}
+
+	if userIdentifier := query.Get("user_identifier"); userIdentifier != "" {
+		filters.UserIdentifier = &userIdentifier
+	}
+
+	if responseID := query.Get("response_id"); responseID != "" {
+		filters.ResponseID = &responseID
+	}
+
+	if piiType := query.Get("pii_type"); piiType != "" {
+		filters.PIIType = &piiType
+	}
+
+	// Parse date range
+	startDate, paramErr := parseDateParam(query, "start_date")
+	if paramErr != nil {
+		return filters, paramErr
+	}
+	filters.StartDate = startDate
+
+	endDate, paramErr := parseDateParam(query, "end_date")
+	if paramErr != nil {
+		return filters, paramErr
+	}
+	filters.EndDate = endDate
+
+	return filters, nil
+}
+
+// parseDateParam reads an optional RFC3339 timestamp from a query parameter
+func parseDateParam(query url.Values, name string) (*time.Time, *paramError) {
+	value := query.Get(name)
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, &paramError{"invalid_date", "Invalid " + name + " format, use RFC3339"}
+	}
+
+	return &date, nil
+}
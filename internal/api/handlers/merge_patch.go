@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// mergePatchContentType is the media type of JSON Merge Patch (RFC 7396) request bodies
+const mergePatchContentType = "application/merge-patch+json"
+
+// isMergePatch reports whether the body of r is a JSON merge patch
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == mergePatchContentType
+}
+
+// decodeMergePatch reads a JSON merge patch of experience data from body into
+// req. Members set to null are listed in req.Clear, the others are decoded like
+// a regular update, and metadata is marked to be merged into the stored object.
+func decodeMergePatch(body io.Reader, req *models.UpdateExperienceRequest) error {
+	var patch map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&patch); err != nil {
+		return err
+	}
+	if patch == nil {
+		return errors.New("merge patch must be an object")
+	}
+
+	for field, value := range patch {
+		if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			req.Clear = append(req.Clear, field)
+			delete(patch, field)
+		}
+	}
+
+	values, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(values, req); err != nil {
+		return err
+	}
+	req.MergeMetadata = req.Metadata != nil
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestIsMergePatch(t *testing.T) {
+	r := httptest.NewRequest("PATCH", "/v1/experiences/1", nil)
+	r.Header.Set("Content-Type", "application/merge-patch+json; charset=utf-8")
+	assert.True(t, isMergePatch(r))
+
+	r.Header.Set("Content-Type", "application/json")
+	assert.False(t, isMergePatch(r))
+}
+
+func TestDecodeMergePatch(t *testing.T) {
+	var req models.UpdateExperienceRequest
+	err := decodeMergePatch(strings.NewReader(`{
+		"value_text": null,
+		"source_name": null,
+		"language": "de",
+		"metadata": {"sentiment": null, "tags": ["billing"]}
+	}`), &req)
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"value_text", "source_name"}, req.Clear)
+	assert.True(t, req.Clears("value_text"))
+	assert.Nil(t, req.ValueText)
+	require.NotNil(t, req.Language)
+	assert.Equal(t, "de", *req.Language)
+	assert.JSONEq(t, `{"sentiment": null, "tags": ["billing"]}`, string(req.Metadata))
+	assert.True(t, req.MergeMetadata)
+}
+
+func TestDecodeMergePatchClearsMetadata(t *testing.T) {
+	var req models.UpdateExperienceRequest
+	require.NoError(t, decodeMergePatch(strings.NewReader(`{"metadata": null}`), &req))
+	assert.Equal(t, []string{"metadata"}, req.Clear)
+	assert.False(t, req.MergeMetadata)
+}
+
+func TestDecodeMergePatchRejectsNonObjects(t *testing.T) {
+	var req models.UpdateExperienceRequest
+	assert.Error(t, decodeMergePatch(strings.NewReader(`null`), &req))
+	assert.Error(t, decodeMergePatch(strings.NewReader(`["value_text"]`), &req))
+}
@@ -2,13 +2,25 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
 	"net/http"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
 )
 
-// ErrorResponse represents an API error response
+// problemContentType is the media type of RFC 7807 problem documents
+const problemContentType = "application/problem+json"
+
+// ErrorResponse represents an API error as an RFC 7807 problem document
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message,omitempty"`
+	Type    string              `json:"type"`              // Problem type URI, about:blank as errors are identified by status and error
+	Title   string              `json:"title"`             // HTTP status text
+	Status  int                 `json:"status"`            // HTTP status code
+	Detail  string              `json:"detail,omitempty"`  // Explanation of this occurrence
+	Error   string              `json:"error"`             // Machine readable error type, e.g. not_found
+	Details []models.FieldError `json:"details,omitempty"` // Per-field reasons for validation errors
 }
 
 // SuccessResponse represents a generic success response
@@ -26,14 +38,58 @@ func RespondJSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	}
 }
 
-// RespondError writes an error JSON response
+// respondProblem writes an RFC 7807 problem document
+func respondProblem(w http.ResponseWriter, problem ErrorResponse) {
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(problem.Status)
+	json.NewEncoder(w).Encode(problem)
+}
+
+// RespondError writes an error response
+// message is shown to clients and must not contain internal error text
 func RespondError(w http.ResponseWriter, statusCode int, errorType string, message string) {
-	RespondJSON(w, statusCode, ErrorResponse{
-		Error:   errorType,
-		Message: message,
+	respondProblem(w, ErrorResponse{
+		Status: statusCode,
+		Detail: message,
+		Error:  errorType,
 	})
 }
 
+// RespondServiceError writes the response for an error returned by the service layer
+// The status follows the sentinel error the error wraps. Errors without one are
+// logged and reported as a generic internal error, so database errors never
+// reach clients.
+func RespondServiceError(w http.ResponseWriter, err error) {
+	var validationErr *service.ValidationError
+	errors.As(err, &validationErr)
+
+	var problem ErrorResponse
+	switch {
+	case errors.Is(err, models.ErrInvalidInput):
+		problem = ErrorResponse{Status: http.StatusBadRequest, Error: "invalid_request", Detail: err.Error()}
+	case errors.Is(err, models.ErrValidation):
+		problem = ErrorResponse{Status: http.StatusUnprocessableEntity, Error: "validation_failed", Detail: err.Error()}
+	case errors.Is(err, models.ErrNotFound):
+		problem = ErrorResponse{Status: http.StatusNotFound, Error: "not_found", Detail: err.Error()}
+	case errors.Is(err, models.ErrConflict):
+		problem = ErrorResponse{Status: http.StatusConflict, Error: "conflict", Detail: err.Error()}
+	case errors.Is(err, models.ErrPreconditionFailed):
+		problem = ErrorResponse{Status: http.StatusPreconditionFailed, Error: "precondition_failed", Detail: err.Error()}
+	default:
+		slog.Error("Request failed", "error", err)
+		problem = ErrorResponse{Status: http.StatusInternalServerError, Error: "internal_error", Detail: "An internal error occurred"}
+	}
+
+	if validationErr != nil {
+		problem.Details = validationErr.Errors
+	}
+
+	respondProblem(w, problem)
+}
+
 // RespondSuccess writes a success JSON response
 func RespondSuccess(w http.ResponseWriter, statusCode int, data interface{}) {
 	RespondJSON(w, statusCode, SuccessResponse{
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+// ResponseHandler handles HTTP requests for responses, the answers of one
+// submission grouped by response_id (not to be confused with HTTP responses)
+type ResponseHandler struct {
+	service *service.ResponseService
+}
+
+// NewResponseHandler creates a new response handler
+func NewResponseHandler(service *service.ResponseService) *ResponseHandler {
+	return &ResponseHandler{service: service}
+}
+
+// Get handles GET /v1/responses/{id}
+// @Summary Get a response
+// @Description Retrieve all answers of a single submission sharing the response_id
+// @Tags responses
+// @Produce json
+// @Param id path string true "Response ID"
+// @Success 200 {object} models.Response
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Response not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/responses/{id} [get]
+func (h *ResponseHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Response ID is required")
+		return
+	}
+
+	response, err := h.service.GetResponse(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, response)
+}
+
+// List handles GET /v1/responses
+// @Summary List responses
+// @Description List submissions with their answer values pivoted into one column per field_id.
+// @Description If a field was answered more than once in a response the latest answer is returned.
+// @Tags responses
+// @Produce json
+// @Param source_type query string false "Filter by source type"
+// @Param source_id query string false "Filter by source ID"
+// @Param user_identifier query string false "Filter by user identifier"
+// @Param start_date query string false "Filter by collected_at >= start_date (RFC3339 format)"
+// @Param end_date query string false "Filter by collected_at <= end_date (RFC3339 format)"
+// @Param limit query int false "Maximum number of responses to return (default 100, max 1000)"
+// @Param offset query int false "Number of responses to skip"
+// @Success 200 {object} models.ListResponsesResponse
+// @Failure 400 {object} ErrorResponse "Invalid request parameters"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/responses [get]
+func (h *ResponseHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters := &models.ListResponsesFilters{}
+
+	if sourceType := query.Get("source_type"); sourceType != "" {
+		filters.SourceType = &sourceType
+	}
+
+	if sourceID := query.Get("source_id"); sourceID != "" {
+		filters.SourceID = &sourceID
+	}
+
+	if userIdentifier := query.Get("user_identifier"); userIdentifier != "" {
+		filters.UserIdentifier = &userIdentifier
+	}
+
+	startDate, paramErr := parseDateParam(query, "start_date")
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+	filters.StartDate = startDate
+
+	endDate, paramErr := parseDateParam(query, "end_date")
+	if paramErr != nil {
+		respondParamError(w, paramErr)
+		return
+	}
+	filters.EndDate = endDate
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err == nil && limit > 0 {
+			filters.Limit = limit
+		}
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err == nil && offset >= 0 {
+			filters.Offset = offset
+		}
+	}
+
+	responses, err := h.service.ListResponses(r.Context(), filters)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, responses)
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+func TestRespondServiceError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantStatus  int
+		wantError   string
+		wantDetail  string
+		wantDetails int
+	}{
+		{
+			name:       "not found",
+			err:        fmt.Errorf("experience %w", models.ErrNotFound),
+			wantStatus: http.StatusNotFound,
+			wantError:  "not_found",
+			wantDetail: "experience not found",
+		},
+		{
+			name:       "conflict",
+			err:        fmt.Errorf("source %w", models.ErrConflict),
+			wantStatus: http.StatusConflict,
+			wantError:  "conflict",
+			wantDetail: "source already exists",
+		},
+		{
+			name:       "precondition failed",
+			err:        fmt.Errorf("experience is at version 3, which does not match If-Match: %w", models.ErrPreconditionFailed),
+			wantStatus: http.StatusPreconditionFailed,
+			wantError:  "precondition_failed",
+			wantDetail: "experience is at version 3, which does not match If-Match: precondition failed",
+		},
+		{
+			name: "missing field",
+			err: &service.ValidationError{
+				Err:    models.ErrInvalidInput,
+				Errors: []models.FieldError{{Field: "field_id", Code: models.ValidationRequired, Message: "field_id is required"}},
+			},
+			wantStatus:  http.StatusBadRequest,
+			wantError:   "invalid_request",
+			wantDetail:  "field_id is required",
+			wantDetails: 1,
+		},
+		{
+			name: "invalid value",
+			err: &service.ValidationError{
+				Err:    models.ErrValidation,
+				Errors: []models.FieldError{{Field: "value_number", Code: models.ValidationOutOfRange, Message: "value_number must be between 0 and 10, got 11"}},
+			},
+			wantStatus:  http.StatusUnprocessableEntity,
+			wantError:   "validation_failed",
+			wantDetail:  "value_number must be between 0 and 10, got 11",
+			wantDetails: 1,
+		},
+		{
+			name:       "database errors are not exposed",
+			err:        fmt.Errorf("failed to create experience: %w", errors.New(`pq: relation "experience_data" does not exist`)),
+			wantStatus: http.StatusInternalServerError,
+			wantError:  "internal_error",
+			wantDetail: "An internal error occurred",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RespondServiceError(rec, tt.err)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+			var problem ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&problem))
+			assert.Equal(t, "about:blank", problem.Type)
+			assert.Equal(t, http.StatusText(tt.wantStatus), problem.Title)
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, tt.wantError, problem.Error)
+			assert.Equal(t, tt.wantDetail, problem.Detail)
+			assert.Len(t, problem.Details, tt.wantDetails)
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+// RetentionHandler handles HTTP requests for retention policies
+type RetentionHandler struct {
+	service *service.RetentionService
+}
+
+// NewRetentionHandler creates a new retention handler
+func NewRetentionHandler(service *service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{service: service}
+}
+
+// parsePolicyID reads the retention policy UUID from the path, writing a 400 response if it is invalid
+func parsePolicyID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// Create handles POST /v1/retention-policies
+// @Summary Create a retention policy
+// @Description Limit how long experience data of a source_type (and optionally a single source_id) is kept.
+// @Description The worker deletes or anonymizes records collected more than max_age_days ago, including records in the trash.
+// @Description Anonymizing clears user_identifier, metadata and the value_text of text and file answers; the change history of affected records is deleted.
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param request body models.CreateRetentionPolicyRequest true "Retention policy to create"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 201 {object} models.RetentionPolicy
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 409 {object} ErrorResponse "Idempotency-Key reused for a different request or still in progress"
+// @Security BearerAuth
+// @Router /v1/retention-policies [post]
+func (h *RetentionHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateRetentionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	policy, err := h.service.CreatePolicy(r.Context(), &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, policy)
+}
+
+// List handles GET /v1/retention-policies
+// @Summary List retention policies
+// @Tags retention
+// @Produce json
+// @Success 200 {array} models.RetentionPolicy
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/retention-policies [get]
+func (h *RetentionHandler) List(w http.ResponseWriter, r *http.Request) {
+	policies, err := h.service.ListPolicies(r.Context())
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, policies)
+}
+
+// Get handles GET /v1/retention-policies/{id}
+// @Summary Get a retention policy
+// @Tags retention
+// @Produce json
+// @Param id path string true "Retention policy ID (UUID)"
+// @Success 200 {object} models.RetentionPolicy
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Retention policy not found"
+// @Security BearerAuth
+// @Router /v1/retention-policies/{id} [get]
+func (h *RetentionHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePolicyID(w, r)
+	if !ok {
+		return
+	}
+
+	policy, err := h.service.GetPolicy(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, policy)
+}
+
+// Update handles PATCH /v1/retention-policies/{id}
+// @Summary Update a retention policy
+// @Description Change the age, action or enabled state of a retention policy
+// @Tags retention
+// @Accept json
+// @Produce json
+// @Param id path string true "Retention policy ID (UUID)"
+// @Param request body models.UpdateRetentionPolicyRequest true "Fields to update"
+// @Success 200 {object} models.RetentionPolicy
+// @Failure 400 {object} ErrorResponse "Invalid request or UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Retention policy not found"
+// @Security BearerAuth
+// @Router /v1/retention-policies/{id} [patch]
+func (h *RetentionHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePolicyID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateRetentionPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	policy, err := h.service.UpdatePolicy(r.Context(), id, &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, policy)
+}
+
+// Delete handles DELETE /v1/retention-policies/{id}
+// @Summary Delete a retention policy
+// @Tags retention
+// @Param id path string true "Retention policy ID (UUID)"
+// @Success 204 "No Content - Successfully deleted"
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Retention policy not found"
+// @Security BearerAuth
+// @Router /v1/retention-policies/{id} [delete]
+func (h *RetentionHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePolicyID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeletePolicy(r.Context(), id); err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DryRun handles POST /v1/retention-policies/{id}/dry-run
+// @Summary Dry-run a retention policy
+// @Description Count the records the policy would delete or anonymize if it ran now, without changing anything.
+// @Description Disabled policies can be dry-run as well.
+// @Tags retention
+// @Produce json
+// @Param id path string true "Retention policy ID (UUID)"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 200 {object} models.RetentionRun
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Retention policy not found"
+// @Failure 409 {object} ErrorResponse "Idempotency-Key reused for a different request or still in progress"
+// @Security BearerAuth
+// @Router /v1/retention-policies/{id}/dry-run [post]
+func (h *RetentionHandler) DryRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := parsePolicyID(w, r)
+	if !ok {
+		return
+	}
+
+	run, err := h.service.DryRunPolicy(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, run)
+}
@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+// maxSurveyDefinitionSize limits the size of imported survey definitions
+const maxSurveyDefinitionSize = 5 << 20
+
+// SourceHandler handles HTTP requests for the source and field registry
+type SourceHandler struct {
+	service *service.SourceService
+}
+
+// NewSourceHandler creates a new source handler
+func NewSourceHandler(service *service.SourceService) *SourceHandler {
+	return &SourceHandler{service: service}
+}
+
+// parseSourceID reads the source UUID from the path, writing a 400 response if it is invalid
+func parseSourceID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_id", "Invalid UUID format")
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// Create handles POST /v1/sources
+// @Summary Register a source
+// @Description Register a source of experience data, such as a survey. Records belong to the source when their source_type and source_id match its source_type and external_id.
+// @Description pii_mode scans value_text of the source's records for email addresses, phone numbers, IBANs and card numbers: off (default), flag, mask or reject.
+// @Description With pii_vault, masked originals are kept encrypted and can be read from /v1/experiences/{id}/vault.
+// @Description With encrypt, value_text, value_json and user_identifier of the source's records are stored encrypted and cannot be searched.
+// @Description With pseudonymize, user_identifier of the source's records is stored as a keyed hash; user_identifier filters hash their input.
+// @Tags sources
+// @Accept json
+// @Produce json
+// @Param request body models.CreateSourceRequest true "Source to register"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 201 {object} models.Source
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 422 {object} ErrorResponse "Unknown pii_mode, or pii_vault, encrypt or pseudonymize without its key configured"
+// @Failure 409 {object} ErrorResponse "A source with the same source_type and external_id exists, or Idempotency-Key reused for a different request or still in progress"
+// @Security BearerAuth
+// @Router /v1/sources [post]
+func (h *SourceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	source, err := h.service.CreateSource(r.Context(), &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusCreated, source)
+}
+
+// List handles GET /v1/sources
+// @Summary List sources
+// @Description Retrieve registered sources, most recently created first
+// @Tags sources
+// @Produce json
+// @Param source_type query string false "Filter by source type"
+// @Param limit query int false "Maximum number of sources to return (default 100, max 1000)"
+// @Param offset query int false "Number of sources to skip"
+// @Success 200 {array} models.Source
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Security BearerAuth
+// @Router /v1/sources [get]
+func (h *SourceHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filters := &models.ListSourcesFilters{}
+
+	if sourceType := query.Get("source_type"); sourceType != "" {
+		filters.SourceType = &sourceType
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err == nil && limit > 0 {
+			filters.Limit = limit
+		}
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err == nil && offset >= 0 {
+			filters.Offset = offset
+		}
+	}
+
+	sources, err := h.service.ListSources(r.Context(), filters)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, sources)
+}
+
+// Get handles GET /v1/sources/{id}
+// @Summary Get a source
+// @Tags sources
+// @Produce json
+// @Param id path string true "Source ID (UUID)"
+// @Success 200 {object} models.Source
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Source not found"
+// @Security BearerAuth
+// @Router /v1/sources/{id} [get]
+func (h *SourceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSourceID(w, r)
+	if !ok {
+		return
+	}
+
+	source, err := h.service.GetSource(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, source)
+}
+
+// Update handles PATCH /v1/sources/{id}
+// @Summary Update a source
+// @Description Rename a source, switch strict mode on or off or change how personal data in value_text is handled.
+// @Description Switching encrypt on encrypts records created or updated afterwards; encrypted records stay encrypted when it is switched off.
+// @Description Switching pseudonymize on applies to records created afterwards; existing records are converted with the pseudonymize command.
+// @Tags sources
+// @Accept json
+// @Produce json
+// @Param id path string true "Source ID (UUID)"
+// @Param request body models.UpdateSourceRequest true "Fields to update"
+// @Success 200 {object} models.Source
+// @Failure 400 {object} ErrorResponse "Invalid request or UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Source not found"
+// @Failure 422 {object} ErrorResponse "Unknown pii_mode, or pii_vault, encrypt or pseudonymize without its key configured"
+// @Security BearerAuth
+// @Router /v1/sources/{id} [patch]
+func (h *SourceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSourceID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	source, err := h.service.UpdateSource(r.Context(), id, &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, source)
+}
+
+// Delete handles DELETE /v1/sources/{id}
+// @Summary Delete a source
+// @Description Delete a source and its registered fields. Experience records of the source are kept.
+// @Tags sources
+// @Param id path string true "Source ID (UUID)"
+// @Success 204 "No Content - Successfully deleted"
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Source not found"
+// @Security BearerAuth
+// @Router /v1/sources/{id} [delete]
+func (h *SourceHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSourceID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSource(r.Context(), id); err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListFields handles GET /v1/sources/{id}/fields
+// @Summary List the fields of a source
+// @Description Retrieve the fields registered for a source, ordered by position
+// @Tags sources
+// @Produce json
+// @Param id path string true "Source ID (UUID)"
+// @Success 200 {array} models.SourceField
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Source not found"
+// @Security BearerAuth
+// @Router /v1/sources/{id}/fields [get]
+func (h *SourceHandler) ListFields(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSourceID(w, r)
+	if !ok {
+		return
+	}
+
+	fields, err := h.service.ListFields(r.Context(), id)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, fields)
+}
+
+// PutField handles PUT /v1/sources/{id}/fields/{field_id}
+// @Summary Register a field of a source
+// @Description Register a field with its label, type, choices and translated labels, replacing an existing registration
+// @Tags sources
+// @Accept json
+// @Produce json
+// @Param id path string true "Source ID (UUID)"
+// @Param field_id path string true "Field ID"
+// @Param request body models.PutSourceFieldRequest true "Field definition"
+// @Success 200 {object} models.SourceField
+// @Failure 400 {object} ErrorResponse "Invalid request or UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Source not found"
+// @Failure 422 {object} ErrorResponse "Unknown field type or invalid choices"
+// @Security BearerAuth
+// @Router /v1/sources/{id}/fields/{field_id} [put]
+func (h *SourceHandler) PutField(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSourceID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.PutSourceFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	field, err := h.service.PutField(r.Context(), id, r.PathValue("field_id"), &req)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, field)
+}
+
+// DeleteField handles DELETE /v1/sources/{id}/fields/{field_id}
+// @Summary Delete a field of a source
+// @Tags sources
+// @Param id path string true "Source ID (UUID)"
+// @Param field_id path string true "Field ID"
+// @Success 204 "No Content - Successfully deleted"
+// @Failure 400 {object} ErrorResponse "Invalid UUID format"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 404 {object} ErrorResponse "Field not found"
+// @Security BearerAuth
+// @Router /v1/sources/{id}/fields/{field_id} [delete]
+func (h *SourceHandler) DeleteField(w http.ResponseWriter, r *http.Request) {
+	id, ok := parseSourceID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteField(r.Context(), id, r.PathValue("field_id")); err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ImportFormbricks handles POST /v1/sources/import/formbricks
+// @Summary Import a Formbricks survey
+// @Description Register a Formbricks survey definition as a source (source_type formbricks, external_id the survey ID) and its questions as fields.
+// @Description The definition may be wrapped in {"data": ...} as returned by the Formbricks management API. Re-importing updates the registration.
+// @Description Questions without a matching field type (e.g. cta, address) are skipped and listed in the response.
+// @Tags sources
+// @Accept json
+// @Produce json
+// @Param strict query bool false "Enable strict mode for the source"
+// @Param request body object true "Formbricks survey definition"
+// @Param Idempotency-Key header string false "Retry safely: repeating the request with the same key replays the original response"
+// @Success 200 {object} models.ImportSurveyResponse
+// @Failure 400 {object} ErrorResponse "Invalid survey definition"
+// @Failure 401 {object} ErrorResponse "Unauthorized - Invalid or missing API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 409 {object} ErrorResponse "Idempotency-Key reused for a different request or still in progress"
+// @Security BearerAuth
+// @Router /v1/sources/import/formbricks [post]
+func (h *SourceHandler) ImportFormbricks(w http.ResponseWriter, r *http.Request) {
+	strict := false
+	if strictStr := r.URL.Query().Get("strict"); strictStr != "" {
+		var err error
+		strict, err = strconv.ParseBool(strictStr)
+		if err != nil {
+			RespondError(w, http.StatusBadRequest, "invalid_parameter", "Invalid strict parameter, use true or false")
+			return
+		}
+	}
+
+	definition, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSurveyDefinitionSize))
+	if err != nil {
+		RespondError(w, http.StatusBadRequest, "invalid_request", "Invalid request body")
+		return
+	}
+
+	result, err := h.service.ImportFormbricksSurvey(r.Context(), definition, strict)
+	if err != nil {
+		RespondServiceError(w, err)
+		return
+	}
+
+	RespondSuccess(w, http.StatusOK, result)
+}
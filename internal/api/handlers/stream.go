@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/export"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// streamFormats maps the media types List and Search can stream their
+// results as to their export formats
+var streamFormats = map[string]string{
+	"text/csv":             models.ExportFormatCSV,
+	"application/x-ndjson": models.ExportFormatNDJSON,
+}
+
+// streamFormat returns the streamed format the Accept header of r prefers,
+// models.ExportFormatCSV or models.ExportFormatNDJSON, or "" when JSON is
+// preferred or the header names neither. Ties go to the type listed first.
+func streamFormat(r *http.Request) string {
+	format, best := "", 0.0
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accepted))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil {
+				continue
+			}
+		}
+		if q <= best {
+			continue
+		}
+
+		if streamed, ok := streamFormats[mediaType]; ok {
+			format, best = streamed, q
+			continue
+		}
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			format, best = "", q
+		}
+	}
+	return format
+}
+
+// recordStream writes records to a CSV or NDJSON response as they are read,
+// instead of encoding them all at once
+type recordStream struct {
+	w      http.ResponseWriter
+	format string
+	// fields is the sparse fieldset, nil for all fields
+	fields []string
+	// keep names the members written besides the fields, e.g. search scores
+	keep []string
+
+	encoder export.Encoder
+}
+
+// newRecordStream creates a stream of records in format, see streamFormat
+func newRecordStream(w http.ResponseWriter, format string, fields []string, keep ...string) *recordStream {
+	return &recordStream{w: w, format: format, fields: fields, keep: keep}
+}
+
+// metadataKeys returns the function receiving the metadata keys flattened into
+// CSV columns, nil when the stream writes metadata as it is or not at all
+func (s *recordStream) metadataKeys() func([]string) error {
+	if !export.FlattensMetadata(s.format, s.fields) {
+		return nil
+	}
+	return s.start
+}
+
+// start writes the response header, and for CSV the header row with metadata
+// flattened into the given keys. Later calls do nothing.
+func (s *recordStream) start(metadataKeys []string) error {
+	if s.encoder != nil {
+		return nil
+	}
+	encoder, err := export.NewEncoder(s.format, s.w, s.fields, s.keep...)
+	if err != nil {
+		return err
+	}
+	s.encoder = encoder
+
+	// Large streams outlast the server's write timeout
+	if err := http.NewResponseController(s.w).SetWriteDeadline(time.Time{}); err != nil {
+		slog.Debug("Write deadline not cleared for stream", "error", err)
+	}
+
+	s.w.Header().Set("Content-Type", export.ContentType(s.format))
+	s.w.WriteHeader(http.StatusOK)
+	return s.encoder.Begin(metadataKeys)
+}
+
+// write writes record as the next line or row
+func (s *recordStream) write(record interface{}) error {
+	if err := s.start(nil); err != nil {
+		return err
+	}
+	return s.encoder.Encode(record)
+}
+
+// close completes the response after the last record. When err is set, it is
+// reported as an error response if nothing was written yet; otherwise the
+// response is aborted, so clients cannot mistake it for a complete one.
+func (s *recordStream) close(err error) {
+	if err == nil {
+		if err = s.start(nil); err == nil {
+			err = s.encoder.End()
+		}
+		if err == nil {
+			return
+		}
+	}
+
+	if s.encoder == nil {
+		RespondServiceError(s.w, err)
+		return
+	}
+	slog.Error("Streamed response failed", "error", err)
+	panic(http.ErrAbortHandler)
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestStreamFormat(t *testing.T) {
+	tests := map[string]string{
+		"":                                   "",
+		"application/json":                   "",
+		"text/csv":                           models.ExportFormatCSV,
+		"application/x-ndjson":               models.ExportFormatNDJSON,
+		"text/csv, application/json":         models.ExportFormatCSV,
+		"application/json, text/csv":         "",
+		"text/csv;q=0.5, application/json":   "",
+		"*/*;q=0.1, application/x-ndjson":    models.ExportFormatNDJSON,
+		"text/html, text/csv;q=0.9":          models.ExportFormatCSV,
+		"text/csv;q=0, application/x-ndjson": models.ExportFormatNDJSON,
+		"text/csv;q=oops":                    "",
+	}
+	for accept, want := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/v1/experiences", nil)
+		r.Header.Set("Accept", accept)
+		assert.Equal(t, want, streamFormat(r), accept)
+	}
+}
+
+func TestRecordStreamCSV(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := newRecordStream(w, models.ExportFormatCSV, []string{"field_id", "metadata"})
+
+	keys := stream.metadataKeys()
+	require.NotNil(t, keys)
+	require.NoError(t, keys([]string{"plan", "tags"}))
+
+	id := uuid.New()
+	require.NoError(t, stream.write(&models.ExperienceData{
+		ID:       id,
+		FieldID:  "feedback",
+		Metadata: json.RawMessage(`{"plan": "pro", "tags": ["a", "b"]}`),
+	}))
+	require.NoError(t, stream.write(&models.ExperienceData{ID: id, FieldID: "nps"}))
+	stream.close(nil)
+
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id,field_id,metadata.plan,metadata.tags\n"+
+		id.String()+`,feedback,pro,"[""a"",""b""]"`+"\n"+
+		id.String()+",nps,,\n", w.Body.String())
+}
+
+func TestRecordStreamNDJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := newRecordStream(w, models.ExportFormatNDJSON, []string{"value_number"}, "rank")
+	assert.Nil(t, stream.metadataKeys())
+
+	score := 9.0
+	rank := 0.5
+	for i := 0; i < 2; i++ {
+		require.NoError(t, stream.write(&models.ExperienceSearchResult{
+			ExperienceData: models.ExperienceData{ID: uuid.New(), FieldID: "nps", ValueNumber: &score},
+			Rank:           &rank,
+		}))
+	}
+	stream.close(nil)
+
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var object map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &object))
+	assert.Len(t, object, 3)
+	assert.Equal(t, 9.0, object["value_number"])
+}
+
+func TestRecordStreamErrorBeforeFirstRecord(t *testing.T) {
+	w := httptest.NewRecorder()
+	stream := newRecordStream(w, models.ExportFormatNDJSON, nil)
+	stream.close(models.ErrInvalidInput)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, problemContentType, w.Header().Get("Content-Type"))
+}
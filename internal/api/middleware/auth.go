@@ -5,40 +5,38 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/xernobyl/formbricks_worktrial/internal/api/handlers"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
 	"github.com/xernobyl/formbricks_worktrial/internal/repository"
 )
 
-type contextKey string
-
-const APIKeyContextKey contextKey = "api_key"
-
 // Auth middleware validates API keys from the Authorization header
 func Auth(apiKeyRepo *repository.APIKeyRepository) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, "Missing Authorization header", http.StatusUnauthorized)
+				handlers.RespondError(w, http.StatusUnauthorized, "unauthorized", "Missing Authorization header")
 				return
 			}
 
 			// Expected format: "Bearer <api-key>"
 			parts := strings.SplitN(authHeader, " ", 2)
 			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				http.Error(w, "Invalid Authorization header format. Expected: Bearer <api-key>", http.StatusUnauthorized)
+				handlers.RespondError(w, http.StatusUnauthorized, "unauthorized", "Invalid Authorization header format. Expected: Bearer <api-key>")
 				return
 			}
 
 			apiKey := parts[1]
 			if apiKey == "" {
-				http.Error(w, "API key is empty", http.StatusUnauthorized)
+				handlers.RespondError(w, http.StatusUnauthorized, "unauthorized", "API key is empty")
 				return
 			}
 
 			// Validate the API key
 			validatedKey, err := apiKeyRepo.ValidateAPIKey(r.Context(), apiKey)
 			if err != nil {
-				http.Error(w, "Invalid or inactive API key", http.StatusUnauthorized)
+				handlers.RespondError(w, http.StatusUnauthorized, "unauthorized", "Invalid or inactive API key")
 				return
 			}
 
@@ -50,7 +48,7 @@ func Auth(apiKeyRepo *repository.APIKeyRepository) func(http.Handler) http.Handl
 			}()
 
 			// Store the validated API key in the request context
-			ctx := context.WithValue(r.Context(), APIKeyContextKey, validatedKey)
+			ctx := models.ContextWithAPIKey(r.Context(), validatedKey)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/api/handlers"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// maxIdempotentRequestSize limits the body of requests with an idempotency key,
+// which is read into memory to fingerprint it
+const maxIdempotentRequestSize = 10 << 20
+
+// idempotencyLockTimeout is how long a request may hold an idempotency key
+// without completing before the key can be claimed again
+const idempotencyLockTimeout = time.Minute
+
+// replayedHeaders are the response headers, besides Content-Type, stored with
+// an idempotent response and replayed with it
+var replayedHeaders = []string{"ETag", "Location"}
+
+// recordingWriter wraps http.ResponseWriter to capture the status code and body
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	rw.statusCode = code
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// requestFingerprint returns the hex encoded SHA-256 of the method, path, query and body of r
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseIDs returns the IDs of the records in a JSON response body: the id of
+// every object under data, at any depth
+func responseIDs(body []byte) []uuid.UUID {
+	var response struct {
+		Data any `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+
+	var ids []uuid.UUID
+	var walk func(value any)
+	walk = func(value any) {
+		switch value := value.(type) {
+		case map[string]any:
+			if text, ok := value["id"].(string); ok {
+				if id, err := uuid.Parse(text); err == nil {
+					ids = append(ids, id)
+				}
+			}
+			for _, child := range value {
+				walk(child)
+			}
+		case []any:
+			for _, child := range value {
+				walk(child)
+			}
+		}
+	}
+	walk(response.Data)
+
+	return ids
+}
+
+// responseContext returns the additional data binding a sealed response body to
+// its API key and Idempotency-Key, so it cannot be replayed for another
+func responseContext(apiKeyID uuid.UUID, key string) []byte {
+	return []byte(apiKeyID.String() + "/" + key + "/response_body")
+}
+
+// storedBody returns the body of a stored response, opened with keys when it was sealed
+func storedBody(keys *encryption.Keyring, apiKeyID uuid.UUID, key string, record *models.IdempotencyRecord) ([]byte, error) {
+	if record.KeyVersion == nil {
+		return record.Body, nil
+	}
+	if keys == nil {
+		return nil, errors.New("stored response is sealed but no encryption keys are configured")
+	}
+	envelope := &encryption.Envelope{Ciphertext: record.Body, DataKey: record.DataKey, KeyVersion: *record.KeyVersion}
+	return keys.Open(envelope, responseContext(apiKeyID, key))
+}
+
+// Idempotency middleware makes POST requests with an Idempotency-Key header safe
+// to retry. The first request with a key runs and its response is stored for ttl;
+// repeating it with the same method, path and body replays the stored response
+// instead of running again. Reusing the key for a different request, or while
+// the first request is in progress, is rejected with 409.
+// Keys are scoped to the API key, so it must run after Auth. Responses with a
+// 5xx status are not stored, so failed requests can be retried with the same key.
+// Stored responses may hold experience data: their bodies are sealed with keys
+// when it is not nil, and they are deleted along with the records they returned
+// when those are erased.
+func Idempotency(repo *repository.IdempotencyRepository, keys *encryption.Keyring, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(models.IdempotencyKeyHeader)
+			apiKey := models.APIKeyFromContext(r.Context())
+			if r.Method != http.MethodPost || key == "" || apiKey == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > models.MaxIdempotencyKeyLength {
+				handlers.RespondError(w, http.StatusBadRequest, "invalid_idempotency_key", "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentRequestSize))
+			if err != nil {
+				handlers.RespondError(w, http.StatusRequestEntityTooLarge, "request_too_large", "Request body is too large for an idempotent request")
+				return
+			}
+			fingerprint := requestFingerprint(r, body)
+
+			now := time.Now()
+			record, err := repo.Reserve(r.Context(), apiKey.ID, key, fingerprint, now, now.Add(ttl), now.Add(-idempotencyLockTimeout))
+			if err != nil {
+				slog.Error("Request failed", "error", err)
+				handlers.RespondError(w, http.StatusInternalServerError, "internal_error", "An internal error occurred")
+				return
+			}
+
+			if record != nil {
+				switch {
+				case record.Fingerprint != fingerprint:
+					handlers.RespondError(w, http.StatusConflict, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+				case record.StatusCode == nil:
+					handlers.RespondError(w, http.StatusConflict, "idempotency_key_in_use", "A request with this Idempotency-Key is still in progress")
+				default:
+					body, err := storedBody(keys, apiKey.ID, key, record)
+					if err != nil {
+						slog.Error("Failed to open idempotent response", "error", err)
+						handlers.RespondError(w, http.StatusInternalServerError, "internal_error", "An internal error occurred")
+						return
+					}
+					if record.ContentType != nil {
+						w.Header().Set("Content-Type", *record.ContentType)
+					}
+					for name, value := range record.Headers {
+						w.Header().Set(name, value)
+					}
+					w.Header().Set(models.IdempotentReplayedHeader, "true")
+					w.WriteHeader(*record.StatusCode)
+					w.Write(body)
+				}
+				return
+			}
+
+			// The key is released unless the response is stored, also when the handler panics
+			ctx := context.WithoutCancel(r.Context())
+			stored := false
+			defer func() {
+				if stored {
+					return
+				}
+				if err := repo.Release(ctx, apiKey.ID, key); err != nil {
+					slog.Error("Failed to release idempotency key", "error", err)
+				}
+			}()
+
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			rw := &recordingWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			if rw.statusCode >= http.StatusInternalServerError {
+				return
+			}
+			contentType := rw.Header().Get("Content-Type")
+			response := &models.IdempotencyRecord{
+				StatusCode:    &rw.statusCode,
+				ContentType:   &contentType,
+				Body:          rw.body.Bytes(),
+				ExperienceIDs: responseIDs(rw.body.Bytes()),
+			}
+			for _, name := range replayedHeaders {
+				if value := rw.Header().Get(name); value != "" {
+					if response.Headers == nil {
+						response.Headers = map[string]string{}
+					}
+					response.Headers[name] = value
+				}
+			}
+			if keys != nil {
+				envelope, err := keys.Seal(response.Body, responseContext(apiKey.ID, key))
+				if err != nil {
+					slog.Error("Failed to seal idempotent response", "error", err)
+					return
+				}
+				response.Body, response.DataKey, response.KeyVersion = envelope.Ciphertext, envelope.DataKey, &envelope.KeyVersion
+			}
+			if err := repo.Complete(ctx, apiKey.ID, key, response); err != nil {
+				slog.Error("Failed to store idempotent response", "error", err)
+				return
+			}
+			stored = true
+		})
+	}
+}
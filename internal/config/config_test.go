@@ -133,6 +133,66 @@ func TestGetEnvAsInt(t *testing.T) {
 	}
 }
 
+func TestGetEnvAsBool(t *testing.T) {
+	tests := []struct {
+		name         string
+		key          string
+		defaultValue bool
+		envValue     string
+		shouldSet    bool
+		want         bool
+	}{
+		{
+			name:         "returns environment variable as bool when set with valid boolean",
+			key:          "TEST_BOOL_VAR",
+			defaultValue: false,
+			envValue:     "true",
+			shouldSet:    true,
+			want:         true,
+		},
+		{
+			name:         "returns default when environment variable not set",
+			key:          "TEST_BOOL_VAR_MISSING",
+			defaultValue: true,
+			envValue:     "",
+			shouldSet:    false,
+			want:         true,
+		},
+		{
+			name:         "returns default when environment variable is not a valid boolean",
+			key:          "TEST_BOOL_VAR_INVALID",
+			defaultValue: true,
+			envValue:     "maybe",
+			shouldSet:    true,
+			want:         true,
+		},
+		{
+			name:         "handles false",
+			key:          "TEST_BOOL_VAR_FALSE",
+			defaultValue: true,
+			envValue:     "0",
+			shouldSet:    true,
+			want:         false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clean up
+			defer os.Unsetenv(tt.key)
+
+			if tt.shouldSet {
+				os.Setenv(tt.key, tt.envValue)
+			}
+
+			got := getEnvAsBool(tt.key, tt.defaultValue)
+			if got != tt.want {
+				t.Errorf("getEnvAsBool() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestLoad(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -220,3 +280,41 @@ func TestLoadAlwaysReturnsNilError(t *testing.T) {
 		t.Error("Load() config = nil, want non-nil config")
 	}
 }
+
+func TestLoadWorkerSettings(t *testing.T) {
+	defer os.Unsetenv("TRASH_RETENTION_DAYS")
+	defer os.Unsetenv("WORKER_INTERVAL_MINUTES")
+	defer os.Unsetenv("JOB_POLL_INTERVAL_SECONDS")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+	if cfg.TrashRetentionDays != 30 {
+		t.Errorf("Load() TrashRetentionDays = %v, want 30", cfg.TrashRetentionDays)
+	}
+	if cfg.WorkerIntervalMinutes != 60 {
+		t.Errorf("Load() WorkerIntervalMinutes = %v, want 60", cfg.WorkerIntervalMinutes)
+	}
+	if cfg.JobPollIntervalSeconds != 5 {
+		t.Errorf("Load() JobPollIntervalSeconds = %v, want 5", cfg.JobPollIntervalSeconds)
+	}
+
+	os.Setenv("TRASH_RETENTION_DAYS", "7")
+	os.Setenv("WORKER_INTERVAL_MINUTES", "5")
+	os.Setenv("JOB_POLL_INTERVAL_SECONDS", "1")
+
+	cfg, err = Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v, want nil", err)
+	}
+	if cfg.TrashRetentionDays != 7 {
+		t.Errorf("Load() TrashRetentionDays = %v, want 7", cfg.TrashRetentionDays)
+	}
+	if cfg.WorkerIntervalMinutes != 5 {
+		t.Errorf("Load() WorkerIntervalMinutes = %v, want 5", cfg.WorkerIntervalMinutes)
+	}
+	if cfg.JobPollIntervalSeconds != 1 {
+		t.Errorf("Load() JobPollIntervalSeconds = %v, want 1", cfg.JobPollIntervalSeconds)
+	}
+}
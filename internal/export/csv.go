@@ -0,0 +1,93 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// csvEncoder writes a header row followed by a row per record
+//
+// Columns follow the field order of experience data, with metadata flattened
+// into a metadata.<key> column for each metadata key passed to Begin.
+type csvEncoder struct {
+	w       io.Writer
+	fields  []string
+	keep    []string
+	csv     *csv.Writer
+	columns []string
+}
+
+// csvColumns returns the CSV columns of a sparse fieldset, with metadata
+// flattened into metadataKeys
+func csvColumns(fields, metadataKeys, keep []string) []string {
+	var names []string
+	for _, name := range columns(fields, keep) {
+		if name != "metadata" {
+			names = append(names, name)
+			continue
+		}
+		for _, key := range metadataKeys {
+			names = append(names, "metadata."+key)
+		}
+	}
+	return names
+}
+
+// csvCell formats a JSON value as a CSV cell: strings as they are, null and
+// missing values empty, and numbers, booleans, arrays and objects as JSON.
+// Strings that spreadsheets would evaluate as a formula are prefixed with '.
+func csvCell(value json.RawMessage) (string, error) {
+	if len(value) == 0 || string(value) == "null" {
+		return "", nil
+	}
+	if value[0] != '"' {
+		return string(value), nil
+	}
+
+	var text string
+	if err := json.Unmarshal(value, &text); err != nil {
+		return "", err
+	}
+	if text != "" && strings.ContainsRune("=+-@\t\r", rune(text[0])) {
+		text = "'" + text
+	}
+	return text, nil
+}
+
+func (e *csvEncoder) Begin(metadataKeys []string) error {
+	e.csv = csv.NewWriter(e.w)
+	e.columns = csvColumns(e.fields, metadataKeys, e.keep)
+	return e.csv.Write(e.columns)
+}
+
+func (e *csvEncoder) Encode(record interface{}) error {
+	object, err := Object(record, nil)
+	if err != nil {
+		return err
+	}
+	var metadata map[string]json.RawMessage
+	if raw := object["metadata"]; len(raw) > 0 && raw[0] == '{' {
+		if err := json.Unmarshal(raw, &metadata); err != nil {
+			return err
+		}
+	}
+
+	row := make([]string, len(e.columns))
+	for i, column := range e.columns {
+		value := object[column]
+		if key, ok := strings.CutPrefix(column, "metadata."); ok {
+			value = metadata[key]
+		}
+		if row[i], err = csvCell(value); err != nil {
+			return err
+		}
+	}
+	return e.csv.Write(row)
+}
+
+func (e *csvEncoder) End() error {
+	e.csv.Flush()
+	return e.csv.Error()
+}
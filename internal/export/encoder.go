@@ -0,0 +1,121 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// Encoder writes experience records in a file format, one after the other
+type Encoder interface {
+	// Begin writes what precedes the records, e.g. the CSV header row with
+	// metadata flattened into metadataKeys. It must be called once before Encode.
+	Begin(metadataKeys []string) error
+	// Encode writes the next record, a *models.ExperienceData or *models.ExperienceSearchResult
+	Encode(record interface{}) error
+	// End writes what follows the records and flushes buffered output
+	End() error
+}
+
+// NewEncoder creates an encoder writing records in format to w. Unless fields
+// is nil, only id, the given fields and the members named by keep are written.
+func NewEncoder(format string, w io.Writer, fields []string, keep ...string) (Encoder, error) {
+	switch format {
+	case models.ExportFormatCSV:
+		return &csvEncoder{w: w, fields: fields, keep: keep}, nil
+	case models.ExportFormatNDJSON:
+		return &ndjsonEncoder{w: w, fields: fields, keep: keep}, nil
+	case models.ExportFormatParquet:
+		return &parquetEncoder{w: w, fields: fields, keep: keep}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// ContentType returns the media type of files in format
+func ContentType(format string) string {
+	switch format {
+	case models.ExportFormatCSV:
+		return "text/csv; charset=utf-8"
+	case models.ExportFormatNDJSON:
+		return "application/x-ndjson"
+	case models.ExportFormatParquet:
+		return "application/vnd.apache.parquet"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// FlattensMetadata reports whether Begin of encoders of format needs the
+// metadata keys of the records, which is the case for CSV unless fields leaves
+// metadata out
+func FlattensMetadata(format string, fields []string) bool {
+	return format == models.ExportFormatCSV && (fields == nil || slices.Contains(fields, "metadata"))
+}
+
+// Object encodes record as a JSON object keyed by member name. Unless fields
+// is nil, it holds only the id, the given fields and the members named by keep.
+func Object(record interface{}, fields []string, keep ...string) (map[string]json.RawMessage, error) {
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(encoded, &object); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return object, nil
+	}
+
+	for name := range object {
+		if name != "id" && !slices.Contains(fields, name) && !slices.Contains(keep, name) {
+			delete(object, name)
+		}
+	}
+	return object, nil
+}
+
+// columns returns the names of the fields written for a sparse fieldset, in
+// the order of models.ExperienceFieldNames followed by keep
+func columns(fields, keep []string) []string {
+	var names []string
+	for _, name := range models.ExperienceFieldNames {
+		if fields == nil || name == "id" || slices.Contains(fields, name) {
+			names = append(names, name)
+		}
+	}
+	return append(names, keep...)
+}
+
+// ndjsonEncoder writes every record as a JSON object on its own line
+type ndjsonEncoder struct {
+	w       io.Writer
+	fields  []string
+	keep    []string
+	encoder *json.Encoder
+}
+
+func (e *ndjsonEncoder) Begin(metadataKeys []string) error {
+	e.encoder = json.NewEncoder(e.w)
+	return nil
+}
+
+func (e *ndjsonEncoder) Encode(record interface{}) error {
+	if e.fields == nil {
+		return e.encoder.Encode(record)
+	}
+	object, err := Object(record, e.fields, e.keep...)
+	if err != nil {
+		return err
+	}
+	return e.encoder.Encode(object)
+}
+
+func (e *ndjsonEncoder) End() error {
+	return nil
+}
@@ -0,0 +1,133 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/parquet"
+)
+
+func TestCSVColumns(t *testing.T) {
+	columns := csvColumns(nil, []string{"plan", "tags"}, nil)
+	assert.Equal(t, len(models.ExperienceFieldNames)+1, len(columns))
+	assert.Equal(t, "id", columns[0])
+	assert.Contains(t, columns, "metadata.plan")
+	assert.NotContains(t, columns, "metadata")
+
+	columns = csvColumns([]string{"value_number", "field_id"}, nil, []string{"rank"})
+	assert.Equal(t, []string{"id", "field_id", "value_number", "rank"}, columns)
+}
+
+func TestCSVCell(t *testing.T) {
+	tests := map[string]string{
+		``:                  "",
+		`null`:              "",
+		`"plain, text"`:     "plain, text",
+		`9.5`:               "9.5",
+		`true`:              "true",
+		`["email","phone"]`: `["email","phone"]`,
+		`"=SUM(A1:A9)"`:     "'=SUM(A1:A9)",
+		`"-5"`:              "'-5",
+	}
+	for value, want := range tests {
+		cell, err := csvCell(json.RawMessage(value))
+		require.NoError(t, err)
+		assert.Equal(t, want, cell, value)
+	}
+}
+
+func TestFlattensMetadata(t *testing.T) {
+	assert.True(t, FlattensMetadata(models.ExportFormatCSV, nil))
+	assert.True(t, FlattensMetadata(models.ExportFormatCSV, []string{"metadata"}))
+	assert.False(t, FlattensMetadata(models.ExportFormatCSV, []string{"field_id"}))
+	assert.False(t, FlattensMetadata(models.ExportFormatNDJSON, nil))
+	assert.False(t, FlattensMetadata(models.ExportFormatParquet, nil))
+}
+
+func TestNDJSONEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	encoder, err := NewEncoder(models.ExportFormatNDJSON, &buf, []string{"field_id"})
+	require.NoError(t, err)
+
+	require.NoError(t, encoder.Begin(nil))
+	for i := 0; i < 2; i++ {
+		require.NoError(t, encoder.Encode(&models.ExperienceData{ID: uuid.New(), FieldID: "nps", SourceType: "survey"}))
+	}
+	require.NoError(t, encoder.End())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	var object map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &object))
+	assert.Len(t, object, 2)
+	assert.Equal(t, "nps", object["field_id"])
+}
+
+func TestParquetEncoder(t *testing.T) {
+	var buf bytes.Buffer
+	encoder, err := NewEncoder(models.ExportFormatParquet, &buf, []string{"collected_at", "value_number", "metadata", "field_id"}, "rank")
+	require.NoError(t, err)
+	require.NoError(t, encoder.Begin(nil))
+
+	columns := encoder.(*parquetEncoder).columns
+	require.Len(t, columns, 6)
+	assert.Equal(t, parquet.Column{Name: "id", Type: parquet.ByteArray, Logical: parquet.String}, columns[0])
+	assert.Equal(t, parquet.Column{Name: "rank", Type: parquet.Double}, columns[5])
+
+	score := 9.0
+	require.NoError(t, encoder.Encode(&models.ExperienceSearchResult{
+		ExperienceData: models.ExperienceData{
+			ID:          uuid.New(),
+			CollectedAt: time.Now(),
+			FieldID:     "nps",
+			ValueNumber: &score,
+			Metadata:    json.RawMessage(`{"plan": "pro"}`),
+		},
+	}))
+	require.NoError(t, encoder.End())
+
+	file := buf.Bytes()
+	assert.Equal(t, "PAR1", string(file[:4]))
+	assert.Equal(t, "PAR1", string(file[len(file)-4:]))
+}
+
+func TestParquetValue(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 6000, time.UTC)
+	encoded, err := json.Marshal(at)
+	require.NoError(t, err)
+
+	tests := []struct {
+		column parquet.Column
+		value  string
+		want   interface{}
+	}{
+		{parquetColumns["collected_at"], string(encoded), at.UnixMicro()},
+		{parquetColumns["version"], `3`, int64(3)},
+		{parquetColumns["value_number"], `9.5`, 9.5},
+		{parquetColumns["value_boolean"], `true`, true},
+		{parquetColumns["metadata"], `{"plan":"pro"}`, []byte(`{"plan":"pro"}`)},
+		{parquet.Column{Type: parquet.ByteArray, Logical: parquet.String}, `"text"`, "text"},
+		{parquetColumns["value_number"], `null`, nil},
+		{parquetColumns["value_number"], ``, nil},
+	}
+	for _, tt := range tests {
+		value, err := parquetValue(tt.column, json.RawMessage(tt.value))
+		require.NoError(t, err, tt.value)
+		assert.Equal(t, tt.want, value, tt.value)
+	}
+
+	_, err = parquetValue(parquetColumns["version"], json.RawMessage(`"three"`))
+	assert.Error(t, err)
+}
+
+func TestNewEncoderRejectsUnknownFormats(t *testing.T) {
+	_, err := NewEncoder("xlsx", &bytes.Buffer{}, nil)
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/xernobyl/formbricks_worktrial/pkg/parquet"
+)
+
+// parquetColumns maps the fields that are not text to their column types.
+// Timestamps are stored in microseconds, arrays and objects as JSON documents.
+var parquetColumns = map[string]parquet.Column{
+	"collected_at":  {Type: parquet.Int64, Logical: parquet.TimestampMicros},
+	"created_at":    {Type: parquet.Int64, Logical: parquet.TimestampMicros},
+	"updated_at":    {Type: parquet.Int64, Logical: parquet.TimestampMicros},
+	"deleted_at":    {Type: parquet.Int64, Logical: parquet.TimestampMicros},
+	"value_date":    {Type: parquet.Int64, Logical: parquet.TimestampMicros},
+	"version":       {Type: parquet.Int64},
+	"value_number":  {Type: parquet.Double},
+	"rank":          {Type: parquet.Double},
+	"similarity":    {Type: parquet.Double},
+	"value_boolean": {Type: parquet.Boolean},
+	"encrypted":     {Type: parquet.Boolean},
+	"value_json":    {Type: parquet.ByteArray, Logical: parquet.JSON},
+	"metadata":      {Type: parquet.ByteArray, Logical: parquet.JSON},
+	"pii_types":     {Type: parquet.ByteArray, Logical: parquet.JSON},
+	"natural_key":   {Type: parquet.ByteArray, Logical: parquet.JSON},
+}
+
+// parquetEncoder writes records as rows of a Parquet file with a column per field
+type parquetEncoder struct {
+	w       io.Writer
+	fields  []string
+	keep    []string
+	columns []parquet.Column
+	writer  *parquet.Writer
+}
+
+func (e *parquetEncoder) Begin(metadataKeys []string) error {
+	for _, name := range columns(e.fields, e.keep) {
+		column, ok := parquetColumns[name]
+		if !ok {
+			column = parquet.Column{Type: parquet.ByteArray, Logical: parquet.String}
+		}
+		column.Name = name
+		e.columns = append(e.columns, column)
+	}
+
+	var err error
+	e.writer, err = parquet.NewWriter(e.w, e.columns)
+	return err
+}
+
+func (e *parquetEncoder) Encode(record interface{}) error {
+	object, err := Object(record, nil)
+	if err != nil {
+		return err
+	}
+
+	row := make([]interface{}, len(e.columns))
+	for i, column := range e.columns {
+		if row[i], err = parquetValue(column, object[column.Name]); err != nil {
+			return fmt.Errorf("field %s: %w", column.Name, err)
+		}
+	}
+	return e.writer.Write(row)
+}
+
+func (e *parquetEncoder) End() error {
+	return e.writer.Close()
+}
+
+// parquetValue converts a JSON value to the value of a Parquet column, nil
+// for null and missing values
+func parquetValue(column parquet.Column, value json.RawMessage) (interface{}, error) {
+	if len(value) == 0 || string(value) == "null" {
+		return nil, nil
+	}
+
+	switch {
+	case column.Logical == parquet.TimestampMicros:
+		var t time.Time
+		if err := json.Unmarshal(value, &t); err != nil {
+			return nil, err
+		}
+		return t.UnixMicro(), nil
+	case column.Logical == parquet.JSON:
+		return []byte(value), nil
+	case column.Type == parquet.ByteArray:
+		if value[0] != '"' {
+			return []byte(value), nil
+		}
+		var text string
+		err := json.Unmarshal(value, &text)
+		return text, err
+	case column.Type == parquet.Int64:
+		var n int64
+		err := json.Unmarshal(value, &n)
+		return n, err
+	case column.Type == parquet.Double:
+		var f float64
+		err := json.Unmarshal(value, &f)
+		return f, err
+	case column.Type == parquet.Boolean:
+		var b bool
+		err := json.Unmarshal(value, &b)
+		return b, err
+	}
+	return nil, fmt.Errorf("unsupported column type %d", column.Type)
+}
@@ -0,0 +1,200 @@
+package models
+
+import "time"
+
+// Survey metrics supported by MetricsRequest.Metric and TimeSeriesRequest.Metric
+const (
+	MetricNPS  = "nps"  // Net Promoter Score on a 0-10 scale
+	MetricCSAT = "csat" // Customer Satisfaction, share of top-2-box answers
+	MetricCES  = "ces"  // Customer Effort Score, average effort rating
+)
+
+// Aggregate metrics supported by TimeSeriesRequest.Metric
+const (
+	MetricCount = "count" // Number of records
+	MetricMean  = "mean"  // Average value_number
+	MetricSum   = "sum"   // Sum of value_number
+	MetricMin   = "min"   // Lowest value_number
+	MetricMax   = "max"   // Highest value_number
+)
+
+// NPS answer classes on the 0-10 scale
+const (
+	NPSPromoterMin = 9 // Promoters answer 9-10
+	NPSPassiveMin  = 7 // Passives answer 7-8, detractors 0-6
+)
+
+// Default rating scales for CSAT and CES
+const (
+	DefaultCSATScaleMax = 5.0
+	DefaultCESScaleMax  = 7.0
+)
+
+// Time series bucket sizes supported by TimeSeriesRequest.Interval
+const (
+	IntervalHour  = "hour"
+	IntervalDay   = "day"
+	IntervalWeek  = "week" // Weeks start on Monday
+	IntervalMonth = "month"
+)
+
+// MetricsRequest represents parameters for computing metrics on the value_number of a field
+type MetricsRequest struct {
+	ExperienceFilters
+	Metric   string   `json:"metric,omitempty"`    // Optional survey metric: nps, csat or ces
+	ScaleMax *float64 `json:"scale_max,omitempty"` // Highest answer on the rating scale (default 5 for csat, 7 for ces)
+}
+
+// MetricsResponse represents summary statistics for the value_number of a field
+type MetricsResponse struct {
+	FieldID            string               `json:"field_id"`
+	Count              int                  `json:"count"`                 // Number of records with a numeric value
+	Mean               *float64             `json:"mean,omitempty"`        // Average value
+	Median             *float64             `json:"median,omitempty"`      // 50th percentile
+	Min                *float64             `json:"min,omitempty"`         // Lowest value
+	Max                *float64             `json:"max,omitempty"`         // Highest value
+	StdDev             *float64             `json:"std_dev,omitempty"`     // Sample standard deviation
+	Percentiles        map[string]float64   `json:"percentiles,omitempty"` // Continuous percentiles keyed p5, p10, p25, p50, p75, p90, p95, p99
+	Distribution       []DistributionBucket `json:"distribution"`          // Count of each distinct value, ascending by value
+	DistributionBinned bool                 `json:"distribution_binned"`   // Set when more than 100 distinct values are counted in 20 equal-width ranges instead
+	NPS                *NPSResult           `json:"nps,omitempty"`
+	CSAT               *CSATResult          `json:"csat,omitempty"`
+	CES                *CESResult           `json:"ces,omitempty"`
+}
+
+// DistributionBucket represents how often a single value, or a range of values
+// in a binned distribution, occurs
+type DistributionBucket struct {
+	Value      float64  `json:"value"`                 // The value, or the lower bound of a range
+	UpperBound *float64 `json:"upper_bound,omitempty"` // Exclusive upper bound of a range, inclusive for the last range
+	Count      int      `json:"count"`
+	Percentage float64  `json:"percentage"` // Share of all counted records (0-100)
+}
+
+// TimeSeriesRequest represents parameters for bucketing experience data over collected_at
+type TimeSeriesRequest struct {
+	ExperienceFilters
+	Interval string         `json:"interval"`            // hour, day, week or month
+	TimeZone string         `json:"time_zone,omitempty"` // IANA time zone buckets are aligned to (default UTC)
+	Location *time.Location `json:"-"`                   // Loaded TimeZone
+	Metric   string         `json:"metric,omitempty"`    // count (default), mean, sum, min, max, nps, csat or ces
+	ScaleMax *float64       `json:"scale_max,omitempty"` // Highest answer on the rating scale for csat and ces
+	GroupBy  *string        `json:"group_by,omitempty"`  // source_type, field_id or metadata.<key>
+}
+
+// TimeSeriesResponse represents one or more series of bucketed values
+type TimeSeriesResponse struct {
+	Interval string       `json:"interval"`
+	TimeZone string       `json:"time_zone"`
+	Metric   string       `json:"metric"`
+	GroupBy  *string      `json:"group_by,omitempty"`
+	Series   []TimeSeries `json:"series"` // One series per group, a single series without grouping
+}
+
+// TimeSeries represents the buckets of a single group
+type TimeSeries struct {
+	Group  *string           `json:"group"` // Group value, null without grouping or for records missing the value
+	Points []TimeSeriesPoint `json:"points"`
+}
+
+// TimeSeriesPoint represents the metric for a single bucket
+// Buckets without matching records have a count of 0 and no value (except for the count metric)
+type TimeSeriesPoint struct {
+	Bucket time.Time `json:"bucket"` // Start of the bucket in the requested time zone
+	Count  int       `json:"count"`  // Number of matching records in the bucket, only those with a value_number for metrics other than count
+	Value  *float64  `json:"value"`  // Metric value, null if it cannot be computed for the bucket
+}
+
+// TimeSeriesBucket represents aggregated values for a single bucket and group as read from the database
+type TimeSeriesBucket struct {
+	Bucket time.Time
+	Group  *string
+	Count  int
+	Value  *float64
+}
+
+// NPSResult represents the Net Promoter Score breakdown
+// Promoters answer 9-10, passives 7-8 and detractors 0-6
+type NPSResult struct {
+	Score                float64 `json:"score"` // % promoters - % detractors (-100 to 100)
+	Promoters            int     `json:"promoters"`
+	Passives             int     `json:"passives"`
+	Detractors           int     `json:"detractors"`
+	PromotersPercentage  float64 `json:"promoters_percentage"`
+	PassivesPercentage   float64 `json:"passives_percentage"`
+	DetractorsPercentage float64 `json:"detractors_percentage"`
+}
+
+// CSATResult represents the Customer Satisfaction score
+// Satisfied answers are the top two points of the scale (e.g. 4 and 5 on a 1-5 scale)
+type CSATResult struct {
+	Score     float64 `json:"score"` // Percentage of satisfied answers (0-100)
+	Satisfied int     `json:"satisfied"`
+	ScaleMax  float64 `json:"scale_max"`
+}
+
+// CESResult represents the Customer Effort Score
+// Low effort answers are the top three points of the scale (e.g. 5-7 on a 1-7 scale)
+type CESResult struct {
+	Score               float64 `json:"score"` // Average answer
+	LowEffort           int     `json:"low_effort"`
+	LowEffortPercentage float64 `json:"low_effort_percentage"`
+	ScaleMax            float64 `json:"scale_max"`
+}
+
+// CrosstabRequest represents parameters for cross-tabulating the answers to two
+// fields given in the same response
+type CrosstabRequest struct {
+	ExperienceFilters
+	RowField    string `json:"row_field"`    // field_id whose answers label the rows
+	ColumnField string `json:"column_field"` // field_id whose answers label the columns
+}
+
+// CrosstabResponse represents a contingency table of two fields' answers
+// Only responses answering both fields are counted
+type CrosstabResponse struct {
+	RowField    string           `json:"row_field"`
+	ColumnField string           `json:"column_field"`
+	Columns     []CrosstabColumn `json:"columns"` // Column answers, in the order of CrosstabRow.Cells
+	Rows        []CrosstabRow    `json:"rows"`
+	Total       int              `json:"total"`                // Number of responses counted
+	ChiSquare   *ChiSquareResult `json:"chi_square,omitempty"` // Test of independence, omitted for tables with fewer than 2 rows or columns
+	// EncryptedNotCounted counts the answers to either field left out because
+	// their value_text or value_json is encrypted
+	EncryptedNotCounted int `json:"encrypted_not_counted,omitempty"`
+}
+
+// CrosstabColumn represents a single answer to the column field
+type CrosstabColumn struct {
+	Value string `json:"value"`
+	Total int    `json:"total"`
+}
+
+// CrosstabRow represents a single answer to the row field
+type CrosstabRow struct {
+	Value string         `json:"value"`
+	Total int            `json:"total"`
+	Cells []CrosstabCell `json:"cells"` // One cell per column
+}
+
+// CrosstabCell represents the responses giving a row and a column answer
+type CrosstabCell struct {
+	Count         int     `json:"count"`
+	RowPercentage float64 `json:"row_percentage"` // Share of the row total (0-100)
+}
+
+// CrosstabCount represents the number of responses for a pair of answers as read from the database
+type CrosstabCount struct {
+	Row    string
+	Column string
+	Count  int
+}
+
+// ChiSquareResult represents Pearson's chi-square test of independence
+type ChiSquareResult struct {
+	Statistic        float64 `json:"statistic"`
+	DegreesOfFreedom int     `json:"degrees_of_freedom"`
+	PValue           float64 `json:"p_value"`
+	Significant      bool    `json:"significant"`        // p_value < 0.05
+	LowExpectedCells int     `json:"low_expected_cells"` // Cells with an expected count below 5, the test is unreliable when this exceeds 20% of cells
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Bulk actions supported by BulkJob.Action
+const (
+	BulkActionUpdate = "update"
+	BulkActionDelete = "delete"
+)
+
+// Statuses of a BulkJob
+const (
+	BulkJobPending   = "pending"   // Waiting for the worker
+	BulkJobRunning   = "running"   // Being processed by the worker
+	BulkJobCompleted = "completed" // Every matching record was processed
+	BulkJobFailed    = "failed"    // Stopped by an error, records processed before it stay changed
+)
+
+// BulkSyncLimit is the number of matching records up to which bulk requests
+// run immediately; requests matching more run as a job
+const BulkSyncLimit = 1000
+
+// DefaultBulkMaxAffected is the safety cap used when a bulk request sets no max_affected
+const DefaultBulkMaxAffected = 10000
+
+// BulkExperiencePatch holds the fields a bulk update sets on every matching record
+type BulkExperiencePatch struct {
+	SourceType *string         `json:"source_type,omitempty"`
+	SourceID   *string         `json:"source_id,omitempty"`
+	SourceName *string         `json:"source_name,omitempty"`
+	FieldID    *string         `json:"field_id,omitempty"`
+	FieldLabel *string         `json:"field_label,omitempty"`
+	Language   *string         `json:"language,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty" swaggertype:"object"` // Merged into the stored metadata as a JSON merge patch (RFC 7396)
+}
+
+// BulkExperiencesRequest represents a bulk update or delete of the experiences matching filters
+type BulkExperiencesRequest struct {
+	ExperienceFilters
+	Patch       *BulkExperiencePatch `json:"patch,omitempty"`        // Fields to set, required by bulk updates
+	DryRun      bool                 `json:"dry_run,omitempty"`      // Only count the matching records
+	MaxAffected int                  `json:"max_affected,omitempty"` // Fail instead when more records match (default 10000)
+	Async       bool                 `json:"async,omitempty"`        // Run as a job even when few records match
+}
+
+// BulkResult reports the outcome of a bulk request
+type BulkResult struct {
+	Matched  int      `json:"matched"`       // Records matching the filters
+	Affected int      `json:"affected"`      // Records updated or deleted, 0 in a dry run or when a job was created
+	DryRun   bool     `json:"dry_run"`       // Nothing was changed
+	Job      *BulkJob `json:"job,omitempty"` // The job processing the records, when the request runs as a job
+}
+
+// BulkJob is a bulk update or delete processed by the worker in batches
+type BulkJob struct {
+	ID          uuid.UUID            `json:"id"`
+	Action      string               `json:"action"` // update or delete
+	Status      string               `json:"status"` // pending, running, completed or failed
+	Filters     ExperienceFilters    `json:"filters"`
+	Patch       *BulkExperiencePatch `json:"patch,omitempty"`
+	Matched     int                  `json:"matched"`      // Records matching the filters when the job was created
+	MaxAffected int                  `json:"max_affected"` // The job fails instead of changing more records
+	Affected    int                  `json:"affected"`     // Records updated or deleted so far
+	Error       *string              `json:"error,omitempty"`
+	CreatedAt   time.Time            `json:"created_at"`
+	StartedAt   *time.Time           `json:"started_at,omitempty"`
+	FinishedAt  *time.Time           `json:"finished_at,omitempty"`
+	Cursor      *uuid.UUID           `json:"-"` // ID of the last processed record
+	APIKeyID    *uuid.UUID           `json:"-"` // API key that created the job
+}
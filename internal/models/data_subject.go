@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Erasure modes supported by EraseDataSubjectRequest.Mode
+const (
+	ErasureModeDelete    = "delete"    // Remove the records (default)
+	ErasureModeAnonymize = "anonymize" // Keep the records for aggregates, without anything identifying the person
+)
+
+// DataSubjectExport holds every record stored for a data subject (the person
+// identified by user_identifier), including records in the trash
+type DataSubjectExport struct {
+	UserIdentifier string                   `json:"user_identifier"`
+	ExportedAt     time.Time                `json:"exported_at"`
+	Experiences    []ExperienceData         `json:"experiences"`
+	History        []ExperienceHistoryEntry `json:"history"` // Changes of the exported records
+}
+
+// EraseDataSubjectRequest represents a request to erase a data subject
+type EraseDataSubjectRequest struct {
+	UserIdentifier string
+	Mode           string
+}
+
+// ErasureReceipt records the erasure of a data subject
+// The user identifier itself is not kept, its keyed hash lets holders of the
+// index key check whether a given person was erased
+type ErasureReceipt struct {
+	ID                    uuid.UUID  `json:"id"`
+	UserIdentifierHash    *string    `json:"user_identifier_hash,omitempty"` // Hex encoded HMAC-SHA256 of the user identifier under ENCRYPTION_INDEX_KEY, omitted without encryption keys
+	Mode                  string     `json:"mode"`                           // delete or anonymize
+	ExperiencesAffected   int64      `json:"experiences_affected"`
+	HistoryEntriesDeleted int64      `json:"history_entries_deleted"`
+	APIKeyID              *uuid.UUID `json:"api_key_id,omitempty"` // API key that requested the erasure
+	ErasedAt              time.Time  `json:"erased_at"`
+}
@@ -0,0 +1,18 @@
+package models
+
+// SealedValues holds the value_text, value_json and user_identifier of a record
+// of an encrypting source, sealed with envelope encryption
+type SealedValues struct {
+	Ciphertext          []byte // JSON of the values sealed with the record's data key
+	DataKey             []byte // Data key sealed with the key-encryption key of KeyVersion
+	KeyVersion          int
+	UserIdentifierIndex []byte // Blind index for user_identifier lookups, nil without user_identifier
+}
+
+// UserIdentifierMatch lists the stored forms a user_identifier filter matches:
+// the identifier as given and its pseudonym, and the blind indexes of both for
+// records whose user_identifier is encrypted
+type UserIdentifierMatch struct {
+	Values  []string
+	Indexes [][]byte
+}
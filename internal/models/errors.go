@@ -0,0 +1,15 @@
+package models
+
+import "errors"
+
+// Sentinel errors shared by the repository, service and handler layers
+// Errors are wrapped with context, e.g. fmt.Errorf("experience %w", ErrNotFound),
+// and matched with errors.Is to choose the HTTP status.
+var (
+	ErrNotFound     = errors.New("not found")      // The requested record does not exist
+	ErrConflict     = errors.New("already exists") // The record conflicts with an existing one
+	ErrInvalidInput = errors.New("invalid input")  // The request is malformed or misses required fields
+	ErrValidation   = errors.New("invalid value")  // The request is well formed but its values are not acceptable
+	// ErrPreconditionFailed is returned when the record changed since the version a conditional request is based on
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
@@ -2,6 +2,7 @@ package models
 
 import (
 	"encoding/json"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,6 +14,7 @@ type ExperienceData struct {
 	CollectedAt    time.Time       `json:"collected_at"`
 	CreatedAt      time.Time       `json:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at"`
+	Version        int             `json:"version"` // Incremented by every change; the ETag of the record is the quoted version
 	SourceType     string          `json:"source_type"`
 	SourceID       *string         `json:"source_id,omitempty"`
 	SourceName     *string         `json:"source_name,omitempty"`
@@ -27,6 +29,21 @@ type ExperienceData struct {
 	Metadata       json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
 	Language       *string         `json:"language,omitempty"`
 	UserIdentifier *string         `json:"user_identifier,omitempty"`
+	ResponseID     *string         `json:"response_id,omitempty"` // Groups the answers of one submission
+	PIITypes       []string        `json:"pii_types,omitempty"`   // Types of personal data detected in value_text
+	Encrypted      bool            `json:"encrypted,omitempty"`   // value_text, value_json and user_identifier are stored encrypted
+	NaturalKey     []string        `json:"natural_key,omitempty"` // Fields of the natural key the record was upserted on
+	DeletedAt      *time.Time      `json:"deleted_at,omitempty"`  // Set while the record is in the trash
+	Sealed         *SealedValues   `json:"-"`                     // Encrypted values, until they are opened
+}
+
+// ExperienceFieldNames lists the fields of experience data a sparse fieldset can select
+var ExperienceFieldNames = []string{
+	"id", "collected_at", "created_at", "updated_at", "version",
+	"source_type", "source_id", "source_name", "field_id", "field_label", "field_type",
+	"value_text", "value_number", "value_boolean", "value_date", "value_json",
+	"metadata", "language", "user_identifier", "response_id",
+	"pii_types", "encrypted", "natural_key", "deleted_at",
 }
 
 // CreateExperienceRequest represents the request to create experience data
@@ -46,6 +63,13 @@ type CreateExperienceRequest struct {
 	Metadata       json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
 	Language       *string         `json:"language,omitempty"`
 	UserIdentifier *string         `json:"user_identifier,omitempty"`
+	ResponseID     *string         `json:"response_id,omitempty"` // Groups the answers of one submission
+	Strict         bool            `json:"-"`                     // Reject fields that are not registered for the source
+	PIITypes       []string        `json:"-"`                     // Set by the PII scanner
+	ValueTextVault []byte          `json:"-"`                     // Sealed original of masked value_text
+	Sealed         *SealedValues   `json:"-"`                     // Replaces value_text, value_json and user_identifier
+	NaturalKey     *string         `json:"-"`                     // Hash of the natural key values, set for upserts
+	ID             uuid.UUID       `json:"-"`                     // Assigned before sealing, since sealed values are bound to it
 }
 
 // UpdateExperienceRequest represents the request to update experience data
@@ -64,37 +88,122 @@ type UpdateExperienceRequest struct {
 	Metadata       json.RawMessage `json:"metadata,omitempty" swaggertype:"object"`
 	Language       *string         `json:"language,omitempty"`
 	UserIdentifier *string         `json:"user_identifier,omitempty"`
+	ResponseID     *string         `json:"response_id,omitempty"` // Groups the answers of one submission
+	PIIScanned     bool            `json:"-"`                     // Replace pii_types and value_text_vault with the fields below
+	PIITypes       []string        `json:"-"`                     // Set by the PII scanner
+	ValueTextVault []byte          `json:"-"`                     // Sealed original of masked value_text
+	Sealed         *SealedValues   `json:"-"`                     // Replaces value_text, value_json and user_identifier
+	NaturalKey     *string         `json:"-"`                     // Hash of the natural key values, set when they change
+	IfMatch        []int           `json:"-"`                     // Versions the record must be at, nil for an unconditional update
+	Clear          []string        `json:"-"`                     // Fields set to null by a merge patch, see NullableExperienceFields
+	MergeMetadata  bool            `json:"-"`                     // Merge Metadata into the stored metadata (RFC 7396) instead of replacing it
+}
+
+// NullableExperienceFields lists the fields of experience data a merge patch can clear with null
+var NullableExperienceFields = []string{
+	"source_id", "source_name", "field_label",
+	"value_text", "value_number", "value_boolean", "value_date", "value_json",
+	"metadata", "language", "user_identifier", "response_id",
+}
+
+// Clears reports whether the update sets field to null
+func (r *UpdateExperienceRequest) Clears(field string) bool {
+	return slices.Contains(r.Clear, field)
 }
 
 // ListExperiencesFilters represents filters for listing experiences
 type ListExperiencesFilters struct {
-	SourceType     *string
-	SourceID       *string
-	FieldID        *string
-	UserIdentifier *string
-	Limit          int
-	Offset         int
+	SourceType          *string
+	SourceID            *string
+	FieldID             *string
+	UserIdentifier      *string
+	UserIdentifierMatch *UserIdentifierMatch // Stored forms of UserIdentifier, set by the service
+	ResponseID          *string
+	PIIType             *string
+	Deleted             bool     // List the trash instead of live records
+	Fields              []string // Sparse fieldset, see ExperienceFieldNames; all fields when empty
+	Limit               int
+	Offset              int
+}
+
+// Search modes supported by ExperienceFilters.Mode
+const (
+	SearchModeFullText = "fulltext" // Stemmed full-text search ranked by ts_rank (default)
+	SearchModeFuzzy    = "fuzzy"    // Typo-tolerant trigram search ranked by similarity
+)
+
+// DefaultMinSimilarity is the fuzzy search similarity threshold used when none is given
+const DefaultMinSimilarity = 0.4
+
+// SearchFacetNames lists the facets supported by SearchExperiencesRequest.Facets
+// The sentiment facet reads metadata.sentiment, there is no dedicated column for it
+var SearchFacetNames = []string{"source_type", "source_id", "field_id", "field_type", "language", "sentiment"}
+
+// ExperienceFilters represents the filters shared by search and analytics
+type ExperienceFilters struct {
+	Query               *string              `json:"query,omitempty"`           // Full-text search query (supports "phrases", -negation, prefix* and OR)
+	Mode                string               `json:"mode,omitempty"`            // Search mode for query: fulltext (default) or fuzzy
+	MinSimilarity       float64              `json:"min_similarity,omitempty"`  // Fuzzy mode similarity threshold between 0 and 1 (default 0.4)
+	SourceType          *string              `json:"source_type,omitempty"`     // Filter by source type
+	SourceID            *string              `json:"source_id,omitempty"`       // Filter by source ID
+	FieldID             *string              `json:"field_id,omitempty"`        // Filter by field ID
+	FieldType           *string              `json:"field_type,omitempty"`      // Filter by field type
+	UserIdentifier      *string              `json:"user_identifier,omitempty"` // Filter by user identifier
+	UserIdentifierMatch *UserIdentifierMatch `json:"-"`                         // Stored forms of UserIdentifier, set by the service
+	ResponseID          *string              `json:"response_id,omitempty"`     // Filter by response ID
+	PIIType             *string              `json:"pii_type,omitempty"`        // Filter by a type of detected personal data
+	StartDate           *time.Time           `json:"start_date,omitempty"`      // Filter by collected_at >= start_date
+	EndDate             *time.Time           `json:"end_date,omitempty"`        // Filter by collected_at <= end_date
 }
 
 // SearchExperiencesRequest represents search parameters for experiences
 type SearchExperiencesRequest struct {
-	Query          *string    `json:"query,omitempty"`           // Full-text search query
-	SourceType     *string    `json:"source_type,omitempty"`     // Filter by source type
-	SourceID       *string    `json:"source_id,omitempty"`       // Filter by source ID
-	FieldID        *string    `json:"field_id,omitempty"`        // Filter by field ID
-	FieldType      *string    `json:"field_type,omitempty"`      // Filter by field type
-	UserIdentifier *string    `json:"user_identifier,omitempty"` // Filter by user identifier
-	StartDate      *time.Time `json:"start_date,omitempty"`      // Filter by collected_at >= start_date
-	EndDate        *time.Time `json:"end_date,omitempty"`        // Filter by collected_at <= end_date
-	PageSize       int        `json:"page_size,omitempty"`       // Number of results per page (default 20, max 40)
-	Page           int        `json:"page,omitempty"`            // Page number (starts at 0)
+	ExperienceFilters
+	PageSize int      `json:"page_size,omitempty"` // Number of results per page (default 20, max 40)
+	Page     int      `json:"page,omitempty"`      // Page number (starts at 0)
+	Facets   []string `json:"facets,omitempty"`    // Facets to count under the current filters (see SearchFacetNames)
+	Fields   []string `json:"fields,omitempty"`    // Sparse fieldset, see ExperienceFieldNames; all fields when empty
+}
+
+// ExperienceSearchResult represents a single search hit
+// Rank and Highlight are only set for full-text queries, Similarity only for fuzzy queries
+type ExperienceSearchResult struct {
+	ExperienceData
+	Rank       *float64 `json:"rank,omitempty"`       // ts_rank relevance of the match
+	Highlight  *string  `json:"highlight,omitempty"`  // value_text snippet with matches wrapped in <mark></mark>
+	Similarity *float64 `json:"similarity,omitempty"` // Trigram word similarity of the match (0-1)
+}
+
+// FacetValue represents the number of matching records for a single facet value
+type FacetValue struct {
+	Value *string `json:"value"` // null counts records without a value
+	Count int     `json:"count"`
 }
 
 // SearchExperiencesResponse represents paginated search results
 type SearchExperiencesResponse struct {
-	Data       []ExperienceData `json:"data"`
-	Page       int              `json:"page"`
-	PageSize   int              `json:"page_size"`
-	TotalCount int              `json:"total_count"`
-	TotalPages int              `json:"total_pages"`
+	Data       []ExperienceSearchResult `json:"data"`
+	Page       int                      `json:"page"`
+	PageSize   int                      `json:"page_size"`
+	TotalCount int                      `json:"total_count"`
+	TotalPages int                      `json:"total_pages"`
+	Facets     map[string][]FacetValue  `json:"facets,omitempty"` // Value counts per requested facet, most frequent first
+	// EncryptedNotSearched counts the encrypted records matching every filter but
+	// query, whose value_text the query could not search
+	EncryptedNotSearched int `json:"encrypted_not_searched,omitempty"`
+}
+
+// MaxLookupIDs limits how many records a lookup can request at once
+const MaxLookupIDs = 500
+
+// LookupExperiencesRequest represents a lookup of experience data by ID
+type LookupExperiencesRequest struct {
+	IDs    []uuid.UUID `json:"ids"`              // At most 500 record IDs
+	Fields []string    `json:"fields,omitempty"` // Sparse fieldset, see ExperienceFieldNames; all fields when empty
+}
+
+// LookupExperiencesResponse represents the records found by a lookup
+type LookupExperiencesResponse struct {
+	Data    []ExperienceData `json:"data"`    // Found records, in the order of the requested IDs
+	Missing []uuid.UUID      `json:"missing"` // Requested IDs without a live record
 }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// File formats of exports and streamed responses
+const (
+	ExportFormatCSV     = "csv"
+	ExportFormatNDJSON  = "ndjson"
+	ExportFormatParquet = "parquet"
+)
+
+// ExportFormats lists the formats an export can be written in
+var ExportFormats = []string{ExportFormatCSV, ExportFormatNDJSON, ExportFormatParquet}
+
+// Statuses of an Export
+const (
+	ExportPending   = "pending"   // Waiting for the worker
+	ExportRunning   = "running"   // Being written by the worker
+	ExportCompleted = "completed" // The file can be downloaded
+	ExportFailed    = "failed"    // Stopped by an error, no file was stored
+	ExportExpired   = "expired"   // The file was deleted after the retention period
+)
+
+// CreateExportRequest represents an export of the experiences matching filters to a file
+type CreateExportRequest struct {
+	ExperienceFilters
+	Format string   `json:"format"`           // csv, ndjson or parquet
+	Fields []string `json:"fields,omitempty"` // Sparse fieldset, see ExperienceFieldNames; all fields when empty
+}
+
+// Export is a file of experience data written by the worker
+type Export struct {
+	ID                uuid.UUID         `json:"id"`
+	Format            string            `json:"format"`
+	Status            string            `json:"status"`
+	Filters           ExperienceFilters `json:"filters"`
+	Fields            []string          `json:"fields,omitempty"`
+	Rows              int64             `json:"rows"`                          // Records written so far
+	Size              int64             `json:"size"`                          // Size of the file in bytes, once completed
+	Error             *string           `json:"error,omitempty"`               // Why a failed export stopped
+	DownloadURL       *string           `json:"download_url,omitempty"`        // Signed URL of the file, set while it is completed
+	DownloadExpiresAt *time.Time        `json:"download_expires_at,omitempty"` // When download_url stops working
+	CreatedAt         time.Time         `json:"created_at"`
+	StartedAt         *time.Time        `json:"started_at,omitempty"`
+	FinishedAt        *time.Time        `json:"finished_at,omitempty"`
+	ExpiresAt         *time.Time        `json:"expires_at,omitempty"` // When the file is deleted
+	ObjectKey         *string           `json:"-"`                    // Key of the file in the blob store
+	APIKeyID          *uuid.UUID        `json:"-"`                    // API key that created the export
+}
@@ -0,0 +1,37 @@
+package models
+
+// Field types supported by ExperienceData.FieldType
+const (
+	FieldTypeText         = "text"          // Free text in value_text
+	FieldTypeNumber       = "number"        // Any number in value_number
+	FieldTypeRating       = "rating"        // Rating from 1 to 10 in value_number
+	FieldTypeNPS          = "nps"           // Net Promoter Score answer from 0 to 10 in value_number
+	FieldTypeBoolean      = "boolean"       // Yes/no answer in value_boolean
+	FieldTypeDate         = "date"          // Date in value_date
+	FieldTypeSingleChoice = "single_choice" // Selected option in value_text
+	FieldTypeMultiChoice  = "multi_choice"  // JSON array of selected options in value_json
+	FieldTypeMatrix       = "matrix"        // JSON object mapping rows to selected columns in value_json
+	FieldTypeFile         = "file"          // http(s) URL of an uploaded file in value_text
+)
+
+// Validation error codes reported in FieldError.Code
+const (
+	ValidationRequired      = "required"       // The field is missing
+	ValidationUnknownType   = "unknown_type"   // field_type is not registered
+	ValidationOutOfRange    = "out_of_range"   // A number is outside the allowed range
+	ValidationNotInteger    = "not_integer"    // A number must be a whole number
+	ValidationInvalidValue  = "invalid_value"  // A value has the wrong shape or format
+	ValidationUnknownSource = "unknown_source" // Strict mode: the source is not registered
+	ValidationUnknownField  = "unknown_field"  // Strict mode: the field is not registered for the source
+	ValidationTypeMismatch  = "type_mismatch"  // Strict mode: field_type differs from the registered field
+	ValidationPIIDetected   = "pii_detected"   // value_text holds personal data the source rejects
+	// ValidationKeyNotConfigured is reported for a source setting needing a key the API is not configured with
+	ValidationKeyNotConfigured = "key_not_configured"
+)
+
+// FieldError describes why a single request field failed validation
+type FieldError struct {
+	Field   string `json:"field"`   // JSON name of the offending field, e.g. value_number
+	Code    string `json:"code"`    // Machine readable reason, see the Validation* constants
+	Message string `json:"message"` // Human readable explanation
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// History actions recorded for experience data
+const (
+	HistoryActionCreate  = "create"
+	HistoryActionUpdate  = "update"
+	HistoryActionDelete  = "delete"  // Moved to the trash
+	HistoryActionRestore = "restore" // Moved out of the trash
+)
+
+// ExperienceHistoryEntry records one change of an experience data record
+type ExperienceHistoryEntry struct {
+	ID           uuid.UUID       `json:"id"`
+	ExperienceID uuid.UUID       `json:"experience_id"`
+	Action       string          `json:"action"`                                // create, update, delete or restore
+	APIKeyID     *uuid.UUID      `json:"api_key_id,omitempty"`                  // API key that made the change
+	Before       json.RawMessage `json:"before,omitempty" swaggertype:"object"` // Record before the change, absent for create
+	After        json.RawMessage `json:"after,omitempty" swaggertype:"object"`  // Record after the change
+	ChangedAt    time.Time       `json:"changed_at"`
+}
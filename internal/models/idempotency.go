@@ -0,0 +1,25 @@
+package models
+
+import "github.com/google/uuid"
+
+// IdempotencyKeyHeader is the request header carrying the idempotency key of a POST request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader is set on responses replayed for a repeated idempotency key
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// MaxIdempotencyKeyLength is the maximum length of an idempotency key
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord represents the request an idempotency key was first used
+// for and, once it completed, its response
+type IdempotencyRecord struct {
+	Fingerprint   string            // Hex encoded SHA-256 of the method, path and body of the request
+	StatusCode    *int              // nil while the request is in progress
+	ContentType   *string           // Content-Type of the response
+	Headers       map[string]string // Other response headers replayed with the body, see the Idempotency middleware
+	Body          []byte            // Response body, sealed when KeyVersion is set
+	DataKey       []byte            // Data key sealing Body, wrapped by the KEK of KeyVersion
+	KeyVersion    *int              // KEK version of DataKey, nil for a body stored in the clear
+	ExperienceIDs []uuid.UUID       // Records returned in the response, whose erasure removes it
+}
@@ -0,0 +1,28 @@
+package models
+
+import "github.com/google/uuid"
+
+// PII modes supported by Source.PIIMode
+const (
+	PIIModeOff    = "off"    // value_text is stored as sent (default)
+	PIIModeFlag   = "flag"   // value_text is stored as sent, detected types are recorded in pii_types
+	PIIModeMask   = "mask"   // Detected values are replaced by a placeholder such as [EMAIL]
+	PIIModeReject = "reject" // Records with detected values are rejected
+)
+
+// Types of personal data detected in value_text
+const (
+	PIITypeEmail = "email"
+	PIITypePhone = "phone"
+	PIITypeIBAN  = "iban"
+	PIITypeCard  = "card" // Payment card number passing the Luhn check
+)
+
+// PIITypeNames lists the types of personal data the scanner detects
+var PIITypeNames = []string{PIITypeEmail, PIITypePhone, PIITypeIBAN, PIITypeCard}
+
+// ExperienceVault represents the original value_text of a masked record
+type ExperienceVault struct {
+	ID        uuid.UUID `json:"id"`
+	ValueText string    `json:"value_text"`
+}
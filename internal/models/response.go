@@ -0,0 +1,44 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Response represents all answers of a single submission, grouped by response_id
+type Response struct {
+	ID             string           `json:"id"`
+	SourceType     string           `json:"source_type"`
+	SourceID       *string          `json:"source_id,omitempty"`
+	UserIdentifier *string          `json:"user_identifier,omitempty"`
+	CollectedAt    time.Time        `json:"collected_at"` // collected_at of the earliest answer
+	Answers        []ExperienceData `json:"answers"`      // Ordered by collected_at, then field_id
+}
+
+// ResponseRow represents a single submission with its answers pivoted into columns
+type ResponseRow struct {
+	ID             string                     `json:"id"`
+	SourceType     string                     `json:"source_type"`
+	SourceID       *string                    `json:"source_id,omitempty"`
+	UserIdentifier *string                    `json:"user_identifier,omitempty"`
+	CollectedAt    time.Time                  `json:"collected_at"`                // collected_at of the earliest answer
+	Values         map[string]json.RawMessage `json:"values" swaggertype:"object"` // Answer value keyed by field_id
+}
+
+// ListResponsesFilters represents filters for listing responses
+type ListResponsesFilters struct {
+	SourceType          *string
+	SourceID            *string
+	UserIdentifier      *string
+	UserIdentifierMatch *UserIdentifierMatch // Stored forms of UserIdentifier, set by the service
+	StartDate           *time.Time
+	EndDate             *time.Time
+	Limit               int
+	Offset              int
+}
+
+// ListResponsesResponse represents a page of pivoted responses
+type ListResponsesResponse struct {
+	Fields []string      `json:"fields"` // field_id of every column present in data, sorted
+	Data   []ResponseRow `json:"data"`   // Most recent responses first
+}
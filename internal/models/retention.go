@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Retention actions supported by RetentionPolicy.Action
+const (
+	RetentionActionDelete    = "delete"    // Remove expired records permanently
+	RetentionActionAnonymize = "anonymize" // Keep expired records for aggregates, without anything identifying the person
+)
+
+// RetentionPolicy limits how long experience data of a source is kept
+// Records of the policy's source_type (and source_id, when set) collected more
+// than MaxAgeDays ago are deleted or anonymized by the worker
+type RetentionPolicy struct {
+	ID              uuid.UUID  `json:"id"`
+	SourceType      string     `json:"source_type"`
+	SourceID        *string    `json:"source_id,omitempty"` // Restricts the policy to one source
+	MaxAgeDays      int        `json:"max_age_days"`        // Age by collected_at after which records expire
+	Action          string     `json:"action"`              // delete or anonymize
+	Enabled         bool       `json:"enabled"`
+	LastRunAt       *time.Time `json:"last_run_at,omitempty"`
+	LastRunAffected *int64     `json:"last_run_affected,omitempty"` // Records deleted or anonymized by the last run
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// CreateRetentionPolicyRequest represents the request to create a retention policy
+type CreateRetentionPolicyRequest struct {
+	SourceType string  `json:"source_type"`
+	SourceID   *string `json:"source_id,omitempty"`
+	MaxAgeDays int     `json:"max_age_days"`
+	Action     string  `json:"action"`
+	Enabled    *bool   `json:"enabled,omitempty"` // Defaults to true
+}
+
+// UpdateRetentionPolicyRequest represents the request to update a retention policy
+type UpdateRetentionPolicyRequest struct {
+	MaxAgeDays *int    `json:"max_age_days,omitempty"`
+	Action     *string `json:"action,omitempty"`
+	Enabled    *bool   `json:"enabled,omitempty"`
+}
+
+// RetentionRun reports the records a retention policy affects
+type RetentionRun struct {
+	PolicyID uuid.UUID `json:"policy_id"`
+	Action   string    `json:"action"`
+	Cutoff   time.Time `json:"cutoff"`   // Records collected before the cutoff are expired
+	Affected int64     `json:"affected"` // Records deleted or anonymized, or that would be in a dry run
+	DryRun   bool      `json:"dry_run"`
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Source represents a registered source of experience data, such as a survey
+// Records belong to a source when their source_type and source_id match its
+// SourceType and ExternalID
+type Source struct {
+	ID           uuid.UUID `json:"id"`
+	SourceType   string    `json:"source_type"`
+	ExternalID   string    `json:"external_id"` // Matches source_id of the source's records
+	Name         *string   `json:"name,omitempty"`
+	Strict       bool      `json:"strict"`       // Reject records for fields that are not registered
+	PIIMode      string    `json:"pii_mode"`     // How personal data in value_text is handled: off, flag, mask or reject
+	PIIVault     bool      `json:"pii_vault"`    // Keep the original of masked value_text encrypted
+	Encrypt      bool      `json:"encrypt"`      // Encrypt value_text, value_json and user_identifier of records
+	Pseudonymize bool      `json:"pseudonymize"` // Store user_identifier of records only as a keyed hash
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// CreateSourceRequest represents the request to register a source
+type CreateSourceRequest struct {
+	SourceType   string  `json:"source_type"`
+	ExternalID   string  `json:"external_id"`
+	Name         *string `json:"name,omitempty"`
+	Strict       bool    `json:"strict,omitempty"`
+	PIIMode      string  `json:"pii_mode,omitempty"` // Default off
+	PIIVault     bool    `json:"pii_vault,omitempty"`
+	Encrypt      bool    `json:"encrypt,omitempty"`
+	Pseudonymize bool    `json:"pseudonymize,omitempty"`
+}
+
+// UpdateSourceRequest represents the request to update a source
+type UpdateSourceRequest struct {
+	Name         *string `json:"name,omitempty"`
+	Strict       *bool   `json:"strict,omitempty"`
+	PIIMode      *string `json:"pii_mode,omitempty"`
+	PIIVault     *bool   `json:"pii_vault,omitempty"`
+	Encrypt      *bool   `json:"encrypt,omitempty"`      // Applies to records created or updated afterwards
+	Pseudonymize *bool   `json:"pseudonymize,omitempty"` // Applies to records created or updated afterwards, see cmd/pseudonymize for existing ones
+}
+
+// ListSourcesFilters represents filters for listing sources
+type ListSourcesFilters struct {
+	SourceType *string
+	Limit      int
+	Offset     int
+}
+
+// SourceField represents a registered field of a source, such as a survey question
+type SourceField struct {
+	SourceID     uuid.UUID         `json:"source_id"`
+	FieldID      string            `json:"field_id"` // Matches field_id of the field's records
+	Label        *string           `json:"label,omitempty"`
+	FieldType    string            `json:"field_type"`
+	Choices      []FieldChoice     `json:"choices,omitempty"`
+	Translations map[string]string `json:"translations,omitempty"` // Label keyed by language code
+	Position     int               `json:"position"`               // Order of the field within the source
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+// FieldChoice represents an answer option of a choice field
+type FieldChoice struct {
+	ID           string            `json:"id"`
+	Label        string            `json:"label"`
+	Translations map[string]string `json:"translations,omitempty"` // Label keyed by language code
+}
+
+// PutSourceFieldRequest represents the request to register or replace a field of a source
+type PutSourceFieldRequest struct {
+	Label        *string           `json:"label,omitempty"`
+	FieldType    string            `json:"field_type"`
+	Choices      []FieldChoice     `json:"choices,omitempty"`
+	Translations map[string]string `json:"translations,omitempty"`
+	Position     int               `json:"position,omitempty"`
+}
+
+// SourceFieldLookup represents the registration of a record's source and field
+type SourceFieldLookup struct {
+	Strict       bool
+	PIIMode      string
+	PIIVault     bool
+	Encrypt      bool
+	Pseudonymize bool
+	Field        *SourceField // nil when the field is not registered
+}
+
+// ImportSurveyResponse represents the result of importing a survey definition
+type ImportSurveyResponse struct {
+	Source  Source            `json:"source"`
+	Fields  []SourceField     `json:"fields"`
+	Skipped []SkippedQuestion `json:"skipped,omitempty"` // Questions without a matching field type
+}
+
+// SkippedQuestion represents a survey question that was not imported
+type SkippedQuestion struct {
+	ID     string `json:"id"`
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// NaturalKeyFields lists the fields a natural key of PUT /v1/experiences may combine
+var NaturalKeyFields = []string{"source_type", "source_id", "field_id", "response_id", "collected_at"}
+
+// DefaultNaturalKey is the natural key used when none is given
+var DefaultNaturalKey = []string{"source_type", "source_id", "field_id", "response_id"}
+
+// MaxUpsertRecords limits the number of records of a single upsert request
+const MaxUpsertRecords = 1000
+
+// Results of upserting a record
+const (
+	UpsertResultCreated = "created" // No live record had the natural key, a new one was created
+	UpsertResultUpdated = "updated" // The live record with the natural key was replaced
+)
+
+// UpsertExperiencesRequest represents the records of an upsert and the natural key they are matched on
+type UpsertExperiencesRequest struct {
+	Key     []string // Natural key fields, DefaultNaturalKey when empty
+	Strict  bool     // Reject fields that are not registered for the source
+	Records []CreateExperienceRequest
+}
+
+// UpsertExperienceResult represents an upserted record and whether it was created or updated
+type UpsertExperienceResult struct {
+	ExperienceData
+	Result string `json:"result" enums:"created,updated"`
+}
+
+// UpsertExperiencesResponse represents the upserted records, in request order
+type UpsertExperiencesResponse struct {
+	Data    []UpsertExperienceResult `json:"data"`
+	Created int                      `json:"created"` // Number of records created
+	Updated int                      `json:"updated"` // Number of records updated
+}
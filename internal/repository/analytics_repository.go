@@ -0,0 +1,380 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// metricPercentiles are the percentiles computed by Metrics, in the order returned by percentile_cont
+var metricPercentiles = []struct {
+	name     string
+	fraction float64
+}{
+	{"p5", 0.05},
+	{"p10", 0.10},
+	{"p25", 0.25},
+	{"p50", 0.50},
+	{"p75", 0.75},
+	{"p90", 0.90},
+	{"p95", 0.95},
+	{"p99", 0.99},
+}
+
+// AnalyticsRepository handles aggregate queries over experience data
+type AnalyticsRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewAnalyticsRepository creates a new analytics repository
+func NewAnalyticsRepository(db *pgxpool.Pool) *AnalyticsRepository {
+	return &AnalyticsRepository{db: db}
+}
+
+// Distributions of fields with more distinct values than maxDistributionValues
+// (e.g. free numeric answers) are binned into distributionBins equal-width ranges
+const (
+	maxDistributionValues = 100
+	distributionBins      = 20
+)
+
+// Metrics computes summary statistics and the value distribution of value_number
+// for the records matched by filters. Records without a numeric value are ignored.
+// It also counts the records with a value of at least each of thresholds, from
+// which the caller derives survey specific scores.
+func (r *AnalyticsRepository) Metrics(ctx context.Context, filters *models.ExperienceFilters, thresholds []float64) (*models.MetricsResponse, []int, error) {
+	filter := buildExperienceFilter(filters)
+	filter.and("value_number IS NOT NULL")
+
+	// Summary and distribution queries share a transaction so they see the same rows
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly, IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin metrics transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := filter.apply(ctx, tx); err != nil {
+		return nil, nil, err
+	}
+
+	fractions := make([]float64, len(metricPercentiles))
+	for i, p := range metricPercentiles {
+		fractions[i] = p.fraction
+	}
+
+	args := append(filter.args, fractions)
+	summaryQuery := filter.with + fmt.Sprintf(`
+		SELECT COUNT(*), AVG(value_number), MIN(value_number), MAX(value_number),
+			STDDEV_SAMP(value_number),
+			percentile_cont($%d::float8[]) WITHIN GROUP (ORDER BY value_number)`, filter.nextArg())
+	for i, threshold := range thresholds {
+		summaryQuery += fmt.Sprintf(`,
+			COUNT(*) FILTER (WHERE value_number >= $%d::float8)`, filter.nextArg()+1+i)
+		args = append(args, threshold)
+	}
+	summaryQuery += filter.from + filter.where
+
+	var metrics models.MetricsResponse
+	var percentiles []float64
+	atLeast := make([]int, len(thresholds))
+	dest := []any{&metrics.Count, &metrics.Mean, &metrics.Min, &metrics.Max, &metrics.StdDev, &percentiles}
+	for i := range atLeast {
+		dest = append(dest, &atLeast[i])
+	}
+	err = tx.QueryRow(ctx, summaryQuery, args...).Scan(dest...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to compute metrics: %w", err)
+	}
+
+	if len(percentiles) == len(metricPercentiles) {
+		metrics.Percentiles = make(map[string]float64, len(percentiles))
+		for i, p := range metricPercentiles {
+			metrics.Percentiles[p.name] = percentiles[i]
+		}
+		median := metrics.Percentiles["p50"]
+		metrics.Median = &median
+	}
+
+	metrics.Distribution, err = distribution(ctx, tx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(metrics.Distribution) > maxDistributionValues {
+		metrics.Distribution, err = binnedDistribution(ctx, tx, filter, *metrics.Min, *metrics.Max)
+		if err != nil {
+			return nil, nil, err
+		}
+		metrics.DistributionBinned = true
+	}
+
+	return &metrics, atLeast, nil
+}
+
+// distribution counts each distinct value, reading no more than one value past
+// maxDistributionValues
+func distribution(ctx context.Context, tx pgx.Tx, filter *experienceFilter) ([]models.DistributionBucket, error) {
+	query := filter.with + `
+		SELECT value_number, COUNT(*)` +
+		filter.from + filter.where + fmt.Sprintf(`
+		GROUP BY value_number
+		ORDER BY value_number
+		LIMIT %d`, maxDistributionValues+1)
+
+	rows, err := tx.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute distribution: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []models.DistributionBucket{}
+	for rows.Next() {
+		var bucket models.DistributionBucket
+		if err := rows.Scan(&bucket.Value, &bucket.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan distribution: %w", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating distribution: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// binnedDistribution counts the values in distributionBins equal-width ranges
+// from low to high. The highest value falls into the last range.
+func binnedDistribution(ctx context.Context, tx pgx.Tx, filter *experienceFilter, low, high float64) ([]models.DistributionBucket, error) {
+	lowArg, highArg := filter.nextArg(), filter.nextArg()+1
+	query := filter.with + fmt.Sprintf(`
+		SELECT LEAST(width_bucket(value_number, $%d::float8, $%d::float8, %d), %d), COUNT(*)`,
+		lowArg, highArg, distributionBins, distributionBins) +
+		filter.from + filter.where + `
+		GROUP BY 1`
+
+	rows, err := tx.Query(ctx, query, append(filter.args, low, high)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute distribution: %w", err)
+	}
+	defer rows.Close()
+
+	width := (high - low) / distributionBins
+	buckets := make([]models.DistributionBucket, distributionBins)
+	for i := range buckets {
+		upper := low + float64(i+1)*width
+		if i == distributionBins-1 {
+			upper = high
+		}
+		buckets[i] = models.DistributionBucket{Value: low + float64(i)*width, UpperBound: &upper}
+	}
+
+	for rows.Next() {
+		var bin, count int
+		if err := rows.Scan(&bin, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan distribution: %w", err)
+		}
+		buckets[bin-1].Count = count
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating distribution: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// timeSeriesGroupColumns maps the supported group_by values to the expression they group by
+// metadata.<key> groups are handled separately
+var timeSeriesGroupColumns = map[string]string{
+	"source_type": "source_type",
+	"field_id":    "field_id",
+}
+
+// timeSeriesValue returns the aggregate expression for a time series metric
+// scaleMaxArg is the parameter holding the rating scale maximum for csat and ces
+func timeSeriesValue(metric string, scaleMaxArg int) (string, error) {
+	switch metric {
+	case models.MetricCount:
+		return "COUNT(*)::float8", nil
+	case models.MetricMean, models.MetricCES:
+		return "AVG(value_number)", nil
+	case models.MetricSum:
+		return "SUM(value_number)", nil
+	case models.MetricMin:
+		return "MIN(value_number)", nil
+	case models.MetricMax:
+		return "MAX(value_number)", nil
+	case models.MetricNPS:
+		return fmt.Sprintf(`100.0 * (
+			COUNT(*) FILTER (WHERE value_number >= %d) -
+			COUNT(*) FILTER (WHERE value_number < %d)
+		) / NULLIF(COUNT(value_number), 0)`, models.NPSPromoterMin, models.NPSPassiveMin), nil
+	case models.MetricCSAT:
+		return fmt.Sprintf(`100.0 * COUNT(*) FILTER (WHERE value_number >= $%d::float8 - 1) / NULLIF(COUNT(value_number), 0)`, scaleMaxArg), nil
+	default:
+		return "", fmt.Errorf("unsupported metric: %s", metric)
+	}
+}
+
+// TimeSeries aggregates the records matched by req into buckets of collected_at,
+// aligned to req.TimeZone. collected_at is stored as UTC.
+// Only buckets containing records are returned, ordered by bucket; filling gaps
+// is left to the caller. Records without value_number are ignored for every
+// metric but count.
+func (r *AnalyticsRepository) TimeSeries(ctx context.Context, req *models.TimeSeriesRequest) ([]models.TimeSeriesBucket, error) {
+	filter := buildExperienceFilter(&req.ExperienceFilters)
+	// Metrics of value_number only count records with a numeric value
+	if req.Metric != models.MetricCount {
+		filter.and("value_number IS NOT NULL")
+	}
+	args := filter.args
+	argCount := filter.nextArg()
+
+	intervalArg, timeZoneArg := argCount, argCount+1
+	args = append(args, req.Interval, req.TimeZone)
+	argCount += 2
+
+	value, err := timeSeriesValue(req.Metric, argCount)
+	if err != nil {
+		return nil, err
+	}
+	if req.Metric == models.MetricCSAT {
+		scaleMax := models.DefaultCSATScaleMax
+		if req.ScaleMax != nil {
+			scaleMax = *req.ScaleMax
+		}
+		args = append(args, scaleMax)
+		argCount++
+	}
+
+	group := "NULL::text"
+	if req.GroupBy != nil {
+		if key, ok := strings.CutPrefix(*req.GroupBy, "metadata."); ok {
+			group = fmt.Sprintf("metadata->>$%d", argCount)
+			args = append(args, key)
+		} else if column, ok := timeSeriesGroupColumns[*req.GroupBy]; ok {
+			group = column
+		} else {
+			return nil, fmt.Errorf("unsupported group_by: %s", *req.GroupBy)
+		}
+	}
+
+	query := filter.with + fmt.Sprintf(`
+		SELECT date_trunc($%d, collected_at AT TIME ZONE 'UTC', $%d) AS bucket,
+			%s AS series,
+			COUNT(*),
+			(%s)::float8`, intervalArg, timeZoneArg, group, value) +
+		filter.from + filter.where + `
+		GROUP BY 1, 2
+		ORDER BY 1`
+
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin time series transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := filter.apply(ctx, tx); err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute time series: %w", err)
+	}
+	defer rows.Close()
+
+	var buckets []models.TimeSeriesBucket
+	for rows.Next() {
+		var bucket models.TimeSeriesBucket
+		if err := rows.Scan(&bucket.Bucket, &bucket.Group, &bucket.Count, &bucket.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan time series bucket: %w", err)
+		}
+		buckets = append(buckets, bucket)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating time series: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// answerText renders the typed value of an answer as text, so answers of any
+// field type can label crosstab rows and columns
+const answerText = `COALESCE(value_text, value_number::text, value_boolean::text, to_char(value_date AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"'), value_json::text)`
+
+// Crosstab counts the responses for every pair of answers to req.RowField and
+// req.ColumnField. Answers are paired by response_id among the records matched
+// by the filters; when a field was answered more than once in a response the
+// latest answer is used. The number of encrypted answers that could not be
+// counted is returned as well.
+func (r *AnalyticsRepository) Crosstab(ctx context.Context, req *models.CrosstabRequest) ([]models.CrosstabCount, int, error) {
+	filter := buildExperienceFilter(&req.ExperienceFilters)
+	rowArg, columnArg := filter.nextArg(), filter.nextArg()+1
+	args := append(filter.args, req.RowField, req.ColumnField)
+
+	filter.and("response_id IS NOT NULL")
+	filter.and(fmt.Sprintf("field_id IN ($%d, $%d)", rowArg, columnArg))
+
+	// The encrypted value_text and value_json of answers cannot label rows or
+	// columns, so report how many answers are left out instead of missing them silently
+	encryptedQuery := filter.with + " SELECT COUNT(*)" + filter.from + filter.where +
+		" AND encrypted_values IS NOT NULL AND " + answerText + " IS NULL"
+
+	filter.and(answerText + " IS NOT NULL")
+
+	answers := fmt.Sprintf(`
+		SELECT DISTINCT ON (response_id, field_id) response_id, field_id, %s AS value`, answerText) +
+		filter.from + filter.where + `
+		ORDER BY response_id, field_id, collected_at DESC`
+
+	query := filter.withCTE("answers", answers) + fmt.Sprintf(`
+		SELECT row_answer.value, column_answer.value, COUNT(*)
+		FROM answers row_answer
+		JOIN answers column_answer ON column_answer.response_id = row_answer.response_id
+			AND column_answer.field_id = $%d
+		WHERE row_answer.field_id = $%d
+		GROUP BY 1, 2`, columnArg, rowArg)
+
+	// The crosstab and the count of encrypted answers left out see the same rows
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly, IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin crosstab transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := filter.apply(ctx, tx); err != nil {
+		return nil, 0, err
+	}
+
+	var encryptedNotCounted int
+	if err := tx.QueryRow(ctx, encryptedQuery, args...).Scan(&encryptedNotCounted); err != nil {
+		return nil, 0, fmt.Errorf("failed to count encrypted answers: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to compute crosstab: %w", err)
+	}
+	defer rows.Close()
+
+	var counts []models.CrosstabCount
+	for rows.Next() {
+		var count models.CrosstabCount
+		if err := rows.Scan(&count.Row, &count.Column, &count.Count); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan crosstab count: %w", err)
+		}
+		counts = append(counts, count)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error iterating crosstab: %w", err)
+	}
+
+	return counts, encryptedNotCounted, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+const bulkJobColumns = `id, action, status, filters, patch, matched, max_affected, affected,
+	last_experience_id, error, api_key_id, created_at, started_at, finished_at`
+
+// BulkJobRepository handles data access for bulk update and delete jobs
+type BulkJobRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewBulkJobRepository creates a new bulk job repository
+func NewBulkJobRepository(db *pgxpool.Pool) *BulkJobRepository {
+	return &BulkJobRepository{db: db}
+}
+
+func scanBulkJob(row pgx.Row) (*models.BulkJob, error) {
+	var job models.BulkJob
+	var filters, patch []byte
+	err := row.Scan(
+		&job.ID, &job.Action, &job.Status, &filters, &patch, &job.Matched, &job.MaxAffected, &job.Affected,
+		&job.Cursor, &job.Error, &job.APIKeyID, &job.CreatedAt, &job.StartedAt, &job.FinishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(filters, &job.Filters); err != nil {
+		return nil, fmt.Errorf("failed to decode bulk job filters: %w", err)
+	}
+	if patch != nil {
+		if err := json.Unmarshal(patch, &job.Patch); err != nil {
+			return nil, fmt.Errorf("failed to decode bulk job patch: %w", err)
+		}
+	}
+	return &job, nil
+}
+
+// Create inserts a new pending bulk job
+func (r *BulkJobRepository) Create(ctx context.Context, job *models.BulkJob) (*models.BulkJob, error) {
+	filters, err := json.Marshal(job.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bulk job filters: %w", err)
+	}
+	var patch []byte
+	if job.Patch != nil {
+		if patch, err = json.Marshal(job.Patch); err != nil {
+			return nil, fmt.Errorf("failed to encode bulk job patch: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO bulk_jobs (action, filters, patch, matched, max_affected, api_key_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING ` + bulkJobColumns
+
+	created, err := scanBulkJob(r.db.QueryRow(ctx, query,
+		job.Action, filters, patch, job.Matched, job.MaxAffected, job.APIKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bulk job: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a single bulk job by ID
+func (r *BulkJobRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.BulkJob, error) {
+	query := `SELECT ` + bulkJobColumns + ` FROM bulk_jobs WHERE id = $1`
+
+	job, err := scanBulkJob(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("bulk job %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get bulk job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Claim marks the oldest pending job as running and returns it, nil when no
+// job is waiting. Running jobs that made no progress since abandonedBefore
+// (e.g. because the worker stopped) are claimed again and resume after their cursor.
+func (r *BulkJobRepository) Claim(ctx context.Context, now, abandonedBefore time.Time) (*models.BulkJob, error) {
+	query := `
+		UPDATE bulk_jobs
+		SET status = $1, started_at = COALESCE(started_at, $3), updated_at = $3
+		WHERE id = (
+			SELECT id FROM bulk_jobs
+			WHERE status = $2 OR (status = $1 AND updated_at <= $4)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + bulkJobColumns
+
+	job, err := scanBulkJob(r.db.QueryRow(ctx, query, models.BulkJobRunning, models.BulkJobPending, now, abandonedBefore))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim bulk job: %w", err)
+	}
+
+	return job, nil
+}
+
+// Progress stores the records a running job has processed so far
+func (r *BulkJobRepository) Progress(ctx context.Context, id uuid.UUID, affected int, cursor *uuid.UUID) error {
+	query := `UPDATE bulk_jobs SET affected = $2, last_experience_id = $3, updated_at = $4 WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, query, id, affected, cursor, time.Now()); err != nil {
+		return fmt.Errorf("failed to store bulk job progress: %w", err)
+	}
+
+	return nil
+}
+
+// Finish stores the status, affected records and error a job ended with
+func (r *BulkJobRepository) Finish(ctx context.Context, job *models.BulkJob) error {
+	now := time.Now()
+	query := `UPDATE bulk_jobs SET status = $2, affected = $3, error = $4, updated_at = $5, finished_at = $5 WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, query, job.ID, job.Status, job.Affected, job.Error, now); err != nil {
+		return fmt.Errorf("failed to finish bulk job: %w", err)
+	}
+
+	job.FinishedAt = &now
+	return nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// freeTextFieldTypes lists the field types whose value_text may contain anything
+// the person wrote, and is cleared when anonymizing
+var freeTextFieldTypes = []string{models.FieldTypeText, models.FieldTypeFile}
+
+// anonymizeAssignments returns the SET assignments clearing everything that
+// identifies a person from experience_data rows: user_identifier, metadata
+// (which holds enrichment outputs such as sentiment), the value_text of free
+// text answers, the vaulted originals of masked value_text and the encrypted
+// values of encrypting sources, which cannot be cleared selectively. The record
+// version is incremented like on every other change.
+// Parameter $freeTextArg must hold freeTextFieldTypes.
+func anonymizeAssignments(freeTextArg int) string {
+	return fmt.Sprintf(`user_identifier = NULL,
+		metadata = NULL,
+		value_text = CASE WHEN field_type = ANY($%d) THEN NULL ELSE value_text END,
+		value_text_vault = NULL,
+		encrypted_values = NULL,
+		encrypted_data_key = NULL,
+		key_version = NULL,
+		user_identifier_index = NULL,
+		version = version + 1`, freeTextArg)
+}
+
+// identifiableCondition matches the rows anonymizeAssignments would change
+// Parameter $freeTextArg must hold freeTextFieldTypes.
+func identifiableCondition(freeTextArg int) string {
+	return fmt.Sprintf(`(user_identifier IS NOT NULL OR metadata IS NOT NULL OR value_text_vault IS NOT NULL OR encrypted_values IS NOT NULL OR (field_type = ANY($%d) AND value_text IS NOT NULL))`, freeTextArg)
+}
+
+// DataSubjectRepository handles data access for data subjects, the people
+// identified by the user_identifier of experience data
+type DataSubjectRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewDataSubjectRepository creates a new data subject repository
+func NewDataSubjectRepository(db *pgxpool.Pool) *DataSubjectRepository {
+	return &DataSubjectRepository{db: db}
+}
+
+// Export retrieves every record of a data subject, including records in the
+// trash, and their history from a single snapshot
+// match lists the stored forms of userIdentifier, see userIdentifierArgs.
+func (r *DataSubjectRepository) Export(ctx context.Context, userIdentifier string, match *models.UserIdentifierMatch) (*models.DataSubjectExport, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin export transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	export := &models.DataSubjectExport{
+		UserIdentifier: userIdentifier,
+		ExportedAt:     time.Now(),
+		Experiences:    []models.ExperienceData{},
+		History:        []models.ExperienceHistoryEntry{},
+	}
+
+	query := `SELECT ` + experienceColumns + ` FROM experience_data WHERE ` + userIdentifierCondition(1) + ` ORDER BY collected_at, id`
+
+	subject := userIdentifierArgs(userIdentifier, match)
+	rows, err := tx.Query(ctx, query, subject...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export experiences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		exp, err := scanExperience(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan experience: %w", err)
+		}
+		export.Experiences = append(export.Experiences, *exp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating experiences: %w", err)
+	}
+
+	if len(export.Experiences) == 0 {
+		return nil, fmt.Errorf("data subject %w", models.ErrNotFound)
+	}
+
+	historyQuery := `
+		SELECT h.id, h.experience_id, h.action, h.api_key_id, h.before, h.after, h.changed_at
+		FROM experience_history h
+		JOIN experience_data e ON e.id = h.experience_id
+		WHERE ` + userIdentifierCondition(1) + `
+		ORDER BY h.changed_at, h.id
+	`
+
+	historyRows, err := tx.Query(ctx, historyQuery, subject...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export history: %w", err)
+	}
+	defer historyRows.Close()
+
+	for historyRows.Next() {
+		var entry models.ExperienceHistoryEntry
+		err := historyRows.Scan(
+			&entry.ID, &entry.ExperienceID, &entry.Action, &entry.APIKeyID,
+			&entry.Before, &entry.After, &entry.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history: %w", err)
+		}
+		export.History = append(export.History, entry)
+	}
+
+	if err := historyRows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating history: %w", err)
+	}
+
+	return export, nil
+}
+
+// Erase deletes or anonymizes (see anonymizeAssignments) every record of a data
+// subject, including records in the trash, deletes their history and the stored
+// idempotent responses returning them, and stores the receipt, all in one transaction
+// match lists the stored forms of userIdentifier, see userIdentifierArgs.
+func (r *DataSubjectRepository) Erase(ctx context.Context, receipt *models.ErasureReceipt, userIdentifier string, match *models.UserIdentifierMatch) error {
+	subject := userIdentifierArgs(userIdentifier, match)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// History snapshots hold copies of the records
+	result, err := tx.Exec(ctx, `
+		DELETE FROM experience_history
+		WHERE experience_id IN (SELECT id FROM experience_data WHERE `+userIdentifierCondition(1)+`)`,
+		subject...)
+	if err != nil {
+		return fmt.Errorf("failed to erase history: %w", err)
+	}
+	receipt.HistoryEntriesDeleted = result.RowsAffected()
+
+	// So do idempotent responses
+	_, err = tx.Exec(ctx, forgetResponses(`SELECT id FROM experience_data WHERE `+userIdentifierCondition(1)), subject...)
+	if err != nil {
+		return fmt.Errorf("failed to erase idempotent responses: %w", err)
+	}
+
+	if receipt.Mode == models.ErasureModeAnonymize {
+		result, err = tx.Exec(ctx, `
+			UPDATE experience_data
+			SET `+anonymizeAssignments(3)+`, updated_at = $4
+			WHERE `+userIdentifierCondition(1),
+			subject[0], subject[1], freeTextFieldTypes, time.Now())
+	} else {
+		result, err = tx.Exec(ctx, `DELETE FROM experience_data WHERE `+userIdentifierCondition(1), subject...)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to erase experiences: %w", err)
+	}
+	receipt.ExperiencesAffected = result.RowsAffected()
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO data_subject_erasures (
+			user_identifier_hash, mode, experiences_affected, history_entries_deleted, api_key_id
+		)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, erased_at`,
+		receipt.UserIdentifierHash, receipt.Mode, receipt.ExperiencesAffected,
+		receipt.HistoryEntriesDeleted, receipt.APIKeyID,
+	).Scan(&receipt.ID, &receipt.ErasedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store erasure receipt: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetErasureReceipt retrieves the receipt of a data subject erasure
+func (r *DataSubjectRepository) GetErasureReceipt(ctx context.Context, id uuid.UUID) (*models.ErasureReceipt, error) {
+	query := `
+		SELECT id, user_identifier_hash, mode, experiences_affected, history_entries_deleted, api_key_id, erased_at
+		FROM data_subject_erasures
+		WHERE id = $1
+	`
+
+	var receipt models.ErasureReceipt
+	err := r.db.QueryRow(ctx, query, id).Scan(
+		&receipt.ID, &receipt.UserIdentifierHash, &receipt.Mode, &receipt.ExperiencesAffected,
+		&receipt.HistoryEntriesDeleted, &receipt.APIKeyID, &receipt.ErasedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("erasure receipt %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get erasure receipt: %w", err)
+	}
+
+	return &receipt, nil
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// CountMatching counts the live records matching filters, only those with an
+// ID greater than after when it is set
+func (r *ExperienceRepository) CountMatching(ctx context.Context, filters *models.ExperienceFilters, after *uuid.UUID) (int, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	filter := buildExperienceFilter(filters)
+	if err := filter.apply(ctx, tx); err != nil {
+		return 0, err
+	}
+	if after != nil {
+		filter.and(fmt.Sprintf("experience_data.id > $%d", filter.nextArg()))
+		filter.args = append(filter.args, *after)
+	}
+
+	var count int
+	query := filter.with + " SELECT COUNT(*)" + filter.from + filter.where
+	if err := tx.QueryRow(ctx, query, filter.args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count experiences: %w", err)
+	}
+
+	return count, nil
+}
+
+// lockMatching retrieves up to limit live records matching filters for update
+// within tx, in ID order starting after the record with ID after when it is set
+func lockMatching(ctx context.Context, tx pgx.Tx, filters *models.ExperienceFilters, after *uuid.UUID, limit int) ([]*models.ExperienceData, error) {
+	filter := buildExperienceFilter(filters)
+	if err := filter.apply(ctx, tx); err != nil {
+		return nil, err
+	}
+	if after != nil {
+		filter.and(fmt.Sprintf("experience_data.id > $%d", filter.nextArg()))
+		filter.args = append(filter.args, *after)
+	}
+
+	query := filter.with + " SELECT " + experienceColumns + filter.from + filter.where +
+		fmt.Sprintf(" ORDER BY experience_data.id LIMIT $%d FOR UPDATE OF experience_data", filter.nextArg())
+
+	rows, err := tx.Query(ctx, query, append(filter.args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select experiences: %w", err)
+	}
+	defer rows.Close()
+
+	batch := []*models.ExperienceData{}
+	for rows.Next() {
+		exp, err := scanExperience(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan experience: %w", err)
+		}
+		batch = append(batch, exp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating experiences: %w", err)
+	}
+
+	return batch, nil
+}
+
+// bulkPatchUpdates returns the SET clauses and parameters applying patch, with
+// parameters numbered from argCount
+func bulkPatchUpdates(patch *models.BulkExperiencePatch, argCount int) ([]string, []interface{}) {
+	var updates []string
+	var args []interface{}
+
+	columns := []struct {
+		name  string
+		value *string
+	}{
+		{"source_type", patch.SourceType},
+		{"source_id", patch.SourceID},
+		{"source_name", patch.SourceName},
+		{"field_id", patch.FieldID},
+		{"field_label", patch.FieldLabel},
+		{"language", patch.Language},
+	}
+	for _, column := range columns {
+		if column.value == nil {
+			continue
+		}
+		updates = append(updates, fmt.Sprintf("%s = $%d", column.name, argCount))
+		args = append(args, *column.value)
+		argCount++
+	}
+
+	if patch.Metadata != nil {
+		updates = append(updates, fmt.Sprintf("metadata = jsonb_merge_patch(metadata, $%d)", argCount))
+		args = append(args, patch.Metadata)
+	}
+
+	return updates, args
+}
+
+// BulkUpdateBatch applies patch to up to limit records matching filters, in ID
+// order after the record with ID after, and records the changes in their
+// history. It returns how many records were updated and the ID of the last one.
+// prepare is called for every record before it is updated and returns its new
+// natural key, nil to keep the current one, or an error rolling back the batch.
+func (r *ExperienceRepository) BulkUpdateBatch(
+	ctx context.Context,
+	filters *models.ExperienceFilters,
+	patch *models.BulkExperiencePatch,
+	after *uuid.UUID,
+	limit int,
+	prepare func(exp *models.ExperienceData) (*string, error),
+) (int, *uuid.UUID, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := lockMatching(ctx, tx, filters, after, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(batch) == 0 {
+		return 0, nil, nil
+	}
+
+	updates, patchArgs := bulkPatchUpdates(patch, 1)
+	argCount := len(patchArgs) + 1
+	query := fmt.Sprintf(`
+		UPDATE experience_data
+		SET %s, natural_key = COALESCE($%d, natural_key), version = version + 1, updated_at = $%d
+		WHERE id = $%d
+		RETURNING %s
+	`, strings.Join(updates, ", "), argCount, argCount+1, argCount+2, experienceColumns)
+
+	now := time.Now()
+	for _, before := range batch {
+		naturalKey, err := prepare(before)
+		if err != nil {
+			return 0, nil, err
+		}
+
+		args := append(append([]interface{}{}, patchArgs...), naturalKey, now, before.ID)
+		updated, err := scanExperience(tx.QueryRow(ctx, query, args...))
+		if err != nil {
+			if isPgError(err, pgUniqueViolation) {
+				return 0, nil, fmt.Errorf("experience %s would have the natural key of another %w", before.ID, models.ErrConflict)
+			}
+			return 0, nil, fmt.Errorf("failed to update experience: %w", err)
+		}
+
+		if err := recordHistory(ctx, tx, models.HistoryActionUpdate, before, updated); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	last := batch[len(batch)-1].ID
+	return len(batch), &last, nil
+}
+
+// BulkDeleteBatch moves up to limit records matching filters to the trash, in
+// ID order after the record with ID after, and records the deletions in their
+// history. It returns how many records were deleted and the ID of the last one.
+func (r *ExperienceRepository) BulkDeleteBatch(ctx context.Context, filters *models.ExperienceFilters, after *uuid.UUID, limit int) (int, *uuid.UUID, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	batch, err := lockMatching(ctx, tx, filters, after, limit)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(batch) == 0 {
+		return 0, nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(batch))
+	for _, exp := range batch {
+		ids = append(ids, exp.ID)
+	}
+
+	now := time.Now()
+	query := `UPDATE experience_data SET deleted_at = $1, updated_at = $1, version = version + 1 WHERE id = ANY($2) RETURNING ` + experienceColumns
+
+	rows, err := tx.Query(ctx, query, now, ids)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to delete experiences: %w", err)
+	}
+
+	deleted := make(map[uuid.UUID]*models.ExperienceData, len(batch))
+	for rows.Next() {
+		exp, err := scanExperience(rows)
+		if err != nil {
+			rows.Close()
+			return 0, nil, fmt.Errorf("failed to scan experience: %w", err)
+		}
+		deleted[exp.ID] = exp
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, fmt.Errorf("error iterating deleted experiences: %w", err)
+	}
+
+	for _, before := range batch {
+		if err := recordHistory(ctx, tx, models.HistoryActionDelete, before, deleted[before.ID]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return len(batch), &ids[len(ids)-1], nil
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// headlineOptions configures the ts_headline snippets returned by Search
+const headlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" ... \""
+
+// experienceFilter holds the SQL fragments that restrict experience_data to the
+// rows matched by a models.ExperienceFilters
+//
+// Queries are assembled as with + SELECT ... + from + where, using args as
+// parameters. Further parameters are numbered from nextArg().
+//
+// In full-text mode the query is parsed once for every text search configuration
+// and each row is matched against the parse for its own language, so stemming
+// follows the row's language while the GIN index on search_vector stays usable.
+//
+// In fuzzy mode value_text and field_label are matched by trigram word similarity
+// above MinSimilarity, which requires apply() to run first in the same transaction.
+type experienceFilter struct {
+	with  string
+	from  string
+	where string
+	args  []interface{}
+
+	// scoreColumns selects rank, highlight and similarity for the current mode
+	scoreColumns string
+	// orderBy sorts the best matches first
+	orderBy string
+
+	minSimilarity *float64
+	// hasQuery is set when the filter searches value_text
+	hasQuery bool
+}
+
+// buildExperienceFilter translates filters into SQL fragments
+func buildExperienceFilter(filters *models.ExperienceFilters) *experienceFilter {
+	f := &experienceFilter{
+		from: " FROM experience_data",
+		scoreColumns: `,
+			NULL::float8 AS rank,
+			NULL::text AS highlight,
+			NULL::float8 AS similarity`,
+		orderBy: " ORDER BY collected_at DESC",
+	}
+
+	// Deleted records stay in the trash, hidden from queries
+	conditions := []string{"deleted_at IS NULL"}
+	argCount := 1
+
+	hasQuery := filters.Query != nil && strings.TrimSpace(*filters.Query) != ""
+	switch {
+	case hasQuery && filters.Mode == models.SearchModeFuzzy:
+		minSimilarity := filters.MinSimilarity
+		if minSimilarity <= 0 {
+			minSimilarity = models.DefaultMinSimilarity
+		}
+		f.minSimilarity = &minSimilarity
+
+		conditions = append(conditions, fmt.Sprintf("($%d <%% value_text OR $%d <%% field_label)", argCount, argCount))
+		f.scoreColumns = fmt.Sprintf(`,
+			NULL::float8 AS rank,
+			NULL::text AS highlight,
+			GREATEST(word_similarity($%d, coalesce(value_text, '')), word_similarity($%d, coalesce(field_label, '')))::float8 AS similarity`,
+			argCount, argCount)
+		f.orderBy = " ORDER BY similarity DESC, collected_at DESC"
+		f.args = append(f.args, *filters.Query)
+		f.hasQuery = true
+		argCount++
+
+	case hasQuery:
+		tsQuery := buildTSQuery(*filters.Query)
+		if tsQuery == "" {
+			break
+		}
+
+		f.with = fmt.Sprintf(`
+		WITH search_query AS (
+			SELECT cfg, to_tsquery(cfg, $%d) AS tsq
+			FROM unnest(experience_ts_configs()) AS cfg
+		)`, argCount)
+		f.from = `
+		FROM experience_data
+		JOIN search_query ON search_query.cfg = experience_data.search_config
+			AND experience_data.search_vector @@ search_query.tsq`
+		f.scoreColumns = fmt.Sprintf(`,
+			ts_rank(search_vector, search_query.tsq)::float8 AS rank,
+			ts_headline(search_config, value_text, search_query.tsq, '%s') AS highlight,
+			NULL::float8 AS similarity`, headlineOptions)
+		f.orderBy = " ORDER BY rank DESC, collected_at DESC"
+		f.args = append(f.args, tsQuery)
+		f.hasQuery = true
+		argCount++
+	}
+
+	// Filter by source_type
+	if filters.SourceType != nil {
+		conditions = append(conditions, fmt.Sprintf("source_type = $%d", argCount))
+		f.args = append(f.args, *filters.SourceType)
+		argCount++
+	}
+
+	// Filter by source_id
+	if filters.SourceID != nil {
+		conditions = append(conditions, fmt.Sprintf("source_id = $%d", argCount))
+		f.args = append(f.args, *filters.SourceID)
+		argCount++
+	}
+
+	// Filter by field_id
+	if filters.FieldID != nil {
+		conditions = append(conditions, fmt.Sprintf("field_id = $%d", argCount))
+		f.args = append(f.args, *filters.FieldID)
+		argCount++
+	}
+
+	// Filter by field_type
+	if filters.FieldType != nil {
+		conditions = append(conditions, fmt.Sprintf("field_type = $%d", argCount))
+		f.args = append(f.args, *filters.FieldType)
+		argCount++
+	}
+
+	// Filter by user_identifier, matching encrypted records by their blind index
+	if filters.UserIdentifier != nil {
+		conditions = append(conditions, userIdentifierCondition(argCount))
+		f.args = append(f.args, userIdentifierArgs(*filters.UserIdentifier, filters.UserIdentifierMatch)...)
+		argCount += 2
+	}
+
+	// Filter by response_id
+	if filters.ResponseID != nil {
+		conditions = append(conditions, fmt.Sprintf("response_id = $%d", argCount))
+		f.args = append(f.args, *filters.ResponseID)
+		argCount++
+	}
+
+	// Filter by a type of detected personal data, using the GIN index on pii_types
+	if filters.PIIType != nil {
+		conditions = append(conditions, fmt.Sprintf("pii_types @> ARRAY[$%d]::text[]", argCount))
+		f.args = append(f.args, *filters.PIIType)
+		argCount++
+	}
+
+	// Filter by date range
+	if filters.StartDate != nil {
+		conditions = append(conditions, fmt.Sprintf("collected_at >= $%d", argCount))
+		f.args = append(f.args, *filters.StartDate)
+		argCount++
+	}
+
+	if filters.EndDate != nil {
+		conditions = append(conditions, fmt.Sprintf("collected_at <= $%d", argCount))
+		f.args = append(f.args, *filters.EndDate)
+	}
+
+	f.where = " WHERE " + strings.Join(conditions, " AND ")
+
+	return f
+}
+
+// nextArg returns the number of the next free query parameter
+func (f *experienceFilter) nextArg() int {
+	return len(f.args) + 1
+}
+
+// and adds a condition to the WHERE clause
+func (f *experienceFilter) and(condition string) {
+	if f.where == "" {
+		f.where = " WHERE " + condition
+	} else {
+		f.where += " AND " + condition
+	}
+}
+
+// withCTE returns the WITH clause extended by an additional named query
+func (f *experienceFilter) withCTE(name, query string) string {
+	if f.with == "" {
+		return " WITH " + name + " AS (" + query + ")"
+	}
+	return f.with + ", " + name + " AS (" + query + ")"
+}
+
+// apply prepares tx for queries using the filter
+// The fuzzy threshold is set per transaction so the <% operator, and with it the
+// trigram indexes, filter by the requested similarity
+func (f *experienceFilter) apply(ctx context.Context, tx pgx.Tx) error {
+	if f.minSimilarity == nil {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, "SELECT set_config('pg_trgm.word_similarity_threshold', $1, true)",
+		strconv.FormatFloat(*f.minSimilarity, 'f', -1, 64))
+	if err != nil {
+		return fmt.Errorf("failed to set similarity threshold: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// recordHistory records a change of an experience data record within the
+// transaction making the change, attributed to the API key authenticating the request
+// before is nil for created records
+func recordHistory(ctx context.Context, db DBPool, action string, before, after *models.ExperienceData) error {
+	var apiKeyID *uuid.UUID
+	if key := models.APIKeyFromContext(ctx); key != nil {
+		apiKeyID = &key.ID
+	}
+
+	var beforeJSON, afterJSON json.RawMessage
+	var err error
+	if before != nil {
+		if beforeJSON, err = json.Marshal(before); err != nil {
+			return fmt.Errorf("failed to encode history: %w", err)
+		}
+	}
+	if afterJSON, err = json.Marshal(after); err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	query := `
+		INSERT INTO experience_history (experience_id, action, api_key_id, before, after)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+
+	if _, err := db.Exec(ctx, query, after.ID, action, apiKeyID, beforeJSON, afterJSON); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+
+	return nil
+}
+
+// ListHistory retrieves the changes of an experience data record, oldest first
+// Records in the trash keep their history until they are purged
+func (r *ExperienceRepository) ListHistory(ctx context.Context, id uuid.UUID) ([]models.ExperienceHistoryEntry, error) {
+	query := `
+		SELECT id, experience_id, action, api_key_id, before, after, changed_at
+		FROM experience_history
+		WHERE experience_id = $1
+		ORDER BY changed_at, id
+	`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.ExperienceHistoryEntry{}
+	for rows.Next() {
+		var entry models.ExperienceHistoryEntry
+		err := rows.Scan(
+			&entry.ID, &entry.ExperienceID, &entry.Action, &entry.APIKeyID,
+			&entry.Before, &entry.After, &entry.ChangedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan history: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating history: %w", err)
+	}
+
+	// Records created before history was recorded have none
+	if len(entries) == 0 {
+		var exists bool
+		err := r.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM experience_data WHERE id = $1)", id).Scan(&exists)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get experience: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("experience %w", models.ErrNotFound)
+		}
+	}
+
+	return entries, nil
+}
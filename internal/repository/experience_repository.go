@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
 )
 
 // ExperienceRepository handles data access for experience data
@@ -22,95 +24,394 @@ func NewExperienceRepository(db *pgxpool.Pool) *ExperienceRepository {
 	return &ExperienceRepository{db: db}
 }
 
-// Create inserts a new experience data record
-func (r *ExperienceRepository) Create(ctx context.Context, req *models.CreateExperienceRequest) (*models.ExperienceData, error) {
-	collectedAt := time.Now()
-	if req.CollectedAt != nil {
-		collectedAt = *req.CollectedAt
-	}
-
-	query := `
-		INSERT INTO experience_data (
-			collected_at, source_type, source_id, source_name,
-			field_id, field_label, field_type,
-			value_text, value_number, value_boolean, value_date, value_json,
-			metadata, language, user_identifier
-		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
-		RETURNING id, collected_at, created_at, updated_at,
+// experienceColumns lists the experience_data columns read by scanExperience
+const experienceColumns = `id, collected_at, created_at, updated_at,
 			source_type, source_id, source_name,
 			field_id, field_label, field_type,
 			value_text, value_number, value_boolean, value_date, value_json,
-			metadata, language, user_identifier
-	`
+			metadata, language, user_identifier, response_id, pii_types, deleted_at,
+			encrypted_values, encrypted_data_key, key_version, user_identifier_index, natural_key_fields, version`
+
+// sparseColumns lists the columns of experienceColumns that can hold large
+// values, which are read as NULL unless a sparse fieldset selects one of fields
+var sparseColumns = []struct {
+	column string
+	null   string
+	fields []string
+}{
+	{"value_text", "NULL::text AS value_text", []string{"value_text"}},
+	{"value_json", "NULL::jsonb AS value_json", []string{"value_json"}},
+	{"metadata", "NULL::jsonb AS metadata", []string{"metadata"}},
+	// Records read without their encrypted values are not opened
+	{"encrypted_values", "NULL::bytea AS encrypted_values", []string{"value_text", "value_json", "user_identifier", "encrypted"}},
+}
+
+// selectColumns returns experienceColumns for a sparse fieldset, with the
+// sparseColumns none of fields selects read as NULL. All columns are read when
+// fields is empty.
+func selectColumns(fields []string) string {
+	columns := experienceColumns
+	if len(fields) == 0 {
+		return columns
+	}
 
+	for _, sparse := range sparseColumns {
+		if !slices.ContainsFunc(sparse.fields, func(field string) bool { return slices.Contains(fields, field) }) {
+			columns = strings.Replace(columns, sparse.column+",", sparse.null+",", 1)
+		}
+	}
+	return columns
+}
+
+// scanExperience scans a row selected with experienceColumns, followed by
+// columns scanned into extra
+func scanExperience(row pgx.Row, extra ...interface{}) (*models.ExperienceData, error) {
 	var exp models.ExperienceData
-	err := r.db.QueryRow(ctx, query,
-		collectedAt, req.SourceType, req.SourceID, req.SourceName,
-		req.FieldID, req.FieldLabel, req.FieldType,
-		req.ValueText, req.ValueNumber, req.ValueBoolean, req.ValueDate, req.ValueJSON,
-		req.Metadata, req.Language, req.UserIdentifier,
-	).Scan(
+	var sealed models.SealedValues
+	var keyVersion *int
+	dest := []interface{}{
 		&exp.ID, &exp.CollectedAt, &exp.CreatedAt, &exp.UpdatedAt,
 		&exp.SourceType, &exp.SourceID, &exp.SourceName,
 		&exp.FieldID, &exp.FieldLabel, &exp.FieldType,
 		&exp.ValueText, &exp.ValueNumber, &exp.ValueBoolean, &exp.ValueDate, &exp.ValueJSON,
-		&exp.Metadata, &exp.Language, &exp.UserIdentifier,
-	)
+		&exp.Metadata, &exp.Language, &exp.UserIdentifier, &exp.ResponseID, &exp.PIITypes, &exp.DeletedAt,
+		&sealed.Ciphertext, &sealed.DataKey, &keyVersion, &sealed.UserIdentifierIndex, &exp.NaturalKey, &exp.Version,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+
+	if sealed.Ciphertext != nil && keyVersion != nil {
+		sealed.KeyVersion = *keyVersion
+		exp.Sealed = &sealed
+		exp.Encrypted = true
+	}
+	return &exp, nil
+}
 
+// sealedArgs returns the encrypted_values, encrypted_data_key, key_version and
+// user_identifier_index of sealed, all nil when sealed is nil
+func sealedArgs(sealed *models.SealedValues) []interface{} {
+	if sealed == nil {
+		return []interface{}{nil, nil, nil, nil}
+	}
+	return []interface{}{sealed.Ciphertext, sealed.DataKey, sealed.KeyVersion, sealed.UserIdentifierIndex}
+}
+
+// userIdentifierCondition matches records by user_identifier, or by the blind
+// index of their encrypted user_identifier. Parameters $arg and $arg+1 must hold
+// userIdentifierArgs.
+func userIdentifierCondition(arg int) string {
+	return fmt.Sprintf("(user_identifier = ANY($%d) OR user_identifier_index = ANY($%d))", arg, arg+1)
+}
+
+// userIdentifierArgs returns the parameters of userIdentifierCondition: the
+// values and indexes of match, or userIdentifier alone when match is nil
+func userIdentifierArgs(userIdentifier string, match *models.UserIdentifierMatch) []interface{} {
+	if match == nil {
+		return []interface{}{[]string{userIdentifier}, [][]byte{}}
+	}
+	indexes := match.Indexes
+	if indexes == nil {
+		indexes = [][]byte{}
+	}
+	return []interface{}{match.Values, indexes}
+}
+
+// experienceInsertColumns lists the experience_data columns written by Create
+// and Upsert, in the order of the arguments returned by insertArgs
+const experienceInsertColumns = `id, collected_at, source_type, source_id, source_name,
+			field_id, field_label, field_type,
+			value_text, value_number, value_boolean, value_date, value_json,
+			metadata, language, user_identifier, response_id,
+			pii_types, value_text_vault,
+			encrypted_values, encrypted_data_key, key_version, user_identifier_index,
+			natural_key, natural_key_fields`
+
+// experienceInsertValues holds the placeholders of experienceInsertColumns
+const experienceInsertValues = `$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+			$14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25`
+
+// insertArgs returns the arguments of experienceInsertColumns for req, which
+// is collected now unless it sets collected_at. req.ID must be set, since
+// sealed values are bound to the ID of their record.
+func insertArgs(req *models.CreateExperienceRequest, naturalKeyFields []string) []interface{} {
+	collectedAt := time.Now()
+	if req.CollectedAt != nil {
+		collectedAt = *req.CollectedAt
+	}
+
+	args := []interface{}{
+		req.ID, collectedAt, req.SourceType, req.SourceID, req.SourceName,
+		req.FieldID, req.FieldLabel, req.FieldType,
+		req.ValueText, req.ValueNumber, req.ValueBoolean, req.ValueDate, req.ValueJSON,
+		req.Metadata, req.Language, req.UserIdentifier, req.ResponseID,
+		req.PIITypes, req.ValueTextVault,
+	}
+	args = append(args, sealedArgs(req.Sealed)...)
+	return append(args, req.NaturalKey, naturalKeyFields)
+}
+
+// Create inserts a new experience data record
+func (r *ExperienceRepository) Create(ctx context.Context, req *models.CreateExperienceRequest) (*models.ExperienceData, error) {
+	query := `
+		INSERT INTO experience_data (` + experienceInsertColumns + `)
+		VALUES (` + experienceInsertValues + `)
+		RETURNING ` + experienceColumns
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	exp, err := scanExperience(tx.QueryRow(ctx, query, insertArgs(req, nil)...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create experience: %w", err)
 	}
 
-	return &exp, nil
+	if err := recordHistory(ctx, tx, models.HistoryActionCreate, nil, exp); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return exp, nil
+}
+
+// Upsert creates the records of reqs in one transaction, replacing the live
+// record with the same natural key instead where there is one. naturalKeyFields
+// is stored with the records, whose NaturalKey must be set.
+// A replaced record keeps its ID, which must be the ID of req since sealed
+// values are bound to it (see LiveIDsByNaturalKey). Upsert fails with
+// ErrConflict when the record with the natural key has changed since.
+// It returns the records in the order of reqs and whether each was created or updated.
+func (r *ExperienceRepository) Upsert(ctx context.Context, naturalKeyFields []string, reqs []models.CreateExperienceRequest) ([]models.UpsertExperienceResult, error) {
+	query := `
+		INSERT INTO experience_data (` + experienceInsertColumns + `)
+		VALUES (` + experienceInsertValues + `)
+		ON CONFLICT (natural_key) WHERE natural_key IS NOT NULL AND deleted_at IS NULL DO UPDATE SET
+			collected_at = EXCLUDED.collected_at,
+			source_type = EXCLUDED.source_type,
+			source_id = EXCLUDED.source_id,
+			source_name = EXCLUDED.source_name,
+			field_id = EXCLUDED.field_id,
+			field_label = EXCLUDED.field_label,
+			field_type = EXCLUDED.field_type,
+			value_text = EXCLUDED.value_text,
+			value_number = EXCLUDED.value_number,
+			value_boolean = EXCLUDED.value_boolean,
+			value_date = EXCLUDED.value_date,
+			value_json = EXCLUDED.value_json,
+			metadata = EXCLUDED.metadata,
+			language = EXCLUDED.language,
+			user_identifier = EXCLUDED.user_identifier,
+			response_id = EXCLUDED.response_id,
+			pii_types = EXCLUDED.pii_types,
+			value_text_vault = EXCLUDED.value_text_vault,
+			encrypted_values = EXCLUDED.encrypted_values,
+			encrypted_data_key = EXCLUDED.encrypted_data_key,
+			key_version = EXCLUDED.key_version,
+			user_identifier_index = EXCLUDED.user_identifier_index,
+			natural_key_fields = EXCLUDED.natural_key_fields,
+			updated_at = NOW(),
+			version = experience_data.version + 1
+		RETURNING ` + experienceColumns + `, (xmax = 0)`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Concurrent upserts of the same natural keys are serialized, so the record
+	// read before replacing it is the one replaced. The locks are taken in
+	// sorted order to avoid deadlocks between overlapping batches.
+	keys := make([]string, 0, len(reqs))
+	for i := range reqs {
+		keys = append(keys, *reqs[i].NaturalKey)
+	}
+	slices.Sort(keys)
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtextextended(key, 0)) FROM unnest($1::text[]) AS key`, slices.Compact(keys)); err != nil {
+		return nil, fmt.Errorf("failed to lock natural keys: %w", err)
+	}
+
+	results := make([]models.UpsertExperienceResult, 0, len(reqs))
+	for i := range reqs {
+		req := &reqs[i]
+
+		before, err := scanExperience(tx.QueryRow(ctx,
+			`SELECT `+experienceColumns+` FROM experience_data WHERE natural_key = $1 AND deleted_at IS NULL FOR UPDATE`, *req.NaturalKey))
+		if err != nil && err != pgx.ErrNoRows {
+			return nil, fmt.Errorf("failed to get experience: %w", err)
+		}
+
+		var created bool
+		after, err := scanExperience(tx.QueryRow(ctx, query, insertArgs(req, naturalKeyFields)...), &created)
+		// The record with the natural key changed since its ID was looked up: it was
+		// replaced by another one, or deleted while its ID stays taken in the trash
+		if isPgError(err, pgUniqueViolation) || (err == nil && after.ID != req.ID) {
+			return nil, fmt.Errorf("experience with natural key %s was replaced concurrently: %w", *req.NaturalKey, models.ErrConflict)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to upsert experience: %w", err)
+		}
+
+		action, result := models.HistoryActionUpdate, models.UpsertResultUpdated
+		if created {
+			action, result, before = models.HistoryActionCreate, models.UpsertResultCreated, nil
+		}
+		if err := recordHistory(ctx, tx, action, before, after); err != nil {
+			return nil, err
+		}
+
+		results = append(results, models.UpsertExperienceResult{ExperienceData: *after, Result: result})
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return results, nil
+}
+
+// LiveIDsByNaturalKey returns the IDs of the live records with the given
+// natural keys, by natural key
+func (r *ExperienceRepository) LiveIDsByNaturalKey(ctx context.Context, naturalKeys []string) (map[string]uuid.UUID, error) {
+	query := `SELECT natural_key, id FROM experience_data WHERE natural_key = ANY($1) AND deleted_at IS NULL`
+
+	rows, err := r.db.Query(ctx, query, naturalKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiences by natural key: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]uuid.UUID, len(naturalKeys))
+	for rows.Next() {
+		var naturalKey string
+		var id uuid.UUID
+		if err := rows.Scan(&naturalKey, &id); err != nil {
+			return nil, fmt.Errorf("failed to scan experience: %w", err)
+		}
+		ids[naturalKey] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating experiences: %w", err)
+	}
+
+	return ids, nil
 }
 
 // GetByID retrieves a single experience data record by ID
 func (r *ExperienceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.ExperienceData, error) {
-	query := `
-		SELECT id, collected_at, created_at, updated_at,
-			source_type, source_id, source_name,
-			field_id, field_label, field_type,
-			value_text, value_number, value_boolean, value_date, value_json,
-			metadata, language, user_identifier
-		FROM experience_data
-		WHERE id = $1
-	`
+	query := `SELECT ` + experienceColumns + ` FROM experience_data WHERE id = $1 AND deleted_at IS NULL`
 
-	var exp models.ExperienceData
-	err := r.db.QueryRow(ctx, query, id).Scan(
-		&exp.ID, &exp.CollectedAt, &exp.CreatedAt, &exp.UpdatedAt,
-		&exp.SourceType, &exp.SourceID, &exp.SourceName,
-		&exp.FieldID, &exp.FieldLabel, &exp.FieldType,
-		&exp.ValueText, &exp.ValueNumber, &exp.ValueBoolean, &exp.ValueDate, &exp.ValueJSON,
-		&exp.Metadata, &exp.Language, &exp.UserIdentifier,
-	)
+	exp, err := scanExperience(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("experience %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get experience: %w", err)
+	}
+
+	return exp, nil
+}
+
+// GetVault retrieves the sealed original value_text of a masked record
+// It returns nil when the record has no vaulted value.
+func (r *ExperienceRepository) GetVault(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	query := `SELECT value_text_vault FROM experience_data WHERE id = $1 AND deleted_at IS NULL`
+
+	var sealed []byte
+	if err := r.db.QueryRow(ctx, query, id).Scan(&sealed); err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("experience %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get vaulted value: %w", err)
+	}
+
+	return sealed, nil
+}
+
+// GetByIDs retrieves the live records with the given IDs in a single query,
+// reading the columns of the sparse fieldset fields. Missing IDs are skipped.
+func (r *ExperienceRepository) GetByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.ExperienceData, error) {
+	query := `SELECT ` + selectColumns(fields) + ` FROM experience_data WHERE id = ANY($1) AND deleted_at IS NULL`
+
+	rows, err := r.db.Query(ctx, query, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get experiences: %w", err)
+	}
+	defer rows.Close()
+
+	experiences := []models.ExperienceData{}
+	for rows.Next() {
+		exp, err := scanExperience(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan experience: %w", err)
+		}
+		experiences = append(experiences, *exp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating experiences: %w", err)
+	}
+
+	return experiences, nil
+}
+
+// lockExperience retrieves an experience data record for update within tx
+// deleted selects a record in the trash instead of a live one
+func lockExperience(ctx context.Context, tx pgx.Tx, id uuid.UUID, deleted bool) (*models.ExperienceData, error) {
+	condition := "deleted_at IS NULL"
+	if deleted {
+		condition = "deleted_at IS NOT NULL"
+	}
+
+	query := `SELECT ` + experienceColumns + ` FROM experience_data WHERE id = $1 AND ` + condition + ` FOR UPDATE`
 
+	exp, err := scanExperience(tx.QueryRow(ctx, query, id))
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("experience not found")
+			if deleted {
+				return nil, fmt.Errorf("deleted experience %w", models.ErrNotFound)
+			}
+			return nil, fmt.Errorf("experience %w", models.ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to get experience: %w", err)
 	}
 
-	return &exp, nil
+	return exp, nil
 }
 
-// List retrieves experience data records with optional filters
-func (r *ExperienceRepository) List(ctx context.Context, filters *models.ListExperiencesFilters) ([]models.ExperienceData, error) {
-	query := `
-		SELECT id, collected_at, created_at, updated_at,
-			source_type, source_id, source_name,
-			field_id, field_label, field_type,
-			value_text, value_number, value_boolean, value_date, value_json,
-			metadata, language, user_identifier
-		FROM experience_data
-	`
+// checkVersion returns an error wrapping models.ErrPreconditionFailed unless
+// ifMatch is nil or holds the version of exp
+func checkVersion(exp *models.ExperienceData, ifMatch []int) error {
+	if ifMatch == nil || slices.Contains(ifMatch, exp.Version) {
+		return nil
+	}
+	return fmt.Errorf("experience is at version %d, which does not match If-Match: %w", exp.Version, models.ErrPreconditionFailed)
+}
+
+// listQuery builds the query of List selecting columns, with its arguments
+func listQuery(columns string, filters *models.ListExperiencesFilters) (string, []interface{}) {
+	query := `SELECT ` + columns + ` FROM experience_data`
 
 	var conditions []string
 	var args []interface{}
 	argCount := 1
 
+	// The trash holds soft-deleted records, most recently deleted first
+	orderBy := " ORDER BY collected_at DESC"
+	if filters.Deleted {
+		conditions = append(conditions, "deleted_at IS NOT NULL")
+		orderBy = " ORDER BY deleted_at DESC, collected_at DESC"
+	} else {
+		conditions = append(conditions, "deleted_at IS NULL")
+	}
+
 	if filters.SourceType != nil {
 		conditions = append(conditions, fmt.Sprintf("source_type = $%d", argCount))
 		args = append(args, *filters.SourceType)
@@ -130,16 +431,25 @@ func (r *ExperienceRepository) List(ctx context.Context, filters *models.ListExp
 	}
 
 	if filters.UserIdentifier != nil {
-		conditions = append(conditions, fmt.Sprintf("user_identifier = $%d", argCount))
-		args = append(args, *filters.UserIdentifier)
+		conditions = append(conditions, userIdentifierCondition(argCount))
+		args = append(args, userIdentifierArgs(*filters.UserIdentifier, filters.UserIdentifierMatch)...)
+		argCount += 2
+	}
+
+	if filters.ResponseID != nil {
+		conditions = append(conditions, fmt.Sprintf("response_id = $%d", argCount))
+		args = append(args, *filters.ResponseID)
 		argCount++
 	}
 
-	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+	if filters.PIIType != nil {
+		conditions = append(conditions, fmt.Sprintf("pii_types @> ARRAY[$%d]::text[]", argCount))
+		args = append(args, *filters.PIIType)
+		argCount++
 	}
 
-	query += " ORDER BY collected_at DESC"
+	query += " WHERE " + strings.Join(conditions, " AND ")
+	query += orderBy
 
 	if filters.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argCount)
@@ -152,6 +462,13 @@ func (r *ExperienceRepository) List(ctx context.Context, filters *models.ListExp
 		args = append(args, filters.Offset)
 	}
 
+	return query, args
+}
+
+// List retrieves experience data records with optional filters
+func (r *ExperienceRepository) List(ctx context.Context, filters *models.ListExperiencesFilters) ([]models.ExperienceData, error) {
+	query, args := listQuery(selectColumns(filters.Fields), filters)
+
 	rows, err := r.db.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list experiences: %w", err)
@@ -160,18 +477,11 @@ func (r *ExperienceRepository) List(ctx context.Context, filters *models.ListExp
 
 	var experiences []models.ExperienceData
 	for rows.Next() {
-		var exp models.ExperienceData
-		err := rows.Scan(
-			&exp.ID, &exp.CollectedAt, &exp.CreatedAt, &exp.UpdatedAt,
-			&exp.SourceType, &exp.SourceID, &exp.SourceName,
-			&exp.FieldID, &exp.FieldLabel, &exp.FieldType,
-			&exp.ValueText, &exp.ValueNumber, &exp.ValueBoolean, &exp.ValueDate, &exp.ValueJSON,
-			&exp.Metadata, &exp.Language, &exp.UserIdentifier,
-		)
+		exp, err := scanExperience(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan experience: %w", err)
 		}
-		experiences = append(experiences, exp)
+		experiences = append(experiences, *exp)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -181,6 +491,81 @@ func (r *ExperienceRepository) List(ctx context.Context, filters *models.ListExp
 	return experiences, nil
 }
 
+// streamMetadataKeys selects the sorted top-level metadata keys of the rows of
+// the streamed CTE. Metadata that is not an object has no keys.
+const streamMetadataKeys = ` SELECT DISTINCT key FROM streamed
+		CROSS JOIN LATERAL jsonb_object_keys(
+			CASE jsonb_typeof(streamed.metadata) WHEN 'object' THEN streamed.metadata ELSE '{}'::jsonb END
+		) AS key
+		ORDER BY key`
+
+// beginStream starts the read-only transaction of a streamed read, whose
+// queries all see the same snapshot
+func (r *ExperienceRepository) beginStream(ctx context.Context) (pgx.Tx, error) {
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin stream transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// streamKeys passes the metadata keys selected by query, a WITH clause defining
+// the streamed CTE followed by streamMetadataKeys, to keys
+func streamKeys(ctx context.Context, tx pgx.Tx, query string, args []interface{}, keys func([]string) error) error {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata keys: %w", err)
+	}
+	names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("failed to scan metadata keys: %w", err)
+	}
+	return keys(names)
+}
+
+// StreamList calls each with every record List would return, as it is read from
+// the database, without holding the records in memory. When keys is set, it is
+// called first with the sorted top-level metadata keys of the records, read from
+// the same snapshot. An error returned by keys or each stops the stream.
+func (r *ExperienceRepository) StreamList(ctx context.Context, filters *models.ListExperiencesFilters, keys func([]string) error, each func(*models.ExperienceData) error) error {
+	tx, err := r.beginStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	query, args := listQuery(selectColumns(filters.Fields), filters)
+
+	if keys != nil {
+		metadataQuery, _ := listQuery("metadata", filters)
+		if err := streamKeys(ctx, tx, " WITH streamed AS ("+metadataQuery+")"+streamMetadataKeys, args, keys); err != nil {
+			return err
+		}
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to list experiences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		exp, err := scanExperience(rows)
+		if err != nil {
+			return fmt.Errorf("failed to scan experience: %w", err)
+		}
+		if err := each(exp); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating experiences: %w", err)
+	}
+
+	return nil
+}
+
 // Update updates an existing experience data record
 func (r *ExperienceRepository) Update(ctx context.Context, id uuid.UUID, req *models.UpdateExperienceRequest) (*models.ExperienceData, error) {
 	var updates []string
@@ -223,7 +608,7 @@ func (r *ExperienceRepository) Update(ctx context.Context, id uuid.UUID, req *mo
 		argCount++
 	}
 
-	if req.ValueText != nil {
+	if req.ValueText != nil && req.Sealed == nil {
 		updates = append(updates, fmt.Sprintf("value_text = $%d", argCount))
 		args = append(args, *req.ValueText)
 		argCount++
@@ -247,13 +632,17 @@ func (r *ExperienceRepository) Update(ctx context.Context, id uuid.UUID, req *mo
 		argCount++
 	}
 
-	if req.ValueJSON != nil {
+	if req.ValueJSON != nil && req.Sealed == nil {
 		updates = append(updates, fmt.Sprintf("value_json = $%d", argCount))
 		args = append(args, req.ValueJSON)
 		argCount++
 	}
 
-	if req.Metadata != nil {
+	if req.Metadata != nil && req.MergeMetadata {
+		updates = append(updates, fmt.Sprintf("metadata = jsonb_merge_patch(metadata, $%d)", argCount))
+		args = append(args, req.Metadata)
+		argCount++
+	} else if req.Metadata != nil {
 		updates = append(updates, fmt.Sprintf("metadata = $%d", argCount))
 		args = append(args, req.Metadata)
 		argCount++
@@ -265,16 +654,65 @@ func (r *ExperienceRepository) Update(ctx context.Context, id uuid.UUID, req *mo
 		argCount++
 	}
 
-	if req.UserIdentifier != nil {
+	if req.UserIdentifier != nil && req.Sealed == nil {
 		updates = append(updates, fmt.Sprintf("user_identifier = $%d", argCount))
 		args = append(args, *req.UserIdentifier)
 		argCount++
 	}
 
+	if req.ResponseID != nil {
+		updates = append(updates, fmt.Sprintf("response_id = $%d", argCount))
+		args = append(args, *req.ResponseID)
+		argCount++
+	}
+
+	// Sealed values replace the plaintext columns they hold. user_identifier is
+	// only kept in the clear when it is not sealed, i.e. when it is a pseudonym.
+	if req.Sealed != nil {
+		updates = append(updates, fmt.Sprintf(
+			"value_text = NULL, value_json = NULL, user_identifier = $%d, encrypted_values = $%d, encrypted_data_key = $%d, key_version = $%d, user_identifier_index = $%d",
+			argCount, argCount+1, argCount+2, argCount+3, argCount+4))
+		args = append(args, req.UserIdentifier)
+		args = append(args, sealedArgs(req.Sealed)...)
+		argCount += 5
+	}
+
+	if req.PIIScanned {
+		updates = append(updates, fmt.Sprintf("pii_types = $%d, value_text_vault = $%d", argCount, argCount+1))
+		args = append(args, req.PIITypes, req.ValueTextVault)
+		argCount += 2
+	}
+
+	// Cleared columns are named by models.NullableExperienceFields, which match
+	// the column names. Sealed values already clear the plaintext columns they replace.
+	for _, field := range models.NullableExperienceFields {
+		if !req.Clears(field) {
+			continue
+		}
+		if req.Sealed != nil && (field == "value_text" || field == "value_json" || field == "user_identifier") {
+			continue
+		}
+		updates = append(updates, field+" = NULL")
+	}
+
+	if req.NaturalKey != nil {
+		updates = append(updates, fmt.Sprintf("natural_key = $%d", argCount))
+		args = append(args, *req.NaturalKey)
+		argCount++
+	}
+
 	if len(updates) == 0 {
-		return r.GetByID(ctx, id)
+		exp, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkVersion(exp, req.IfMatch); err != nil {
+			return nil, err
+		}
+		return exp, nil
 	}
 
+	updates = append(updates, "version = version + 1")
 	updates = append(updates, fmt.Sprintf("updated_at = $%d", argCount))
 	args = append(args, time.Now())
 	argCount++
@@ -285,177 +723,553 @@ func (r *ExperienceRepository) Update(ctx context.Context, id uuid.UUID, req *mo
 		UPDATE experience_data
 		SET %s
 		WHERE id = $%d
-		RETURNING id, collected_at, created_at, updated_at,
-			source_type, source_id, source_name,
-			field_id, field_label, field_type,
-			value_text, value_number, value_boolean, value_date, value_json,
-			metadata, language, user_identifier
-	`, strings.Join(updates, ", "), argCount)
+		RETURNING %s
+	`, strings.Join(updates, ", "), argCount, experienceColumns)
 
-	var exp models.ExperienceData
-	err := r.db.QueryRow(ctx, query, args...).Scan(
-		&exp.ID, &exp.CollectedAt, &exp.CreatedAt, &exp.UpdatedAt,
-		&exp.SourceType, &exp.SourceID, &exp.SourceName,
-		&exp.FieldID, &exp.FieldLabel, &exp.FieldType,
-		&exp.ValueText, &exp.ValueNumber, &exp.ValueBoolean, &exp.ValueDate, &exp.ValueJSON,
-		&exp.Metadata, &exp.Language, &exp.UserIdentifier,
-	)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
 
+	before, err := lockExperience(ctx, tx, id, false)
 	if err != nil {
-		if err == pgx.ErrNoRows {
-			return nil, fmt.Errorf("experience not found")
+		return nil, err
+	}
+	if err := checkVersion(before, req.IfMatch); err != nil {
+		return nil, err
+	}
+
+	after, err := scanExperience(tx.QueryRow(ctx, query, args...))
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return nil, fmt.Errorf("experience with the same natural key %w", models.ErrConflict)
 		}
 		return nil, fmt.Errorf("failed to update experience: %w", err)
 	}
 
-	return &exp, nil
+	if err := recordHistory(ctx, tx, models.HistoryActionUpdate, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return after, nil
 }
 
-// Delete removes an experience data record
-func (r *ExperienceRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	query := `DELETE FROM experience_data WHERE id = $1`
+// Delete moves an experience data record to the trash
+// ifMatch lists the versions the record must be at, nil deletes unconditionally.
+func (r *ExperienceRepository) Delete(ctx context.Context, id uuid.UUID, ifMatch []int) error {
+	_, err := r.setDeletedAt(ctx, id, models.HistoryActionDelete, ifMatch)
+	return err
+}
 
-	result, err := r.db.Exec(ctx, query, id)
+// Restore moves an experience data record out of the trash
+func (r *ExperienceRepository) Restore(ctx context.Context, id uuid.UUID) (*models.ExperienceData, error) {
+	return r.setDeletedAt(ctx, id, models.HistoryActionRestore, nil)
+}
+
+// setDeletedAt moves a record into the trash (delete) or out of it (restore)
+// and records the change in its history
+func (r *ExperienceRepository) setDeletedAt(ctx context.Context, id uuid.UUID, action string, ifMatch []int) (*models.ExperienceData, error) {
+	now := time.Now()
+	var deletedAt *time.Time
+	if action == models.HistoryActionDelete {
+		deletedAt = &now
+	}
+
+	tx, err := r.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to delete experience: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	if result.RowsAffected() == 0 {
-		return fmt.Errorf("experience not found")
+	before, err := lockExperience(ctx, tx, id, action == models.HistoryActionRestore)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(before, ifMatch); err != nil {
+		return nil, err
 	}
 
-	return nil
+	query := `UPDATE experience_data SET deleted_at = $1, updated_at = $2, version = version + 1 WHERE id = $3 RETURNING ` + experienceColumns
+
+	after, err := scanExperience(tx.QueryRow(ctx, query, deletedAt, now, id))
+	if err != nil {
+		// A live record was upserted with the natural key of the restored one
+		if isPgError(err, pgUniqueViolation) {
+			return nil, fmt.Errorf("experience with the same natural key %w", models.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to %s experience: %w", action, err)
+	}
+
+	if err := recordHistory(ctx, tx, action, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return after, nil
 }
 
-// Search performs advanced search with filters and pagination
-func (r *ExperienceRepository) Search(ctx context.Context, req *models.SearchExperiencesRequest) ([]models.ExperienceData, int, error) {
-	// Build base query
-	baseQuery := `
-		SELECT id, collected_at, created_at, updated_at,
-			source_type, source_id, source_name,
-			field_id, field_label, field_type,
-			value_text, value_number, value_boolean, value_date, value_json,
-			metadata, language, user_identifier
+// ReencryptBatch re-seals up to limit records sealed with a key version other
+// than current, using reseal, and returns how many were re-sealed
+// reseal receives the ID of the record along with its sealed values.
+// Records are locked for the transaction and locked records are skipped, so
+// concurrent updates are neither blocked nor overwritten.
+func (r *ExperienceRepository) ReencryptBatch(ctx context.Context, current, limit int, reseal func(uuid.UUID, *models.SealedValues) (*models.SealedValues, error)) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		SELECT id, encrypted_values, encrypted_data_key, key_version, user_identifier_index
 		FROM experience_data
+		WHERE key_version <> $1
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
 	`
 
-	countQuery := `SELECT COUNT(*) FROM experience_data`
+	rows, err := tx.Query(ctx, query, current, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select records to re-encrypt: %w", err)
+	}
 
-	var conditions []string
-	var args []interface{}
-	argCount := 1
+	ids := []uuid.UUID{}
+	batch := []*models.SealedValues{}
+	for rows.Next() {
+		var id uuid.UUID
+		var sealed models.SealedValues
+		if err := rows.Scan(&id, &sealed.Ciphertext, &sealed.DataKey, &sealed.KeyVersion, &sealed.UserIdentifierIndex); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan record to re-encrypt: %w", err)
+		}
+		ids = append(ids, id)
+		batch = append(batch, &sealed)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating records to re-encrypt: %w", err)
+	}
 
-	// Full-text search on text fields
-	if req.Query != nil && *req.Query != "" {
-		conditions = append(conditions, fmt.Sprintf(`(
-			value_text ILIKE $%d OR
-			field_label ILIKE $%d OR
-			source_name ILIKE $%d OR
-			field_id ILIKE $%d
-		)`, argCount, argCount, argCount, argCount))
-		args = append(args, "%"+*req.Query+"%")
-		argCount++
+	for i, sealed := range batch {
+		resealed, err := reseal(ids[i], sealed)
+		if err != nil {
+			return 0, fmt.Errorf("failed to re-encrypt experience %s: %w", ids[i], err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			UPDATE experience_data
+			SET encrypted_values = $1, encrypted_data_key = $2, key_version = $3
+			WHERE id = $4`,
+			resealed.Ciphertext, resealed.DataKey, resealed.KeyVersion, ids[i])
+		if err != nil {
+			return 0, fmt.Errorf("failed to store re-encrypted experience: %w", err)
+		}
 	}
 
-	// Filter by source_type
-	if req.SourceType != nil {
-		conditions = append(conditions, fmt.Sprintf("source_type = $%d", argCount))
-		args = append(args, *req.SourceType)
-		argCount++
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	// Filter by source_id
-	if req.SourceID != nil {
-		conditions = append(conditions, fmt.Sprintf("source_id = $%d", argCount))
-		args = append(args, *req.SourceID)
-		argCount++
+	return int64(len(batch)), nil
+}
+
+// PseudonymizeBatch converts up to limit records of pseudonymizing sources
+// whose user_identifier is neither a pseudonym nor empty, and returns how many
+// were converted
+// convert receives the ID of a record, its user_identifier stored in the clear
+// and its sealed values, and returns them with the user_identifier pseudonymized. The
+// user_identifier in the change history of the records is replaced by its
+// pseudonym as well. Records are locked for the transaction and locked records
+// are skipped, like in ReencryptBatch.
+func (r *ExperienceRepository) PseudonymizeBatch(
+	ctx context.Context,
+	limit int,
+	convert func(id uuid.UUID, userIdentifier *string, sealed *models.SealedValues) (*string, *models.SealedValues, error),
+	pseudonym func(userIdentifier string) string,
+) (int64, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	// Filter by field_id
-	if req.FieldID != nil {
-		conditions = append(conditions, fmt.Sprintf("field_id = $%d", argCount))
-		args = append(args, *req.FieldID)
-		argCount++
+	// Encrypted records with a user_identifier have a blind index of it
+	query := `
+		SELECT e.id, e.user_identifier, e.encrypted_values, e.encrypted_data_key, e.key_version, e.user_identifier_index
+		FROM experience_data e
+		JOIN sources s ON s.source_type = e.source_type AND s.external_id = e.source_id
+		WHERE s.pseudonymize
+		  AND ((e.user_identifier IS NOT NULL AND e.user_identifier !~ $1) OR e.user_identifier_index IS NOT NULL)
+		LIMIT $2
+		FOR UPDATE OF e SKIP LOCKED
+	`
+
+	rows, err := tx.Query(ctx, query, encryption.PseudonymPattern, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select records to pseudonymize: %w", err)
 	}
 
-	// Filter by field_type
-	if req.FieldType != nil {
-		conditions = append(conditions, fmt.Sprintf("field_type = $%d", argCount))
-		args = append(args, *req.FieldType)
-		argCount++
+	type record struct {
+		id             uuid.UUID
+		userIdentifier *string
+		sealed         *models.SealedValues
+	}
+	batch := []record{}
+	for rows.Next() {
+		var rec record
+		var sealed models.SealedValues
+		var keyVersion *int
+		if err := rows.Scan(&rec.id, &rec.userIdentifier, &sealed.Ciphertext, &sealed.DataKey, &keyVersion, &sealed.UserIdentifierIndex); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan record to pseudonymize: %w", err)
+		}
+		if sealed.Ciphertext != nil {
+			sealed.KeyVersion = *keyVersion
+			rec.sealed = &sealed
+		}
+		batch = append(batch, rec)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating records to pseudonymize: %w", err)
 	}
 
-	// Filter by user_identifier
-	if req.UserIdentifier != nil {
-		conditions = append(conditions, fmt.Sprintf("user_identifier = $%d", argCount))
-		args = append(args, *req.UserIdentifier)
-		argCount++
+	ids := make([]uuid.UUID, 0, len(batch))
+	for _, rec := range batch {
+		userIdentifier, sealed, err := convert(rec.id, rec.userIdentifier, rec.sealed)
+		if err != nil {
+			return 0, fmt.Errorf("failed to pseudonymize experience %s: %w", rec.id, err)
+		}
+
+		args := append([]interface{}{userIdentifier}, sealedArgs(sealed)...)
+		args = append(args, rec.id)
+		_, err = tx.Exec(ctx, `
+			UPDATE experience_data
+			SET user_identifier = $1, encrypted_values = $2, encrypted_data_key = $3, key_version = $4, user_identifier_index = $5,
+				version = version + 1
+			WHERE id = $6`,
+			args...)
+		if err != nil {
+			return 0, fmt.Errorf("failed to store pseudonymized experience: %w", err)
+		}
+		ids = append(ids, rec.id)
 	}
 
-	// Filter by date range
-	if req.StartDate != nil {
-		conditions = append(conditions, fmt.Sprintf("collected_at >= $%d", argCount))
-		args = append(args, *req.StartDate)
-		argCount++
+	if err := pseudonymizeHistory(ctx, tx, ids, pseudonym); err != nil {
+		return 0, err
 	}
 
-	if req.EndDate != nil {
-		conditions = append(conditions, fmt.Sprintf("collected_at <= $%d", argCount))
-		args = append(args, *req.EndDate)
-		argCount++
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return int64(len(batch)), nil
+}
+
+// pseudonymizeHistory replaces the user_identifier in the history snapshots of
+// the given records by its pseudonym. Snapshots of encrypted records hold no
+// user_identifier.
+func pseudonymizeHistory(ctx context.Context, tx pgx.Tx, ids []uuid.UUID, pseudonym func(string) string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, before->>'user_identifier', after->>'user_identifier'
+		FROM experience_history
+		WHERE experience_id = ANY($1)
+		  AND (before ? 'user_identifier' OR after ? 'user_identifier')`,
+		ids)
+	if err != nil {
+		return fmt.Errorf("failed to select history to pseudonymize: %w", err)
+	}
+
+	type snapshot struct {
+		id            uuid.UUID
+		before, after *string
+	}
+	snapshots := []snapshot{}
+	for rows.Next() {
+		var snap snapshot
+		if err := rows.Scan(&snap.id, &snap.before, &snap.after); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan history: %w", err)
+		}
+		snapshots = append(snapshots, snap)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating history: %w", err)
+	}
+
+	for _, snap := range snapshots {
+		var before, after *string
+		if snap.before != nil {
+			p := pseudonym(*snap.before)
+			before = &p
+		}
+		if snap.after != nil {
+			p := pseudonym(*snap.after)
+			after = &p
+		}
+
+		_, err := tx.Exec(ctx, `
+			UPDATE experience_history
+			SET before = CASE WHEN $1::text IS NULL THEN before ELSE jsonb_set(before, '{user_identifier}', to_jsonb($1::text)) END,
+			    after = CASE WHEN $2::text IS NULL THEN after ELSE jsonb_set(after, '{user_identifier}', to_jsonb($2::text)) END
+			WHERE id = $3`,
+			before, after, snap.id)
+		if err != nil {
+			return fmt.Errorf("failed to pseudonymize history: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// PurgeDeleted permanently removes up to limit records deleted before the given time,
+// with the stored idempotent responses returning them, and returns how many were removed
+func (r *ExperienceRepository) PurgeDeleted(ctx context.Context, before time.Time, limit int) (int64, error) {
+	query := `
+		WITH batch AS (
+			SELECT id FROM experience_data
+			WHERE deleted_at < $1
+			LIMIT $2
+		),
+		responses AS (` + forgetResponses(`SELECT id FROM batch`) + `)
+		DELETE FROM experience_data
+		WHERE id IN (SELECT id FROM batch)
+	`
+
+	result, err := r.db.Exec(ctx, query, before, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge experiences: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// facetColumns maps the facets supported by Search to the expression they count
+var facetColumns = map[string]string{
+	"source_type": "source_type",
+	"source_id":   "source_id",
+	"field_id":    "field_id",
+	"field_type":  "field_type",
+	"language":    "language",
+	"sentiment":   "metadata->>'sentiment'",
+}
+
+// maxFacetValues limits how many values are returned per facet
+const maxFacetValues = 50
+
+// Search performs advanced search with filters and pagination
+//
+// Full-text matches are ordered by ts_rank and include a ts_headline snippet of
+// value_text, fuzzy matches are ordered by similarity (see experienceFilter).
+//
+// The returned response holds the page of results, the total count and any
+// requested facets; pagination metadata is left to the caller.
+func (r *ExperienceRepository) Search(ctx context.Context, req *models.SearchExperiencesRequest) (*models.SearchExperiencesResponse, error) {
+	filter := buildExperienceFilter(&req.ExperienceFilters)
+
+	// Count, facet and page queries share a transaction so the filter applies to
+	// all of them, and a snapshot so total, facets and page agree under concurrent writes
+	tx, err := r.db.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly, IsoLevel: pgx.RepeatableRead})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin search transaction: %w", err)
 	}
+	defer tx.Rollback(ctx)
 
-	// Add WHERE clause if conditions exist
-	whereClause := ""
-	if len(conditions) > 0 {
-		whereClause = " WHERE " + strings.Join(conditions, " AND ")
+	if err := filter.apply(ctx, tx); err != nil {
+		return nil, err
 	}
 
 	// Get total count
 	var totalCount int
-	err := r.db.QueryRow(ctx, countQuery+whereClause, args...).Scan(&totalCount)
+	countQuery := filter.with + " SELECT COUNT(*)" + filter.from + filter.where
+	err = tx.QueryRow(ctx, countQuery, filter.args...).Scan(&totalCount)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to count experiences: %w", err)
+		return nil, fmt.Errorf("failed to count experiences: %w", err)
 	}
 
-	// Add ORDER BY
-	orderBy := " ORDER BY collected_at DESC"
+	// Count facet values under the same filters in a single query
+	var facets map[string][]models.FacetValue
+	if len(req.Facets) > 0 {
+		facets, err = r.searchFacets(ctx, tx, filter, req.Facets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The query cannot search the encrypted value_text of records, so report how
+	// many records under the other filters are encrypted instead of missing them silently
+	var encryptedNotSearched int
+	if filter.hasQuery {
+		withoutQuery := req.ExperienceFilters
+		withoutQuery.Query = nil
+		plain := buildExperienceFilter(&withoutQuery)
+		plain.and("encrypted_values IS NOT NULL")
+		err = tx.QueryRow(ctx, "SELECT COUNT(*)"+plain.from+plain.where, plain.args...).Scan(&encryptedNotSearched)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count encrypted experiences: %w", err)
+		}
+	}
+
+	selectClause := `
+		SELECT ` + selectColumns(req.Fields) + filter.scoreColumns
 
 	// Calculate limit and offset based on page and pageSize
 	limit := req.PageSize
 	offset := req.Page * req.PageSize
 
 	// Add pagination
+	argCount := filter.nextArg()
 	paginationClause := fmt.Sprintf(" LIMIT $%d OFFSET $%d", argCount, argCount+1)
-	args = append(args, limit, offset)
+	args := append(filter.args, limit, offset)
 
 	// Execute search query
-	fullQuery := baseQuery + whereClause + orderBy + paginationClause
-	rows, err := r.db.Query(ctx, fullQuery, args...)
+	fullQuery := filter.with + selectClause + filter.from + filter.where + filter.orderBy + paginationClause
+	rows, err := tx.Query(ctx, fullQuery, args...)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to search experiences: %w", err)
+		return nil, fmt.Errorf("failed to search experiences: %w", err)
 	}
 	defer rows.Close()
 
-	var experiences []models.ExperienceData
+	var results []models.ExperienceSearchResult
 	for rows.Next() {
-		var exp models.ExperienceData
-		err := rows.Scan(
-			&exp.ID, &exp.CollectedAt, &exp.CreatedAt, &exp.UpdatedAt,
-			&exp.SourceType, &exp.SourceID, &exp.SourceName,
-			&exp.FieldID, &exp.FieldLabel, &exp.FieldType,
-			&exp.ValueText, &exp.ValueNumber, &exp.ValueBoolean, &exp.ValueDate, &exp.ValueJSON,
-			&exp.Metadata, &exp.Language, &exp.UserIdentifier,
-		)
+		res, err := scanSearchResult(rows)
 		if err != nil {
-			return nil, 0, fmt.Errorf("failed to scan experience: %w", err)
+			return nil, err
+		}
+		results = append(results, *res)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating experiences: %w", err)
+	}
+
+	return &models.SearchExperiencesResponse{
+		Data:                 results,
+		TotalCount:           totalCount,
+		Facets:               facets,
+		EncryptedNotSearched: encryptedNotSearched,
+	}, nil
+}
+
+// scanSearchResult scans a row selected with experienceColumns and the score
+// columns of an experienceFilter
+func scanSearchResult(row pgx.Row) (*models.ExperienceSearchResult, error) {
+	var res models.ExperienceSearchResult
+	exp, err := scanExperience(row, &res.Rank, &res.Highlight, &res.Similarity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan experience: %w", err)
+	}
+	res.ExperienceData = *exp
+	return &res, nil
+}
+
+// StreamSearch calls each with every result matching the filters of req, best
+// matches first, as it is read from the database. Results are not paginated,
+// and neither counted nor faceted. When keys is set, it is called first with the
+// sorted top-level metadata keys of the results, read from the same snapshot.
+// An error returned by keys or each stops the stream.
+func (r *ExperienceRepository) StreamSearch(ctx context.Context, req *models.SearchExperiencesRequest, keys func([]string) error, each func(*models.ExperienceSearchResult) error) error {
+	filter := buildExperienceFilter(&req.ExperienceFilters)
+
+	tx, err := r.beginStream(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := filter.apply(ctx, tx); err != nil {
+		return err
+	}
+
+	if keys != nil {
+		query := filter.withCTE("streamed", "SELECT metadata"+filter.from+filter.where) + streamMetadataKeys
+		if err := streamKeys(ctx, tx, query, filter.args, keys); err != nil {
+			return err
+		}
+	}
+
+	query := filter.with + " SELECT " + selectColumns(req.Fields) + filter.scoreColumns + filter.from + filter.where + filter.orderBy
+	rows, err := tx.Query(ctx, query, filter.args...)
+	if err != nil {
+		return fmt.Errorf("failed to search experiences: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		res, err := scanSearchResult(rows)
+		if err != nil {
+			return err
+		}
+		if err := each(res); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating experiences: %w", err)
+	}
+
+	return nil
+}
+
+// searchFacets counts the most frequent values of each facet among the rows
+// matched by a search, using one UNION ALL query over the filtered rows
+func (r *ExperienceRepository) searchFacets(ctx context.Context, tx pgx.Tx, filter *experienceFilter, names []string) (map[string][]models.FacetValue, error) {
+	var columns []string
+	var selects []string
+	for _, name := range names {
+		column, ok := facetColumns[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported facet: %s", name)
+		}
+		columns = append(columns, fmt.Sprintf("%s AS %s", column, name))
+		selects = append(selects, fmt.Sprintf(`(
+			SELECT '%s', %s::text, COUNT(*)
+			FROM filtered_experiences
+			GROUP BY %s
+			ORDER BY COUNT(*) DESC, %s
+			LIMIT %d
+		)`, name, name, name, name, maxFacetValues))
+	}
+
+	query := filter.withCTE("filtered_experiences", "SELECT "+strings.Join(columns, ", ")+filter.from+filter.where) +
+		" " + strings.Join(selects, " UNION ALL ")
+
+	rows, err := tx.Query(ctx, query, filter.args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count facets: %w", err)
+	}
+	defer rows.Close()
+
+	facets := make(map[string][]models.FacetValue, len(names))
+	for _, name := range names {
+		facets[name] = []models.FacetValue{}
+	}
+	for rows.Next() {
+		var name string
+		var value models.FacetValue
+		if err := rows.Scan(&name, &value.Value, &value.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan facet: %w", err)
 		}
-		experiences = append(experiences, exp)
+		facets[name] = append(facets[name], value)
 	}
 
 	if err := rows.Err(); err != nil {
-		return nil, 0, fmt.Errorf("error iterating experiences: %w", err)
+		return nil, fmt.Errorf("error iterating facets: %w", err)
 	}
 
-	return experiences, totalCount, nil
+	return facets, nil
 }
@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelectColumns(t *testing.T) {
+	assert.Equal(t, experienceColumns, selectColumns(nil))
+
+	columns := selectColumns([]string{"id", "value_number"})
+	for _, sparse := range sparseColumns {
+		assert.Contains(t, columns, sparse.null)
+	}
+	// Every column is still read, so rows scan the same
+	assert.Equal(t, strings.Count(experienceColumns, ","), strings.Count(columns, ","))
+
+	columns = selectColumns([]string{"metadata", "user_identifier"})
+	assert.Contains(t, columns, "NULL::text AS value_text")
+	assert.NotContains(t, columns, "AS metadata")
+	assert.NotContains(t, columns, "AS encrypted_values")
+}
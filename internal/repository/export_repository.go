@@ -0,0 +1,176 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+const exportColumns = `id, format, status, filters, fields, rows, size, error, object_key,
+	api_key_id, created_at, started_at, finished_at, expires_at`
+
+// ExportRepository handles data access for exports of experience data
+type ExportRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewExportRepository creates a new export repository
+func NewExportRepository(db *pgxpool.Pool) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+func scanExport(row pgx.Row) (*models.Export, error) {
+	var exp models.Export
+	var filters []byte
+	err := row.Scan(
+		&exp.ID, &exp.Format, &exp.Status, &filters, &exp.Fields, &exp.Rows, &exp.Size, &exp.Error, &exp.ObjectKey,
+		&exp.APIKeyID, &exp.CreatedAt, &exp.StartedAt, &exp.FinishedAt, &exp.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(filters, &exp.Filters); err != nil {
+		return nil, fmt.Errorf("failed to decode export filters: %w", err)
+	}
+	return &exp, nil
+}
+
+// Create inserts a new pending export
+func (r *ExportRepository) Create(ctx context.Context, exp *models.Export) (*models.Export, error) {
+	filters, err := json.Marshal(exp.Filters)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode export filters: %w", err)
+	}
+
+	query := `
+		INSERT INTO exports (format, filters, fields, api_key_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING ` + exportColumns
+
+	created, err := scanExport(r.db.QueryRow(ctx, query, exp.Format, filters, exp.Fields, exp.APIKeyID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create export: %w", err)
+	}
+
+	return created, nil
+}
+
+// GetByID retrieves a single export by ID
+func (r *ExportRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Export, error) {
+	query := `SELECT ` + exportColumns + ` FROM exports WHERE id = $1`
+
+	exp, err := scanExport(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("export %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get export: %w", err)
+	}
+
+	return exp, nil
+}
+
+// Claim marks the oldest pending export as running and returns it, nil when
+// none is waiting. Running exports that made no progress since abandonedBefore
+// (e.g. because the worker stopped) are claimed again and written from the start.
+func (r *ExportRepository) Claim(ctx context.Context, now, abandonedBefore time.Time) (*models.Export, error) {
+	query := `
+		UPDATE exports
+		SET status = $1, rows = 0, started_at = $3, updated_at = $3
+		WHERE id = (
+			SELECT id FROM exports
+			WHERE status = $2 OR (status = $1 AND updated_at <= $4)
+			ORDER BY created_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + exportColumns
+
+	exp, err := scanExport(r.db.QueryRow(ctx, query, models.ExportRunning, models.ExportPending, now, abandonedBefore))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim export: %w", err)
+	}
+
+	return exp, nil
+}
+
+// Progress stores the records a running export has written so far
+func (r *ExportRepository) Progress(ctx context.Context, id uuid.UUID, rows int64) error {
+	query := `UPDATE exports SET rows = $2, updated_at = $3 WHERE id = $1`
+
+	if _, err := r.db.Exec(ctx, query, id, rows, time.Now()); err != nil {
+		return fmt.Errorf("failed to store export progress: %w", err)
+	}
+
+	return nil
+}
+
+// Finish stores the status, file and error an export ended with
+func (r *ExportRepository) Finish(ctx context.Context, exp *models.Export) error {
+	now := time.Now()
+	query := `
+		UPDATE exports
+		SET status = $2, rows = $3, size = $4, object_key = $5, error = $6, expires_at = $7,
+			updated_at = $8, finished_at = $8
+		WHERE id = $1`
+
+	_, err := r.db.Exec(ctx, query, exp.ID, exp.Status, exp.Rows, exp.Size, exp.ObjectKey, exp.Error, exp.ExpiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to finish export: %w", err)
+	}
+
+	exp.FinishedAt = &now
+	return nil
+}
+
+// ListExpired returns up to limit completed exports whose files are kept
+// past their expiry, oldest first
+func (r *ExportRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.Export, error) {
+	query := `
+		SELECT ` + exportColumns + ` FROM exports
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3`
+
+	rows, err := r.db.Query(ctx, query, models.ExportCompleted, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expired exports: %w", err)
+	}
+	defer rows.Close()
+
+	exports := []models.Export{}
+	for rows.Next() {
+		exp, err := scanExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan export: %w", err)
+		}
+		exports = append(exports, *exp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating exports: %w", err)
+	}
+
+	return exports, nil
+}
+
+// Expire marks a completed export as expired once its file was deleted
+func (r *ExportRepository) Expire(ctx context.Context, id uuid.UUID) error {
+	query := `UPDATE exports SET status = $2, object_key = NULL, updated_at = $3 WHERE id = $1 AND status = $4`
+
+	if _, err := r.db.Exec(ctx, query, id, models.ExportExpired, time.Now(), models.ExportCompleted); err != nil {
+		return fmt.Errorf("failed to expire export: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// IdempotencyRepository handles data access for idempotency keys
+type IdempotencyRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewIdempotencyRepository creates a new idempotency repository
+func NewIdempotencyRepository(db *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims an idempotency key of an API key for a request with the given
+// fingerprint until expiresAt. It returns nil when the key was claimed, or the
+// record of the earlier request holding the key.
+// Expired keys, and keys of requests still in progress since before abandonedBefore
+// (e.g. because the server stopped), are claimed again.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, apiKeyID uuid.UUID, key, fingerprint string, now, expiresAt, abandonedBefore time.Time) (*models.IdempotencyRecord, error) {
+	query := `
+		INSERT INTO idempotency_keys (api_key_id, key, fingerprint, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (api_key_id, key) DO UPDATE SET
+			fingerprint = EXCLUDED.fingerprint,
+			status_code = NULL,
+			content_type = NULL,
+			response_headers = NULL,
+			response_body = NULL,
+			data_key = NULL,
+			key_version = NULL,
+			experience_ids = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= $4
+		   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at <= $6)
+		RETURNING true
+	`
+
+	var claimed bool
+	err := r.db.QueryRow(ctx, query, apiKeyID, key, fingerprint, now, expiresAt, abandonedBefore).Scan(&claimed)
+	if err == nil {
+		return nil, nil
+	}
+	if err != pgx.ErrNoRows {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var record models.IdempotencyRecord
+	err = r.db.QueryRow(ctx, `
+		SELECT fingerprint, status_code, content_type, response_headers, response_body, data_key, key_version
+		FROM idempotency_keys
+		WHERE api_key_id = $1 AND key = $2`,
+		apiKeyID, key,
+	).Scan(&record.Fingerprint, &record.StatusCode, &record.ContentType, &record.Headers, &record.Body, &record.DataKey, &record.KeyVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response of the request holding an idempotency key
+func (r *IdempotencyRepository) Complete(ctx context.Context, apiKeyID uuid.UUID, key string, record *models.IdempotencyRecord) error {
+	query := `
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = $4, response_headers = $5, response_body = $6, data_key = $7,
+			key_version = $8, experience_ids = $9
+		WHERE api_key_id = $1 AND key = $2
+	`
+
+	_, err := r.db.Exec(ctx, query, apiKeyID, key, record.StatusCode, record.ContentType, record.Headers, record.Body,
+		record.DataKey, record.KeyVersion, record.ExperienceIDs)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+
+	return nil
+}
+
+// Release frees an idempotency key whose request did not complete, so it can be retried
+func (r *IdempotencyRepository) Release(ctx context.Context, apiKeyID uuid.UUID, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE api_key_id = $1 AND key = $2 AND status_code IS NULL`
+
+	if _, err := r.db.Exec(ctx, query, apiKeyID, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteExpired removes the idempotency keys expired before the given time and
+// returns how many were removed
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// forgetResponses returns a statement deleting the idempotency keys whose
+// stored responses returned any of the records selected by ids, a query of
+// experience_data ids. Erasing records must not leave copies of them behind.
+func forgetResponses(ids string) string {
+	return `DELETE FROM idempotency_keys WHERE experience_ids && ARRAY(` + ids + `)`
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// ResponseRepository handles data access for responses, the experience data
+// records sharing a response_id
+type ResponseRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewResponseRepository creates a new response repository
+func NewResponseRepository(db *pgxpool.Pool) *ResponseRepository {
+	return &ResponseRepository{db: db}
+}
+
+// GetByID retrieves all answers of a response
+func (r *ResponseRepository) GetByID(ctx context.Context, id string) (*models.Response, error) {
+	query := `SELECT ` + experienceColumns + `
+		FROM experience_data
+		WHERE response_id = $1 AND deleted_at IS NULL
+		ORDER BY collected_at, field_id
+	`
+
+	rows, err := r.db.Query(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get response: %w", err)
+	}
+	defer rows.Close()
+
+	var answers []models.ExperienceData
+	for rows.Next() {
+		exp, err := scanExperience(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan answer: %w", err)
+		}
+		answers = append(answers, *exp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating answers: %w", err)
+	}
+
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("response %w", models.ErrNotFound)
+	}
+
+	// The earliest answer describes the response
+	first := answers[0]
+	return &models.Response{
+		ID:             id,
+		SourceType:     first.SourceType,
+		SourceID:       first.SourceID,
+		UserIdentifier: first.UserIdentifier,
+		CollectedAt:    first.CollectedAt,
+		Answers:        answers,
+	}, nil
+}
+
+// List retrieves a page of responses with their answers matching filters
+// Answers are read with their sealed values, so they can be decrypted before
+// being pivoted by field_id
+func (r *ResponseRepository) List(ctx context.Context, filters *models.ListResponsesFilters) ([]models.Response, error) {
+	conditions := []string{"response_id IS NOT NULL", "deleted_at IS NULL"}
+	var args []interface{}
+	argCount := 1
+
+	if filters.SourceType != nil {
+		conditions = append(conditions, fmt.Sprintf("source_type = $%d", argCount))
+		args = append(args, *filters.SourceType)
+		argCount++
+	}
+
+	if filters.SourceID != nil {
+		conditions = append(conditions, fmt.Sprintf("source_id = $%d", argCount))
+		args = append(args, *filters.SourceID)
+		argCount++
+	}
+
+	if filters.UserIdentifier != nil {
+		conditions = append(conditions, userIdentifierCondition(argCount))
+		args = append(args, userIdentifierArgs(*filters.UserIdentifier, filters.UserIdentifierMatch)...)
+		argCount += 2
+	}
+
+	if filters.StartDate != nil {
+		conditions = append(conditions, fmt.Sprintf("collected_at >= $%d", argCount))
+		args = append(args, *filters.StartDate)
+		argCount++
+	}
+
+	if filters.EndDate != nil {
+		conditions = append(conditions, fmt.Sprintf("collected_at <= $%d", argCount))
+		args = append(args, *filters.EndDate)
+		argCount++
+	}
+
+	where := strings.Join(conditions, " AND ")
+	page := fmt.Sprintf(`
+		SELECT response_id, MIN(collected_at) AS first_collected_at
+		FROM experience_data
+		WHERE %s
+		GROUP BY response_id
+		ORDER BY MIN(collected_at) DESC, response_id`, where)
+
+	if filters.Limit > 0 {
+		page += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, filters.Limit)
+		argCount++
+	}
+
+	if filters.Offset > 0 {
+		page += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	query := fmt.Sprintf(`
+		WITH page AS (%s)
+		SELECT `+experienceColumns+`
+		FROM experience_data
+		JOIN page USING (response_id)
+		WHERE %s
+		ORDER BY page.first_collected_at DESC, response_id, collected_at, field_id`, page, where)
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list responses: %w", err)
+	}
+	defer rows.Close()
+
+	responses := []models.Response{}
+	for rows.Next() {
+		exp, err := scanExperience(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan answer: %w", err)
+		}
+
+		// Answers arrive grouped by response, the earliest describing it
+		if n := len(responses); n == 0 || responses[n-1].ID != *exp.ResponseID {
+			responses = append(responses, models.Response{
+				ID:             *exp.ResponseID,
+				SourceType:     exp.SourceType,
+				SourceID:       exp.SourceID,
+				UserIdentifier: exp.UserIdentifier,
+				CollectedAt:    exp.CollectedAt,
+			})
+		}
+		last := &responses[len(responses)-1]
+		last.Answers = append(last.Answers, *exp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating responses: %w", err)
+	}
+
+	return responses, nil
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+const retentionPolicyColumns = `id, source_type, source_id, max_age_days, action, enabled,
+	last_run_at, last_run_affected, created_at, updated_at`
+
+// RetentionRepository handles data access for retention policies and the
+// records they expire
+type RetentionRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewRetentionRepository creates a new retention repository
+func NewRetentionRepository(db *pgxpool.Pool) *RetentionRepository {
+	return &RetentionRepository{db: db}
+}
+
+func scanRetentionPolicy(row pgx.Row) (*models.RetentionPolicy, error) {
+	var policy models.RetentionPolicy
+	err := row.Scan(
+		&policy.ID, &policy.SourceType, &policy.SourceID, &policy.MaxAgeDays, &policy.Action, &policy.Enabled,
+		&policy.LastRunAt, &policy.LastRunAffected, &policy.CreatedAt, &policy.UpdatedAt,
+	)
+	return &policy, err
+}
+
+// Create inserts a new retention policy
+func (r *RetentionRepository) Create(ctx context.Context, req *models.CreateRetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	query := `
+		INSERT INTO retention_policies (source_type, source_id, max_age_days, action, enabled)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + retentionPolicyColumns
+
+	policy, err := scanRetentionPolicy(r.db.QueryRow(ctx, query,
+		req.SourceType, req.SourceID, req.MaxAgeDays, req.Action, *req.Enabled))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// GetByID retrieves a single retention policy by ID
+func (r *RetentionRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.RetentionPolicy, error) {
+	query := `SELECT ` + retentionPolicyColumns + ` FROM retention_policies WHERE id = $1`
+
+	policy, err := scanRetentionPolicy(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("retention policy %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// List retrieves retention policies, optionally only the enabled ones
+func (r *RetentionRepository) List(ctx context.Context, enabledOnly bool) ([]models.RetentionPolicy, error) {
+	query := `SELECT ` + retentionPolicyColumns + ` FROM retention_policies`
+	if enabledOnly {
+		query += ` WHERE enabled`
+	}
+	query += ` ORDER BY source_type, source_id NULLS FIRST, created_at`
+
+	rows, err := r.db.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []models.RetentionPolicy{}
+	for rows.Next() {
+		policy, err := scanRetentionPolicy(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan retention policy: %w", err)
+		}
+		policies = append(policies, *policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating retention policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// Update updates an existing retention policy
+func (r *RetentionRepository) Update(ctx context.Context, id uuid.UUID, req *models.UpdateRetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	var updates []string
+	var args []interface{}
+	argCount := 1
+
+	if req.MaxAgeDays != nil {
+		updates = append(updates, fmt.Sprintf("max_age_days = $%d", argCount))
+		args = append(args, *req.MaxAgeDays)
+		argCount++
+	}
+
+	if req.Action != nil {
+		updates = append(updates, fmt.Sprintf("action = $%d", argCount))
+		args = append(args, *req.Action)
+		argCount++
+	}
+
+	if req.Enabled != nil {
+		updates = append(updates, fmt.Sprintf("enabled = $%d", argCount))
+		args = append(args, *req.Enabled)
+		argCount++
+	}
+
+	if len(updates) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argCount))
+	args = append(args, time.Now())
+	argCount++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE retention_policies
+		SET %s
+		WHERE id = $%d
+		RETURNING `+retentionPolicyColumns, strings.Join(updates, ", "), argCount)
+
+	policy, err := scanRetentionPolicy(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("retention policy %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update retention policy: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Delete removes a retention policy
+func (r *RetentionRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM retention_policies WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete retention policy: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("retention policy %w", models.ErrNotFound)
+	}
+
+	return nil
+}
+
+// expiredCondition returns the condition matching the records a policy still
+// has to delete or anonymize, with its parameters
+// Records in the trash expire like any other record.
+func expiredCondition(policy *models.RetentionPolicy, cutoff time.Time) (string, []interface{}) {
+	conditions := []string{"source_type = $1", "collected_at < $2"}
+	args := []interface{}{policy.SourceType, cutoff}
+
+	if policy.SourceID != nil {
+		args = append(args, *policy.SourceID)
+		conditions = append(conditions, fmt.Sprintf("source_id = $%d", len(args)))
+	}
+
+	// Anonymized records no longer match, so every run only touches records left to do
+	if policy.Action == models.RetentionActionAnonymize {
+		args = append(args, freeTextFieldTypes)
+		conditions = append(conditions, identifiableCondition(len(args)))
+	}
+
+	return strings.Join(conditions, " AND "), args
+}
+
+// CountExpired counts the records a policy would delete or anonymize
+func (r *RetentionRepository) CountExpired(ctx context.Context, policy *models.RetentionPolicy, cutoff time.Time) (int64, error) {
+	condition, args := expiredCondition(policy, cutoff)
+
+	var count int64
+	err := r.db.QueryRow(ctx, `SELECT COUNT(*) FROM experience_data WHERE `+condition, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count expired experiences: %w", err)
+	}
+
+	return count, nil
+}
+
+// ExpireBatch deletes or anonymizes up to limit expired records of a policy and
+// returns how many were affected
+// Deleting removes the records' history with them, anonymizing deletes it as
+// history snapshots hold copies of the records. Stored idempotent responses
+// returning the records are deleted either way. Rows locked by other
+// transactions are skipped and picked up by a later batch.
+func (r *RetentionRepository) ExpireBatch(ctx context.Context, policy *models.RetentionPolicy, cutoff time.Time, limit int) (int64, error) {
+	condition, args := expiredCondition(policy, cutoff)
+	// For anonymize policies freeTextFieldTypes is the last parameter of the condition
+	freeTextArg := len(args)
+
+	args = append(args, limit)
+	batch := fmt.Sprintf(`SELECT id FROM experience_data WHERE %s LIMIT $%d FOR UPDATE SKIP LOCKED`, condition, len(args))
+
+	query := fmt.Sprintf(`
+		WITH batch AS (%s),
+		responses AS (%s)
+		DELETE FROM experience_data WHERE id IN (SELECT id FROM batch)`,
+		batch, forgetResponses(`SELECT id FROM batch`))
+	if policy.Action == models.RetentionActionAnonymize {
+		args = append(args, time.Now())
+		query = fmt.Sprintf(`
+			WITH batch AS (%s),
+			history AS (
+				DELETE FROM experience_history WHERE experience_id IN (SELECT id FROM batch)
+			),
+			responses AS (%s)
+			UPDATE experience_data
+			SET %s, updated_at = $%d
+			WHERE id IN (SELECT id FROM batch)`,
+			batch, forgetResponses(`SELECT id FROM batch`), anonymizeAssignments(freeTextArg), len(args))
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to %s expired experiences: %w", policy.Action, err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// RecordRun stores the time and outcome of a policy's last run
+func (r *RetentionRepository) RecordRun(ctx context.Context, id uuid.UUID, ranAt time.Time, affected int64) error {
+	_, err := r.db.Exec(ctx, `
+		UPDATE retention_policies
+		SET last_run_at = $1, last_run_affected = $2
+		WHERE id = $3`,
+		ranAt, affected, id)
+	if err != nil {
+		return fmt.Errorf("failed to record retention run: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestExpiredCondition(t *testing.T) {
+	cutoff := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sourceID := "survey-1"
+
+	tests := []struct {
+		name          string
+		policy        models.RetentionPolicy
+		wantCondition string
+		wantArgs      []interface{}
+	}{
+		{
+			name:          "delete by source type",
+			policy:        models.RetentionPolicy{SourceType: "survey", Action: models.RetentionActionDelete},
+			wantCondition: "source_type = $1 AND collected_at < $2",
+			wantArgs:      []interface{}{"survey", cutoff},
+		},
+		{
+			name:          "delete by source",
+			policy:        models.RetentionPolicy{SourceType: "survey", SourceID: &sourceID, Action: models.RetentionActionDelete},
+			wantCondition: "source_type = $1 AND collected_at < $2 AND source_id = $3",
+			wantArgs:      []interface{}{"survey", cutoff, sourceID},
+		},
+		{
+			name:          "anonymize skips anonymized records",
+			policy:        models.RetentionPolicy{SourceType: "survey", SourceID: &sourceID, Action: models.RetentionActionAnonymize},
+			wantCondition: "source_type = $1 AND collected_at < $2 AND source_id = $3 AND " + identifiableCondition(4),
+			wantArgs:      []interface{}{"survey", cutoff, sourceID, freeTextFieldTypes},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			condition, args := expiredCondition(&tt.policy, cutoff)
+			assert.Equal(t, tt.wantCondition, condition)
+			assert.Equal(t, tt.wantArgs, args)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"strings"
+	"unicode"
+)
+
+// buildTSQuery converts a user search string into PostgreSQL to_tsquery syntax.
+//
+// Supported syntax:
+//   - words are ANDed together: great support
+//   - "quoted text" matches an exact phrase
+//   - a leading - negates a word or phrase: -slow
+//   - a trailing * matches by prefix: improv*
+//   - OR between two terms matches either of them: pricing OR cost
+//
+// Anything other than letters and digits is treated as a word separator, so user
+// input can never produce invalid tsquery syntax. Returns an empty string if the
+// input contains no searchable words.
+func buildTSQuery(input string) string {
+	var terms []string
+	pendingOr := false
+
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		negate := false
+		if runes[i] == '-' {
+			negate = true
+			i++
+		}
+
+		var text string
+		quoted := i < len(runes) && runes[i] == '"'
+		if quoted {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			text = string(runes[i+1 : min(end, len(runes))])
+			i = end + 1
+		} else {
+			end := i
+			for end < len(runes) && !unicode.IsSpace(runes[end]) {
+				end++
+			}
+			text = string(runes[i:end])
+			i = end
+		}
+
+		if !quoted && !negate && text == "OR" {
+			pendingOr = len(terms) > 0
+			continue
+		}
+
+		term := buildTSQueryTerm(text)
+		if term == "" {
+			continue
+		}
+		if negate {
+			term = "!" + term
+		}
+
+		if len(terms) > 0 {
+			if pendingOr {
+				terms = append(terms, "|")
+			} else {
+				terms = append(terms, "&")
+			}
+		}
+		terms = append(terms, term)
+		pendingOr = false
+	}
+
+	return strings.Join(terms, " ")
+}
+
+// buildTSQueryTerm converts a single word or phrase into a tsquery operand.
+// Multiple words are combined with the followed-by operator so they must appear
+// next to each other in the document.
+func buildTSQueryTerm(text string) string {
+	prefix := strings.HasSuffix(strings.TrimSpace(text), "*")
+
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return ""
+	}
+
+	lexemes := make([]string, len(words))
+	for i, word := range words {
+		lexemes[i] = "'" + strings.ToLower(word) + "'"
+	}
+	if prefix {
+		lexemes[len(lexemes)-1] += ":*"
+	}
+
+	if len(lexemes) == 1 {
+		return lexemes[0]
+	}
+	return "(" + strings.Join(lexemes, " <-> ") + ")"
+}
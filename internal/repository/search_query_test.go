@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildTSQuery(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "single word",
+			input: "amazing",
+			want:  "'amazing'",
+		},
+		{
+			name:  "words are ANDed",
+			input: "great support",
+			want:  "'great' & 'support'",
+		},
+		{
+			name:  "quoted phrase",
+			input: `"great support"`,
+			want:  "('great' <-> 'support')",
+		},
+		{
+			name:  "negated word",
+			input: "product -slow",
+			want:  "'product' & !'slow'",
+		},
+		{
+			name:  "negated phrase",
+			input: `-"too expensive"`,
+			want:  "!('too' <-> 'expensive')",
+		},
+		{
+			name:  "prefix word",
+			input: "improv*",
+			want:  "'improv':*",
+		},
+		{
+			name:  "prefix on last word of phrase",
+			input: `"customer supp*"`,
+			want:  "('customer' <-> 'supp':*)",
+		},
+		{
+			name:  "OR between terms",
+			input: "pricing OR cost",
+			want:  "'pricing' | 'cost'",
+		},
+		{
+			name:  "leading OR is ignored",
+			input: "OR pricing",
+			want:  "'pricing'",
+		},
+		{
+			name:  "lowercase or is a regular word",
+			input: "this or that",
+			want:  "'this' & 'or' & 'that'",
+		},
+		{
+			name:  "tsquery operators are stripped",
+			input: "a&b | c:* !(d)",
+			want:  "('a' <-> 'b') & 'c':* & 'd'",
+		},
+		{
+			name:  "single quotes cannot break out of lexemes",
+			input: "it's",
+			want:  "('it' <-> 's')",
+		},
+		{
+			name:  "unterminated quote runs to end of input",
+			input: `"great support`,
+			want:  "('great' <-> 'support')",
+		},
+		{
+			name:  "unicode letters are kept",
+			input: "Größe café",
+			want:  "'größe' & 'café'",
+		},
+		{
+			name:  "no searchable words",
+			input: `-"" * !!`,
+			want:  "",
+		},
+		{
+			name:  "empty input",
+			input: "   ",
+			want:  "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, buildTSQuery(tt.input))
+		})
+	}
+}
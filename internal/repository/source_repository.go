@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// PostgreSQL error codes for constraint violations
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+)
+
+const sourceColumns = `id, source_type, external_id, name, strict, pii_mode, pii_vault, encrypt, pseudonymize, created_at, updated_at`
+
+const sourceFieldColumns = `source_id, field_id, label, field_type, choices, translations, position, created_at, updated_at`
+
+// SourceRepository handles data access for the source and field registry
+type SourceRepository struct {
+	db *pgxpool.Pool
+}
+
+// NewSourceRepository creates a new source repository
+func NewSourceRepository(db *pgxpool.Pool) *SourceRepository {
+	return &SourceRepository{db: db}
+}
+
+// isPgError reports whether err is a PostgreSQL error with the given code
+func isPgError(err error, code string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == code
+}
+
+func scanSource(row pgx.Row) (*models.Source, error) {
+	var source models.Source
+	err := row.Scan(
+		&source.ID, &source.SourceType, &source.ExternalID, &source.Name, &source.Strict,
+		&source.PIIMode, &source.PIIVault, &source.Encrypt, &source.Pseudonymize, &source.CreatedAt, &source.UpdatedAt,
+	)
+	return &source, err
+}
+
+func scanSourceField(row pgx.Row) (*models.SourceField, error) {
+	var field models.SourceField
+	err := row.Scan(
+		&field.SourceID, &field.FieldID, &field.Label, &field.FieldType,
+		&field.Choices, &field.Translations, &field.Position,
+		&field.CreatedAt, &field.UpdatedAt,
+	)
+	return &field, err
+}
+
+// Create registers a new source
+func (r *SourceRepository) Create(ctx context.Context, req *models.CreateSourceRequest) (*models.Source, error) {
+	query := `
+		INSERT INTO sources (source_type, external_id, name, strict, pii_mode, pii_vault, encrypt, pseudonymize)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING ` + sourceColumns
+
+	source, err := scanSource(r.db.QueryRow(ctx, query,
+		req.SourceType, req.ExternalID, req.Name, req.Strict, req.PIIMode, req.PIIVault, req.Encrypt, req.Pseudonymize,
+	))
+	if err != nil {
+		if isPgError(err, pgUniqueViolation) {
+			return nil, fmt.Errorf("source %w", models.ErrConflict)
+		}
+		return nil, fmt.Errorf("failed to create source: %w", err)
+	}
+
+	return source, nil
+}
+
+// GetByID retrieves a single source by ID
+func (r *SourceRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Source, error) {
+	query := `SELECT ` + sourceColumns + ` FROM sources WHERE id = $1`
+
+	source, err := scanSource(r.db.QueryRow(ctx, query, id))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("source %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get source: %w", err)
+	}
+
+	return source, nil
+}
+
+// List retrieves sources with optional filters
+func (r *SourceRepository) List(ctx context.Context, filters *models.ListSourcesFilters) ([]models.Source, error) {
+	query := `SELECT ` + sourceColumns + ` FROM sources`
+
+	var args []interface{}
+	argCount := 1
+
+	if filters.SourceType != nil {
+		query += fmt.Sprintf(" WHERE source_type = $%d", argCount)
+		args = append(args, *filters.SourceType)
+		argCount++
+	}
+
+	query += " ORDER BY created_at DESC, id"
+
+	if filters.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT $%d", argCount)
+		args = append(args, filters.Limit)
+		argCount++
+	}
+
+	if filters.Offset > 0 {
+		query += fmt.Sprintf(" OFFSET $%d", argCount)
+		args = append(args, filters.Offset)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sources: %w", err)
+	}
+	defer rows.Close()
+
+	sources := []models.Source{}
+	for rows.Next() {
+		source, err := scanSource(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan source: %w", err)
+		}
+		sources = append(sources, *source)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sources: %w", err)
+	}
+
+	return sources, nil
+}
+
+// Update updates the name, strict mode, PII and encryption settings of a source
+func (r *SourceRepository) Update(ctx context.Context, id uuid.UUID, req *models.UpdateSourceRequest) (*models.Source, error) {
+	var updates []string
+	var args []interface{}
+	argCount := 1
+
+	if req.Name != nil {
+		updates = append(updates, fmt.Sprintf("name = $%d", argCount))
+		args = append(args, *req.Name)
+		argCount++
+	}
+
+	if req.Strict != nil {
+		updates = append(updates, fmt.Sprintf("strict = $%d", argCount))
+		args = append(args, *req.Strict)
+		argCount++
+	}
+
+	if req.PIIMode != nil {
+		updates = append(updates, fmt.Sprintf("pii_mode = $%d", argCount))
+		args = append(args, *req.PIIMode)
+		argCount++
+	}
+
+	if req.PIIVault != nil {
+		updates = append(updates, fmt.Sprintf("pii_vault = $%d", argCount))
+		args = append(args, *req.PIIVault)
+		argCount++
+	}
+
+	if req.Encrypt != nil {
+		updates = append(updates, fmt.Sprintf("encrypt = $%d", argCount))
+		args = append(args, *req.Encrypt)
+		argCount++
+	}
+
+	if req.Pseudonymize != nil {
+		updates = append(updates, fmt.Sprintf("pseudonymize = $%d", argCount))
+		args = append(args, *req.Pseudonymize)
+		argCount++
+	}
+
+	if len(updates) == 0 {
+		return r.GetByID(ctx, id)
+	}
+
+	updates = append(updates, fmt.Sprintf("updated_at = $%d", argCount))
+	args = append(args, time.Now())
+	argCount++
+
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE sources
+		SET %s
+		WHERE id = $%d
+		RETURNING `+sourceColumns, strings.Join(updates, ", "), argCount)
+
+	source, err := scanSource(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("source %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to update source: %w", err)
+	}
+
+	return source, nil
+}
+
+// Delete removes a source and its fields
+// Records of the source are kept
+func (r *SourceRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM sources WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete source: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("source %w", models.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListFields retrieves the fields of a source, ordered by position
+func (r *SourceRepository) ListFields(ctx context.Context, sourceID uuid.UUID) ([]models.SourceField, error) {
+	if _, err := r.GetByID(ctx, sourceID); err != nil {
+		return nil, err
+	}
+
+	query := `SELECT ` + sourceFieldColumns + ` FROM source_fields WHERE source_id = $1 ORDER BY position, field_id`
+
+	rows, err := r.db.Query(ctx, query, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fields: %w", err)
+	}
+	defer rows.Close()
+
+	fields := []models.SourceField{}
+	for rows.Next() {
+		field, err := scanSourceField(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan field: %w", err)
+		}
+		fields = append(fields, *field)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating fields: %w", err)
+	}
+
+	return fields, nil
+}
+
+// PutField registers a field of a source, replacing an existing registration
+func (r *SourceRepository) PutField(ctx context.Context, sourceID uuid.UUID, fieldID string, req *models.PutSourceFieldRequest) (*models.SourceField, error) {
+	field, err := putField(ctx, r.db, sourceID, fieldID, req)
+	if err != nil {
+		if isPgError(err, pgForeignKeyViolation) {
+			return nil, fmt.Errorf("source %w", models.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to save field: %w", err)
+	}
+
+	return field, nil
+}
+
+// putField upserts a field using db, which may be a pool or a transaction
+func putField(ctx context.Context, db DBPool, sourceID uuid.UUID, fieldID string, req *models.PutSourceFieldRequest) (*models.SourceField, error) {
+	query := `
+		INSERT INTO source_fields (source_id, field_id, label, field_type, choices, translations, position)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (source_id, field_id) DO UPDATE SET
+			label = EXCLUDED.label,
+			field_type = EXCLUDED.field_type,
+			choices = EXCLUDED.choices,
+			translations = EXCLUDED.translations,
+			position = EXCLUDED.position,
+			updated_at = NOW()
+		RETURNING ` + sourceFieldColumns
+
+	return scanSourceField(db.QueryRow(ctx, query,
+		sourceID, fieldID, req.Label, req.FieldType, req.Choices, req.Translations, req.Position,
+	))
+}
+
+// DeleteField removes a field from a source
+func (r *SourceRepository) DeleteField(ctx context.Context, sourceID uuid.UUID, fieldID string) error {
+	result, err := r.db.Exec(ctx, `DELETE FROM source_fields WHERE source_id = $1 AND field_id = $2`, sourceID, fieldID)
+	if err != nil {
+		return fmt.Errorf("failed to delete field: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("field %w", models.ErrNotFound)
+	}
+
+	return nil
+}
+
+// LookupField finds the registration of a record's field by the record's
+// source_type, source_id and field_id. It returns nil when the source is not registered.
+func (r *SourceRepository) LookupField(ctx context.Context, sourceType, externalID, fieldID string) (*models.SourceFieldLookup, error) {
+	query := `
+		SELECT s.strict, s.pii_mode, s.pii_vault, s.encrypt, s.pseudonymize, f.field_id IS NOT NULL, f.label, f.field_type
+		FROM sources s
+		LEFT JOIN source_fields f ON f.source_id = s.id AND f.field_id = $3
+		WHERE s.source_type = $1 AND s.external_id = $2
+	`
+
+	var lookup models.SourceFieldLookup
+	var registered bool
+	var label, fieldType *string
+	err := r.db.QueryRow(ctx, query, sourceType, externalID, fieldID).Scan(
+		&lookup.Strict, &lookup.PIIMode, &lookup.PIIVault, &lookup.Encrypt, &lookup.Pseudonymize, &registered, &label, &fieldType,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to look up field: %w", err)
+	}
+
+	if registered {
+		lookup.Field = &models.SourceField{FieldID: fieldID, Label: label, FieldType: *fieldType}
+	}
+
+	return &lookup, nil
+}
+
+// Import registers a source and its fields in a single transaction
+// An existing source with the same source_type and external_id is updated and
+// its fields are upserted; fields missing from the import and the PII,
+// encryption and pseudonymization settings of the source are kept.
+func (r *SourceRepository) Import(ctx context.Context, req *models.CreateSourceRequest, fields map[string]*models.PutSourceFieldRequest) (*models.Source, []models.SourceField, error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin import transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO sources (source_type, external_id, name, strict)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (source_type, external_id) DO UPDATE SET
+			name = EXCLUDED.name,
+			strict = sources.strict OR EXCLUDED.strict,
+			updated_at = NOW()
+		RETURNING ` + sourceColumns
+
+	source, err := scanSource(tx.QueryRow(ctx, query, req.SourceType, req.ExternalID, req.Name, req.Strict))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to import source: %w", err)
+	}
+
+	imported := make([]models.SourceField, 0, len(fields))
+	for fieldID, fieldReq := range fields {
+		field, err := putField(ctx, tx, source.ID, fieldID, fieldReq)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to import field %s: %w", fieldID, err)
+		}
+		imported = append(imported, *field)
+	}
+	slices.SortFunc(imported, func(a, b models.SourceField) int { return a.Position - b.Position })
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	return source, imported, nil
+}
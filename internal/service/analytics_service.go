@@ -0,0 +1,378 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// Limits keeping time series responses chart sized
+const (
+	maxTimeSeriesBuckets = 1000 // Buckets per series
+	maxTimeSeries        = 50   // Series per response, the groups with most records are kept
+)
+
+// maxCrosstabValues limits the distinct answers per crosstab dimension
+const maxCrosstabValues = 100
+
+// ErrTooManyCrosstabValues is returned when a crosstab field has more than maxCrosstabValues distinct answers
+var ErrTooManyCrosstabValues = fmt.Errorf("%w: crosstab fields must have at most %d distinct answers, use fields with categorical answers", models.ErrInvalidInput, maxCrosstabValues)
+
+// ErrTooManyBuckets is returned when a time series range holds more than maxTimeSeriesBuckets buckets
+var ErrTooManyBuckets = fmt.Errorf("%w: time range contains too many buckets for the interval, use a larger interval or a shorter range", models.ErrInvalidInput)
+
+// defaultTimeSeriesRange is how far back a time series reaches when no start_date is given
+var defaultTimeSeriesRange = map[string]func(end time.Time) time.Time{
+	models.IntervalHour:  func(end time.Time) time.Time { return end.Add(-48 * time.Hour) },
+	models.IntervalDay:   func(end time.Time) time.Time { return end.AddDate(0, 0, -30) },
+	models.IntervalWeek:  func(end time.Time) time.Time { return end.AddDate(0, 0, -7*26) },
+	models.IntervalMonth: func(end time.Time) time.Time { return end.AddDate(-1, 0, 0) },
+}
+
+// AnalyticsService handles business logic for experience analytics
+type AnalyticsService struct {
+	repo       *repository.AnalyticsRepository
+	keys       *encryption.Keyring       // Blind indexes user_identifier filters, may be nil
+	pseudonyms *encryption.Pseudonymizer // Pseudonymizes user_identifier filters, may be nil
+}
+
+// NewAnalyticsService creates a new analytics service
+func NewAnalyticsService(repo *repository.AnalyticsRepository, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer) *AnalyticsService {
+	return &AnalyticsService{repo: repo, keys: keys, pseudonyms: pseudonyms}
+}
+
+// GetMetrics computes statistics for the value_number of a field, plus the
+// requested survey metric (NPS, CSAT or CES)
+func (s *AnalyticsService) GetMetrics(ctx context.Context, req *models.MetricsRequest) (*models.MetricsResponse, error) {
+	if req.Mode == "" {
+		req.Mode = models.SearchModeFullText
+	}
+	req.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, req.UserIdentifier)
+
+	// Survey scores count the answers at or above their class thresholds
+	var thresholds []float64
+	var scaleMax float64
+	switch req.Metric {
+	case models.MetricNPS:
+		thresholds = []float64{models.NPSPromoterMin, models.NPSPassiveMin}
+	case models.MetricCSAT:
+		scaleMax = models.DefaultCSATScaleMax
+		if req.ScaleMax != nil {
+			scaleMax = *req.ScaleMax
+		}
+		thresholds = []float64{scaleMax - 1}
+	case models.MetricCES:
+		scaleMax = models.DefaultCESScaleMax
+		if req.ScaleMax != nil {
+			scaleMax = *req.ScaleMax
+		}
+		thresholds = []float64{scaleMax - 2}
+	}
+
+	metrics, atLeast, err := s.repo.Metrics(ctx, &req.ExperienceFilters, thresholds)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.FieldID != nil {
+		metrics.FieldID = *req.FieldID
+	}
+
+	for i := range metrics.Distribution {
+		metrics.Distribution[i].Percentage = percentage(metrics.Distribution[i].Count, metrics.Count)
+	}
+
+	switch req.Metric {
+	case models.MetricNPS:
+		metrics.NPS = computeNPS(atLeast[0], atLeast[1]-atLeast[0], metrics.Count)
+	case models.MetricCSAT:
+		metrics.CSAT = computeCSAT(atLeast[0], metrics.Count, scaleMax)
+	case models.MetricCES:
+		metrics.CES = computeCES(atLeast[0], metrics.Count, metrics.Mean, scaleMax)
+	}
+
+	return metrics, nil
+}
+
+// GetTimeSeries buckets the records matched by req over collected_at and computes
+// the requested metric per bucket. Every series covers the full time range, with
+// empty buckets filling the gaps.
+func (s *AnalyticsService) GetTimeSeries(ctx context.Context, req *models.TimeSeriesRequest) (*models.TimeSeriesResponse, error) {
+	if req.Mode == "" {
+		req.Mode = models.SearchModeFullText
+	}
+	req.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, req.UserIdentifier)
+	if req.Metric == "" {
+		req.Metric = models.MetricCount
+	}
+	if req.Location == nil {
+		req.Location = time.UTC
+	}
+	req.TimeZone = req.Location.String()
+
+	// Default to a chart sized window ending now
+	if req.EndDate == nil {
+		end := time.Now()
+		req.EndDate = &end
+	}
+	if req.StartDate == nil {
+		start := defaultTimeSeriesRange[req.Interval](*req.EndDate)
+		req.StartDate = &start
+	}
+
+	buckets := timeSeriesBuckets(*req.StartDate, *req.EndDate, req.Interval, req.Location)
+	if len(buckets) > maxTimeSeriesBuckets {
+		return nil, ErrTooManyBuckets
+	}
+
+	rows, err := s.repo.TimeSeries(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index values by group and bucket start
+	type groupValues struct {
+		group  *string
+		total  int
+		points map[int64]models.TimeSeriesBucket
+	}
+	groups := map[string]*groupValues{}
+	var order []*groupValues
+	for _, row := range rows {
+		key := "\x00" // Distinct from any string value, used for records without a group value
+		if row.Group != nil {
+			key = *row.Group
+		}
+		g, ok := groups[key]
+		if !ok {
+			g = &groupValues{group: row.Group, points: map[int64]models.TimeSeriesBucket{}}
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.total += row.Count
+		g.points[row.Bucket.Unix()] = row
+	}
+
+	// Keep the largest groups
+	sort.SliceStable(order, func(i, j int) bool { return order[i].total > order[j].total })
+	if len(order) > maxTimeSeries {
+		order = order[:maxTimeSeries]
+	}
+	if len(order) == 0 {
+		order = append(order, &groupValues{points: map[int64]models.TimeSeriesBucket{}})
+	}
+
+	series := make([]models.TimeSeries, 0, len(order))
+	for _, g := range order {
+		points := make([]models.TimeSeriesPoint, 0, len(buckets))
+		for _, bucket := range buckets {
+			point := models.TimeSeriesPoint{Bucket: bucket}
+			if row, ok := g.points[bucket.Unix()]; ok {
+				point.Count = row.Count
+				point.Value = row.Value
+			} else if req.Metric == models.MetricCount {
+				zero := 0.0
+				point.Value = &zero
+			}
+			points = append(points, point)
+		}
+		series = append(series, models.TimeSeries{Group: g.group, Points: points})
+	}
+
+	return &models.TimeSeriesResponse{
+		Interval: req.Interval,
+		TimeZone: req.TimeZone,
+		Metric:   req.Metric,
+		GroupBy:  req.GroupBy,
+		Series:   series,
+	}, nil
+}
+
+// GetCrosstab cross-tabulates the answers to two fields given in the same response,
+// with row percentages and a chi-square test of independence
+func (s *AnalyticsService) GetCrosstab(ctx context.Context, req *models.CrosstabRequest) (*models.CrosstabResponse, error) {
+	if req.Mode == "" {
+		req.Mode = models.SearchModeFullText
+	}
+	req.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, req.UserIdentifier)
+
+	counts, encryptedNotCounted, err := s.repo.Crosstab(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	crosstab, err := buildCrosstab(counts)
+	if err != nil {
+		return nil, err
+	}
+	crosstab.RowField = req.RowField
+	crosstab.ColumnField = req.ColumnField
+	crosstab.EncryptedNotCounted = encryptedNotCounted
+
+	return crosstab, nil
+}
+
+// buildCrosstab arranges answer pair counts into a table
+// Rows and columns are sorted by answer, numerically when all answers are numbers
+func buildCrosstab(counts []models.CrosstabCount) (*models.CrosstabResponse, error) {
+	rowTotals := map[string]int{}
+	columnTotals := map[string]int{}
+	cells := map[[2]string]int{}
+	total := 0
+	for _, c := range counts {
+		rowTotals[c.Row] += c.Count
+		columnTotals[c.Column] += c.Count
+		cells[[2]string{c.Row, c.Column}] += c.Count
+		total += c.Count
+	}
+
+	if len(rowTotals) > maxCrosstabValues || len(columnTotals) > maxCrosstabValues {
+		return nil, ErrTooManyCrosstabValues
+	}
+
+	rowValues := sortedAnswers(rowTotals)
+	columnValues := sortedAnswers(columnTotals)
+
+	crosstab := &models.CrosstabResponse{
+		Columns: make([]models.CrosstabColumn, 0, len(columnValues)),
+		Rows:    make([]models.CrosstabRow, 0, len(rowValues)),
+		Total:   total,
+	}
+	for _, column := range columnValues {
+		crosstab.Columns = append(crosstab.Columns, models.CrosstabColumn{Value: column, Total: columnTotals[column]})
+	}
+
+	observed := make([][]int, 0, len(rowValues))
+	for _, row := range rowValues {
+		r := models.CrosstabRow{Value: row, Total: rowTotals[row], Cells: make([]models.CrosstabCell, 0, len(columnValues))}
+		counts := make([]int, 0, len(columnValues))
+		for _, column := range columnValues {
+			count := cells[[2]string{row, column}]
+			r.Cells = append(r.Cells, models.CrosstabCell{Count: count, RowPercentage: percentage(count, r.Total)})
+			counts = append(counts, count)
+		}
+		crosstab.Rows = append(crosstab.Rows, r)
+		observed = append(observed, counts)
+	}
+
+	crosstab.ChiSquare = chiSquareTest(observed)
+
+	return crosstab, nil
+}
+
+// sortedAnswers returns the keys of totals, sorted numerically when all of them
+// are numbers and lexically otherwise
+func sortedAnswers(totals map[string]int) []string {
+	answers := make([]string, 0, len(totals))
+	numbers := make(map[string]float64, len(totals))
+	numeric := true
+	for answer := range totals {
+		answers = append(answers, answer)
+		if number, err := strconv.ParseFloat(answer, 64); err == nil {
+			numbers[answer] = number
+		} else {
+			numeric = false
+		}
+	}
+
+	if numeric {
+		sort.Slice(answers, func(i, j int) bool { return numbers[answers[i]] < numbers[answers[j]] })
+	} else {
+		sort.Strings(answers)
+	}
+
+	return answers
+}
+
+// timeSeriesBuckets returns the start of every bucket between start and end, inclusive
+// It stops after maxTimeSeriesBuckets+1 buckets so oversized ranges can be rejected cheaply
+func timeSeriesBuckets(start, end time.Time, interval string, loc *time.Location) []time.Time {
+	var buckets []time.Time
+	for bucket := truncateToBucket(start, interval, loc); !bucket.After(end); bucket = nextBucket(bucket, interval, loc) {
+		buckets = append(buckets, bucket)
+		if len(buckets) > maxTimeSeriesBuckets {
+			break
+		}
+	}
+	return buckets
+}
+
+// truncateToBucket returns the start of the bucket containing t, in loc
+// This matches PostgreSQL's date_trunc(interval, t, time zone), with weeks starting on Monday
+func truncateToBucket(t time.Time, interval string, loc *time.Location) time.Time {
+	t = t.In(loc)
+	switch interval {
+	case models.IntervalHour:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, loc)
+	case models.IntervalWeek:
+		daysSinceMonday := (int(t.Weekday()) + 6) % 7
+		return time.Date(t.Year(), t.Month(), t.Day()-daysSinceMonday, 0, 0, 0, 0, loc)
+	case models.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	}
+}
+
+// nextBucket returns the start of the bucket following the one starting at bucket
+func nextBucket(bucket time.Time, interval string, loc *time.Location) time.Time {
+	switch interval {
+	case models.IntervalHour:
+		return bucket.Add(time.Hour)
+	case models.IntervalWeek:
+		return time.Date(bucket.Year(), bucket.Month(), bucket.Day()+7, 0, 0, 0, 0, loc)
+	case models.IntervalMonth:
+		return time.Date(bucket.Year(), bucket.Month()+1, 1, 0, 0, 0, 0, loc)
+	default:
+		return time.Date(bucket.Year(), bucket.Month(), bucket.Day()+1, 0, 0, 0, 0, loc)
+	}
+}
+
+// computeNPS scores 0-10 answers from the number of promoters (9-10) and
+// passives (7-8); the remaining answers are detractors (0-6)
+func computeNPS(promoters, passives, total int) *models.NPSResult {
+	nps := models.NPSResult{Promoters: promoters, Passives: passives, Detractors: total - promoters - passives}
+
+	nps.PromotersPercentage = percentage(nps.Promoters, total)
+	nps.PassivesPercentage = percentage(nps.Passives, total)
+	nps.DetractorsPercentage = percentage(nps.Detractors, total)
+	nps.Score = round2(nps.PromotersPercentage - nps.DetractorsPercentage)
+
+	return &nps
+}
+
+// computeCSAT scores the answers in the top two points of the scale as satisfied
+func computeCSAT(satisfied, total int, scaleMax float64) *models.CSATResult {
+	return &models.CSATResult{Score: percentage(satisfied, total), Satisfied: satisfied, ScaleMax: scaleMax}
+}
+
+// computeCES scores effort as the average answer, with the answers in the top
+// three points of the scale counted as low effort
+func computeCES(lowEffort, total int, mean *float64, scaleMax float64) *models.CESResult {
+	ces := models.CESResult{LowEffort: lowEffort, LowEffortPercentage: percentage(lowEffort, total), ScaleMax: scaleMax}
+	if mean != nil {
+		ces.Score = round2(*mean)
+	}
+
+	return &ces
+}
+
+// percentage returns part as a percentage of total, rounded to two decimals
+func percentage(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round2(float64(part) * 100 / float64(total))
+}
+
+// round2 rounds to two decimals
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
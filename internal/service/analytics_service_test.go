@@ -0,0 +1,166 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestComputeNPS(t *testing.T) {
+	// 10 answers: 5 promoters, 2 passives and 3 detractors
+	nps := computeNPS(5, 2, 10)
+
+	assert.Equal(t, 5, nps.Promoters)
+	assert.Equal(t, 2, nps.Passives)
+	assert.Equal(t, 3, nps.Detractors)
+	assert.Equal(t, 50.0, nps.PromotersPercentage)
+	assert.Equal(t, 20.0, nps.PassivesPercentage)
+	assert.Equal(t, 30.0, nps.DetractorsPercentage)
+	assert.Equal(t, 20.0, nps.Score)
+}
+
+func TestComputeNPSEmpty(t *testing.T) {
+	nps := computeNPS(0, 0, 0)
+
+	assert.Equal(t, 0.0, nps.Score)
+	assert.Equal(t, 0, nps.Promoters)
+	assert.Equal(t, 0, nps.Detractors)
+}
+
+func TestComputeCSAT(t *testing.T) {
+	tests := []struct {
+		name      string
+		satisfied int
+		total     int
+		scaleMax  float64
+		wantScore float64
+	}{
+		{name: "top-2-box on a 1-5 scale", satisfied: 5, total: 8, scaleMax: 5, wantScore: 62.5},
+		{name: "top-2-box on a 1-10 scale", satisfied: 2, total: 3, scaleMax: 10, wantScore: 66.67},
+		{name: "no answers", satisfied: 0, total: 0, scaleMax: 5, wantScore: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			csat := computeCSAT(tt.satisfied, tt.total, tt.scaleMax)
+
+			assert.Equal(t, tt.satisfied, csat.Satisfied)
+			assert.Equal(t, tt.wantScore, csat.Score)
+			assert.Equal(t, tt.scaleMax, csat.ScaleMax)
+		})
+	}
+}
+
+func TestComputeCES(t *testing.T) {
+	mean := 4.5
+
+	ces := computeCES(2, 4, &mean, 7)
+
+	assert.Equal(t, 4.5, ces.Score)
+	assert.Equal(t, 2, ces.LowEffort)
+	assert.Equal(t, 50.0, ces.LowEffortPercentage)
+}
+
+func TestTruncateToBucket(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+
+	// Wednesday 2025-03-12 14:45:00 UTC
+	ts := time.Date(2025, 3, 12, 14, 45, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		interval string
+		loc      *time.Location
+		want     time.Time
+	}{
+		{"hour in UTC", models.IntervalHour, time.UTC, time.Date(2025, 3, 12, 14, 0, 0, 0, time.UTC)},
+		{"hour with half hour offset", models.IntervalHour, kolkata, time.Date(2025, 3, 12, 20, 0, 0, 0, kolkata)},
+		{"day in Berlin", models.IntervalDay, berlin, time.Date(2025, 3, 12, 0, 0, 0, 0, berlin)},
+		{"week starts on Monday", models.IntervalWeek, berlin, time.Date(2025, 3, 10, 0, 0, 0, 0, berlin)},
+		{"month", models.IntervalMonth, berlin, time.Date(2025, 3, 1, 0, 0, 0, 0, berlin)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateToBucket(ts, tt.interval, tt.loc)
+			assert.True(t, tt.want.Equal(got), "got %s, want %s", got, tt.want)
+		})
+	}
+}
+
+func TestTimeSeriesBucketsAcrossDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	// Berlin switches to summer time on 2025-03-30, making that day 23 hours long
+	start := time.Date(2025, 3, 29, 12, 0, 0, 0, berlin)
+	end := time.Date(2025, 3, 31, 12, 0, 0, 0, berlin)
+
+	buckets := timeSeriesBuckets(start, end, models.IntervalDay, berlin)
+
+	require.Len(t, buckets, 3)
+	for i, day := range []int{29, 30, 31} {
+		assert.True(t, time.Date(2025, 3, day, 0, 0, 0, 0, berlin).Equal(buckets[i]))
+	}
+	assert.Equal(t, 23*time.Hour, buckets[2].Sub(buckets[1]))
+}
+
+func TestTimeSeriesBucketsStopsAfterLimit(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	buckets := timeSeriesBuckets(start, end, models.IntervalHour, time.UTC)
+
+	assert.Len(t, buckets, maxTimeSeriesBuckets+1)
+}
+
+func TestBuildCrosstab(t *testing.T) {
+	counts := []models.CrosstabCount{
+		{Row: "pro", Column: "10", Count: 6},
+		{Row: "pro", Column: "9", Count: 2},
+		{Row: "free", Column: "9", Count: 1},
+		{Row: "free", Column: "3", Count: 3},
+	}
+
+	crosstab, err := buildCrosstab(counts)
+	require.NoError(t, err)
+
+	assert.Equal(t, 12, crosstab.Total)
+
+	// Numeric answers sort by value, text answers lexically
+	require.Len(t, crosstab.Columns, 3)
+	assert.Equal(t, models.CrosstabColumn{Value: "3", Total: 3}, crosstab.Columns[0])
+	assert.Equal(t, models.CrosstabColumn{Value: "9", Total: 3}, crosstab.Columns[1])
+	assert.Equal(t, models.CrosstabColumn{Value: "10", Total: 6}, crosstab.Columns[2])
+
+	require.Len(t, crosstab.Rows, 2)
+	assert.Equal(t, "free", crosstab.Rows[0].Value)
+	assert.Equal(t, 4, crosstab.Rows[0].Total)
+	assert.Equal(t, []models.CrosstabCell{
+		{Count: 3, RowPercentage: 75},
+		{Count: 1, RowPercentage: 25},
+		{Count: 0, RowPercentage: 0},
+	}, crosstab.Rows[0].Cells)
+	assert.Equal(t, "pro", crosstab.Rows[1].Value)
+	assert.Equal(t, 75.0, crosstab.Rows[1].Cells[2].RowPercentage)
+
+	require.NotNil(t, crosstab.ChiSquare)
+	assert.Equal(t, 2, crosstab.ChiSquare.DegreesOfFreedom)
+}
+
+func TestBuildCrosstabTooManyValues(t *testing.T) {
+	var counts []models.CrosstabCount
+	for i := 0; i <= maxCrosstabValues; i++ {
+		counts = append(counts, models.CrosstabCount{Row: "row", Column: strconv.Itoa(i), Count: 1})
+	}
+
+	_, err := buildCrosstab(counts)
+	assert.ErrorIs(t, err, ErrTooManyCrosstabValues)
+}
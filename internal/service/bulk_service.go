@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// bulkBatchSize limits how many records are changed per transaction. It equals
+// models.BulkSyncLimit, so requests run immediately change their records all or none.
+const bulkBatchSize = models.BulkSyncLimit
+
+// bulkJobTimeout is how long a running job may go without progress before
+// another worker takes it over
+const bulkJobTimeout = 15 * time.Minute
+
+// BulkService handles business logic for bulk updates and deletes of experience data
+type BulkService struct {
+	repo       *repository.ExperienceRepository
+	sources    *repository.SourceRepository
+	jobs       *repository.BulkJobRepository
+	keys       *encryption.Keyring       // Matches encrypted records by user_identifier, may be nil
+	pseudonyms *encryption.Pseudonymizer // Matches pseudonymized records by user_identifier, may be nil
+}
+
+// NewBulkService creates a new bulk service
+func NewBulkService(repo *repository.ExperienceRepository, sources *repository.SourceRepository, jobs *repository.BulkJobRepository, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer) *BulkService {
+	return &BulkService{repo: repo, sources: sources, jobs: jobs, keys: keys, pseudonyms: pseudonyms}
+}
+
+// BulkUpdate applies the patch of req to the records matching its filters
+func (s *BulkService) BulkUpdate(ctx context.Context, req *models.BulkExperiencesRequest) (*models.BulkResult, error) {
+	if err := validateBulkPatch(req.Patch); err != nil {
+		return nil, err
+	}
+
+	return s.run(ctx, models.BulkActionUpdate, req)
+}
+
+// BulkDelete moves the records matching the filters of req to the trash
+func (s *BulkService) BulkDelete(ctx context.Context, req *models.BulkExperiencesRequest) (*models.BulkResult, error) {
+	if req.Patch != nil {
+		return nil, invalidInput("patch", models.ValidationInvalidValue, "bulk deletes take no patch")
+	}
+
+	return s.run(ctx, models.BulkActionDelete, req)
+}
+
+// GetJob retrieves a bulk job by ID
+func (s *BulkService) GetJob(ctx context.Context, id uuid.UUID) (*models.BulkJob, error) {
+	return s.jobs.GetByID(ctx, id)
+}
+
+// run counts the records matching req and, unless it is a dry run or more
+// records match than allowed, changes them right away or creates a job when
+// req asks for one or more than models.BulkSyncLimit records match
+func (s *BulkService) run(ctx context.Context, action string, req *models.BulkExperiencesRequest) (*models.BulkResult, error) {
+	if err := validateBulkFilters(&req.ExperienceFilters); err != nil {
+		return nil, err
+	}
+
+	if req.MaxAffected < 0 {
+		return nil, invalidInput("max_affected", models.ValidationOutOfRange, "max_affected cannot be negative")
+	}
+	if req.MaxAffected == 0 {
+		req.MaxAffected = models.DefaultBulkMaxAffected
+	}
+
+	filters := req.ExperienceFilters
+	filters.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, filters.UserIdentifier)
+
+	matched, err := s.repo.CountMatching(ctx, &filters, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BulkResult{Matched: matched, DryRun: req.DryRun}
+	if req.DryRun {
+		return result, nil
+	}
+	if matched > req.MaxAffected {
+		return nil, tooManyMatches(req.MaxAffected)
+	}
+
+	job := &models.BulkJob{
+		Action:      action,
+		Filters:     req.ExperienceFilters,
+		Patch:       req.Patch,
+		Matched:     matched,
+		MaxAffected: req.MaxAffected,
+	}
+
+	if req.Async || matched > models.BulkSyncLimit {
+		if key := models.APIKeyFromContext(ctx); key != nil {
+			job.APIKeyID = &key.ID
+		}
+		result.Job, err = s.jobs.Create(ctx, job)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+
+	if err := s.process(ctx, job, nil); err != nil {
+		return nil, err
+	}
+	result.Affected = job.Affected
+
+	return result, nil
+}
+
+// RunJobs processes waiting bulk jobs one after the other until none is left
+// and returns the jobs it finished. A job stopped by an error fails, keeping
+// the changes of the batches processed before it.
+func (s *BulkService) RunJobs(ctx context.Context) ([]models.BulkJob, error) {
+	finished := []models.BulkJob{}
+	for ctx.Err() == nil {
+		now := time.Now()
+		job, err := s.jobs.Claim(ctx, now, now.Add(-bulkJobTimeout))
+		if err != nil {
+			return finished, err
+		}
+		if job == nil {
+			return finished, nil
+		}
+
+		// Changes are recorded in the history as made by the API key that created the job
+		jobCtx := ctx
+		if job.APIKeyID != nil {
+			jobCtx = models.ContextWithAPIKey(ctx, &models.APIKey{ID: *job.APIKeyID})
+		}
+
+		progress := func(job *models.BulkJob) error {
+			return s.jobs.Progress(ctx, job.ID, job.Affected, job.Cursor)
+		}
+		jobErr := s.process(jobCtx, job, progress)
+		if ctx.Err() != nil {
+			// Interrupted jobs stay running and are resumed once they time out
+			return finished, ctx.Err()
+		}
+
+		job.Status = models.BulkJobCompleted
+		if jobErr != nil {
+			message := jobErr.Error()
+			job.Status, job.Error = models.BulkJobFailed, &message
+		}
+		if err := s.jobs.Finish(ctx, job); err != nil {
+			return finished, err
+		}
+		finished = append(finished, *job)
+	}
+
+	return finished, ctx.Err()
+}
+
+// process changes the records matching job in batches, starting after its
+// cursor, and calls progress, when set, after every batch. It fails once more
+// records would change than the job allows.
+// Batches resumed after an interruption are safe to repeat: deleted records no
+// longer match, and updated ones only get the same patch again.
+func (s *BulkService) process(ctx context.Context, job *models.BulkJob, progress func(*models.BulkJob) error) error {
+	filters := job.Filters
+	filters.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, filters.UserIdentifier)
+
+	var prepare func(*models.ExperienceData) (*string, error)
+	if job.Action == models.BulkActionUpdate {
+		prepare = s.bulkPrepare(ctx, job.Patch)
+	}
+
+	for {
+		limit := min(bulkBatchSize, job.MaxAffected-job.Affected)
+		if limit <= 0 {
+			remaining, err := s.repo.CountMatching(ctx, &filters, job.Cursor)
+			if err != nil {
+				return err
+			}
+			if remaining > 0 {
+				return tooManyMatches(job.MaxAffected)
+			}
+			return nil
+		}
+
+		var n int
+		var cursor *uuid.UUID
+		var err error
+		if job.Action == models.BulkActionUpdate {
+			n, cursor, err = s.repo.BulkUpdateBatch(ctx, &filters, job.Patch, job.Cursor, limit, prepare)
+		} else {
+			n, cursor, err = s.repo.BulkDeleteBatch(ctx, &filters, job.Cursor, limit)
+		}
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return nil
+		}
+
+		job.Affected += n
+		job.Cursor = cursor
+		if progress != nil {
+			if err := progress(job); err != nil {
+				return err
+			}
+		}
+		if n < limit {
+			return nil
+		}
+	}
+}
+
+// bulkPrepare returns the check run on every record before patch is applied to
+// it, which returns the record's new natural key when patch changes its source
+// or field. Records cannot move into sources that would require them to be
+// encrypted or pseudonymized or their value_text to be scanned for personal
+// data differently, nor into fields a strict source does not register with
+// their field_type.
+func (s *BulkService) bulkPrepare(ctx context.Context, patch *models.BulkExperiencePatch) func(*models.ExperienceData) (*string, error) {
+	sourceChange := patch.SourceType != nil || patch.SourceID != nil || patch.FieldID != nil
+	lookups := map[[3]string]*models.SourceFieldLookup{}
+	lookupField := func(sourceType string, sourceID *string, fieldID string) (*models.SourceFieldLookup, error) {
+		if sourceID == nil {
+			return nil, nil
+		}
+		key := [3]string{sourceType, *sourceID, fieldID}
+		lookup, ok := lookups[key]
+		if !ok {
+			var err error
+			lookup, err = s.sources.LookupField(ctx, sourceType, *sourceID, fieldID)
+			if err != nil {
+				return nil, err
+			}
+			lookups[key] = lookup
+		}
+		return lookup, nil
+	}
+
+	return func(exp *models.ExperienceData) (*string, error) {
+		if !sourceChange {
+			return nil, nil
+		}
+
+		sourceType, sourceID, fieldID := exp.SourceType, exp.SourceID, exp.FieldID
+		if patch.SourceType != nil {
+			sourceType = *patch.SourceType
+		}
+		if patch.SourceID != nil {
+			sourceID = patch.SourceID
+		}
+		if patch.FieldID != nil {
+			fieldID = *patch.FieldID
+		}
+
+		lookup, err := lookupField(sourceType, sourceID, fieldID)
+		if err != nil {
+			return nil, err
+		}
+		// The PII mode the record was stored under only matters when the target scans value_text
+		var current *models.SourceFieldLookup
+		if piiMode(lookup) != models.PIIModeOff {
+			if current, err = lookupField(exp.SourceType, exp.SourceID, exp.FieldID); err != nil {
+				return nil, err
+			}
+		}
+		if err := checkBulkTarget(exp, piiMode(current), lookup, fieldID); err != nil {
+			return nil, err
+		}
+
+		if len(exp.NaturalKey) == 0 {
+			return nil, nil
+		}
+		key, err := naturalKey(exp.NaturalKey, naturalKeyValues{
+			SourceType:  sourceType,
+			SourceID:    sourceID,
+			FieldID:     fieldID,
+			ResponseID:  exp.ResponseID,
+			CollectedAt: &exp.CollectedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &key, nil
+	}
+}
+
+// piiMode returns the PII mode of the source of lookup, off when the source is
+// not registered
+func piiMode(lookup *models.SourceFieldLookup) string {
+	if lookup == nil || lookup.PIIMode == "" {
+		return models.PIIModeOff
+	}
+	return lookup.PIIMode
+}
+
+// checkBulkTarget rejects moving exp, stored under the PII mode currentPIIMode,
+// by a bulk update to the source and field of lookup, nil when the source is
+// not registered
+func checkBulkTarget(exp *models.ExperienceData, currentPIIMode string, lookup *models.SourceFieldLookup, fieldID string) error {
+	if lookup == nil {
+		return nil
+	}
+
+	if lookup.Encrypt && !exp.Encrypted {
+		return invalidValues(models.FieldError{
+			Field:   "patch",
+			Code:    models.ValidationInvalidValue,
+			Message: fmt.Sprintf("experience %s is not encrypted and cannot be moved into an encrypting source", exp.ID),
+		})
+	}
+
+	// Sealed identifiers have a blind index unless they are pseudonyms kept in the clear
+	clearIdentifier := exp.UserIdentifier != nil && !encryption.IsPseudonym(*exp.UserIdentifier)
+	if lookup.Pseudonymize && (clearIdentifier || (exp.Sealed != nil && exp.Sealed.UserIdentifierIndex != nil)) {
+		return invalidValues(models.FieldError{
+			Field:   "patch",
+			Code:    models.ValidationInvalidValue,
+			Message: fmt.Sprintf("experience %s has a user_identifier that is not pseudonymized and cannot be moved into a pseudonymizing source", exp.ID),
+		})
+	}
+
+	// value_text was scanned under the PII mode of the current source, so it may
+	// hold personal data the target rejects or masks, and pii_types would not
+	// match what the target records
+	if mode := piiMode(lookup); mode != models.PIIModeOff && mode != currentPIIMode {
+		return invalidValues(models.FieldError{
+			Field:   "patch",
+			Code:    models.ValidationInvalidValue,
+			Message: fmt.Sprintf("experience %s was stored with pii_mode %s and cannot be moved into a source with pii_mode %s", exp.ID, currentPIIMode, mode),
+		})
+	}
+
+	if lookup.Strict && lookup.Field == nil {
+		return invalidValues(models.FieldError{
+			Field:   "patch.field_id",
+			Code:    models.ValidationUnknownField,
+			Message: fmt.Sprintf("field_id %q is not registered for the source", fieldID),
+		})
+	}
+	if lookup.Strict && lookup.Field.FieldType != exp.FieldType {
+		return invalidValues(models.FieldError{
+			Field:   "patch.field_id",
+			Code:    models.ValidationTypeMismatch,
+			Message: fmt.Sprintf("experience %s has field_type %s, but field_id %q is registered as %s", exp.ID, exp.FieldType, fieldID, lookup.Field.FieldType),
+		})
+	}
+
+	return nil
+}
+
+// tooManyMatches returns the error of a bulk request matching more records than maxAffected
+func tooManyMatches(maxAffected int) error {
+	return invalidValues(models.FieldError{
+		Field:   "max_affected",
+		Code:    models.ValidationOutOfRange,
+		Message: fmt.Sprintf("more than %d records match, narrow the filters or raise max_affected", maxAffected),
+	})
+}
+
+// validateBulkFilters requires at least one filter, so a bulk request cannot
+// change every record by accident, and validates the search mode
+func validateBulkFilters(filters *models.ExperienceFilters) error {
+	hasQuery := filters.Query != nil && strings.TrimSpace(*filters.Query) != ""
+	if !hasQuery && filters.SourceType == nil && filters.SourceID == nil && filters.FieldID == nil &&
+		filters.FieldType == nil && filters.UserIdentifier == nil && filters.ResponseID == nil &&
+		filters.PIIType == nil && filters.StartDate == nil && filters.EndDate == nil {
+		return invalidInput("filters", models.ValidationRequired, "at least one filter is required")
+	}
+
+	return validateSearchMode(filters)
+}
+
+// validateSearchMode validates the search mode and similarity threshold of filters
+func validateSearchMode(filters *models.ExperienceFilters) error {
+	if filters.Mode != "" && filters.Mode != models.SearchModeFullText && filters.Mode != models.SearchModeFuzzy {
+		return invalidInput("mode", models.ValidationInvalidValue, "mode must be fulltext or fuzzy")
+	}
+	if filters.MinSimilarity < 0 || filters.MinSimilarity > 1 {
+		return invalidInput("min_similarity", models.ValidationOutOfRange, "min_similarity must be greater than 0 and at most 1")
+	}
+
+	return nil
+}
+
+// validateBulkPatch requires a patch setting at least one field, none of the
+// required ones to an empty string, and metadata to a JSON object
+func validateBulkPatch(patch *models.BulkExperiencePatch) error {
+	if patch == nil || (patch.SourceType == nil && patch.SourceID == nil && patch.SourceName == nil &&
+		patch.FieldID == nil && patch.FieldLabel == nil && patch.Language == nil && patch.Metadata == nil) {
+		return invalidInput("patch", models.ValidationRequired, "patch must set at least one field")
+	}
+
+	if patch.SourceType != nil && *patch.SourceType == "" {
+		return invalidInput("patch.source_type", models.ValidationRequired, "source_type cannot be empty")
+	}
+	if patch.FieldID != nil && *patch.FieldID == "" {
+		return invalidInput("patch.field_id", models.ValidationRequired, "field_id cannot be empty")
+	}
+
+	if patch.Metadata != nil {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(patch.Metadata, &object); err != nil || object == nil {
+			return invalidInput("patch.metadata", models.ValidationInvalidValue, "metadata must be a JSON object")
+		}
+	}
+
+	return nil
+}
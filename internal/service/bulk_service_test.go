@@ -0,0 +1,81 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestValidateBulkFilters(t *testing.T) {
+	blank := "  "
+	assert.ErrorIs(t, validateBulkFilters(&models.ExperienceFilters{}), models.ErrInvalidInput)
+	assert.ErrorIs(t, validateBulkFilters(&models.ExperienceFilters{Query: &blank}), models.ErrInvalidInput)
+
+	sourceID := "spam-source"
+	assert.NoError(t, validateBulkFilters(&models.ExperienceFilters{SourceID: &sourceID}))
+	assert.ErrorIs(t, validateBulkFilters(&models.ExperienceFilters{SourceID: &sourceID, Mode: "exact"}), models.ErrInvalidInput)
+	assert.ErrorIs(t, validateBulkFilters(&models.ExperienceFilters{SourceID: &sourceID, MinSimilarity: 1.5}), models.ErrInvalidInput)
+}
+
+func TestValidateBulkPatch(t *testing.T) {
+	assert.ErrorIs(t, validateBulkPatch(nil), models.ErrInvalidInput)
+	assert.ErrorIs(t, validateBulkPatch(&models.BulkExperiencePatch{}), models.ErrInvalidInput)
+
+	fieldID := "nps_score"
+	assert.NoError(t, validateBulkPatch(&models.BulkExperiencePatch{FieldID: &fieldID}))
+
+	empty := ""
+	err := validateBulkPatch(&models.BulkExperiencePatch{FieldID: &empty})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "patch.field_id", validationErr.Errors[0].Field)
+
+	assert.NoError(t, validateBulkPatch(&models.BulkExperiencePatch{Metadata: json.RawMessage(`{"sentiment": null}`)}))
+	assert.ErrorIs(t, validateBulkPatch(&models.BulkExperiencePatch{Metadata: json.RawMessage(`null`)}), models.ErrInvalidInput)
+	assert.ErrorIs(t, validateBulkPatch(&models.BulkExperiencePatch{Metadata: json.RawMessage(`["spam"]`)}), models.ErrInvalidInput)
+}
+
+func TestCheckBulkTarget(t *testing.T) {
+	userIdentifier := "user@example.com"
+	pseudonym := "hmac:" + strings.Repeat("0", 64)
+	lookalike := "hmac:0123"
+	exp := &models.ExperienceData{FieldType: "nps", UserIdentifier: &userIdentifier}
+
+	assert.NoError(t, checkBulkTarget(exp, models.PIIModeOff, nil, "nps"))
+	assert.NoError(t, checkBulkTarget(exp, models.PIIModeOff, &models.SourceFieldLookup{}, "nps"))
+
+	// Plaintext records cannot move into sources protecting their values
+	assert.ErrorIs(t, checkBulkTarget(exp, models.PIIModeOff, &models.SourceFieldLookup{Encrypt: true}, "nps"), models.ErrValidation)
+	assert.ErrorIs(t, checkBulkTarget(exp, models.PIIModeOff, &models.SourceFieldLookup{Pseudonymize: true}, "nps"), models.ErrValidation)
+
+	pseudonymized := &models.ExperienceData{FieldType: "nps", UserIdentifier: &pseudonym}
+	assert.NoError(t, checkBulkTarget(pseudonymized, models.PIIModeOff, &models.SourceFieldLookup{Pseudonymize: true}, "nps"))
+	unhashed := &models.ExperienceData{FieldType: "nps", UserIdentifier: &lookalike}
+	assert.ErrorIs(t, checkBulkTarget(unhashed, models.PIIModeOff, &models.SourceFieldLookup{Pseudonymize: true}, "nps"), models.ErrValidation)
+
+	sealed := &models.ExperienceData{FieldType: "nps", Encrypted: true, Sealed: &models.SealedValues{UserIdentifierIndex: []byte{1}}}
+	assert.NoError(t, checkBulkTarget(sealed, models.PIIModeOff, &models.SourceFieldLookup{Encrypt: true}, "nps"))
+	assert.ErrorIs(t, checkBulkTarget(sealed, models.PIIModeOff, &models.SourceFieldLookup{Encrypt: true, Pseudonymize: true}, "nps"), models.ErrValidation)
+
+	// value_text must have been scanned under the PII mode of the target
+	masking := &models.SourceFieldLookup{PIIMode: models.PIIModeMask}
+	assert.ErrorIs(t, checkBulkTarget(exp, models.PIIModeOff, masking, "nps"), models.ErrValidation)
+	assert.ErrorIs(t, checkBulkTarget(exp, models.PIIModeFlag, masking, "nps"), models.ErrValidation)
+	assert.ErrorIs(t, checkBulkTarget(exp, models.PIIModeOff, &models.SourceFieldLookup{PIIMode: models.PIIModeFlag}, "nps"), models.ErrValidation)
+	assert.NoError(t, checkBulkTarget(exp, models.PIIModeMask, masking, "nps"))
+	assert.NoError(t, checkBulkTarget(exp, models.PIIModeReject, &models.SourceFieldLookup{PIIMode: models.PIIModeOff}, "nps"))
+	assert.Equal(t, models.PIIModeOff, piiMode(nil))
+	assert.Equal(t, models.PIIModeOff, piiMode(&models.SourceFieldLookup{}))
+
+	// Strict sources only take registered fields of the same type
+	strict := &models.SourceFieldLookup{Strict: true}
+	assert.ErrorIs(t, checkBulkTarget(exp, models.PIIModeOff, strict, "nps"), models.ErrValidation)
+	strict.Field = &models.SourceField{FieldType: "rating"}
+	assert.ErrorIs(t, checkBulkTarget(exp, models.PIIModeOff, strict, "nps"), models.ErrValidation)
+	strict.Field.FieldType = "nps"
+	assert.NoError(t, checkBulkTarget(exp, models.PIIModeOff, strict, "nps"))
+}
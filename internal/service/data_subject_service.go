@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"encoding/hex"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// DataSubjectService handles business logic for data subject requests, such as
+// GDPR access and erasure requests of the person identified by a user_identifier
+type DataSubjectService struct {
+	repo       *repository.DataSubjectRepository
+	keys       *encryption.Keyring       // Finds and decrypts records of encrypting sources, may be nil
+	pseudonyms *encryption.Pseudonymizer // Finds records of pseudonymizing sources, may be nil
+}
+
+// NewDataSubjectService creates a new data subject service
+func NewDataSubjectService(repo *repository.DataSubjectRepository, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer) *DataSubjectService {
+	return &DataSubjectService{repo: repo, keys: keys, pseudonyms: pseudonyms}
+}
+
+// ExportDataSubject retrieves every record of a data subject and their history
+func (s *DataSubjectService) ExportDataSubject(ctx context.Context, userIdentifier string) (*models.DataSubjectExport, error) {
+	if userIdentifier == "" {
+		return nil, invalidInput("user_identifier", models.ValidationRequired, "user_identifier is required")
+	}
+
+	export, err := s.repo.Export(ctx, userIdentifier, userIdentifierMatch(s.keys, s.pseudonyms, &userIdentifier))
+	if err != nil {
+		return nil, err
+	}
+	if err := openAll(s.keys, export.Experiences); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// EraseDataSubject deletes or anonymizes every record of a data subject and
+// returns the stored erasure receipt
+// Erasing a data subject without records still stores a receipt, so repeated
+// requests are recorded as well
+func (s *DataSubjectService) EraseDataSubject(ctx context.Context, req *models.EraseDataSubjectRequest) (*models.ErasureReceipt, error) {
+	if req.UserIdentifier == "" {
+		return nil, invalidInput("user_identifier", models.ValidationRequired, "user_identifier is required")
+	}
+
+	if req.Mode == "" {
+		req.Mode = models.ErasureModeDelete
+	}
+	if req.Mode != models.ErasureModeDelete && req.Mode != models.ErasureModeAnonymize {
+		return nil, invalidInput("mode", models.ValidationInvalidValue, "mode must be delete or anonymize")
+	}
+
+	receipt := &models.ErasureReceipt{
+		UserIdentifierHash: HashUserIdentifier(s.keys, req.UserIdentifier),
+		Mode:               req.Mode,
+	}
+	if key := models.APIKeyFromContext(ctx); key != nil {
+		receipt.APIKeyID = &key.ID
+	}
+
+	if err := s.repo.Erase(ctx, receipt, req.UserIdentifier, userIdentifierMatch(s.keys, s.pseudonyms, &req.UserIdentifier)); err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// GetErasureReceipt retrieves the receipt of a data subject erasure
+func (s *DataSubjectService) GetErasureReceipt(ctx context.Context, id uuid.UUID) (*models.ErasureReceipt, error) {
+	return s.repo.GetErasureReceipt(ctx, id)
+}
+
+// HashUserIdentifier returns the hash stored in erasure receipts, the hex
+// encoded blind index of userIdentifier, or nil when no keys are configured
+// The hash is keyed: a plain hash of an email address or user ID is reversed by
+// hashing a list of candidates.
+func HashUserIdentifier(keys *encryption.Keyring, userIdentifier string) *string {
+	if keys == nil {
+		return nil
+	}
+	hash := hex.EncodeToString(keys.Index(userIdentifier))
+	return &hash
+}
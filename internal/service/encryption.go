@@ -0,0 +1,144 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// sensitiveValues are the columns of a record sealed for encrypting sources
+type sensitiveValues struct {
+	ValueText      *string         `json:"value_text,omitempty"`
+	ValueJSON      json.RawMessage `json:"value_json,omitempty"`
+	UserIdentifier *string         `json:"user_identifier,omitempty"`
+}
+
+// errNoEncryptionKeys is returned when a record must be sealed or opened but no keys are configured
+var errNoEncryptionKeys = invalidValues(keyNotConfigured("encrypt", "ENCRYPTION_KEYS"))
+
+// Columns holding sealed values, which are bound to their record and column
+const (
+	sealedValuesColumn = "encrypted_values"
+	vaultColumn        = "value_text_vault"
+)
+
+// recordContext returns the additional data binding a value sealed in column
+// to the record with the given ID, so it cannot be copied to another record or
+// column and still open
+func recordContext(id uuid.UUID, column string) []byte {
+	return []byte(id.String() + "/" + column)
+}
+
+// sealValues encrypts the sensitive values of the record with the given ID with keys
+func sealValues(keys *encryption.Keyring, id uuid.UUID, values sensitiveValues) (*models.SealedValues, error) {
+	if keys == nil {
+		return nil, errNoEncryptionKeys
+	}
+
+	plaintext, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode values: %w", err)
+	}
+
+	envelope, err := keys.Seal(plaintext, recordContext(id, sealedValuesColumn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt values: %w", err)
+	}
+
+	return &models.SealedValues{
+		Ciphertext:          envelope.Ciphertext,
+		DataKey:             envelope.DataKey,
+		KeyVersion:          envelope.KeyVersion,
+		UserIdentifierIndex: userIdentifierIndex(keys, values.UserIdentifier),
+	}, nil
+}
+
+// sealRecord seals the sensitive values of a record of an encrypting source and
+// returns the user_identifier to store in the clear: a pseudonym is not sealed,
+// so pseudonymized records can still be grouped by person.
+func sealRecord(keys *encryption.Keyring, id uuid.UUID, values sensitiveValues) (*models.SealedValues, *string, error) {
+	var clearUserIdentifier *string
+	if values.UserIdentifier != nil && encryption.IsPseudonym(*values.UserIdentifier) {
+		clearUserIdentifier, values.UserIdentifier = values.UserIdentifier, nil
+	}
+
+	sealed, err := sealValues(keys, id, values)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sealed, clearUserIdentifier, nil
+}
+
+// openSealed decrypts the sealed values of the record with the given ID
+func openSealed(keys *encryption.Keyring, id uuid.UUID, sealed *models.SealedValues) (*sensitiveValues, error) {
+	if keys == nil {
+		return nil, errNoEncryptionKeys
+	}
+
+	plaintext, err := keys.Open(&encryption.Envelope{
+		Ciphertext: sealed.Ciphertext,
+		DataKey:    sealed.DataKey,
+		KeyVersion: sealed.KeyVersion,
+	}, recordContext(id, sealedValuesColumn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt values: %w", err)
+	}
+
+	var values sensitiveValues
+	if err := json.Unmarshal(plaintext, &values); err != nil {
+		return nil, fmt.Errorf("failed to decode values: %w", err)
+	}
+	return &values, nil
+}
+
+// openValues decrypts the sealed values of exp into its value_text, value_json
+// and user_identifier; records that are not encrypted are left unchanged
+func openValues(keys *encryption.Keyring, exp *models.ExperienceData) error {
+	if exp.Sealed == nil {
+		return nil
+	}
+
+	values, err := openSealed(keys, exp.ID, exp.Sealed)
+	if err != nil {
+		return fmt.Errorf("experience %s: %w", exp.ID, err)
+	}
+
+	exp.ValueText, exp.ValueJSON = values.ValueText, values.ValueJSON
+	// Pseudonyms are not sealed, they are kept in the clear
+	if values.UserIdentifier != nil {
+		exp.UserIdentifier = values.UserIdentifier
+	}
+	exp.Sealed = nil
+	return nil
+}
+
+// openAll decrypts the sealed values of every record in experiences
+func openAll(keys *encryption.Keyring, experiences []models.ExperienceData) error {
+	for i := range experiences {
+		if err := openValues(keys, &experiences[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resealValues re-encrypts sealed values under a fresh data key and the current key version
+func resealValues(keys *encryption.Keyring, id uuid.UUID, sealed *models.SealedValues) (*models.SealedValues, error) {
+	values, err := openSealed(keys, id, sealed)
+	if err != nil {
+		return nil, err
+	}
+	return sealValues(keys, id, *values)
+}
+
+// userIdentifierIndex returns the blind index matching the encrypted
+// user_identifier of records, nil when keys or userIdentifier is nil
+func userIdentifierIndex(keys *encryption.Keyring, userIdentifier *string) []byte {
+	if keys == nil || userIdentifier == nil {
+		return nil
+	}
+	return keys.Index(*userIdentifier)
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+func testKeyring(t *testing.T, current int) *encryption.Keyring {
+	keys, err := encryption.NewKeyring(map[int][]byte{
+		1: bytes.Repeat([]byte{1}, encryption.KeySize),
+		2: bytes.Repeat([]byte{2}, encryption.KeySize),
+	}, current, bytes.Repeat([]byte{3}, encryption.KeySize))
+	require.NoError(t, err)
+	return keys
+}
+
+func TestSealValues(t *testing.T) {
+	keys := testKeyring(t, 1)
+	text := "Very happy with the support"
+	user := "user_42"
+	id := uuid.New()
+
+	sealed, err := sealValues(keys, id, sensitiveValues{
+		ValueText:      &text,
+		ValueJSON:      json.RawMessage(`["a","b"]`),
+		UserIdentifier: &user,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, sealed.KeyVersion)
+	assert.NotContains(t, string(sealed.Ciphertext), text)
+	assert.Equal(t, userIdentifierIndex(keys, &user), sealed.UserIdentifierIndex)
+
+	exp := &models.ExperienceData{ID: id, Sealed: sealed, Encrypted: true}
+	require.NoError(t, openValues(keys, exp))
+	assert.Equal(t, text, *exp.ValueText)
+	assert.JSONEq(t, `["a","b"]`, string(exp.ValueJSON))
+	assert.Equal(t, user, *exp.UserIdentifier)
+	assert.Nil(t, exp.Sealed)
+	assert.True(t, exp.Encrypted)
+
+	// Sealed values copied to another record do not open there
+	copied := &models.ExperienceData{ID: uuid.New(), Sealed: sealed, Encrypted: true}
+	assert.ErrorIs(t, openValues(keys, copied), encryption.ErrInvalidCiphertext)
+}
+
+func TestSealValuesWithoutUserIdentifier(t *testing.T) {
+	text := "No identifier"
+
+	sealed, err := sealValues(testKeyring(t, 1), uuid.New(), sensitiveValues{ValueText: &text})
+	require.NoError(t, err)
+	assert.Nil(t, sealed.UserIdentifierIndex)
+}
+
+func TestSealValuesWithoutKeys(t *testing.T) {
+	_, err := sealValues(nil, uuid.New(), sensitiveValues{})
+	assert.ErrorIs(t, err, errNoEncryptionKeys)
+
+	// Records that are not encrypted open without keys
+	text := "plain"
+	exp := &models.ExperienceData{ValueText: &text}
+	require.NoError(t, openValues(nil, exp))
+	assert.Equal(t, "plain", *exp.ValueText)
+}
+
+func TestResealValues(t *testing.T) {
+	text := "Rotated"
+	user := "user_7"
+	id := uuid.New()
+
+	sealed, err := sealValues(testKeyring(t, 1), id, sensitiveValues{ValueText: &text, UserIdentifier: &user})
+	require.NoError(t, err)
+
+	rotated := testKeyring(t, 2)
+	resealed, err := resealValues(rotated, id, sealed)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resealed.KeyVersion)
+	assert.NotEqual(t, sealed.DataKey, resealed.DataKey)
+	// The index key does not rotate, so user_identifier lookups keep matching
+	assert.Equal(t, sealed.UserIdentifierIndex, resealed.UserIdentifierIndex)
+
+	values, err := openSealed(rotated, id, resealed)
+	require.NoError(t, err)
+	assert.Equal(t, text, *values.ValueText)
+	assert.Equal(t, user, *values.UserIdentifier)
+}
@@ -0,0 +1,72 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// ValidationError is returned when request fields are missing or hold values
+// that are not acceptable. It lists the offending fields and wraps Err, either
+// models.ErrInvalidInput or models.ErrValidation.
+type ValidationError struct {
+	Err    error
+	Errors []models.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, 0, len(e.Errors))
+	for _, fieldErr := range e.Errors {
+		messages = append(messages, fieldErr.Message)
+	}
+	return strings.Join(messages, "; ")
+}
+
+func (e *ValidationError) Unwrap() error {
+	if e.Err == nil {
+		return models.ErrValidation
+	}
+	return e.Err
+}
+
+// invalidInput returns a ValidationError for a missing or malformed request field
+func invalidInput(field, code, message string) error {
+	return &ValidationError{
+		Err:    models.ErrInvalidInput,
+		Errors: []models.FieldError{{Field: field, Code: code, Message: message}},
+	}
+}
+
+// invalidValues returns a ValidationError for request fields holding values that are not acceptable
+func invalidValues(fieldErrors ...models.FieldError) error {
+	return &ValidationError{Err: models.ErrValidation, Errors: fieldErrors}
+}
+
+// keyNotConfigured describes a source setting that needs the key of the
+// environment variable env, which is not configured
+func keyNotConfigured(field, env string) models.FieldError {
+	return models.FieldError{
+		Field:   field,
+		Code:    models.ValidationKeyNotConfigured,
+		Message: fmt.Sprintf("%s requires %s, which is not configured", field, env),
+	}
+}
+
+// inRecord prefixes the fields of a ValidationError with the index of the
+// record of a batch they belong to, e.g. [2].value_number. Other errors are
+// returned unchanged.
+func inRecord(index int, err error) error {
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		return err
+	}
+
+	fieldErrors := make([]models.FieldError, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fieldErr.Field = fmt.Sprintf("[%d].%s", index, fieldErr.Field)
+		fieldErrors = append(fieldErrors, fieldErr)
+	}
+	return &ValidationError{Err: validationErr.Err, Errors: fieldErrors}
+}
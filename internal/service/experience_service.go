@@ -2,35 +2,221 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/xernobyl/formbricks_worktrial/internal/models"
 	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
 )
 
+// maxUpdateAttempts limits how often an update derived from the stored record
+// is retried when concurrent writes change the record
+const maxUpdateAttempts = 3
+
 // ExperienceService handles business logic for experience data
 type ExperienceService struct {
-	repo *repository.ExperienceRepository
+	repo       *repository.ExperienceRepository
+	sources    *repository.SourceRepository
+	vault      *encryption.Cipher        // Seals masked value_text, nil when no vault key is configured
+	keys       *encryption.Keyring       // Seals values of encrypting sources, nil when no keys are configured
+	pseudonyms *encryption.Pseudonymizer // Hashes user_identifier of pseudonymizing sources, nil when no key is configured
 }
 
 // NewExperienceService creates a new experience service
-func NewExperienceService(repo *repository.ExperienceRepository) *ExperienceService {
-	return &ExperienceService{repo: repo}
+// vault, keys and pseudonyms may be nil, in which case sources cannot keep masked
+// originals, encrypt their records or pseudonymize user identifiers.
+func NewExperienceService(repo *repository.ExperienceRepository, sources *repository.SourceRepository, vault *encryption.Cipher, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer) *ExperienceService {
+	return &ExperienceService{repo: repo, sources: sources, vault: vault, keys: keys, pseudonyms: pseudonyms}
 }
 
 // CreateExperience creates a new experience data record
 func (s *ExperienceService) CreateExperience(ctx context.Context, req *models.CreateExperienceRequest) (*models.ExperienceData, error) {
+	if err := s.prepareCreate(ctx, req); err != nil {
+		return nil, err
+	}
+
+	return s.opened(s.repo.Create(ctx, req))
+}
+
+// prepareCreate validates a new record and applies the PII handling,
+// pseudonymization and encryption of its source to req
+// req is assigned a new ID unless it has one, as sealed values are bound to it.
+func (s *ExperienceService) prepareCreate(ctx context.Context, req *models.CreateExperienceRequest) error {
 	if err := s.validateCreateRequest(req); err != nil {
+		return err
+	}
+	if req.ID == uuid.Nil {
+		req.ID = uuid.New()
+	}
+
+	lookup, err := s.checkRegisteredField(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	scan, err := scanPII(lookup, req.ID, req.ValueText, s.vault)
+	if err != nil {
+		return err
+	}
+	req.ValueText, req.PIITypes, req.ValueTextVault = scan.Text, scan.Types, scan.Vault
+
+	if req.UserIdentifier != nil && lookup != nil && lookup.Pseudonymize {
+		pseudonym, err := pseudonymize(s.pseudonyms, *req.UserIdentifier)
+		if err != nil {
+			return err
+		}
+		req.UserIdentifier = &pseudonym
+	}
+
+	if lookup != nil && lookup.Encrypt {
+		req.Sealed, req.UserIdentifier, err = sealRecord(s.keys, req.ID, sensitiveValues{
+			ValueText:      req.ValueText,
+			ValueJSON:      req.ValueJSON,
+			UserIdentifier: req.UserIdentifier,
+		})
+		if err != nil {
+			return err
+		}
+		req.ValueText, req.ValueJSON = nil, nil
+	}
+
+	return nil
+}
+
+// UpsertExperiences creates the records of req, or replaces the live record
+// with the same natural key, all or none. Records are validated and handled
+// like those created one by one; validation errors name the offending record,
+// e.g. [2].value_number.
+func (s *ExperienceService) UpsertExperiences(ctx context.Context, req *models.UpsertExperiencesRequest) (*models.UpsertExperiencesResponse, error) {
+	fields, err := naturalKeyFields(req.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Records) == 0 {
+		return nil, invalidInput("records", models.ValidationRequired, "at least one record is required")
+	}
+	if len(req.Records) > models.MaxUpsertRecords {
+		return nil, invalidInput("records", models.ValidationOutOfRange,
+			fmt.Sprintf("at most %d records can be upserted at once", models.MaxUpsertRecords))
+	}
+
+	keys := make([]string, len(req.Records))
+	for i := range req.Records {
+		record := &req.Records[i]
+		key, err := naturalKey(fields, naturalKeyValues{
+			SourceType:  record.SourceType,
+			SourceID:    record.SourceID,
+			FieldID:     record.FieldID,
+			ResponseID:  record.ResponseID,
+			CollectedAt: record.CollectedAt,
+		})
+		if err != nil {
+			return nil, inRecord(i, err)
+		}
+		keys[i] = key
+	}
+
+	// Replaced records keep their ID, which their sealed values are bound to.
+	// Later records of the batch replace earlier ones with the same key.
+	ids, err := s.repo.LiveIDsByNaturalKey(ctx, keys)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range req.Records {
+		record := &req.Records[i]
+		record.Strict = record.Strict || req.Strict
+		record.ID = ids[keys[i]]
+		if err := s.prepareCreate(ctx, record); err != nil {
+			return nil, inRecord(i, err)
+		}
+		record.NaturalKey = &keys[i]
+		ids[keys[i]] = record.ID
+	}
+
+	results, err := s.repo.Upsert(ctx, fields, req.Records)
+	if err != nil {
 		return nil, err
 	}
 
-	return s.repo.Create(ctx, req)
+	resp := &models.UpsertExperiencesResponse{Data: results}
+	for i := range results {
+		if err := openValues(s.keys, &results[i].ExperienceData); err != nil {
+			return nil, err
+		}
+		if results[i].Result == models.UpsertResultCreated {
+			resp.Created++
+		} else {
+			resp.Updated++
+		}
+	}
+
+	return resp, nil
+}
+
+// opened decrypts the values of exp, passing on err
+func (s *ExperienceService) opened(exp *models.ExperienceData, err error) (*models.ExperienceData, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err := openValues(s.keys, exp); err != nil {
+		return nil, err
+	}
+	return exp, nil
 }
 
 // GetExperience retrieves a single experience by ID
 func (s *ExperienceService) GetExperience(ctx context.Context, id uuid.UUID) (*models.ExperienceData, error) {
-	return s.repo.GetByID(ctx, id)
+	return s.opened(s.repo.GetByID(ctx, id))
+}
+
+// LookupExperiences retrieves the live records with the IDs of req, in the
+// order they were requested, and reports the IDs without one
+func (s *ExperienceService) LookupExperiences(ctx context.Context, req *models.LookupExperiencesRequest) (*models.LookupExperiencesResponse, error) {
+	if len(req.IDs) == 0 {
+		return nil, invalidInput("ids", models.ValidationRequired, "at least one id is required")
+	}
+	if len(req.IDs) > models.MaxLookupIDs {
+		return nil, invalidInput("ids", models.ValidationOutOfRange,
+			fmt.Sprintf("at most %d records can be looked up at once", models.MaxLookupIDs))
+	}
+
+	experiences, err := s.repo.GetByIDs(ctx, req.IDs, req.Fields)
+	if err != nil {
+		return nil, err
+	}
+	if err := openAll(s.keys, experiences); err != nil {
+		return nil, err
+	}
+
+	found := make(map[uuid.UUID]models.ExperienceData, len(experiences))
+	for _, exp := range experiences {
+		found[exp.ID] = exp
+	}
+
+	resp := &models.LookupExperiencesResponse{
+		Data:    make([]models.ExperienceData, 0, len(experiences)),
+		Missing: []uuid.UUID{},
+	}
+	seen := make(map[uuid.UUID]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if exp, ok := found[id]; ok {
+			resp.Data = append(resp.Data, exp)
+		} else {
+			resp.Missing = append(resp.Missing, id)
+		}
+	}
+
+	return resp, nil
 }
 
 // ListExperiences retrieves a list of experiences with optional filters
@@ -41,8 +227,35 @@ func (s *ExperienceService) ListExperiences(ctx context.Context, filters *models
 	if filters.Limit > 1000 {
 		filters.Limit = 1000 // Max limit
 	}
+	filters.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, filters.UserIdentifier)
+
+	experiences, err := s.repo.List(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+	if err := openAll(s.keys, experiences); err != nil {
+		return nil, err
+	}
+
+	return experiences, nil
+}
+
+// StreamExperiences calls each with every record matching filters, decrypted,
+// as it is read, for responses too large to hold in memory. Unlike
+// ListExperiences, the limit is optional and not capped. When keys is set, it is
+// called first with the sorted top-level metadata keys of the records.
+func (s *ExperienceService) StreamExperiences(ctx context.Context, filters *models.ListExperiencesFilters, keys func([]string) error, each func(*models.ExperienceData) error) error {
+	if filters.Limit < 0 {
+		filters.Limit = 0
+	}
+	filters.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, filters.UserIdentifier)
 
-	return s.repo.List(ctx, filters)
+	return s.repo.StreamList(ctx, filters, keys, func(exp *models.ExperienceData) error {
+		if err := openValues(s.keys, exp); err != nil {
+			return err
+		}
+		return each(exp)
+	})
 }
 
 // UpdateExperience updates an existing experience
@@ -51,12 +264,345 @@ func (s *ExperienceService) UpdateExperience(ctx context.Context, id uuid.UUID,
 		return nil, err
 	}
 
-	return s.repo.Update(ctx, id, req)
+	changes := updateChangesOf(req)
+	if !changes.values && !changes.source && !changes.user && !changes.response {
+		return s.opened(s.repo.Update(ctx, id, req))
+	}
+
+	if req.IfMatch != nil {
+		return s.updateFrom(ctx, id, req, changes)
+	}
+
+	// The update is derived from the record as read, so it is only applied to
+	// the version read and derived again when another write got there first
+	for attempt := 1; ; attempt++ {
+		attemptReq := *req
+		exp, err := s.updateFrom(ctx, id, &attemptReq, changes)
+		if !errors.Is(err, models.ErrPreconditionFailed) {
+			return exp, err
+		}
+		if attempt == maxUpdateAttempts {
+			return nil, fmt.Errorf("experience kept changing during the update, retry it: %w", models.ErrConflict)
+		}
+	}
+}
+
+// updateChanges tells which groups of fields an update changes
+type updateChanges struct {
+	values, source, response, user bool
+}
+
+// updateChangesOf reports the groups of fields req changes
+func updateChangesOf(req *models.UpdateExperienceRequest) updateChanges {
+	return updateChanges{
+		values: req.FieldType != nil || req.ValueText != nil || req.ValueNumber != nil ||
+			req.ValueBoolean != nil || req.ValueDate != nil || req.ValueJSON != nil ||
+			clearsAny(req, "value_text", "value_number", "value_boolean", "value_date", "value_json"),
+		source:   req.SourceType != nil || req.SourceID != nil || req.FieldID != nil || req.Clears("source_id"),
+		response: req.ResponseID != nil || req.Clears("response_id"),
+		user:     req.UserIdentifier != nil || req.Clears("user_identifier"),
+	}
+}
+
+// updateFrom applies an update to the record as currently stored, checking
+// that it is still at that version when written unless req.IfMatch is set
+func (s *ExperienceService) updateFrom(ctx context.Context, id uuid.UUID, req *models.UpdateExperienceRequest, changes updateChanges) (*models.ExperienceData, error) {
+	existing, err := s.GetExperience(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if req.IfMatch == nil {
+		req.IfMatch = []int{existing.Version}
+	}
+
+	// Upserted records keep a natural key matching their values
+	if len(existing.NaturalKey) > 0 && (changes.source || changes.response) {
+		key, err := naturalKey(existing.NaturalKey, updatedKeyValues(existing, req))
+		if err != nil {
+			return nil, err
+		}
+		req.NaturalKey = &key
+	}
+
+	// Validate the values the record will hold once the update is applied
+	if changes.values {
+		if err := validateUpdatedValues(existing, req); err != nil {
+			return nil, err
+		}
+	}
+
+	lookup, err := s.updatedSource(ctx, existing, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new value_text is scanned again, replacing the detected types and vaulted original
+	if req.ValueText != nil {
+		scan, err := scanPII(lookup, id, req.ValueText, s.vault)
+		if err != nil {
+			return nil, err
+		}
+		req.ValueText, req.PIITypes, req.ValueTextVault = scan.Text, scan.Types, scan.Vault
+		req.PIIScanned = true
+	} else if req.Clears("value_text") {
+		req.PIITypes, req.ValueTextVault = nil, nil
+		req.PIIScanned = true
+	}
+
+	// Records moving to a pseudonymizing source take the pseudonym of their identifier
+	if lookup != nil && lookup.Pseudonymize {
+		userIdentifier := req.UserIdentifier
+		if userIdentifier == nil && changes.source && !req.Clears("user_identifier") {
+			userIdentifier = existing.UserIdentifier
+		}
+		if userIdentifier != nil {
+			pseudonym, err := pseudonymize(s.pseudonyms, *userIdentifier)
+			if err != nil {
+				return nil, err
+			}
+			req.UserIdentifier = &pseudonym
+		}
+	}
+
+	if err := s.sealUpdate(existing, lookup, req); err != nil {
+		return nil, err
+	}
+
+	return s.opened(s.repo.Update(ctx, id, req))
+}
+
+// updatedSource looks up the registration of the source and field an updated
+// record belongs to, nil when the source is not registered
+func (s *ExperienceService) updatedSource(ctx context.Context, existing *models.ExperienceData, req *models.UpdateExperienceRequest) (*models.SourceFieldLookup, error) {
+	sourceType, sourceID, fieldID := existing.SourceType, existing.SourceID, existing.FieldID
+	if req.SourceType != nil {
+		sourceType = *req.SourceType
+	}
+	if req.SourceID != nil {
+		sourceID = req.SourceID
+	}
+	if req.Clears("source_id") {
+		sourceID = nil
+	}
+	if req.FieldID != nil {
+		fieldID = *req.FieldID
+	}
+
+	if sourceID == nil {
+		return nil, nil
+	}
+	return s.sources.LookupField(ctx, sourceType, *sourceID, fieldID)
+}
+
+// updatedKeyValues returns the natural key values of existing with req applied
+func updatedKeyValues(existing *models.ExperienceData, req *models.UpdateExperienceRequest) naturalKeyValues {
+	values := naturalKeyValues{
+		SourceType:  existing.SourceType,
+		SourceID:    existing.SourceID,
+		FieldID:     existing.FieldID,
+		ResponseID:  existing.ResponseID,
+		CollectedAt: &existing.CollectedAt,
+	}
+	if req.SourceType != nil {
+		values.SourceType = *req.SourceType
+	}
+	if req.SourceID != nil {
+		values.SourceID = req.SourceID
+	}
+	if req.FieldID != nil {
+		values.FieldID = *req.FieldID
+	}
+	if req.ResponseID != nil {
+		values.ResponseID = req.ResponseID
+	}
+	if req.Clears("source_id") {
+		values.SourceID = nil
+	}
+	if req.Clears("response_id") {
+		values.ResponseID = nil
+	}
+	return values
+}
+
+// sealUpdate seals the sensitive values of an updated record when it is
+// encrypted or moves to an encrypting source. Encrypted records stay encrypted.
+// The existing values, decrypted, are merged with those of req.
+func (s *ExperienceService) sealUpdate(existing *models.ExperienceData, lookup *models.SourceFieldLookup, req *models.UpdateExperienceRequest) error {
+	encrypt := existing.Encrypted || (lookup != nil && lookup.Encrypt)
+	sensitiveChange := req.ValueText != nil || req.ValueJSON != nil || req.UserIdentifier != nil ||
+		clearsAny(req, "value_text", "value_json", "user_identifier")
+	if !encrypt || (existing.Encrypted && !sensitiveChange) {
+		return nil
+	}
+
+	values := sensitiveValues{
+		ValueText:      existing.ValueText,
+		ValueJSON:      existing.ValueJSON,
+		UserIdentifier: existing.UserIdentifier,
+	}
+	if req.ValueText != nil {
+		values.ValueText = req.ValueText
+	}
+	if req.ValueJSON != nil {
+		values.ValueJSON = req.ValueJSON
+	}
+	if req.UserIdentifier != nil {
+		values.UserIdentifier = req.UserIdentifier
+	}
+	if req.Clears("value_text") {
+		values.ValueText = nil
+	}
+	if req.Clears("value_json") {
+		values.ValueJSON = nil
+	}
+	if req.Clears("user_identifier") {
+		values.UserIdentifier = nil
+	}
+
+	sealed, clearUserIdentifier, err := sealRecord(s.keys, existing.ID, values)
+	if err != nil {
+		return err
+	}
+	req.Sealed, req.UserIdentifier = sealed, clearUserIdentifier
+	req.ValueText, req.ValueJSON = nil, nil
+
+	return nil
+}
+
+// GetExperienceVault retrieves the original value_text of a masked experience
+// from the vault
+func (s *ExperienceService) GetExperienceVault(ctx context.Context, id uuid.UUID) (*models.ExperienceVault, error) {
+	sealed, err := s.repo.GetVault(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if sealed == nil {
+		return nil, fmt.Errorf("vaulted value_text %w", models.ErrNotFound)
+	}
+	if s.vault == nil {
+		return nil, errNoVaultKey
+	}
+
+	original, err := s.vault.Open(sealed, recordContext(id, vaultColumn))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vaulted value_text: %w", err)
+	}
+
+	return &models.ExperienceVault{ID: id, ValueText: string(original)}, nil
+}
+
+// GetExperienceHistory retrieves the changes of an experience, oldest first
+func (s *ExperienceService) GetExperienceHistory(ctx context.Context, id uuid.UUID) ([]models.ExperienceHistoryEntry, error) {
+	return s.repo.ListHistory(ctx, id)
+}
+
+// purgeBatchSize limits how many records are purged per query, keeping locks short
+const purgeBatchSize = 1000
+
+// DeleteExperience moves an experience to the trash
+// ifMatch lists the versions the record must be at, nil deletes unconditionally.
+func (s *ExperienceService) DeleteExperience(ctx context.Context, id uuid.UUID, ifMatch []int) error {
+	return s.repo.Delete(ctx, id, ifMatch)
+}
+
+// ListTrash retrieves deleted experiences, most recently deleted first
+func (s *ExperienceService) ListTrash(ctx context.Context, filters *models.ListExperiencesFilters) ([]models.ExperienceData, error) {
+	filters.Deleted = true
+	return s.ListExperiences(ctx, filters)
+}
+
+// RestoreExperience moves a deleted experience out of the trash
+func (s *ExperienceService) RestoreExperience(ctx context.Context, id uuid.UUID) (*models.ExperienceData, error) {
+	return s.opened(s.repo.Restore(ctx, id))
+}
+
+// PurgeTrash permanently removes experiences that have been in the trash for
+// longer than retention and returns how many were removed
+func (s *ExperienceService) PurgeTrash(ctx context.Context, retention time.Duration) (int64, error) {
+	before := time.Now().Add(-retention)
+
+	var purged int64
+	for {
+		n, err := s.repo.PurgeDeleted(ctx, before, purgeBatchSize)
+		purged += n
+		if err != nil {
+			return purged, err
+		}
+		if n < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
+
+// reencryptBatchSize limits how many records are re-encrypted per transaction
+const reencryptBatchSize = 500
+
+// ReencryptRecords re-encrypts the records sealed with a key version other than
+// the current one and returns how many were re-encrypted. It does nothing when
+// no keys are configured.
+func (s *ExperienceService) ReencryptRecords(ctx context.Context) (int64, error) {
+	if s.keys == nil {
+		return 0, nil
+	}
+
+	reseal := func(id uuid.UUID, sealed *models.SealedValues) (*models.SealedValues, error) {
+		return resealValues(s.keys, id, sealed)
+	}
+
+	var reencrypted int64
+	for {
+		n, err := s.repo.ReencryptBatch(ctx, s.keys.CurrentVersion(), reencryptBatchSize, reseal)
+		reencrypted += n
+		if err != nil {
+			return reencrypted, err
+		}
+		if n < reencryptBatchSize {
+			return reencrypted, nil
+		}
+	}
 }
 
-// DeleteExperience deletes an experience by ID
-func (s *ExperienceService) DeleteExperience(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
+// pseudonymizeBatchSize limits how many records are pseudonymized per transaction
+const pseudonymizeBatchSize = 500
+
+// PseudonymizeRecords replaces the user_identifier of existing records of
+// pseudonymizing sources, and of their change history, by its pseudonym and
+// returns how many records were converted
+// Encrypted records are resealed with their user_identifier moved to the clear.
+func (s *ExperienceService) PseudonymizeRecords(ctx context.Context) (int64, error) {
+	if s.pseudonyms == nil {
+		return 0, errNoPseudonymKey
+	}
+
+	convert := func(id uuid.UUID, userIdentifier *string, sealed *models.SealedValues) (*string, *models.SealedValues, error) {
+		if sealed == nil {
+			pseudonym := s.pseudonyms.Pseudonym(*userIdentifier)
+			return &pseudonym, nil, nil
+		}
+
+		values, err := openSealed(s.keys, id, sealed)
+		if err != nil {
+			return nil, nil, err
+		}
+		if values.UserIdentifier != nil {
+			pseudonym := s.pseudonyms.Pseudonym(*values.UserIdentifier)
+			values.UserIdentifier = &pseudonym
+		}
+		resealed, clearUserIdentifier, err := sealRecord(s.keys, id, *values)
+		return clearUserIdentifier, resealed, err
+	}
+
+	var pseudonymized int64
+	for {
+		n, err := s.repo.PseudonymizeBatch(ctx, pseudonymizeBatchSize, convert, s.pseudonyms.Pseudonym)
+		pseudonymized += n
+		if err != nil {
+			return pseudonymized, err
+		}
+		if n < pseudonymizeBatchSize {
+			return pseudonymized, nil
+		}
+	}
 }
 
 // SearchExperiences performs advanced search with pagination
@@ -74,44 +620,141 @@ func (s *ExperienceService) SearchExperiences(ctx context.Context, req *models.S
 		req.Page = 0
 	}
 
+	prepareSearch(req, s.keys, s.pseudonyms)
+
 	// Call repository search
-	experiences, totalCount, err := s.repo.Search(ctx, req)
+	result, err := s.repo.Search(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
 	// Calculate total pages
-	totalPages := totalCount / req.PageSize
-	if totalCount%req.PageSize > 0 {
+	totalPages := result.TotalCount / req.PageSize
+	if result.TotalCount%req.PageSize > 0 {
 		totalPages++
 	}
 
 	// Ensure we have at least 0 data
-	if experiences == nil {
-		experiences = []models.ExperienceData{}
+	if result.Data == nil {
+		result.Data = []models.ExperienceSearchResult{}
+	}
+	for i := range result.Data {
+		if err := openValues(s.keys, &result.Data[i].ExperienceData); err != nil {
+			return nil, err
+		}
+	}
+
+	result.Page = req.Page
+	result.PageSize = req.PageSize
+	result.TotalPages = totalPages
+
+	return result, nil
+}
+
+// prepareSearch fills in the defaults of the search filters of req and the
+// stored forms of its user_identifier
+func prepareSearch(req *models.SearchExperiencesRequest, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer) {
+	// Default to full-text search
+	if req.Mode == "" {
+		req.Mode = models.SearchModeFullText
+	}
+	if req.MinSimilarity <= 0 {
+		req.MinSimilarity = models.DefaultMinSimilarity
+	}
+	req.UserIdentifierMatch = userIdentifierMatch(keys, pseudonyms, req.UserIdentifier)
+}
+
+// StreamSearch calls each with every result matching the filters of req,
+// decrypted, best matches first, as it is read. Results are not paginated, so
+// page and page size are ignored, and neither counted nor faceted. When keys is
+// set, it is called first with the sorted top-level metadata keys of the results.
+func (s *ExperienceService) StreamSearch(ctx context.Context, req *models.SearchExperiencesRequest, keys func([]string) error, each func(*models.ExperienceSearchResult) error) error {
+	prepareSearch(req, s.keys, s.pseudonyms)
+
+	return s.repo.StreamSearch(ctx, req, keys, func(res *models.ExperienceSearchResult) error {
+		if err := openValues(s.keys, &res.ExperienceData); err != nil {
+			return err
+		}
+		return each(res)
+	})
+}
+
+// checkRegisteredField looks up the record's field in the source registry.
+// Registered labels fill in a missing field_label. In strict mode, requested by
+// req.Strict or set on the source, unregistered sources and fields and field
+// types differing from the registration are rejected. It returns the lookup,
+// nil when the source is not registered.
+func (s *ExperienceService) checkRegisteredField(ctx context.Context, req *models.CreateExperienceRequest) (*models.SourceFieldLookup, error) {
+	var lookup *models.SourceFieldLookup
+	if req.SourceID != nil {
+		var err error
+		lookup, err = s.sources.LookupField(ctx, req.SourceType, *req.SourceID, req.FieldID)
+		if err != nil {
+			return nil, err
+		}
 	}
 
-	return &models.SearchExperiencesResponse{
-		Data:       experiences,
-		Page:       req.Page,
-		PageSize:   req.PageSize,
-		TotalCount: totalCount,
-		TotalPages: totalPages,
-	}, nil
+	strict := req.Strict || (lookup != nil && lookup.Strict)
+
+	switch {
+	case lookup == nil:
+		if strict {
+			return nil, invalidValues(models.FieldError{
+				Field:   "source_id",
+				Code:    models.ValidationUnknownSource,
+				Message: "source_type and source_id must match a registered source in strict mode",
+			})
+		}
+	case lookup.Field == nil:
+		if strict {
+			return nil, invalidValues(models.FieldError{
+				Field:   "field_id",
+				Code:    models.ValidationUnknownField,
+				Message: fmt.Sprintf("field_id %q is not registered for the source", req.FieldID),
+			})
+		}
+	default:
+		if strict && lookup.Field.FieldType != req.FieldType {
+			return nil, invalidValues(models.FieldError{
+				Field:   "field_type",
+				Code:    models.ValidationTypeMismatch,
+				Message: fmt.Sprintf("field_type must be %s as registered for field_id %q", lookup.Field.FieldType, req.FieldID),
+			})
+		}
+		if req.FieldLabel == nil {
+			req.FieldLabel = lookup.Field.Label
+		}
+	}
+
+	return lookup, nil
 }
 
 // validateCreateRequest validates the create request
 func (s *ExperienceService) validateCreateRequest(req *models.CreateExperienceRequest) error {
 	if req.SourceType == "" {
-		return fmt.Errorf("source_type is required")
+		return invalidInput("source_type", models.ValidationRequired, "source_type is required")
 	}
 
 	if req.FieldID == "" {
-		return fmt.Errorf("field_id is required")
+		return invalidInput("field_id", models.ValidationRequired, "field_id is required")
 	}
 
 	if req.FieldType == "" {
-		return fmt.Errorf("field_type is required")
+		return invalidInput("field_type", models.ValidationRequired, "field_type is required")
+	}
+
+	fieldErrors := validateFieldValues(req.FieldType, experienceValues{
+		Text:    req.ValueText,
+		Number:  req.ValueNumber,
+		Boolean: req.ValueBoolean,
+		Date:    req.ValueDate,
+		JSON:    req.ValueJSON,
+	})
+	if fieldErr := userIdentifierError(req.UserIdentifier); fieldErr != nil {
+		fieldErrors = append(fieldErrors, *fieldErr)
+	}
+	if len(fieldErrors) > 0 {
+		return invalidValues(fieldErrors...)
 	}
 
 	return nil
@@ -120,15 +763,89 @@ func (s *ExperienceService) validateCreateRequest(req *models.CreateExperienceRe
 // validateUpdateRequest validates the update request
 func (s *ExperienceService) validateUpdateRequest(req *models.UpdateExperienceRequest) error {
 	if req.SourceType != nil && *req.SourceType == "" {
-		return fmt.Errorf("source_type cannot be empty")
+		return invalidInput("source_type", models.ValidationRequired, "source_type cannot be empty")
 	}
 
 	if req.FieldID != nil && *req.FieldID == "" {
-		return fmt.Errorf("field_id cannot be empty")
+		return invalidInput("field_id", models.ValidationRequired, "field_id cannot be empty")
 	}
 
 	if req.FieldType != nil && *req.FieldType == "" {
-		return fmt.Errorf("field_type cannot be empty")
+		return invalidInput("field_type", models.ValidationRequired, "field_type cannot be empty")
+	}
+
+	for _, field := range []string{"source_type", "field_id", "field_type"} {
+		if req.Clears(field) {
+			return invalidInput(field, models.ValidationRequired, field+" cannot be null")
+		}
+	}
+
+	// Other fields cannot be cleared either; unknown ones would otherwise be ignored silently
+	for _, field := range slices.Sorted(slices.Values(req.Clear)) {
+		if !slices.Contains(models.NullableExperienceFields, field) {
+			return invalidInput(field, models.ValidationInvalidValue, field+" cannot be set to null")
+		}
+	}
+
+	if fieldErr := userIdentifierError(req.UserIdentifier); fieldErr != nil {
+		return invalidValues(*fieldErr)
+	}
+
+	return nil
+}
+
+// clearsAny reports whether req sets any of fields to null
+func clearsAny(req *models.UpdateExperienceRequest, fields ...string) bool {
+	return slices.ContainsFunc(fields, req.Clears)
+}
+
+// validateUpdatedValues validates the field type and values of existing with req applied
+func validateUpdatedValues(existing *models.ExperienceData, req *models.UpdateExperienceRequest) error {
+	fieldType := existing.FieldType
+	if req.FieldType != nil {
+		fieldType = *req.FieldType
+	}
+
+	values := experienceValues{
+		Text:    existing.ValueText,
+		Number:  existing.ValueNumber,
+		Boolean: existing.ValueBoolean,
+		Date:    existing.ValueDate,
+		JSON:    existing.ValueJSON,
+	}
+	if req.ValueText != nil {
+		values.Text = req.ValueText
+	}
+	if req.ValueNumber != nil {
+		values.Number = req.ValueNumber
+	}
+	if req.ValueBoolean != nil {
+		values.Boolean = req.ValueBoolean
+	}
+	if req.ValueDate != nil {
+		values.Date = req.ValueDate
+	}
+	if req.ValueJSON != nil {
+		values.JSON = req.ValueJSON
+	}
+	if req.Clears("value_text") {
+		values.Text = nil
+	}
+	if req.Clears("value_number") {
+		values.Number = nil
+	}
+	if req.Clears("value_boolean") {
+		values.Boolean = nil
+	}
+	if req.Clears("value_date") {
+		values.Date = nil
+	}
+	if req.Clears("value_json") {
+		values.JSON = nil
+	}
+
+	if fieldErrors := validateFieldValues(fieldType, values); len(fieldErrors) > 0 {
+		return invalidValues(fieldErrors...)
 	}
 
 	return nil
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/export"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/blob"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// exportJobTimeout is how long a running export may go without progress
+// before another worker starts it over
+const exportJobTimeout = 15 * time.Minute
+
+// exportProgressRows is how many records are written between progress updates
+const exportProgressRows = 10000
+
+// expireBatchSize limits how many expired exports are listed at once
+const expireBatchSize = 100
+
+// ExportService handles business logic for exports of experience data to files
+type ExportService struct {
+	repo       *repository.ExperienceRepository
+	exports    *repository.ExportRepository
+	store      blob.Store                // Keeps the files of completed exports
+	keys       *encryption.Keyring       // Decrypts records of encrypting sources, may be nil
+	pseudonyms *encryption.Pseudonymizer // Matches pseudonymized records by user_identifier, may be nil
+	urlTTL     time.Duration             // How long download URLs stay valid
+}
+
+// NewExportService creates a new export service
+func NewExportService(repo *repository.ExperienceRepository, exports *repository.ExportRepository, store blob.Store, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer, urlTTL time.Duration) *ExportService {
+	return &ExportService{repo: repo, exports: exports, store: store, keys: keys, pseudonyms: pseudonyms, urlTTL: urlTTL}
+}
+
+// CreateExport creates a pending export of the records matching the filters of
+// req, which the worker writes to a file
+func (s *ExportService) CreateExport(ctx context.Context, req *models.CreateExportRequest) (*models.Export, error) {
+	if req.Format == "" {
+		return nil, invalidInput("format", models.ValidationRequired, "format is required")
+	}
+	if !slices.Contains(models.ExportFormats, req.Format) {
+		return nil, invalidInput("format", models.ValidationInvalidValue,
+			"format must be one of "+strings.Join(models.ExportFormats, ", "))
+	}
+	for _, field := range req.Fields {
+		if !slices.Contains(models.ExperienceFieldNames, field) {
+			return nil, invalidInput("fields", models.ValidationInvalidValue, "unknown field: "+field)
+		}
+	}
+	if err := validateSearchMode(&req.ExperienceFilters); err != nil {
+		return nil, err
+	}
+
+	exp := &models.Export{Format: req.Format, Filters: req.ExperienceFilters}
+	if len(req.Fields) > 0 {
+		exp.Fields = req.Fields
+	}
+	if key := models.APIKeyFromContext(ctx); key != nil {
+		exp.APIKeyID = &key.ID
+	}
+
+	return s.exports.Create(ctx, exp)
+}
+
+// GetExport retrieves an export by ID, with a signed download URL while its
+// file is kept
+func (s *ExportService) GetExport(ctx context.Context, id uuid.UUID) (*models.Export, error) {
+	exp, err := s.exports.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if exp.Status != models.ExportCompleted || exp.ObjectKey == nil {
+		return exp, nil
+	}
+
+	expires := time.Now().Add(s.urlTTL)
+	if exp.ExpiresAt != nil && exp.ExpiresAt.Before(expires) {
+		expires = *exp.ExpiresAt
+	}
+	url, err := s.store.SignedURL(*exp.ObjectKey, expires)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign download URL: %w", err)
+	}
+	exp.DownloadURL, exp.DownloadExpiresAt = &url, &expires
+
+	return exp, nil
+}
+
+// RunJobs writes waiting exports one after the other until none is left and
+// returns the exports it finished. Files of completed exports are kept for
+// retention; an export stopped by an error fails without a file.
+func (s *ExportService) RunJobs(ctx context.Context, retention time.Duration) ([]models.Export, error) {
+	finished := []models.Export{}
+	for ctx.Err() == nil {
+		now := time.Now()
+		exp, err := s.exports.Claim(ctx, now, now.Add(-exportJobTimeout))
+		if err != nil {
+			return finished, err
+		}
+		if exp == nil {
+			return finished, nil
+		}
+
+		jobErr := s.write(ctx, exp)
+		if ctx.Err() != nil {
+			// Interrupted exports stay running and start over once they time out
+			return finished, ctx.Err()
+		}
+
+		exp.Status = models.ExportCompleted
+		if jobErr != nil {
+			message := jobErr.Error()
+			exp.Status, exp.Error, exp.ObjectKey = models.ExportFailed, &message, nil
+		} else {
+			expiresAt := time.Now().Add(retention)
+			exp.ExpiresAt = &expiresAt
+		}
+		if err := s.exports.Finish(ctx, exp); err != nil {
+			return finished, err
+		}
+		finished = append(finished, *exp)
+	}
+
+	return finished, ctx.Err()
+}
+
+// write writes the records matching exp to a temporary file, read from a
+// single snapshot, and stores it under exports/<id>.<format>
+func (s *ExportService) write(ctx context.Context, exp *models.Export) error {
+	file, err := os.CreateTemp("", "export-*."+exp.Format)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	req := &models.SearchExperiencesRequest{ExperienceFilters: exp.Filters, Fields: exp.Fields}
+	prepareSearch(req, s.keys, s.pseudonyms)
+
+	// Scores are only written for queries, in the columns of their search mode
+	var keep []string
+	if req.Query != nil && strings.TrimSpace(*req.Query) != "" {
+		keep = []string{"rank", "highlight"}
+		if req.Mode == models.SearchModeFuzzy {
+			keep = []string{"similarity"}
+		}
+	}
+
+	buffered := bufio.NewWriter(file)
+	encoder, err := export.NewEncoder(exp.Format, buffered, exp.Fields, keep...)
+	if err != nil {
+		return err
+	}
+
+	var keys func([]string) error
+	if export.FlattensMetadata(exp.Format, exp.Fields) {
+		keys = encoder.Begin
+	} else if err := encoder.Begin(nil); err != nil {
+		return err
+	}
+
+	exp.Rows = 0
+	err = s.repo.StreamSearch(ctx, req, keys, func(res *models.ExperienceSearchResult) error {
+		if err := openValues(s.keys, &res.ExperienceData); err != nil {
+			return err
+		}
+		if err := encoder.Encode(res); err != nil {
+			return fmt.Errorf("failed to encode experience %s: %w", res.ID, err)
+		}
+		exp.Rows++
+		if exp.Rows%exportProgressRows == 0 {
+			return s.exports.Progress(ctx, exp.ID, exp.Rows)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := encoder.End(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read export file: %w", err)
+	}
+
+	// Uploading large files takes a while, which must not look like an abandoned export
+	if err := s.exports.Progress(ctx, exp.ID, exp.Rows); err != nil {
+		return err
+	}
+
+	key := fmt.Sprintf("exports/%s.%s", exp.ID, exp.Format)
+	if err := s.store.Put(ctx, key, file, size); err != nil {
+		return fmt.Errorf("failed to store export file: %w", err)
+	}
+	exp.ObjectKey, exp.Size = &key, size
+
+	return nil
+}
+
+// ExpireExports deletes the files of completed exports past their expiry and
+// returns how many expired
+func (s *ExportService) ExpireExports(ctx context.Context) (int, error) {
+	var expired int
+	for {
+		exports, err := s.exports.ListExpired(ctx, time.Now(), expireBatchSize)
+		if err != nil {
+			return expired, err
+		}
+
+		for _, exp := range exports {
+			if exp.ObjectKey != nil {
+				if err := s.store.Delete(ctx, *exp.ObjectKey); err != nil {
+					return expired, fmt.Errorf("failed to delete export %s: %w", exp.ID, err)
+				}
+			}
+			if err := s.exports.Expire(ctx, exp.ID); err != nil {
+				return expired, err
+			}
+			expired++
+		}
+
+		if len(exports) < expireBatchSize {
+			return expired, nil
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestCreateExportValidation(t *testing.T) {
+	s := &ExportService{}
+	ctx := context.Background()
+
+	tests := map[string]*models.CreateExportRequest{
+		"format required":  {},
+		"unknown format":   {Format: "xlsx"},
+		"unknown field":    {Format: models.ExportFormatCSV, Fields: []string{"value_blob"}},
+		"unknown mode":     {Format: models.ExportFormatNDJSON, ExperienceFilters: models.ExperienceFilters{Mode: "exact"}},
+		"similarity range": {Format: models.ExportFormatParquet, ExperienceFilters: models.ExperienceFilters{MinSimilarity: 2}},
+	}
+	for name, req := range tests {
+		_, err := s.CreateExport(ctx, req)
+		assert.ErrorIs(t, err, models.ErrInvalidInput, name)
+	}
+}
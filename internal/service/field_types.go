@@ -0,0 +1,174 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// experienceValues holds the value columns of a record, as created or after an update
+type experienceValues struct {
+	Text    *string
+	Number  *float64
+	Boolean *bool
+	Date    *time.Time
+	JSON    json.RawMessage
+}
+
+// fieldTypeSpec declares the value column a field type stores its answer in and
+// the constraints on that answer
+type fieldTypeSpec struct {
+	// valueField is the JSON name of the required value column
+	valueField string
+	// validate checks the answer once the value column is known to be set, may be nil
+	validate func(values experienceValues) *models.FieldError
+}
+
+// fieldTypes is the registry of supported field types
+var fieldTypes = map[string]fieldTypeSpec{
+	models.FieldTypeText:         {valueField: "value_text"},
+	models.FieldTypeNumber:       {valueField: "value_number"},
+	models.FieldTypeRating:       {valueField: "value_number", validate: integerInRange(1, 10)},
+	models.FieldTypeNPS:          {valueField: "value_number", validate: integerInRange(0, 10)},
+	models.FieldTypeBoolean:      {valueField: "value_boolean"},
+	models.FieldTypeDate:         {valueField: "value_date"},
+	models.FieldTypeSingleChoice: {valueField: "value_text", validate: nonEmptyText},
+	models.FieldTypeMultiChoice:  {valueField: "value_json", validate: stringArray},
+	models.FieldTypeMatrix:       {valueField: "value_json", validate: jsonObject},
+	models.FieldTypeFile:         {valueField: "value_text", validate: fileURL},
+}
+
+// FieldTypeNames returns the registered field types, sorted
+func FieldTypeNames() []string {
+	names := make([]string, 0, len(fieldTypes))
+	for name := range fieldTypes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// validateFieldValues checks that values hold a valid answer for fieldType
+func validateFieldValues(fieldType string, values experienceValues) []models.FieldError {
+	spec, ok := fieldTypes[fieldType]
+	if !ok {
+		return []models.FieldError{{
+			Field:   "field_type",
+			Code:    models.ValidationUnknownType,
+			Message: fmt.Sprintf("unknown field_type %q, use one of %s", fieldType, strings.Join(FieldTypeNames(), ", ")),
+		}}
+	}
+
+	if !values.has(spec.valueField) {
+		return []models.FieldError{{
+			Field:   spec.valueField,
+			Code:    models.ValidationRequired,
+			Message: fmt.Sprintf("%s is required for field_type %s", spec.valueField, fieldType),
+		}}
+	}
+
+	if spec.validate != nil {
+		if fieldErr := spec.validate(values); fieldErr != nil {
+			return []models.FieldError{*fieldErr}
+		}
+	}
+
+	return nil
+}
+
+// has reports whether the value column with the given JSON name is set
+func (v experienceValues) has(field string) bool {
+	switch field {
+	case "value_text":
+		return v.Text != nil
+	case "value_number":
+		return v.Number != nil
+	case "value_boolean":
+		return v.Boolean != nil
+	case "value_date":
+		return v.Date != nil
+	case "value_json":
+		return len(v.JSON) > 0 && string(v.JSON) != "null"
+	default:
+		return false
+	}
+}
+
+// integerInRange requires value_number to be a whole number between min and max
+func integerInRange(min, max float64) func(values experienceValues) *models.FieldError {
+	return func(values experienceValues) *models.FieldError {
+		number := *values.Number
+		if number != math.Trunc(number) {
+			return &models.FieldError{
+				Field:   "value_number",
+				Code:    models.ValidationNotInteger,
+				Message: fmt.Sprintf("value_number must be a whole number, got %g", number),
+			}
+		}
+		if number < min || number > max {
+			return &models.FieldError{
+				Field:   "value_number",
+				Code:    models.ValidationOutOfRange,
+				Message: fmt.Sprintf("value_number must be between %g and %g, got %g", min, max, number),
+			}
+		}
+		return nil
+	}
+}
+
+// nonEmptyText requires value_text to contain more than whitespace
+func nonEmptyText(values experienceValues) *models.FieldError {
+	if strings.TrimSpace(*values.Text) == "" {
+		return &models.FieldError{
+			Field:   "value_text",
+			Code:    models.ValidationInvalidValue,
+			Message: "value_text must not be empty",
+		}
+	}
+	return nil
+}
+
+// stringArray requires value_json to be an array of strings
+func stringArray(values experienceValues) *models.FieldError {
+	var options []string
+	if err := json.Unmarshal(values.JSON, &options); err != nil {
+		return &models.FieldError{
+			Field:   "value_json",
+			Code:    models.ValidationInvalidValue,
+			Message: "value_json must be an array of strings",
+		}
+	}
+	return nil
+}
+
+// jsonObject requires value_json to be an object
+func jsonObject(values experienceValues) *models.FieldError {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(values.JSON, &object); err != nil || object == nil {
+		return &models.FieldError{
+			Field:   "value_json",
+			Code:    models.ValidationInvalidValue,
+			Message: "value_json must be an object",
+		}
+	}
+	return nil
+}
+
+// fileURL requires value_text to be an absolute http or https URL
+func fileURL(values experienceValues) *models.FieldError {
+	u, err := url.Parse(*values.Text)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &models.FieldError{
+			Field:   "value_text",
+			Code:    models.ValidationInvalidValue,
+			Message: "value_text must be an http or https URL",
+		}
+	}
+	return nil
+}
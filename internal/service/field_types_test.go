@@ -0,0 +1,114 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestValidateFieldValues(t *testing.T) {
+	text := func(s string) *string { return &s }
+	number := func(f float64) *float64 { return &f }
+	yes := true
+	now := time.Now()
+
+	tests := []struct {
+		name      string
+		fieldType string
+		values    experienceValues
+		wantField string
+		wantCode  string
+	}{
+		{name: "text", fieldType: models.FieldTypeText, values: experienceValues{Text: text("Great")}},
+		{name: "empty text is allowed", fieldType: models.FieldTypeText, values: experienceValues{Text: text("")}},
+		{name: "number requires value_number", fieldType: models.FieldTypeNumber, values: experienceValues{Text: text("5")}, wantField: "value_number", wantCode: models.ValidationRequired},
+		{name: "number", fieldType: models.FieldTypeNumber, values: experienceValues{Number: number(-3.5)}},
+		{name: "rating", fieldType: models.FieldTypeRating, values: experienceValues{Number: number(5)}},
+		{name: "rating below range", fieldType: models.FieldTypeRating, values: experienceValues{Number: number(0)}, wantField: "value_number", wantCode: models.ValidationOutOfRange},
+		{name: "nps lowest answer", fieldType: models.FieldTypeNPS, values: experienceValues{Number: number(0)}},
+		{name: "nps above range", fieldType: models.FieldTypeNPS, values: experienceValues{Number: number(11)}, wantField: "value_number", wantCode: models.ValidationOutOfRange},
+		{name: "nps fraction", fieldType: models.FieldTypeNPS, values: experienceValues{Number: number(7.5)}, wantField: "value_number", wantCode: models.ValidationNotInteger},
+		{name: "boolean", fieldType: models.FieldTypeBoolean, values: experienceValues{Boolean: &yes}},
+		{name: "boolean requires value_boolean", fieldType: models.FieldTypeBoolean, values: experienceValues{Text: text("yes")}, wantField: "value_boolean", wantCode: models.ValidationRequired},
+		{name: "date", fieldType: models.FieldTypeDate, values: experienceValues{Date: &now}},
+		{name: "single choice", fieldType: models.FieldTypeSingleChoice, values: experienceValues{Text: text("Pro")}},
+		{name: "single choice blank", fieldType: models.FieldTypeSingleChoice, values: experienceValues{Text: text("  ")}, wantField: "value_text", wantCode: models.ValidationInvalidValue},
+		{name: "multi choice", fieldType: models.FieldTypeMultiChoice, values: experienceValues{JSON: json.RawMessage(`["a","b"]`)}},
+		{name: "multi choice object", fieldType: models.FieldTypeMultiChoice, values: experienceValues{JSON: json.RawMessage(`{"a":1}`)}, wantField: "value_json", wantCode: models.ValidationInvalidValue},
+		{name: "multi choice null", fieldType: models.FieldTypeMultiChoice, values: experienceValues{JSON: json.RawMessage(`null`)}, wantField: "value_json", wantCode: models.ValidationRequired},
+		{name: "matrix", fieldType: models.FieldTypeMatrix, values: experienceValues{JSON: json.RawMessage(`{"speed":"good"}`)}},
+		{name: "matrix array", fieldType: models.FieldTypeMatrix, values: experienceValues{JSON: json.RawMessage(`["good"]`)}, wantField: "value_json", wantCode: models.ValidationInvalidValue},
+		{name: "file", fieldType: models.FieldTypeFile, values: experienceValues{Text: text("https://example.com/upload.pdf")}},
+		{name: "file without scheme", fieldType: models.FieldTypeFile, values: experienceValues{Text: text("example.com/upload.pdf")}, wantField: "value_text", wantCode: models.ValidationInvalidValue},
+		{name: "unknown type", fieldType: "slider", values: experienceValues{Number: number(5)}, wantField: "field_type", wantCode: models.ValidationUnknownType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fieldErrors := validateFieldValues(tt.fieldType, tt.values)
+			if tt.wantCode == "" {
+				assert.Empty(t, fieldErrors)
+				return
+			}
+			require.Len(t, fieldErrors, 1)
+			assert.Equal(t, tt.wantField, fieldErrors[0].Field)
+			assert.Equal(t, tt.wantCode, fieldErrors[0].Code)
+		})
+	}
+}
+
+func TestValidateUpdatedValues(t *testing.T) {
+	score := 8.0
+	existing := &models.ExperienceData{FieldType: models.FieldTypeNPS, ValueNumber: &score}
+
+	t.Run("keeps existing value", func(t *testing.T) {
+		label := "Recommend?"
+		assert.NoError(t, validateUpdatedValues(existing, &models.UpdateExperienceRequest{FieldLabel: &label}))
+	})
+
+	t.Run("new value out of range", func(t *testing.T) {
+		tooHigh := 12.0
+		err := validateUpdatedValues(existing, &models.UpdateExperienceRequest{ValueNumber: &tooHigh})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, models.ValidationOutOfRange, validationErr.Errors[0].Code)
+	})
+
+	t.Run("new type requires its value column", func(t *testing.T) {
+		fieldType := models.FieldTypeBoolean
+		err := validateUpdatedValues(existing, &models.UpdateExperienceRequest{FieldType: &fieldType})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "value_boolean", validationErr.Errors[0].Field)
+	})
+
+	t.Run("clearing the value column of the type", func(t *testing.T) {
+		err := validateUpdatedValues(existing, &models.UpdateExperienceRequest{Clear: []string{"value_number"}})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, "value_number", validationErr.Errors[0].Field)
+		assert.Equal(t, models.ValidationRequired, validationErr.Errors[0].Code)
+	})
+}
+
+func TestValidateUpdateRequestClears(t *testing.T) {
+	s := &ExperienceService{}
+	assert.NoError(t, s.validateUpdateRequest(&models.UpdateExperienceRequest{Clear: []string{"value_text", "metadata"}}))
+
+	for _, field := range []string{"collected_at", "id", "value_txt"} {
+		err := s.validateUpdateRequest(&models.UpdateExperienceRequest{Clear: []string{"language", field}})
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+		assert.Equal(t, field, validationErr.Errors[0].Field)
+		assert.Equal(t, models.ValidationInvalidValue, validationErr.Errors[0].Code)
+	}
+}
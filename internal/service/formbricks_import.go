@@ -0,0 +1,149 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// formbricksSourceType is the source_type of sources imported from Formbricks surveys
+const formbricksSourceType = "formbricks"
+
+// formbricksQuestionTypes maps Formbricks question types to field types
+var formbricksQuestionTypes = map[string]string{
+	"openText":             models.FieldTypeText,
+	"multipleChoiceSingle": models.FieldTypeSingleChoice,
+	"multipleChoiceMulti":  models.FieldTypeMultiChoice,
+	"pictureSelection":     models.FieldTypeMultiChoice,
+	"ranking":              models.FieldTypeMultiChoice,
+	"nps":                  models.FieldTypeNPS,
+	"rating":               models.FieldTypeRating,
+	"consent":              models.FieldTypeBoolean,
+	"date":                 models.FieldTypeDate,
+	"fileUpload":           models.FieldTypeFile,
+	"matrix":               models.FieldTypeMatrix,
+}
+
+// formbricksSurvey is the part of a Formbricks survey definition used for import
+type formbricksSurvey struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	Questions []formbricksQuestion `json:"questions"`
+}
+
+// formbricksQuestion is the part of a Formbricks survey question used for import
+type formbricksQuestion struct {
+	ID       string         `json:"id"`
+	Type     string         `json:"type"`
+	Headline formbricksText `json:"headline"`
+	Choices  []struct {
+		ID    string         `json:"id"`
+		Label formbricksText `json:"label"`
+	} `json:"choices"`
+}
+
+// formbricksText is a Formbricks string, either plain or translated as an
+// object keyed by language code with the survey's default language under "default"
+type formbricksText map[string]string
+
+func (t *formbricksText) UnmarshalJSON(data []byte) error {
+	var plain string
+	if err := json.Unmarshal(data, &plain); err == nil {
+		*t = formbricksText{"default": plain}
+		return nil
+	}
+
+	var translated map[string]string
+	if err := json.Unmarshal(data, &translated); err != nil {
+		return fmt.Errorf("expected a string or an object of translations")
+	}
+	*t = translated
+	return nil
+}
+
+// label returns the text in the default language
+func (t formbricksText) label() string {
+	return t["default"]
+}
+
+// translations returns the text in every language except the default, nil if there are none
+func (t formbricksText) translations() map[string]string {
+	var translations map[string]string
+	for language, text := range t {
+		if language == "default" {
+			continue
+		}
+		if translations == nil {
+			translations = map[string]string{}
+		}
+		translations[language] = text
+	}
+	return translations
+}
+
+// parseFormbricksSurvey converts a Formbricks survey definition into a source
+// and its fields. The definition may be wrapped in {"data": ...} as returned by
+// the Formbricks management API. Questions of types without a matching field
+// type are skipped.
+func parseFormbricksSurvey(data []byte) (*models.CreateSourceRequest, map[string]*models.PutSourceFieldRequest, []models.SkippedQuestion, error) {
+	var wrapper struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &wrapper); err == nil && len(wrapper.Data) > 0 && wrapper.Data[0] == '{' {
+		data = wrapper.Data
+	}
+
+	var survey formbricksSurvey
+	if err := json.Unmarshal(data, &survey); err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: invalid survey definition: %s", models.ErrInvalidInput, err)
+	}
+
+	if survey.ID == "" {
+		return nil, nil, nil, fmt.Errorf("%w: survey id is required", models.ErrInvalidInput)
+	}
+
+	source := &models.CreateSourceRequest{
+		SourceType: formbricksSourceType,
+		ExternalID: survey.ID,
+	}
+	if survey.Name != "" {
+		source.Name = &survey.Name
+	}
+
+	fields := make(map[string]*models.PutSourceFieldRequest, len(survey.Questions))
+	var skipped []models.SkippedQuestion
+	for position, question := range survey.Questions {
+		fieldType, ok := formbricksQuestionTypes[question.Type]
+		switch {
+		case question.ID == "":
+			return nil, nil, nil, fmt.Errorf("%w: question %d has no id", models.ErrInvalidInput, position+1)
+		case !ok:
+			skipped = append(skipped, models.SkippedQuestion{
+				ID:     question.ID,
+				Type:   question.Type,
+				Reason: fmt.Sprintf("question type %s has no matching field type", question.Type),
+			})
+			continue
+		}
+
+		field := &models.PutSourceFieldRequest{
+			FieldType:    fieldType,
+			Translations: question.Headline.translations(),
+			Position:     position,
+		}
+		if label := question.Headline.label(); label != "" {
+			field.Label = &label
+		}
+		for _, choice := range question.Choices {
+			field.Choices = append(field.Choices, models.FieldChoice{
+				ID:           choice.ID,
+				Label:        choice.Label.label(),
+				Translations: choice.Label.translations(),
+			})
+		}
+		fields[question.ID] = field
+	}
+
+	return source, fields, skipped, nil
+}
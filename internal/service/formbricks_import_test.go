@@ -0,0 +1,105 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+const formbricksSurveyDefinition = `{
+	"id": "clx123survey",
+	"name": "Product Feedback",
+	"questions": [
+		{
+			"id": "nps",
+			"type": "nps",
+			"headline": {"default": "How likely are you to recommend us?", "de": "Wie wahrscheinlich empfehlen Sie uns?"}
+		},
+		{
+			"id": "plan",
+			"type": "multipleChoiceSingle",
+			"headline": {"default": "What plan are you on?"},
+			"choices": [
+				{"id": "free", "label": {"default": "Free", "de": "Kostenlos"}},
+				{"id": "pro", "label": {"default": "Pro"}}
+			]
+		},
+		{
+			"id": "cta",
+			"type": "cta",
+			"headline": {"default": "Book a call"}
+		},
+		{
+			"id": "feedback",
+			"type": "openText",
+			"headline": "Anything else?"
+		}
+	]
+}`
+
+func TestParseFormbricksSurvey(t *testing.T) {
+	source, fields, skipped, err := parseFormbricksSurvey([]byte(formbricksSurveyDefinition))
+	require.NoError(t, err)
+
+	assert.Equal(t, "formbricks", source.SourceType)
+	assert.Equal(t, "clx123survey", source.ExternalID)
+	require.NotNil(t, source.Name)
+	assert.Equal(t, "Product Feedback", *source.Name)
+
+	require.Len(t, fields, 3)
+
+	nps := fields["nps"]
+	require.NotNil(t, nps)
+	assert.Equal(t, models.FieldTypeNPS, nps.FieldType)
+	assert.Equal(t, "How likely are you to recommend us?", *nps.Label)
+	assert.Equal(t, map[string]string{"de": "Wie wahrscheinlich empfehlen Sie uns?"}, nps.Translations)
+	assert.Equal(t, 0, nps.Position)
+
+	plan := fields["plan"]
+	require.NotNil(t, plan)
+	assert.Equal(t, models.FieldTypeSingleChoice, plan.FieldType)
+	assert.Nil(t, plan.Translations)
+	assert.Equal(t, []models.FieldChoice{
+		{ID: "free", Label: "Free", Translations: map[string]string{"de": "Kostenlos"}},
+		{ID: "pro", Label: "Pro"},
+	}, plan.Choices)
+
+	// Plain string headlines from older surveys are supported
+	feedback := fields["feedback"]
+	require.NotNil(t, feedback)
+	assert.Equal(t, "Anything else?", *feedback.Label)
+	assert.Equal(t, 3, feedback.Position)
+
+	require.Len(t, skipped, 1)
+	assert.Equal(t, "cta", skipped[0].ID)
+}
+
+func TestParseFormbricksSurveyWrapped(t *testing.T) {
+	source, fields, _, err := parseFormbricksSurvey([]byte(`{"data": ` + formbricksSurveyDefinition + `}`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "clx123survey", source.ExternalID)
+	assert.Len(t, fields, 3)
+}
+
+func TestParseFormbricksSurveyInvalid(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+	}{
+		{name: "not JSON", definition: `survey`},
+		{name: "missing survey id", definition: `{"name": "Survey", "questions": []}`},
+		{name: "missing question id", definition: `{"id": "s1", "questions": [{"type": "openText"}]}`},
+		{name: "invalid headline", definition: `{"id": "s1", "questions": [{"id": "q1", "type": "openText", "headline": 5}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := parseFormbricksSurvey([]byte(tt.definition))
+
+			assert.ErrorIs(t, err, models.ErrInvalidInput)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// naturalKeyValues holds the values of a record a natural key can combine
+type naturalKeyValues struct {
+	SourceType  string
+	SourceID    *string
+	FieldID     string
+	ResponseID  *string
+	CollectedAt *time.Time
+}
+
+// naturalKeyFields validates the fields of a natural key and returns them in
+// the order of models.NaturalKeyFields, so the same fields given in another
+// order make the same key. It returns models.DefaultNaturalKey when fields is empty.
+func naturalKeyFields(fields []string) ([]string, error) {
+	if len(fields) == 0 {
+		return models.DefaultNaturalKey, nil
+	}
+
+	for _, field := range fields {
+		if !slices.Contains(models.NaturalKeyFields, field) {
+			return nil, invalidInput("key", models.ValidationInvalidValue,
+				fmt.Sprintf("key field %q is not supported, use %s", field, strings.Join(models.NaturalKeyFields, ", ")))
+		}
+	}
+	if !slices.Contains(fields, "source_type") || !slices.Contains(fields, "field_id") {
+		return nil, invalidInput("key", models.ValidationInvalidValue, "key must include source_type and field_id")
+	}
+
+	ordered := make([]string, 0, len(fields))
+	for _, field := range models.NaturalKeyFields {
+		if slices.Contains(fields, field) {
+			ordered = append(ordered, field)
+		}
+	}
+	return ordered, nil
+}
+
+// naturalKey returns the hex encoded SHA-256 of fields and their values, which
+// must all be set
+func naturalKey(fields []string, values naturalKeyValues) (string, error) {
+	key := make([]string, 0, 2*len(fields))
+	for _, field := range fields {
+		var value *string
+		switch field {
+		case "source_type":
+			value = &values.SourceType
+		case "source_id":
+			value = values.SourceID
+		case "field_id":
+			value = &values.FieldID
+		case "response_id":
+			value = values.ResponseID
+		case "collected_at":
+			if values.CollectedAt != nil {
+				// collected_at is stored without time zone to the microsecond, so
+				// the key is computed from the value as it is read back
+				collectedAt := values.CollectedAt.Truncate(time.Microsecond).Format("2006-01-02T15:04:05.999999")
+				value = &collectedAt
+			}
+		}
+		if value == nil {
+			return "", invalidValues(models.FieldError{
+				Field:   field,
+				Code:    models.ValidationRequired,
+				Message: fmt.Sprintf("%s is required as part of the natural key", field),
+			})
+		}
+		key = append(key, field, *value)
+	}
+
+	encoded, err := json.Marshal(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode natural key: %w", err)
+	}
+	hash := sha256.Sum256(encoded)
+	return hex.EncodeToString(hash[:]), nil
+}
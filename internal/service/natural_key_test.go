@@ -0,0 +1,79 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestNaturalKeyFields(t *testing.T) {
+	fields, err := naturalKeyFields(nil)
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultNaturalKey, fields)
+
+	fields, err = naturalKeyFields([]string{"collected_at", "field_id", "source_type"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"source_type", "field_id", "collected_at"}, fields)
+
+	_, err = naturalKeyFields([]string{"source_type", "field_id", "user_identifier"})
+	assert.ErrorIs(t, err, models.ErrInvalidInput)
+
+	_, err = naturalKeyFields([]string{"source_type", "response_id"})
+	assert.ErrorIs(t, err, models.ErrInvalidInput)
+}
+
+func TestNaturalKey(t *testing.T) {
+	sourceID := "survey-1"
+	responseID := "resp-1"
+	values := naturalKeyValues{SourceType: "formbricks", SourceID: &sourceID, FieldID: "nps", ResponseID: &responseID}
+
+	key, err := naturalKey(models.DefaultNaturalKey, values)
+	require.NoError(t, err)
+	assert.Len(t, key, 64)
+
+	again, err := naturalKey(models.DefaultNaturalKey, values)
+	require.NoError(t, err)
+	assert.Equal(t, key, again)
+
+	// The same values under another key make another natural key
+	other, err := naturalKey([]string{"source_type", "source_id", "field_id"}, values)
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+
+	values.ResponseID = nil
+	_, err = naturalKey(models.DefaultNaturalKey, values)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "response_id", validationErr.Errors[0].Field)
+	assert.ErrorIs(t, err, models.ErrValidation)
+}
+
+func TestNaturalKeyCollectedAt(t *testing.T) {
+	fields := []string{"source_type", "field_id", "collected_at"}
+
+	// collected_at is compared as stored, to the microsecond
+	collectedAt := time.Date(2025, 3, 1, 12, 30, 0, 123456789, time.UTC)
+	stored := time.Date(2025, 3, 1, 12, 30, 0, 123456000, time.UTC)
+
+	key, err := naturalKey(fields, naturalKeyValues{SourceType: "formbricks", FieldID: "nps", CollectedAt: &collectedAt})
+	require.NoError(t, err)
+	readBack, err := naturalKey(fields, naturalKeyValues{SourceType: "formbricks", FieldID: "nps", CollectedAt: &stored})
+	require.NoError(t, err)
+	assert.Equal(t, key, readBack)
+}
+
+func TestInRecord(t *testing.T) {
+	err := inRecord(2, invalidValues(models.FieldError{Field: "value_number", Code: models.ValidationOutOfRange}))
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "[2].value_number", validationErr.Errors[0].Field)
+	assert.ErrorIs(t, err, models.ErrValidation)
+
+	other := errors.New("connection refused")
+	assert.Equal(t, other, inRecord(1, other))
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// piiDetector finds one type of personal data in free text
+type piiDetector struct {
+	piiType string
+	// pattern finds candidates, which valid confirms when set
+	pattern *regexp.Regexp
+	valid   func(candidate string) bool
+	// mask replaces confirmed matches when masking
+	mask string
+}
+
+// piiDetectors are applied in order; a candidate overlapping an earlier match is
+// skipped, so an IBAN or card number is not reported as a phone number as well
+var piiDetectors = []piiDetector{
+	{
+		piiType: models.PIITypeEmail,
+		pattern: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`),
+		mask:    "[EMAIL]",
+	},
+	{
+		piiType: models.PIITypeIBAN,
+		pattern: regexp.MustCompile(`\b[A-Z]{2}[0-9]{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+		valid:   validIBAN,
+		mask:    "[IBAN]",
+	},
+	{
+		piiType: models.PIITypeCard,
+		pattern: regexp.MustCompile(`\b[0-9](?:[ \-]?[0-9]){12,18}\b`),
+		valid:   validCardNumber,
+		mask:    "[CARD]",
+	},
+	{
+		// International numbers (+ or 00) and national numbers with a trunk
+		// prefix (0) or an area code in parentheses
+		piiType: models.PIITypePhone,
+		pattern: regexp.MustCompile(`(?:\+|\(|\b0)[0-9 ()./\-]{5,22}[0-9]`),
+		valid:   validPhone,
+		mask:    "[PHONE]",
+	},
+}
+
+// piiMatch is a detected value at text[Start:End]
+type piiMatch struct {
+	Type  string
+	Start int
+	End   int
+}
+
+// detectPII finds email addresses, phone numbers, IBANs and card numbers in
+// text, ordered by position
+func detectPII(text string) []piiMatch {
+	var matches []piiMatch
+	for _, detector := range piiDetectors {
+		for _, loc := range detector.pattern.FindAllStringIndex(text, -1) {
+			if detector.valid != nil && !detector.valid(text[loc[0]:loc[1]]) {
+				continue
+			}
+			overlaps := slices.ContainsFunc(matches, func(m piiMatch) bool {
+				return loc[0] < m.End && m.Start < loc[1]
+			})
+			if !overlaps {
+				matches = append(matches, piiMatch{Type: detector.piiType, Start: loc[0], End: loc[1]})
+			}
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b piiMatch) int { return a.Start - b.Start })
+	return matches
+}
+
+// piiTypes returns the distinct types of matches in the order of models.PIITypeNames
+func piiTypes(matches []piiMatch) []string {
+	var types []string
+	for _, piiType := range models.PIITypeNames {
+		if slices.ContainsFunc(matches, func(m piiMatch) bool { return m.Type == piiType }) {
+			types = append(types, piiType)
+		}
+	}
+	return types
+}
+
+// maskPII replaces every match in text by the placeholder of its type
+func maskPII(text string, matches []piiMatch) string {
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		b.WriteString(text[last:m.Start])
+		for _, detector := range piiDetectors {
+			if detector.piiType == m.Type {
+				b.WriteString(detector.mask)
+			}
+		}
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// validIBAN checks the length and ISO 13616 mod-97 checksum of an IBAN
+func validIBAN(candidate string) bool {
+	iban := strings.ReplaceAll(candidate, " ", "")
+	if len(iban) < 15 || len(iban) > 34 {
+		return false
+	}
+
+	// Move the country code and check digits to the end and read letters as 10-35
+	remainder := 0
+	for _, r := range iban[4:] + iban[:4] {
+		switch {
+		case r >= '0' && r <= '9':
+			remainder = (remainder*10 + int(r-'0')) % 97
+		case r >= 'A' && r <= 'Z':
+			remainder = (remainder*100 + int(r-'A') + 10) % 97
+		default:
+			return false
+		}
+	}
+	return remainder == 1
+}
+
+// validCardNumber checks the length and Luhn checksum of a payment card number
+func validCardNumber(candidate string) bool {
+	digits := onlyDigits(candidate)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	for i := range digits {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// datePattern matches dates such as 01.02.2024, which look like phone numbers with a trunk prefix
+var datePattern = regexp.MustCompile(`^[0-9]{1,4}[./\-][0-9]{1,2}[./\-][0-9]{1,4}$`)
+
+// validPhone checks that a candidate has as many digits as an E.164 number and is not a date
+func validPhone(candidate string) bool {
+	digits := onlyDigits(candidate)
+	return len(digits) >= 7 && len(digits) <= 15 && !datePattern.MatchString(candidate)
+}
+
+// onlyDigits returns the ASCII digits of s
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// errNoVaultKey is returned when a masked original must be sealed or opened but no vault key is configured
+var errNoVaultKey = invalidValues(keyNotConfigured("pii_vault", "PII_VAULT_KEY"))
+
+// piiScan is the outcome of applying a source's PII mode to value_text
+type piiScan struct {
+	Text  *string  // value_text to store, masked in mask mode
+	Types []string // Detected types, nil when the source does not scan
+	Vault []byte   // Sealed original of masked text, when the source keeps a vault
+}
+
+// scanPII applies the PII mode of the record's source, described by lookup, to
+// text. Unregistered sources are not scanned. In reject mode detected values fail
+// validation; in mask mode they are replaced irreversibly unless the source keeps
+// the original sealed in the vault, bound to the record with the given ID.
+func scanPII(lookup *models.SourceFieldLookup, id uuid.UUID, text *string, vault *encryption.Cipher) (*piiScan, error) {
+	if lookup == nil || lookup.PIIMode == "" || lookup.PIIMode == models.PIIModeOff || text == nil {
+		return &piiScan{Text: text}, nil
+	}
+
+	matches := detectPII(*text)
+	if len(matches) == 0 {
+		return &piiScan{Text: text}, nil
+	}
+	types := piiTypes(matches)
+
+	switch lookup.PIIMode {
+	case models.PIIModeReject:
+		return nil, invalidValues(models.FieldError{
+			Field:   "value_text",
+			Code:    models.ValidationPIIDetected,
+			Message: fmt.Sprintf("value_text contains personal data (%s), which the source rejects", strings.Join(types, ", ")),
+		})
+
+	case models.PIIModeMask:
+		masked := maskPII(*text, matches)
+		scan := &piiScan{Text: &masked, Types: types}
+		if lookup.PIIVault {
+			if vault == nil {
+				return nil, errNoVaultKey
+			}
+			sealed, err := vault.Seal([]byte(*text), recordContext(id, vaultColumn))
+			if err != nil {
+				return nil, fmt.Errorf("failed to seal value_text: %w", err)
+			}
+			scan.Vault = sealed
+		}
+		return scan, nil
+
+	default:
+		return &piiScan{Text: text, Types: types}, nil
+	}
+}
+
+// validatePIIMode checks a source's PII mode
+func validatePIIMode(mode string) error {
+	switch mode {
+	case models.PIIModeOff, models.PIIModeFlag, models.PIIModeMask, models.PIIModeReject:
+		return nil
+	}
+	return invalidValues(models.FieldError{
+		Field:   "pii_mode",
+		Code:    models.ValidationInvalidValue,
+		Message: fmt.Sprintf("unknown pii_mode %q, use off, flag, mask or reject", mode),
+	})
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+func TestDetectPII(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wantTypes []string
+		wantMask  string
+	}{
+		{name: "no personal data", text: "Great product, fast support", wantMask: "Great product, fast support"},
+		{name: "email", text: "Write to jane.doe+hub@example.co.uk please", wantTypes: []string{models.PIITypeEmail}, wantMask: "Write to [EMAIL] please"},
+		{name: "international phone", text: "Call +49 30 1234567 tomorrow", wantTypes: []string{models.PIITypePhone}, wantMask: "Call [PHONE] tomorrow"},
+		{name: "national phone", text: "my number is 030/1234567", wantTypes: []string{models.PIITypePhone}, wantMask: "my number is [PHONE]"},
+		{name: "phone with area code in parentheses", text: "(555) 123-4567", wantTypes: []string{models.PIITypePhone}, wantMask: "[PHONE]"},
+		{name: "date is not a phone", text: "since 01.02.2024", wantMask: "since 01.02.2024"},
+		{name: "short number is not a phone", text: "rated 0 of 10", wantMask: "rated 0 of 10"},
+		{name: "iban with spaces", text: "IBAN DE89 3704 0044 0532 0130 00, thanks", wantTypes: []string{models.PIITypeIBAN}, wantMask: "IBAN [IBAN], thanks"},
+		{name: "compact iban", text: "GB82WEST12345698765432", wantTypes: []string{models.PIITypeIBAN}, wantMask: "[IBAN]"},
+		{name: "iban with wrong checksum", text: "GB00WEST12345698765432", wantMask: "GB00WEST12345698765432"},
+		{name: "card number", text: "card 4111 1111 1111 1111 expired", wantTypes: []string{models.PIITypeCard}, wantMask: "card [CARD] expired"},
+		{name: "card number with dashes", text: "5500-0000-0000-0004", wantTypes: []string{models.PIITypeCard}, wantMask: "[CARD]"},
+		{name: "number failing luhn", text: "order 4111111111111112", wantMask: "order 4111111111111112"},
+		{
+			name:      "several types",
+			text:      "mail a@b.io or call 0301234567, card 4111111111111111",
+			wantTypes: []string{models.PIITypeEmail, models.PIITypePhone, models.PIITypeCard},
+			wantMask:  "mail [EMAIL] or call [PHONE], card [CARD]",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches := detectPII(tt.text)
+			assert.Equal(t, tt.wantTypes, piiTypes(matches))
+			assert.Equal(t, tt.wantMask, maskPII(tt.text, matches))
+		})
+	}
+}
+
+func TestScanPII(t *testing.T) {
+	text := func(s string) *string { return &s }
+	lookup := func(mode string, vault bool) *models.SourceFieldLookup {
+		return &models.SourceFieldLookup{PIIMode: mode, PIIVault: vault}
+	}
+
+	vault, err := encryption.NewCipher(bytes.Repeat([]byte{1}, encryption.KeySize))
+	require.NoError(t, err)
+	id := uuid.New()
+
+	t.Run("unregistered sources are not scanned", func(t *testing.T) {
+		scan, err := scanPII(nil, id, text("a@b.io"), vault)
+		require.NoError(t, err)
+		assert.Equal(t, "a@b.io", *scan.Text)
+		assert.Nil(t, scan.Types)
+	})
+
+	t.Run("off", func(t *testing.T) {
+		scan, err := scanPII(lookup(models.PIIModeOff, false), id, text("a@b.io"), vault)
+		require.NoError(t, err)
+		assert.Equal(t, "a@b.io", *scan.Text)
+		assert.Nil(t, scan.Types)
+	})
+
+	t.Run("flag keeps the text", func(t *testing.T) {
+		scan, err := scanPII(lookup(models.PIIModeFlag, false), id, text("a@b.io"), vault)
+		require.NoError(t, err)
+		assert.Equal(t, "a@b.io", *scan.Text)
+		assert.Equal(t, []string{models.PIITypeEmail}, scan.Types)
+		assert.Nil(t, scan.Vault)
+	})
+
+	t.Run("mask without vault", func(t *testing.T) {
+		scan, err := scanPII(lookup(models.PIIModeMask, false), id, text("a@b.io"), vault)
+		require.NoError(t, err)
+		assert.Equal(t, "[EMAIL]", *scan.Text)
+		assert.Nil(t, scan.Vault)
+	})
+
+	t.Run("mask with vault seals the original", func(t *testing.T) {
+		scan, err := scanPII(lookup(models.PIIModeMask, true), id, text("a@b.io"), vault)
+		require.NoError(t, err)
+		assert.Equal(t, "[EMAIL]", *scan.Text)
+
+		original, err := vault.Open(scan.Vault, recordContext(id, vaultColumn))
+		require.NoError(t, err)
+		assert.Equal(t, "a@b.io", string(original))
+
+		// The original is bound to its record
+		_, err = vault.Open(scan.Vault, recordContext(uuid.New(), vaultColumn))
+		assert.ErrorIs(t, err, encryption.ErrInvalidCiphertext)
+	})
+
+	t.Run("mask with vault requires a key", func(t *testing.T) {
+		_, err := scanPII(lookup(models.PIIModeMask, true), id, text("a@b.io"), nil)
+		assert.ErrorIs(t, err, models.ErrValidation)
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		_, err := scanPII(lookup(models.PIIModeReject, false), id, text("a@b.io"), vault)
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, models.ErrValidation))
+
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Equal(t, models.ValidationPIIDetected, validationErr.Errors[0].Code)
+	})
+
+	t.Run("reject accepts text without personal data", func(t *testing.T) {
+		scan, err := scanPII(lookup(models.PIIModeReject, false), id, text("all good"), vault)
+		require.NoError(t, err)
+		assert.Equal(t, "all good", *scan.Text)
+	})
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// errNoPseudonymKey is returned when a user identifier must be pseudonymized but no key is configured
+var errNoPseudonymKey = invalidValues(keyNotConfigured("pseudonymize", "PSEUDONYM_KEY"))
+
+// pseudonymize returns the pseudonym of userIdentifier
+func pseudonymize(pseudonyms *encryption.Pseudonymizer, userIdentifier string) (string, error) {
+	if pseudonyms == nil {
+		return "", errNoPseudonymKey
+	}
+	return pseudonyms.Pseudonym(userIdentifier), nil
+}
+
+// userIdentifierError reports a user identifier starting with the prefix
+// reserved for pseudonyms without being one, nil for any other identifier
+func userIdentifierError(userIdentifier *string) *models.FieldError {
+	if userIdentifier == nil || !strings.HasPrefix(*userIdentifier, encryption.PseudonymPrefix) || encryption.IsPseudonym(*userIdentifier) {
+		return nil
+	}
+	return &models.FieldError{
+		Field:   "user_identifier",
+		Code:    models.ValidationInvalidValue,
+		Message: "user_identifier starting with " + encryption.PseudonymPrefix + " must be a pseudonym, followed by 64 lowercase hex characters",
+	}
+}
+
+// userIdentifierMatch returns the stored forms a filter on userIdentifier
+// matches, so filters work the same on plain, pseudonymized and encrypted
+// records. It returns nil when userIdentifier is nil.
+func userIdentifierMatch(keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer, userIdentifier *string) *models.UserIdentifierMatch {
+	if userIdentifier == nil {
+		return nil
+	}
+
+	match := &models.UserIdentifierMatch{Values: []string{*userIdentifier}}
+	if pseudonyms != nil && !encryption.IsPseudonym(*userIdentifier) {
+		match.Values = append(match.Values, pseudonyms.Pseudonym(*userIdentifier))
+	}
+	if keys != nil {
+		for _, value := range match.Values {
+			match.Indexes = append(match.Indexes, keys.Index(value))
+		}
+	}
+
+	return match
+}
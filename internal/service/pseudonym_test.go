@@ -0,0 +1,89 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+func testPseudonymizer(t *testing.T) *encryption.Pseudonymizer {
+	pseudonyms, err := encryption.NewPseudonymizer(bytes.Repeat([]byte{4}, encryption.KeySize))
+	require.NoError(t, err)
+	return pseudonyms
+}
+
+func TestUserIdentifierMatch(t *testing.T) {
+	pseudonyms := testPseudonymizer(t)
+	keys := testKeyring(t, 1)
+	user := "jane@example.com"
+	pseudonym := pseudonyms.Pseudonym(user)
+
+	assert.Nil(t, userIdentifierMatch(keys, pseudonyms, nil))
+
+	// Without keys the identifier matches itself only
+	assert.Equal(t, &models.UserIdentifierMatch{Values: []string{user}}, userIdentifierMatch(nil, nil, &user))
+
+	match := userIdentifierMatch(keys, pseudonyms, &user)
+	assert.Equal(t, []string{user, pseudonym}, match.Values)
+	assert.Equal(t, [][]byte{keys.Index(user), keys.Index(pseudonym)}, match.Indexes)
+
+	// A pseudonym is not pseudonymized again
+	match = userIdentifierMatch(nil, pseudonyms, &pseudonym)
+	assert.Equal(t, []string{pseudonym}, match.Values)
+}
+
+func TestLookalikePseudonymsAreRejected(t *testing.T) {
+	s := &ExperienceService{}
+	pseudonym := testPseudonymizer(t).Pseudonym("jane@example.com")
+	lookalike := "hmac:jane@example.com"
+	text := "Great product"
+
+	create := func(userIdentifier string) error {
+		return s.validateCreateRequest(&models.CreateExperienceRequest{
+			SourceType: "formbricks", FieldID: "nps", FieldType: models.FieldTypeText, ValueText: &text, UserIdentifier: &userIdentifier,
+		})
+	}
+	assert.NoError(t, create("jane@example.com"))
+	assert.NoError(t, create(pseudonym))
+	assert.ErrorIs(t, create(lookalike), models.ErrValidation)
+
+	assert.NoError(t, s.validateUpdateRequest(&models.UpdateExperienceRequest{UserIdentifier: &pseudonym}))
+	assert.ErrorIs(t, s.validateUpdateRequest(&models.UpdateExperienceRequest{UserIdentifier: &lookalike}), models.ErrValidation)
+}
+
+func TestPseudonymizeWithoutKey(t *testing.T) {
+	_, err := pseudonymize(nil, "jane@example.com")
+	assert.ErrorIs(t, err, errNoPseudonymKey)
+}
+
+func TestSealRecordKeepsPseudonymInTheClear(t *testing.T) {
+	keys := testKeyring(t, 1)
+	text := "Confidential"
+	pseudonym := testPseudonymizer(t).Pseudonym("jane@example.com")
+	id := uuid.New()
+
+	sealed, clearUserIdentifier, err := sealRecord(keys, id, sensitiveValues{ValueText: &text, UserIdentifier: &pseudonym})
+	require.NoError(t, err)
+	require.NotNil(t, clearUserIdentifier)
+	assert.Equal(t, pseudonym, *clearUserIdentifier)
+	assert.Nil(t, sealed.UserIdentifierIndex)
+
+	// Opening keeps the identifier stored in the clear
+	exp := &models.ExperienceData{ID: id, UserIdentifier: clearUserIdentifier, Sealed: sealed}
+	require.NoError(t, openValues(keys, exp))
+	assert.Equal(t, text, *exp.ValueText)
+	assert.Equal(t, pseudonym, *exp.UserIdentifier)
+
+	// Raw identifiers are sealed, including those that only look like pseudonyms
+	for _, user := range []string{"jane@example.com", "hmac:jane@example.com"} {
+		sealed, clearUserIdentifier, err = sealRecord(keys, id, sensitiveValues{ValueText: &text, UserIdentifier: &user})
+		require.NoError(t, err)
+		assert.Nil(t, clearUserIdentifier)
+		assert.Equal(t, keys.Index(user), sealed.UserIdentifierIndex)
+	}
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// ResponseService handles business logic for responses
+type ResponseService struct {
+	repo       *repository.ResponseRepository
+	keys       *encryption.Keyring       // Decrypts answers of encrypting sources, may be nil
+	pseudonyms *encryption.Pseudonymizer // Pseudonymizes user_identifier filters, may be nil
+}
+
+// NewResponseService creates a new response service
+func NewResponseService(repo *repository.ResponseRepository, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer) *ResponseService {
+	return &ResponseService{repo: repo, keys: keys, pseudonyms: pseudonyms}
+}
+
+// GetResponse retrieves all answers of a response
+func (s *ResponseService) GetResponse(ctx context.Context, id string) (*models.Response, error) {
+	resp, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := openAll(s.keys, resp.Answers); err != nil {
+		return nil, err
+	}
+
+	// The user identifier of encrypted answers is only known once decrypted
+	if resp.UserIdentifier == nil && len(resp.Answers) > 0 {
+		resp.UserIdentifier = resp.Answers[0].UserIdentifier
+	}
+
+	return resp, nil
+}
+
+// ListResponses retrieves responses with their answers pivoted into columns
+func (s *ResponseService) ListResponses(ctx context.Context, filters *models.ListResponsesFilters) (*models.ListResponsesResponse, error) {
+	if filters.Limit <= 0 {
+		filters.Limit = 100 // Default limit
+	}
+	if filters.Limit > 1000 {
+		filters.Limit = 1000 // Max limit
+	}
+	filters.UserIdentifierMatch = userIdentifierMatch(s.keys, s.pseudonyms, filters.UserIdentifier)
+
+	responses, err := s.repo.List(ctx, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypted answers are opened before pivoting, so their values and user
+	// identifier are not lost
+	rows := make([]models.ResponseRow, 0, len(responses))
+	for _, resp := range responses {
+		if err := openAll(s.keys, resp.Answers); err != nil {
+			return nil, err
+		}
+		row, err := pivotResponse(&resp)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, *row)
+	}
+
+	return &models.ListResponsesResponse{
+		Fields: responseFields(rows),
+		Data:   rows,
+	}, nil
+}
+
+// pivotResponse returns the answers of resp keyed by field_id, which must be
+// ordered by collected_at: when a field was answered more than once the latest
+// answer wins
+func pivotResponse(resp *models.Response) (*models.ResponseRow, error) {
+	row := &models.ResponseRow{
+		ID:             resp.ID,
+		SourceType:     resp.SourceType,
+		SourceID:       resp.SourceID,
+		UserIdentifier: resp.UserIdentifier,
+		CollectedAt:    resp.CollectedAt,
+		Values:         make(map[string]json.RawMessage, len(resp.Answers)),
+	}
+
+	// The user identifier of encrypted answers is only known once decrypted
+	if row.UserIdentifier == nil && len(resp.Answers) > 0 {
+		row.UserIdentifier = resp.Answers[0].UserIdentifier
+	}
+
+	for i := range resp.Answers {
+		value, err := answerValue(&resp.Answers[i])
+		if err != nil {
+			return nil, err
+		}
+		row.Values[resp.Answers[i].FieldID] = value
+	}
+	return row, nil
+}
+
+// answerValue returns the typed value of an answer as JSON, null when it has none
+func answerValue(exp *models.ExperienceData) (json.RawMessage, error) {
+	var value interface{}
+	switch {
+	case exp.ValueText != nil:
+		value = *exp.ValueText
+	case exp.ValueNumber != nil:
+		value = *exp.ValueNumber
+	case exp.ValueBoolean != nil:
+		value = *exp.ValueBoolean
+	case exp.ValueDate != nil:
+		value = *exp.ValueDate
+	case exp.ValueJSON != nil:
+		return exp.ValueJSON, nil
+	default:
+		return json.RawMessage("null"), nil
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("experience %s: failed to encode answer value: %w", exp.ID, err)
+	}
+	return encoded, nil
+}
+
+// responseFields returns the sorted union of the field IDs answered in rows
+func responseFields(rows []models.ResponseRow) []string {
+	seen := make(map[string]bool)
+	fields := []string{}
+	for _, row := range rows {
+		for fieldID := range row.Values {
+			if !seen[fieldID] {
+				seen[fieldID] = true
+				fields = append(fields, fieldID)
+			}
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestPivotResponse(t *testing.T) {
+	keys := testKeyring(t, 1)
+	text := "Fast support"
+	user := "user_42"
+	id := uuid.New()
+	sealed, err := sealValues(keys, id, sensitiveValues{ValueText: &text, UserIdentifier: &user})
+	require.NoError(t, err)
+
+	first, second := 7.0, 9.0
+	recommend := true
+	resp := &models.Response{
+		ID:          "response-1",
+		SourceType:  "formbricks",
+		CollectedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		Answers: []models.ExperienceData{
+			{ID: id, FieldID: "reason", Sealed: sealed, Encrypted: true},
+			{FieldID: "nps", ValueNumber: &first},
+			{FieldID: "recommend", ValueBoolean: &recommend},
+			{FieldID: "tags", ValueJSON: json.RawMessage(`["a","b"]`)},
+			{FieldID: "skipped"},
+			{FieldID: "nps", ValueNumber: &second},
+		},
+	}
+	require.NoError(t, openAll(keys, resp.Answers))
+
+	row, err := pivotResponse(resp)
+	require.NoError(t, err)
+	require.NotNil(t, row.UserIdentifier)
+	assert.Equal(t, user, *row.UserIdentifier)
+	assert.JSONEq(t, `"Fast support"`, string(row.Values["reason"]))
+	assert.JSONEq(t, `9`, string(row.Values["nps"]))
+	assert.JSONEq(t, `true`, string(row.Values["recommend"]))
+	assert.JSONEq(t, `["a","b"]`, string(row.Values["tags"]))
+	assert.JSONEq(t, `null`, string(row.Values["skipped"]))
+}
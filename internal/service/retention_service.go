@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+)
+
+// retentionBatchSize limits how many records a retention run deletes or
+// anonymizes per query, keeping locks short
+const retentionBatchSize = 1000
+
+// RetentionService handles business logic for retention policies
+type RetentionService struct {
+	repo *repository.RetentionRepository
+}
+
+// NewRetentionService creates a new retention service
+func NewRetentionService(repo *repository.RetentionRepository) *RetentionService {
+	return &RetentionService{repo: repo}
+}
+
+// CreatePolicy creates a new retention policy
+func (s *RetentionService) CreatePolicy(ctx context.Context, req *models.CreateRetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	if req.SourceType == "" {
+		return nil, invalidInput("source_type", models.ValidationRequired, "source_type is required")
+	}
+
+	if err := validateMaxAgeDays(req.MaxAgeDays); err != nil {
+		return nil, err
+	}
+
+	if err := validateRetentionAction(req.Action); err != nil {
+		return nil, err
+	}
+
+	if req.Enabled == nil {
+		enabled := true
+		req.Enabled = &enabled
+	}
+
+	return s.repo.Create(ctx, req)
+}
+
+// GetPolicy retrieves a single retention policy by ID
+func (s *RetentionService) GetPolicy(ctx context.Context, id uuid.UUID) (*models.RetentionPolicy, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListPolicies retrieves all retention policies
+func (s *RetentionService) ListPolicies(ctx context.Context) ([]models.RetentionPolicy, error) {
+	return s.repo.List(ctx, false)
+}
+
+// UpdatePolicy updates an existing retention policy
+func (s *RetentionService) UpdatePolicy(ctx context.Context, id uuid.UUID, req *models.UpdateRetentionPolicyRequest) (*models.RetentionPolicy, error) {
+	if req.MaxAgeDays != nil {
+		if err := validateMaxAgeDays(*req.MaxAgeDays); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.Action != nil {
+		if err := validateRetentionAction(*req.Action); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.Update(ctx, id, req)
+}
+
+// DeletePolicy deletes a retention policy
+func (s *RetentionService) DeletePolicy(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// DryRunPolicy reports how many records a policy would delete or anonymize now,
+// without changing anything
+func (s *RetentionService) DryRunPolicy(ctx context.Context, id uuid.UUID) (*models.RetentionRun, error) {
+	policy, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.runPolicy(ctx, policy, time.Now(), true)
+}
+
+// EnforcePolicies deletes or anonymizes the expired records of every enabled
+// policy, or only counts them when dryRun is set
+// A failing policy does not stop the others; their errors are joined.
+func (s *RetentionService) EnforcePolicies(ctx context.Context, dryRun bool) ([]models.RetentionRun, error) {
+	policies, err := s.repo.List(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var runs []models.RetentionRun
+	var errs []error
+	for i := range policies {
+		run, err := s.runPolicy(ctx, &policies[i], time.Now(), dryRun)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("retention policy %s: %w", policies[i].ID, err))
+			continue
+		}
+		runs = append(runs, *run)
+	}
+
+	return runs, errors.Join(errs...)
+}
+
+// runPolicy expires the records of a policy in batches and records the run
+func (s *RetentionService) runPolicy(ctx context.Context, policy *models.RetentionPolicy, now time.Time, dryRun bool) (*models.RetentionRun, error) {
+	run := &models.RetentionRun{
+		PolicyID: policy.ID,
+		Action:   policy.Action,
+		Cutoff:   retentionCutoff(now, policy.MaxAgeDays),
+		DryRun:   dryRun,
+	}
+
+	if dryRun {
+		count, err := s.repo.CountExpired(ctx, policy, run.Cutoff)
+		if err != nil {
+			return nil, err
+		}
+		run.Affected = count
+		return run, nil
+	}
+
+	for {
+		n, err := s.repo.ExpireBatch(ctx, policy, run.Cutoff, retentionBatchSize)
+		run.Affected += n
+		if err != nil {
+			return nil, err
+		}
+		if n < retentionBatchSize {
+			break
+		}
+	}
+
+	if err := s.repo.RecordRun(ctx, policy.ID, now, run.Affected); err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// retentionCutoff returns the time before which records expire under a policy
+// keeping records for maxAgeDays
+func retentionCutoff(now time.Time, maxAgeDays int) time.Time {
+	return now.AddDate(0, 0, -maxAgeDays)
+}
+
+// validateMaxAgeDays checks that records are kept for at least a day
+func validateMaxAgeDays(maxAgeDays int) error {
+	if maxAgeDays <= 0 {
+		return invalidInput("max_age_days", models.ValidationOutOfRange, "max_age_days must be a positive number of days")
+	}
+	return nil
+}
+
+// validateRetentionAction checks that action is a supported retention action
+func validateRetentionAction(action string) error {
+	if action != models.RetentionActionDelete && action != models.RetentionActionAnonymize {
+		return invalidInput("action", models.ValidationInvalidValue, "action must be delete or anonymize")
+	}
+	return nil
+}
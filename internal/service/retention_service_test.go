@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestRetentionCutoff(t *testing.T) {
+	now := time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC), retentionCutoff(now, 30))
+	assert.Equal(t, time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC), retentionCutoff(now, 730))
+}
+
+func TestValidateRetention(t *testing.T) {
+	assert.NoError(t, validateMaxAgeDays(1))
+	assert.NoError(t, validateRetentionAction(models.RetentionActionDelete))
+	assert.NoError(t, validateRetentionAction(models.RetentionActionAnonymize))
+
+	for _, err := range []error{
+		validateMaxAgeDays(0),
+		validateMaxAgeDays(-30),
+		validateRetentionAction(""),
+		validateRetentionAction("archive"),
+	} {
+		require.Error(t, err)
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+	}
+}
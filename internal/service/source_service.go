@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+// SourceService handles business logic for the source and field registry
+type SourceService struct {
+	repo *repository.SourceRepository
+	// The keys of the API, which sources can only use when configured
+	vault      *encryption.Cipher        // Nil when no vault key is configured
+	keys       *encryption.Keyring       // Nil when no encryption keys are configured
+	pseudonyms *encryption.Pseudonymizer // Nil when no pseudonym key is configured
+}
+
+// NewSourceService creates a new source service
+func NewSourceService(repo *repository.SourceRepository, vault *encryption.Cipher, keys *encryption.Keyring, pseudonyms *encryption.Pseudonymizer) *SourceService {
+	return &SourceService{repo: repo, vault: vault, keys: keys, pseudonyms: pseudonyms}
+}
+
+// CreateSource registers a new source
+func (s *SourceService) CreateSource(ctx context.Context, req *models.CreateSourceRequest) (*models.Source, error) {
+	if req.SourceType == "" {
+		return nil, invalidInput("source_type", models.ValidationRequired, "source_type is required")
+	}
+
+	if req.ExternalID == "" {
+		return nil, invalidInput("external_id", models.ValidationRequired, "external_id is required")
+	}
+
+	if req.PIIMode == "" {
+		req.PIIMode = models.PIIModeOff
+	}
+	if err := validatePIIMode(req.PIIMode); err != nil {
+		return nil, err
+	}
+	if err := s.checkKeys(req.PIIVault, req.Encrypt, req.Pseudonymize); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Create(ctx, req)
+}
+
+// GetSource retrieves a single source by ID
+func (s *SourceService) GetSource(ctx context.Context, id uuid.UUID) (*models.Source, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListSources retrieves a list of sources with optional filters
+func (s *SourceService) ListSources(ctx context.Context, filters *models.ListSourcesFilters) ([]models.Source, error) {
+	if filters.Limit <= 0 {
+		filters.Limit = 100 // Default limit
+	}
+	if filters.Limit > 1000 {
+		filters.Limit = 1000 // Max limit
+	}
+
+	return s.repo.List(ctx, filters)
+}
+
+// UpdateSource updates an existing source
+func (s *SourceService) UpdateSource(ctx context.Context, id uuid.UUID, req *models.UpdateSourceRequest) (*models.Source, error) {
+	if req.PIIMode != nil {
+		if err := validatePIIMode(*req.PIIMode); err != nil {
+			return nil, err
+		}
+	}
+	isSet := func(b *bool) bool { return b != nil && *b }
+	if err := s.checkKeys(isSet(req.PIIVault), isSet(req.Encrypt), isSet(req.Pseudonymize)); err != nil {
+		return nil, err
+	}
+
+	return s.repo.Update(ctx, id, req)
+}
+
+// checkKeys rejects switching on source settings whose key the API is not
+// configured with, as every record written to such a source would fail
+func (s *SourceService) checkKeys(piiVault, encrypt, pseudonymize bool) error {
+	var fieldErrors []models.FieldError
+	if piiVault && s.vault == nil {
+		fieldErrors = append(fieldErrors, keyNotConfigured("pii_vault", "PII_VAULT_KEY"))
+	}
+	if encrypt && s.keys == nil {
+		fieldErrors = append(fieldErrors, keyNotConfigured("encrypt", "ENCRYPTION_KEYS"))
+	}
+	if pseudonymize && s.pseudonyms == nil {
+		fieldErrors = append(fieldErrors, keyNotConfigured("pseudonymize", "PSEUDONYM_KEY"))
+	}
+
+	if len(fieldErrors) > 0 {
+		return invalidValues(fieldErrors...)
+	}
+	return nil
+}
+
+// DeleteSource deletes a source and its fields
+func (s *SourceService) DeleteSource(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+// ListFields retrieves the fields registered for a source
+func (s *SourceService) ListFields(ctx context.Context, sourceID uuid.UUID) ([]models.SourceField, error) {
+	return s.repo.ListFields(ctx, sourceID)
+}
+
+// PutField registers or replaces a field of a source
+func (s *SourceService) PutField(ctx context.Context, sourceID uuid.UUID, fieldID string, req *models.PutSourceFieldRequest) (*models.SourceField, error) {
+	if err := validatePutFieldRequest(req); err != nil {
+		return nil, err
+	}
+
+	return s.repo.PutField(ctx, sourceID, fieldID, req)
+}
+
+// DeleteField removes a field from a source
+func (s *SourceService) DeleteField(ctx context.Context, sourceID uuid.UUID, fieldID string) error {
+	return s.repo.DeleteField(ctx, sourceID, fieldID)
+}
+
+// ImportFormbricksSurvey registers a Formbricks survey definition as a source
+// and its questions as fields. Re-importing a survey updates its registration.
+func (s *SourceService) ImportFormbricksSurvey(ctx context.Context, definition []byte, strict bool) (*models.ImportSurveyResponse, error) {
+	source, fields, skipped, err := parseFormbricksSurvey(definition)
+	if err != nil {
+		return nil, err
+	}
+	source.Strict = strict
+
+	imported, importedFields, err := s.repo.Import(ctx, source, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ImportSurveyResponse{
+		Source:  *imported,
+		Fields:  importedFields,
+		Skipped: skipped,
+	}, nil
+}
+
+// validatePutFieldRequest checks that a field has a registered type and well-formed choices
+func validatePutFieldRequest(req *models.PutSourceFieldRequest) error {
+	var fieldErrors []models.FieldError
+
+	if _, ok := fieldTypes[req.FieldType]; !ok {
+		fieldErrors = append(fieldErrors, models.FieldError{
+			Field:   "field_type",
+			Code:    models.ValidationUnknownType,
+			Message: fmt.Sprintf("unknown field_type %q, use one of %s", req.FieldType, strings.Join(FieldTypeNames(), ", ")),
+		})
+	}
+
+	for i, choice := range req.Choices {
+		if choice.ID == "" {
+			fieldErrors = append(fieldErrors, models.FieldError{
+				Field:   fmt.Sprintf("choices[%d].id", i),
+				Code:    models.ValidationRequired,
+				Message: "choice id is required",
+			})
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return invalidValues(fieldErrors...)
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
+)
+
+func TestSourceSettingsNeedConfiguredKeys(t *testing.T) {
+	unconfigured := &SourceService{}
+	_, err := unconfigured.CreateSource(context.Background(), &models.CreateSourceRequest{
+		SourceType:   "formbricks",
+		ExternalID:   "survey-123",
+		PIIMode:      models.PIIModeMask,
+		PIIVault:     true,
+		Encrypt:      true,
+		Pseudonymize: true,
+	})
+	assert.ErrorIs(t, err, models.ErrValidation)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Errors, 3)
+	for i, field := range []string{"pii_vault", "encrypt", "pseudonymize"} {
+		assert.Equal(t, field, validationErr.Errors[i].Field)
+		assert.Equal(t, models.ValidationKeyNotConfigured, validationErr.Errors[i].Code)
+	}
+
+	// Only settings switched on need a key
+	off, on := false, true
+	_, err = unconfigured.UpdateSource(context.Background(), uuid.Nil, &models.UpdateSourceRequest{Encrypt: &off, Pseudonymize: &on})
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Errors, 1)
+	assert.Equal(t, "pseudonymize", validationErr.Errors[0].Field)
+
+	vault, err := encryption.NewCipher(bytes.Repeat([]byte{7}, encryption.KeySize))
+	require.NoError(t, err)
+	configured := &SourceService{vault: vault, keys: testKeyring(t, 1), pseudonyms: testPseudonymizer(t)}
+	assert.NoError(t, configured.checkKeys(true, true, true))
+}
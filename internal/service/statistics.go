@@ -0,0 +1,117 @@
+package service
+
+import (
+	"math"
+
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+// significanceLevel is the p-value below which a test result is reported as significant
+const significanceLevel = 0.05
+
+// chiSquareTest runs Pearson's chi-square test of independence on a contingency
+// table of observed counts. It returns nil for tables with fewer than 2 rows or
+// columns, where the test is undefined.
+func chiSquareTest(observed [][]int) *models.ChiSquareResult {
+	if len(observed) < 2 || len(observed[0]) < 2 {
+		return nil
+	}
+
+	rowTotals := make([]float64, len(observed))
+	columnTotals := make([]float64, len(observed[0]))
+	total := 0.0
+	for i, row := range observed {
+		for j, count := range row {
+			rowTotals[i] += float64(count)
+			columnTotals[j] += float64(count)
+			total += float64(count)
+		}
+	}
+
+	result := &models.ChiSquareResult{
+		DegreesOfFreedom: (len(rowTotals) - 1) * (len(columnTotals) - 1),
+	}
+	statistic := 0.0
+	for i, row := range observed {
+		for j, count := range row {
+			expected := rowTotals[i] * columnTotals[j] / total
+			if expected < 5 {
+				result.LowExpectedCells++
+			}
+			diff := float64(count) - expected
+			statistic += diff * diff / expected
+		}
+	}
+
+	result.Statistic = round4(statistic)
+	result.PValue = round4(chiSquarePValue(statistic, result.DegreesOfFreedom))
+	result.Significant = result.PValue < significanceLevel
+
+	return result
+}
+
+// chiSquarePValue returns the probability of a chi-square statistic at least as
+// large as x with df degrees of freedom
+func chiSquarePValue(x float64, df int) float64 {
+	if x <= 0 {
+		return 1
+	}
+	return regularizedGammaQ(float64(df)/2, x/2)
+}
+
+// regularizedGammaQ computes the regularized upper incomplete gamma function Q(a, x),
+// using the series expansion for x < a+1 and the continued fraction otherwise
+func regularizedGammaQ(a, x float64) float64 {
+	const (
+		maxIterations = 500
+		epsilon       = 1e-14
+		tiny          = 1e-300
+	)
+
+	lgamma, _ := math.Lgamma(a)
+	prefix := math.Exp(a*math.Log(x) - x - lgamma)
+
+	if x < a+1 {
+		// Series for P(a, x)
+		sum := 1 / a
+		term := sum
+		for n := 1; n < maxIterations; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if math.Abs(term) < math.Abs(sum)*epsilon {
+				break
+			}
+		}
+		return math.Max(0, 1-sum*prefix)
+	}
+
+	// Lentz's continued fraction for Q(a, x)
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < maxIterations; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < epsilon {
+			break
+		}
+	}
+	return math.Min(1, prefix*h)
+}
+
+// round4 rounds to four decimals
+func round4(value float64) float64 {
+	return math.Round(value*10000) / 10000
+}
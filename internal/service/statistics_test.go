@@ -0,0 +1,67 @@
+package service
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChiSquarePValue(t *testing.T) {
+	tests := []struct {
+		name string
+		x    float64
+		df   int
+		want float64
+	}{
+		// df 1 and 2 have closed forms: erfc(sqrt(x/2)) and exp(-x/2)
+		{name: "df 1 critical value", x: 3.841459, df: 1, want: 0.05},
+		{name: "df 1 small statistic", x: 0.5, df: 1, want: math.Erfc(math.Sqrt(0.25))},
+		{name: "df 1 large statistic", x: 30, df: 1, want: math.Erfc(math.Sqrt(15))},
+		{name: "df 2", x: 4, df: 2, want: math.Exp(-2)},
+		{name: "df 4 critical value", x: 9.487729, df: 4, want: 0.05},
+		{name: "df 10 critical value", x: 23.209251, df: 10, want: 0.01},
+		{name: "zero statistic", x: 0, df: 3, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, chiSquarePValue(tt.x, tt.df), 1e-6)
+		})
+	}
+}
+
+func TestChiSquareTest(t *testing.T) {
+	t.Run("2x2 table", func(t *testing.T) {
+		// Expected counts are 12, 18, 28 and 42
+		result := chiSquareTest([][]int{{10, 20}, {30, 40}})
+		require.NotNil(t, result)
+
+		assert.Equal(t, 1, result.DegreesOfFreedom)
+		assert.InDelta(t, 0.7937, result.Statistic, 1e-4)
+		assert.InDelta(t, math.Erfc(math.Sqrt(0.793651/2)), result.PValue, 1e-4)
+		assert.False(t, result.Significant)
+		assert.Equal(t, 0, result.LowExpectedCells)
+	})
+
+	t.Run("dependent answers are significant", func(t *testing.T) {
+		result := chiSquareTest([][]int{{40, 5, 5}, {5, 40, 5}, {5, 5, 40}})
+		require.NotNil(t, result)
+
+		assert.Equal(t, 4, result.DegreesOfFreedom)
+		assert.True(t, result.Significant)
+		assert.Less(t, result.PValue, 0.001)
+	})
+
+	t.Run("low expected counts are reported", func(t *testing.T) {
+		result := chiSquareTest([][]int{{1, 2}, {3, 4}})
+		require.NotNil(t, result)
+
+		assert.Equal(t, 4, result.LowExpectedCells)
+	})
+
+	t.Run("single column has no test", func(t *testing.T) {
+		assert.Nil(t, chiSquareTest([][]int{{3}, {4}}))
+	})
+}
@@ -0,0 +1,58 @@
+-- Full-text search on experience data
+
+-- Maps an experience language code (e.g. "en", "pt-BR") to a text search configuration.
+-- Unknown or missing languages fall back to "simple" (no stemming, no stop words).
+CREATE OR REPLACE FUNCTION experience_ts_config(lang VARCHAR)
+RETURNS regconfig
+LANGUAGE sql
+IMMUTABLE
+AS $$
+  SELECT CASE lower(split_part(replace(coalesce(lang, ''), '_', '-'), '-', 1))
+    WHEN 'da' THEN 'danish'::regconfig
+    WHEN 'de' THEN 'german'::regconfig
+    WHEN 'en' THEN 'english'::regconfig
+    WHEN 'es' THEN 'spanish'::regconfig
+    WHEN 'fi' THEN 'finnish'::regconfig
+    WHEN 'fr' THEN 'french'::regconfig
+    WHEN 'hu' THEN 'hungarian'::regconfig
+    WHEN 'it' THEN 'italian'::regconfig
+    WHEN 'nl' THEN 'dutch'::regconfig
+    WHEN 'nb' THEN 'norwegian'::regconfig
+    WHEN 'no' THEN 'norwegian'::regconfig
+    WHEN 'pt' THEN 'portuguese'::regconfig
+    WHEN 'ro' THEN 'romanian'::regconfig
+    WHEN 'ru' THEN 'russian'::regconfig
+    WHEN 'sv' THEN 'swedish'::regconfig
+    WHEN 'tr' THEN 'turkish'::regconfig
+    ELSE 'simple'::regconfig
+  END
+$$;
+
+-- All configurations experience_ts_config can return.
+-- Search parses the query once per configuration so each row is matched with its own stemming rules.
+CREATE OR REPLACE FUNCTION experience_ts_configs()
+RETURNS regconfig[]
+LANGUAGE sql
+IMMUTABLE
+AS $$
+  SELECT ARRAY[
+    'simple', 'danish', 'german', 'english', 'spanish', 'finnish', 'french', 'hungarian',
+    'italian', 'dutch', 'norwegian', 'portuguese', 'romanian', 'russian', 'swedish', 'turkish'
+  ]::regconfig[]
+$$;
+
+ALTER TABLE experience_data
+  ADD COLUMN IF NOT EXISTS search_config regconfig
+    GENERATED ALWAYS AS (experience_ts_config(language)) STORED;
+
+-- value_text ranks highest, followed by the field label, source name and field ID
+ALTER TABLE experience_data
+  ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+      setweight(to_tsvector(experience_ts_config(language), coalesce(value_text, '')), 'A') ||
+      setweight(to_tsvector(experience_ts_config(language), coalesce(field_label, '')), 'B') ||
+      setweight(to_tsvector(experience_ts_config(language), coalesce(source_name, '')), 'C') ||
+      setweight(to_tsvector(experience_ts_config(language), coalesce(field_id, '')), 'D')
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_experience_data_search_vector ON experience_data USING GIN (search_vector);
@@ -0,0 +1,7 @@
+-- Typo-tolerant fuzzy search on experience data
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Trigram indexes back the word similarity operator (<%) used by fuzzy search
+CREATE INDEX IF NOT EXISTS idx_experience_data_value_text_trgm ON experience_data USING GIN (value_text gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_experience_data_field_label_trgm ON experience_data USING GIN (field_label gin_trgm_ops);
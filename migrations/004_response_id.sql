@@ -0,0 +1,5 @@
+-- Group the answers of one submission (survey response) together
+
+ALTER TABLE experience_data ADD COLUMN IF NOT EXISTS response_id VARCHAR;
+
+CREATE INDEX IF NOT EXISTS idx_experience_data_response_id ON experience_data(response_id) WHERE response_id IS NOT NULL;
@@ -0,0 +1,31 @@
+-- Registry of sources (e.g. surveys) and the fields they collect
+
+CREATE TABLE IF NOT EXISTS sources (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+  source_type VARCHAR NOT NULL,
+  external_id VARCHAR NOT NULL, -- Matches experience_data.source_id
+  name VARCHAR,
+  strict BOOLEAN NOT NULL DEFAULT false, -- Reject records for unregistered fields
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  UNIQUE (source_type, external_id)
+);
+
+CREATE TABLE IF NOT EXISTS source_fields (
+  source_id UUID NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+  field_id VARCHAR NOT NULL, -- Matches experience_data.field_id
+
+  label VARCHAR,
+  field_type VARCHAR NOT NULL,
+  choices JSONB,
+  translations JSONB,
+  position INTEGER NOT NULL DEFAULT 0,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+
+  PRIMARY KEY (source_id, field_id)
+);
@@ -0,0 +1,5 @@
+-- Soft delete: deleted records stay in the trash until they are restored or purged
+
+ALTER TABLE experience_data ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_experience_data_deleted_at ON experience_data(deleted_at) WHERE deleted_at IS NOT NULL;
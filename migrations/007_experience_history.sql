@@ -0,0 +1,17 @@
+-- Change history of experience data: who changed a record, when, and how
+
+CREATE TABLE IF NOT EXISTS experience_history (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+  -- Purging a record removes its history with it
+  experience_id UUID NOT NULL REFERENCES experience_data(id) ON DELETE CASCADE,
+  action VARCHAR NOT NULL, -- create, update, delete or restore
+  api_key_id UUID, -- Acting API key, NULL for changes made outside of requests
+
+  before JSONB, -- Record before the change, NULL for create
+  after JSONB, -- Record after the change
+
+  changed_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_experience_history_experience_id ON experience_history(experience_id, changed_at);
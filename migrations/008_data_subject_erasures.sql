@@ -0,0 +1,18 @@
+-- Receipts of data subject erasures (GDPR right to erasure)
+-- Only a hash of the user identifier is stored, so the receipt does not identify the person by itself
+
+CREATE TABLE IF NOT EXISTS data_subject_erasures (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+  user_identifier_hash VARCHAR(64) NOT NULL, -- Hex encoded SHA-256 of the user identifier
+  mode VARCHAR NOT NULL, -- delete or anonymize
+  experiences_affected BIGINT NOT NULL,
+  history_entries_deleted BIGINT NOT NULL,
+  api_key_id UUID, -- Acting API key
+
+  erased_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_data_subject_erasures_user_identifier_hash ON data_subject_erasures(user_identifier_hash);
+
+CREATE INDEX IF NOT EXISTS idx_experience_data_user_identifier ON experience_data(user_identifier) WHERE user_identifier IS NOT NULL;
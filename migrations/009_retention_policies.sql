@@ -0,0 +1,19 @@
+-- Retention policies: how long experience data of a source is kept
+
+CREATE TABLE IF NOT EXISTS retention_policies (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+  source_type VARCHAR NOT NULL,
+  source_id VARCHAR, -- NULL applies the policy to every source of source_type
+  max_age_days INTEGER NOT NULL CHECK (max_age_days > 0),
+  action VARCHAR NOT NULL, -- delete or anonymize
+  enabled BOOLEAN NOT NULL DEFAULT true,
+
+  last_run_at TIMESTAMP,
+  last_run_affected BIGINT,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_experience_data_source_type_collected_at ON experience_data(source_type, collected_at);
@@ -0,0 +1,12 @@
+-- PII scanning of value_text, configured per source
+
+ALTER TABLE sources
+  ADD COLUMN IF NOT EXISTS pii_mode VARCHAR NOT NULL DEFAULT 'off', -- off, flag, mask or reject
+  ADD COLUMN IF NOT EXISTS pii_vault BOOLEAN NOT NULL DEFAULT false; -- Keep masked originals encrypted
+
+-- Types of personal data detected in value_text (email, phone, iban, card)
+ALTER TABLE experience_data
+  ADD COLUMN IF NOT EXISTS pii_types TEXT[],
+  ADD COLUMN IF NOT EXISTS value_text_vault BYTEA; -- AES-GCM sealed original of masked value_text
+
+CREATE INDEX IF NOT EXISTS idx_experience_data_pii_types ON experience_data USING GIN (pii_types);
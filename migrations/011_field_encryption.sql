@@ -0,0 +1,15 @@
+-- Field-level envelope encryption of value_text, value_json and user_identifier
+
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS encrypt BOOLEAN NOT NULL DEFAULT false;
+
+-- Records of encrypting sources keep value_text, value_json and user_identifier
+-- NULL and store them sealed in encrypted_values under a per-record data key,
+-- which is sealed by the key-encryption key of key_version
+ALTER TABLE experience_data
+  ADD COLUMN IF NOT EXISTS encrypted_values BYTEA,
+  ADD COLUMN IF NOT EXISTS encrypted_data_key BYTEA,
+  ADD COLUMN IF NOT EXISTS key_version INTEGER,
+  ADD COLUMN IF NOT EXISTS user_identifier_index BYTEA; -- HMAC-SHA256 blind index of the encrypted user_identifier
+
+CREATE INDEX IF NOT EXISTS idx_experience_data_key_version ON experience_data(key_version) WHERE key_version IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_experience_data_user_identifier_index ON experience_data(user_identifier_index) WHERE user_identifier_index IS NOT NULL;
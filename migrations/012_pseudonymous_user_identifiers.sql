@@ -0,0 +1,5 @@
+-- Pseudonymous user identifiers
+
+-- Records of pseudonymizing sources store user_identifier as "hmac:" followed by
+-- the hex encoded HMAC-SHA256 of the identifier
+ALTER TABLE sources ADD COLUMN IF NOT EXISTS pseudonymize BOOLEAN NOT NULL DEFAULT false;
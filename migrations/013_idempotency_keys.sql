@@ -0,0 +1,18 @@
+-- Idempotency keys of POST requests, so retried requests are not applied twice
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+  api_key_id UUID NOT NULL, -- Keys are scoped to the API key sending them
+  key VARCHAR(255) NOT NULL, -- Idempotency-Key header
+
+  fingerprint VARCHAR(64) NOT NULL, -- Hex encoded SHA-256 of the method, path and body of the request
+  status_code INTEGER, -- NULL while the request is in progress
+  content_type VARCHAR,
+  response_body BYTEA,
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (api_key_id, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
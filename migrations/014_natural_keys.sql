@@ -0,0 +1,13 @@
+-- Natural keys of records written by PUT /v1/experiences
+
+-- natural_key is the hex encoded SHA-256 of the natural_key_fields and their
+-- values. Records created with POST have none and are never matched by upserts.
+ALTER TABLE experience_data
+  ADD COLUMN IF NOT EXISTS natural_key VARCHAR(64),
+  ADD COLUMN IF NOT EXISTS natural_key_fields TEXT[];
+
+-- Backs INSERT ... ON CONFLICT of upserts. Records in the trash are not matched,
+-- so restoring one whose natural key was upserted again conflicts.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_experience_data_natural_key
+  ON experience_data(natural_key)
+  WHERE natural_key IS NOT NULL AND deleted_at IS NULL;
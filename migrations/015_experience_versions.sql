@@ -0,0 +1,5 @@
+-- Versions of experience data records for optimistic concurrency control
+
+-- Incremented by every change of a record; its ETag is the quoted version, so
+-- If-Match requests fail instead of overwriting changes made in between
+ALTER TABLE experience_data ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
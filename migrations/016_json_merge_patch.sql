@@ -0,0 +1,29 @@
+-- JSON Merge Patch (RFC 7396) of metadata in merge patch updates
+
+-- Applies patch to target: members of an object patch are merged recursively,
+-- null members remove the key and any other patch replaces target
+CREATE OR REPLACE FUNCTION jsonb_merge_patch(target JSONB, patch JSONB)
+RETURNS JSONB
+LANGUAGE plpgsql
+IMMUTABLE
+AS $$
+DECLARE
+  result JSONB;
+  member RECORD;
+BEGIN
+  IF patch IS NULL OR jsonb_typeof(patch) <> 'object' THEN
+    RETURN patch;
+  END IF;
+
+  result := CASE WHEN jsonb_typeof(target) = 'object' THEN target ELSE '{}'::jsonb END;
+  FOR member IN SELECT key, value FROM jsonb_each(patch) LOOP
+    IF jsonb_typeof(member.value) = 'null' THEN
+      result := result - member.key;
+    ELSE
+      result := jsonb_set(result, ARRAY[member.key], jsonb_merge_patch(result -> member.key, member.value));
+    END IF;
+  END LOOP;
+
+  RETURN result;
+END;
+$$;
@@ -0,0 +1,24 @@
+-- Bulk updates and deletes of experience data run by the worker
+
+CREATE TABLE IF NOT EXISTS bulk_jobs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+  action VARCHAR NOT NULL, -- update or delete
+  filters JSONB NOT NULL, -- Experience filters selecting the records
+  patch JSONB, -- Fields set by updates
+  status VARCHAR NOT NULL DEFAULT 'pending', -- pending, running, completed or failed
+
+  matched INTEGER NOT NULL, -- Records matching the filters when the job was created
+  max_affected INTEGER NOT NULL, -- The job fails instead of changing more records
+  affected INTEGER NOT NULL DEFAULT 0, -- Records updated or deleted so far
+  last_experience_id UUID, -- ID of the last processed record, so interrupted jobs resume after it
+  error TEXT,
+  api_key_id UUID, -- API key that created the job, recorded in the history of changed records
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(), -- Advanced by every processed batch while running
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_bulk_jobs_unfinished ON bulk_jobs(created_at) WHERE status IN ('pending', 'running');
@@ -0,0 +1,25 @@
+-- Exports of experience data to files, written by the worker
+
+CREATE TABLE IF NOT EXISTS exports (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+
+  format VARCHAR NOT NULL, -- csv, ndjson or parquet
+  filters JSONB NOT NULL, -- Experience filters selecting the records
+  fields TEXT[], -- Sparse fieldset, all fields when NULL
+  status VARCHAR NOT NULL DEFAULT 'pending', -- pending, running, completed, failed or expired
+
+  rows BIGINT NOT NULL DEFAULT 0, -- Records written so far
+  size BIGINT NOT NULL DEFAULT 0, -- Size of the file in bytes
+  object_key VARCHAR, -- Key of the file in the blob store while it is kept
+  error TEXT,
+  api_key_id UUID, -- API key that created the export
+
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(), -- Advanced while running as records are written
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP,
+  expires_at TIMESTAMP -- When the file of a completed export is deleted
+);
+
+CREATE INDEX IF NOT EXISTS idx_exports_unfinished ON exports(created_at) WHERE status IN ('pending', 'running');
+CREATE INDEX IF NOT EXISTS idx_exports_expires_at ON exports(expires_at) WHERE status = 'completed';
@@ -0,0 +1,9 @@
+-- Stored idempotent responses may hold experience data, so they are sealed when
+-- encryption keys are configured and removed when the records they returned
+-- are erased, expired by a retention policy or purged from the trash
+
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS key_version INTEGER; -- KEK version sealing response_body, NULL when stored in the clear
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS data_key BYTEA; -- Data key of response_body, sealed with the KEK
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS experience_ids UUID[]; -- Records returned in the response
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_experience_ids ON idempotency_keys USING GIN (experience_ids);
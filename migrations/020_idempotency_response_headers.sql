@@ -0,0 +1,4 @@
+-- Headers of idempotent responses replayed along with the body, e.g. the ETag
+-- of a created record
+
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers JSONB;
@@ -0,0 +1,8 @@
+-- Erasure receipts hold a keyed HMAC of the user identifier instead of a plain
+-- SHA-256, which a list of email addresses or user IDs reverses. Receipts of
+-- APIs without encryption keys hold no hash and are correlated by their id.
+-- Existing hashes cannot be rekeyed without the identifiers, so they are removed.
+
+ALTER TABLE data_subject_erasures ALTER COLUMN user_identifier_hash DROP NOT NULL;
+
+UPDATE data_subject_erasures SET user_identifier_hash = NULL;
@@ -0,0 +1,146 @@
+package blob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileStore keeps objects as files in a local directory
+//
+// Its download URLs are BaseURL followed by the key, with an expiry time and
+// an HMAC-SHA256 signature of both in the query. The API serves them after
+// checking the signature with Open.
+type FileStore struct {
+	dir        string
+	baseURL    string
+	signingKey []byte
+}
+
+// NewFileStore creates a store keeping objects in dir, which is created when missing
+func NewFileStore(dir, baseURL string, signingKey []byte) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("local blob store needs a directory")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &FileStore{dir: dir, baseURL: strings.TrimSuffix(baseURL, "/"), signingKey: signingKey}, nil
+}
+
+func (s *FileStore) path(key string) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	return filepath.Join(s.dir, filepath.FromSlash(key)), nil
+}
+
+// Put writes the object to a temporary file renamed into place once complete,
+// so an object is never read half written
+func (s *FileStore) Put(ctx context.Context, key string, body io.Reader, size int64) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	written, err := io.Copy(tmp, body)
+	if err != nil {
+		return fmt.Errorf("failed to write blob file: %w", err)
+	}
+	if written != size {
+		return fmt.Errorf("blob %s has %d bytes, expected %d", key, written, size)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write blob file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to store blob file: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the file of the object
+func (s *FileStore) Delete(ctx context.Context, key string) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob file: %w", err)
+	}
+	return nil
+}
+
+// signature returns the hex encoded signature of a download of key until expires
+func (s *FileStore) signature(key string, expires int64) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedURL returns BaseURL/key?expires=...&signature=...
+func (s *FileStore) SignedURL(key string, expires time.Time) (string, error) {
+	if err := validKey(key); err != nil {
+		return "", err
+	}
+	if len(s.signingKey) == 0 {
+		return "", errors.New("local blob store has no signing key")
+	}
+
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	query := url.Values{
+		"expires":   {strconv.FormatInt(expires.Unix(), 10)},
+		"signature": {s.signature(key, expires.Unix())},
+	}
+	return s.baseURL + "/" + strings.Join(segments, "/") + "?" + query.Encode(), nil
+}
+
+// Open opens the object under key for a download URL with the given expires
+// and signature query parameters, which must be signed by the store and not
+// have expired at now
+func (s *FileStore) Open(key, expires, signature string, now time.Time) (*os.File, error) {
+	path, err := s.path(key)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || len(s.signingKey) == 0 || now.Unix() > expiresAt ||
+		!hmac.Equal([]byte(signature), []byte(s.signature(key, expiresAt))) {
+		return nil, ErrInvalidSignature
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to open blob file: %w", err)
+	}
+	return file, nil
+}
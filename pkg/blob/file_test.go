@@ -0,0 +1,86 @@
+package blob
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, "/v1/downloads/", []byte("signing-key"))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, store.Put(ctx, "exports/1.csv", strings.NewReader("id\n"), 3))
+	assert.FileExists(t, filepath.Join(dir, "exports", "1.csv"))
+
+	now := time.Now()
+	signed, err := store.SignedURL("exports/1.csv", now.Add(time.Hour))
+	require.NoError(t, err)
+	u, err := url.Parse(signed)
+	require.NoError(t, err)
+	assert.Equal(t, "/v1/downloads/exports/1.csv", u.Path)
+
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	file, err := store.Open("exports/1.csv", expires, signature, now)
+	require.NoError(t, err)
+	content, err := io.ReadAll(file)
+	file.Close()
+	require.NoError(t, err)
+	assert.Equal(t, "id\n", string(content))
+
+	// Signatures cover the key and the expiry time
+	_, err = store.Open("exports/2.csv", expires, signature, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = store.Open("exports/1.csv", expires+"0", signature, now)
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+	_, err = store.Open("exports/1.csv", expires, signature, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	require.NoError(t, store.Delete(ctx, "exports/1.csv"))
+	require.NoError(t, store.Delete(ctx, "exports/1.csv"))
+	_, err = store.Open("exports/1.csv", expires, signature, now)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFileStorePutRejectsIncompleteObjects(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir, "/v1/downloads", []byte("signing-key"))
+	require.NoError(t, err)
+
+	err = store.Put(context.Background(), "exports/1.csv", strings.NewReader("id"), 3)
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "exports", "1.csv"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	entries, err := os.ReadDir(filepath.Join(dir, "exports"))
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestValidKey(t *testing.T) {
+	assert.NoError(t, validKey("exports/1.parquet"))
+	for _, key := range []string{"", "/etc/passwd", "../secrets", "exports/../../x", "exports//1", `exports\..\x`} {
+		assert.Error(t, validKey(key), key)
+	}
+}
+
+func TestNew(t *testing.T) {
+	store, err := New(Config{Dir: t.TempDir(), BaseURL: "/v1/downloads", SigningKey: []byte("k")})
+	require.NoError(t, err)
+	assert.IsType(t, &FileStore{}, store)
+
+	_, err = New(Config{Kind: "s3"})
+	assert.Error(t, err)
+	_, err = New(Config{Kind: "ftp"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,69 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidSignature is returned for download URLs that were not signed by
+// the store or have expired
+var ErrInvalidSignature = errors.New("invalid or expired signature")
+
+// Store keeps files, e.g. exports, as objects under slash separated keys
+type Store interface {
+	// Put stores the size bytes read from body under key, replacing any object stored before
+	Put(ctx context.Context, key string, body io.Reader, size int64) error
+	// Delete removes the object under key; deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL returns a URL downloading the object under key without further
+	// authentication until expires
+	SignedURL(key string, expires time.Time) (string, error)
+}
+
+// Config selects and configures a Store
+type Config struct {
+	// Kind is local (default) or s3
+	Kind string
+
+	// Dir is the directory of a local store
+	Dir string
+	// BaseURL prefixes the download URLs of a local store, which serves them
+	// with its Open method
+	BaseURL string
+	// SigningKey signs the download URLs of a local store
+	SigningKey []byte
+
+	S3 S3Config
+}
+
+// New creates the store selected by cfg
+func New(cfg Config) (Store, error) {
+	switch cfg.Kind {
+	case "", "local":
+		return NewFileStore(cfg.Dir, cfg.BaseURL, cfg.SigningKey)
+	case "s3":
+		return NewS3Store(cfg.S3)
+	default:
+		return nil, fmt.Errorf("unknown blob store %q, must be local or s3", cfg.Kind)
+	}
+}
+
+// validKey rejects keys that are empty, absolute or step out of the store
+func validKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." || strings.Contains(segment, `\`) {
+			return fmt.Errorf("invalid object key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testKey(b byte) []byte {
+	return bytes.Repeat([]byte{b}, KeySize)
+}
+
+func TestCipherRoundTrip(t *testing.T) {
+	c, err := NewCipher(testKey(1))
+	require.NoError(t, err)
+
+	sealed, err := c.Seal([]byte("call me at +49 30 1234567"), []byte("record-1"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(sealed), "1234567")
+
+	opened, err := c.Open(sealed, []byte("record-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "call me at +49 30 1234567", string(opened))
+
+	// A fresh nonce per value keeps equal plaintexts apart
+	again, err := c.Seal([]byte("call me at +49 30 1234567"), []byte("record-1"))
+	require.NoError(t, err)
+	assert.NotEqual(t, sealed, again)
+}
+
+func TestCipherOpenRejectsTamperedValues(t *testing.T) {
+	c, err := NewCipher(testKey(1))
+	require.NoError(t, err)
+
+	sealed, err := c.Seal([]byte("secret"), []byte("record-1"))
+	require.NoError(t, err)
+
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 0xff
+	_, err = c.Open(tampered, []byte("record-1"))
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+
+	_, err = c.Open(sealed[:4], []byte("record-1"))
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+
+	other, err := NewCipher(testKey(2))
+	require.NoError(t, err)
+	_, err = other.Open(sealed, []byte("record-1"))
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+
+	// A value copied to another record does not open there
+	_, err = c.Open(sealed, []byte("record-2"))
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+	_, err = c.Open(sealed, nil)
+	assert.True(t, errors.Is(err, ErrInvalidCiphertext))
+}
+
+func TestNewCipherFromBase64(t *testing.T) {
+	_, err := NewCipherFromBase64(base64.StdEncoding.EncodeToString(testKey(3)))
+	assert.NoError(t, err)
+
+	_, err = NewCipherFromBase64(base64.StdEncoding.EncodeToString([]byte("short")))
+	assert.Error(t, err)
+
+	_, err = NewCipherFromBase64("not base64!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Keyring performs envelope encryption with versioned key-encryption keys (KEKs)
+//
+// Every value is sealed under a fresh random data key, which is in turn sealed
+// (wrapped) by the current KEK. Values are opened with the KEK version stored
+// next to them, so older KEKs must stay configured until every value sealed
+// with them has been re-encrypted.
+//
+// The keyring also computes blind indexes, keyed hashes of values that allow
+// equality lookups without storing the value in the clear. The index key never
+// rotates, since that would orphan every stored index.
+type Keyring struct {
+	current  int
+	keys     map[int]*Cipher
+	indexKey []byte
+}
+
+// NewKeyring creates a keyring sealing with the KEK of version current
+// indexKey keys the blind indexes and must be 32 bytes.
+func NewKeyring(keys map[int][]byte, current int, indexKey []byte) (*Keyring, error) {
+	if _, ok := keys[current]; !ok {
+		return nil, fmt.Errorf("no key configured for current version %d", current)
+	}
+	if len(indexKey) != KeySize {
+		return nil, fmt.Errorf("index key must be %d bytes, got %d", KeySize, len(indexKey))
+	}
+
+	k := &Keyring{current: current, keys: make(map[int]*Cipher, len(keys)), indexKey: indexKey}
+	for version, key := range keys {
+		c, err := NewCipher(key)
+		if err != nil {
+			return nil, fmt.Errorf("key version %d: %w", version, err)
+		}
+		k.keys[version] = c
+	}
+
+	return k, nil
+}
+
+// ParseKeyring creates a keyring from configuration values
+// keys lists base64 encoded KEKs by version, e.g. "1:<key>,2:<key>". current
+// selects the KEK new values are sealed with, 0 selects the highest version.
+func ParseKeyring(keys string, current int, indexKey string) (*Keyring, error) {
+	parsed := make(map[int][]byte)
+	highest := 0
+	for _, entry := range strings.Split(keys, ",") {
+		versionStr, encodedKey, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, fmt.Errorf("key entry must be <version>:<base64 key>")
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("key version must be a positive integer, got %q", versionStr)
+		}
+		if _, exists := parsed[version]; exists {
+			return nil, fmt.Errorf("key version %d is configured twice", version)
+		}
+		key, err := base64.StdEncoding.DecodeString(encodedKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode key version %d: %w", version, err)
+		}
+		parsed[version] = key
+		highest = max(highest, version)
+	}
+
+	if current == 0 {
+		current = highest
+	}
+
+	decodedIndexKey, err := base64.StdEncoding.DecodeString(indexKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode index key: %w", err)
+	}
+
+	return NewKeyring(parsed, current, decodedIndexKey)
+}
+
+// CurrentVersion returns the version of the KEK new values are sealed with
+func (k *Keyring) CurrentVersion() int {
+	return k.current
+}
+
+// Envelope is a value sealed by a Keyring
+type Envelope struct {
+	Ciphertext []byte // Value sealed with the data key
+	DataKey    []byte // Data key sealed with the KEK of KeyVersion
+	KeyVersion int
+}
+
+// Seal encrypts plaintext under a fresh data key wrapped by the current KEK
+// Both the value and the wrapped data key are bound to additionalData, see Cipher.Seal.
+func (k *Keyring) Seal(plaintext, additionalData []byte) (*Envelope, error) {
+	dataKey := make([]byte, KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	dataCipher, err := NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := dataCipher.Seal(plaintext, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapped, err := k.keys[k.current].Seal(dataKey, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{Ciphertext: ciphertext, DataKey: wrapped, KeyVersion: k.current}, nil
+}
+
+// Open decrypts an envelope produced by Seal with the same additionalData
+func (k *Keyring) Open(envelope *Envelope, additionalData []byte) ([]byte, error) {
+	kek, ok := k.keys[envelope.KeyVersion]
+	if !ok {
+		return nil, fmt.Errorf("key version %d is not configured", envelope.KeyVersion)
+	}
+
+	dataKey, err := kek.Open(envelope.DataKey, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	dataCipher, err := NewCipher(dataKey)
+	if err != nil {
+		return nil, ErrInvalidCiphertext
+	}
+	return dataCipher.Open(envelope.Ciphertext, additionalData)
+}
+
+// Index returns the blind index of value, an HMAC-SHA256 under the index key
+func (k *Keyring) Index(value string) []byte {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,89 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodedKey(b byte) string {
+	return base64.StdEncoding.EncodeToString(testKey(b))
+}
+
+func TestKeyringSealAndRotate(t *testing.T) {
+	indexKey := encodedKey(9)
+
+	v1, err := ParseKeyring("1:"+encodedKey(1), 0, indexKey)
+	require.NoError(t, err)
+	assert.Equal(t, 1, v1.CurrentVersion())
+
+	envelope, err := v1.Seal([]byte("jane@example.com"), []byte("record-1"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, envelope.KeyVersion)
+
+	// After rotation the old KEK still opens old envelopes, new ones use version 2
+	v2, err := ParseKeyring(fmt.Sprintf("1:%s, 2:%s", encodedKey(1), encodedKey(2)), 0, indexKey)
+	require.NoError(t, err)
+	assert.Equal(t, 2, v2.CurrentVersion())
+
+	plaintext, err := v2.Open(envelope, []byte("record-1"))
+	require.NoError(t, err)
+	assert.Equal(t, "jane@example.com", string(plaintext))
+
+	resealed, err := v2.Seal(plaintext, []byte("record-1"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, resealed.KeyVersion)
+
+	// Version 1 does not know the new KEK
+	_, err = v1.Open(resealed, []byte("record-1"))
+	assert.Error(t, err)
+
+	// Envelopes only open with the additional data they were sealed with
+	_, err = v2.Open(resealed, []byte("record-2"))
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	// A pinned current version keeps sealing with the old KEK
+	pinned, err := ParseKeyring(fmt.Sprintf("1:%s,2:%s", encodedKey(1), encodedKey(2)), 1, indexKey)
+	require.NoError(t, err)
+	assert.Equal(t, 1, pinned.CurrentVersion())
+}
+
+func TestKeyringIndex(t *testing.T) {
+	a, err := ParseKeyring("1:"+encodedKey(1), 0, encodedKey(9))
+	require.NoError(t, err)
+	b, err := ParseKeyring("2:"+encodedKey(2), 0, encodedKey(9))
+	require.NoError(t, err)
+	other, err := ParseKeyring("1:"+encodedKey(1), 0, encodedKey(8))
+	require.NoError(t, err)
+
+	// The index depends on the index key only, so it survives KEK rotation
+	assert.Equal(t, a.Index("user-1"), b.Index("user-1"))
+	assert.NotEqual(t, a.Index("user-1"), a.Index("user-2"))
+	assert.NotEqual(t, a.Index("user-1"), other.Index("user-1"))
+}
+
+func TestParseKeyringErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		current  int
+		indexKey string
+	}{
+		{name: "missing version", keys: encodedKey(1), indexKey: encodedKey(9)},
+		{name: "invalid version", keys: "v1:" + encodedKey(1), indexKey: encodedKey(9)},
+		{name: "duplicate version", keys: "1:" + encodedKey(1) + ",1:" + encodedKey(2), indexKey: encodedKey(9)},
+		{name: "short key", keys: "1:" + base64.StdEncoding.EncodeToString([]byte("short")), indexKey: encodedKey(9)},
+		{name: "unknown current version", keys: "1:" + encodedKey(1), current: 2, indexKey: encodedKey(9)},
+		{name: "missing index key", keys: "1:" + encodedKey(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseKeyring(tt.keys, tt.current, tt.indexKey)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package encryption
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// PseudonymPrefix marks values replaced by their pseudonym
+const PseudonymPrefix = "hmac:"
+
+// PseudonymPattern is a regular expression matching the values IsPseudonym
+// accepts, for use in database queries
+const PseudonymPattern = "^" + PseudonymPrefix + "[0-9a-f]{64}$"
+
+// Pseudonymizer replaces values by pseudonyms, keyed hashes that are equal for
+// equal values but cannot be reversed without brute forcing the key
+//
+// Pseudonyms are stable for as long as the key is, so the key must not change
+// once values have been pseudonymized with it.
+type Pseudonymizer struct {
+	key []byte
+}
+
+// NewPseudonymizer creates a pseudonymizer from a 32 byte key
+func NewPseudonymizer(key []byte) (*Pseudonymizer, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("pseudonym key must be %d bytes, got %d", KeySize, len(key))
+	}
+	return &Pseudonymizer{key: key}, nil
+}
+
+// NewPseudonymizerFromBase64 creates a pseudonymizer from a base64 encoded 32 byte key
+func NewPseudonymizerFromBase64(encodedKey string) (*Pseudonymizer, error) {
+	key, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key: %w", err)
+	}
+	return NewPseudonymizer(key)
+}
+
+// Pseudonym returns PseudonymPrefix followed by the hex encoded HMAC-SHA256 of
+// value. Values that already are pseudonyms are returned unchanged.
+func (p *Pseudonymizer) Pseudonym(value string) string {
+	if IsPseudonym(value) {
+		return value
+	}
+
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(value))
+	return PseudonymPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// IsPseudonym reports whether value is a pseudonym: PseudonymPrefix followed by
+// the 64 lowercase hex characters of a hash. Other values starting with the
+// prefix are not pseudonyms and must not be stored as if they were.
+func IsPseudonym(value string) bool {
+	hash, ok := strings.CutPrefix(value, PseudonymPrefix)
+	if !ok || len(hash) != hex.EncodedLen(sha256.Size) {
+		return false
+	}
+	for _, c := range hash {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package encryption
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPseudonym(t *testing.T) {
+	p, err := NewPseudonymizerFromBase64(encodedKey(5))
+	require.NoError(t, err)
+
+	pseudonym := p.Pseudonym("jane@example.com")
+	assert.True(t, IsPseudonym(pseudonym))
+	assert.Len(t, pseudonym, len(PseudonymPrefix)+64)
+	assert.NotContains(t, pseudonym, "jane")
+
+	// Equal values get equal pseudonyms, so records can still be grouped by person
+	assert.Equal(t, pseudonym, p.Pseudonym("jane@example.com"))
+	assert.NotEqual(t, pseudonym, p.Pseudonym("john@example.com"))
+
+	// Pseudonymizing twice is a no-op
+	assert.Equal(t, pseudonym, p.Pseudonym(pseudonym))
+
+	// Another key gives other pseudonyms
+	other, err := NewPseudonymizer(testKey(6))
+	require.NoError(t, err)
+	assert.NotEqual(t, pseudonym, other.Pseudonym("jane@example.com"))
+}
+
+func TestIsPseudonym(t *testing.T) {
+	p, err := NewPseudonymizer(testKey(5))
+	require.NoError(t, err)
+	pseudonym := p.Pseudonym("jane@example.com")
+
+	assert.True(t, IsPseudonym(pseudonym))
+	assert.Regexp(t, PseudonymPattern, pseudonym)
+	for _, value := range []string{
+		"jane@example.com",
+		"hmac:jane@example.com",
+		"hmac:",
+		pseudonym[:len(pseudonym)-1],
+		pseudonym + "0",
+		strings.ToUpper(pseudonym[:5]) + pseudonym[5:],
+		PseudonymPrefix + strings.ToUpper(pseudonym[5:]),
+		PseudonymPrefix + strings.Repeat("g", 64),
+	} {
+		assert.False(t, IsPseudonym(value), value)
+		assert.NotRegexp(t, PseudonymPattern, value)
+	}
+
+	// Values that only look like pseudonyms are pseudonymized
+	assert.True(t, IsPseudonym(p.Pseudonym("hmac:jane@example.com")))
+	assert.NotEqual(t, "hmac:jane@example.com", p.Pseudonym("hmac:jane@example.com"))
+}
+
+func TestNewPseudonymizerKeySize(t *testing.T) {
+	_, err := NewPseudonymizer([]byte("short"))
+	assert.Error(t, err)
+
+	_, err = NewPseudonymizerFromBase64("not base64!")
+	assert.Error(t, err)
+}
@@ -0,0 +1,448 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The reader below decodes files the way any Parquet reader would, following
+// the format specification rather than the Writer. It shares no code or
+// constants with the Writer, so the round trip checks the file format instead
+// of the Writer agreeing with itself.
+
+// thriftStructValue is a decoded Thrift struct, keyed by field id
+type thriftStructValue map[int16]interface{}
+
+// thriftReader decodes the Thrift compact protocol
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+var errThriftTruncated = errors.New("thrift: truncated input")
+
+func (r *thriftReader) byte() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errThriftTruncated
+	}
+	b := r.buf[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *thriftReader) uvarint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errThriftTruncated
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) varint() (int64, error) {
+	v, err := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+// readStruct decodes the fields of a struct up to its stop field
+func (r *thriftReader) readStruct() (thriftStructValue, error) {
+	fields := thriftStructValue{}
+	var id int16
+	for {
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return fields, nil
+		}
+		typ := header & 0x0f
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			v, err := r.varint()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+
+		switch typ {
+		case 1:
+			fields[id] = true
+		case 2:
+			fields[id] = false
+		default:
+			if fields[id], err = r.value(typ); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// value decodes a value of the compact type typ
+func (r *thriftReader) value(typ byte) (interface{}, error) {
+	switch typ {
+	case 1, 2:
+		b, err := r.byte()
+		return b == 1, err
+	case 3:
+		b, err := r.byte()
+		return int64(int8(b)), err
+	case 4, 5, 6:
+		return r.varint()
+	case 7:
+		if r.pos+8 > len(r.buf) {
+			return nil, errThriftTruncated
+		}
+		v := math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos:]))
+		r.pos += 8
+		return v, nil
+	case 8:
+		n, err := r.uvarint()
+		if err != nil {
+			return nil, err
+		}
+		if r.pos+int(n) > len(r.buf) {
+			return nil, errThriftTruncated
+		}
+		v := string(r.buf[r.pos : r.pos+int(n)])
+		r.pos += int(n)
+		return v, nil
+	case 9, 10:
+		header, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.uvarint(); err != nil {
+				return nil, err
+			}
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			if list[i], err = r.value(header & 0x0f); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case 12:
+		return r.readStruct()
+	default:
+		return nil, fmt.Errorf("thrift: unsupported type %d", typ)
+	}
+}
+
+// Accessors of decoded fields, failing the test when a field is missing or of
+// another type
+
+func fieldInt(t *testing.T, s thriftStructValue, id int16) int64 {
+	t.Helper()
+	v, ok := s[id].(int64)
+	require.Truef(t, ok, "field %d is %T, want an integer", id, s[id])
+	return v
+}
+
+func fieldString(t *testing.T, s thriftStructValue, id int16) string {
+	t.Helper()
+	v, ok := s[id].(string)
+	require.Truef(t, ok, "field %d is %T, want binary", id, s[id])
+	return v
+}
+
+func fieldStruct(t *testing.T, s thriftStructValue, id int16) thriftStructValue {
+	t.Helper()
+	v, ok := s[id].(thriftStructValue)
+	require.Truef(t, ok, "field %d is %T, want a struct", id, s[id])
+	return v
+}
+
+func fieldStructs(t *testing.T, s thriftStructValue, id int16) []thriftStructValue {
+	t.Helper()
+	list, ok := s[id].([]interface{})
+	require.Truef(t, ok, "field %d is %T, want a list", id, s[id])
+	structs := make([]thriftStructValue, len(list))
+	for i, v := range list {
+		structs[i], ok = v.(thriftStructValue)
+		require.Truef(t, ok, "element %d of field %d is %T, want a struct", i, id, v)
+	}
+	return structs
+}
+
+// schemaElement is a leaf of a decoded flat schema
+type schemaElement struct {
+	name       string
+	typ        int64
+	repetition int64
+	converted  int64
+	logical    thriftStructValue
+}
+
+// parquetFile is a decoded Parquet file
+type parquetFile struct {
+	schema    []schemaElement
+	numRows   int64
+	rowGroups int
+	codecs    []int64
+	// columns holds every value of every column, nil for null
+	columns [][]interface{}
+}
+
+// readParquet decodes a Parquet file with a flat schema of optional columns,
+// GZIP compressed v1 data pages, PLAIN values and RLE definition levels
+func readParquet(t *testing.T, file []byte) *parquetFile {
+	t.Helper()
+	require.Greater(t, len(file), 12)
+	require.Equal(t, "PAR1", string(file[:4]))
+	require.Equal(t, "PAR1", string(file[len(file)-4:]))
+	footerLength := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	require.LessOrEqual(t, footerLength, len(file)-12)
+
+	footer := &thriftReader{buf: file[len(file)-8-footerLength : len(file)-8]}
+	meta, err := footer.readStruct()
+	require.NoError(t, err)
+	require.Equal(t, footerLength, footer.pos, "footer has trailing bytes")
+
+	pf := &parquetFile{numRows: fieldInt(t, meta, 3)}
+	elements := fieldStructs(t, meta, 2)
+	require.NotEmpty(t, elements)
+	require.Equal(t, int64(len(elements)-1), fieldInt(t, elements[0], 5), "root num_children")
+	for _, e := range elements[1:] {
+		el := schemaElement{name: fieldString(t, e, 4), typ: fieldInt(t, e, 1), repetition: fieldInt(t, e, 3), converted: -1}
+		if _, ok := e[6]; ok {
+			el.converted = fieldInt(t, e, 6)
+		}
+		if _, ok := e[10]; ok {
+			el.logical = fieldStruct(t, e, 10)
+		}
+		pf.schema = append(pf.schema, el)
+	}
+	pf.columns = make([][]interface{}, len(pf.schema))
+
+	groups := fieldStructs(t, meta, 4)
+	pf.rowGroups = len(groups)
+	for _, group := range groups {
+		rows := fieldInt(t, group, 3)
+		chunks := fieldStructs(t, group, 1)
+		require.Len(t, chunks, len(pf.schema))
+		for i, chunk := range chunks {
+			cm := fieldStruct(t, chunk, 3)
+			require.Equal(t, pf.schema[i].typ, fieldInt(t, cm, 1))
+			require.Equal(t, rows, fieldInt(t, cm, 5))
+			pf.codecs = append(pf.codecs, fieldInt(t, cm, 4))
+
+			start := fieldInt(t, cm, 9)
+			end := start + fieldInt(t, cm, 7)
+			require.LessOrEqual(t, end, int64(len(file)-8-footerLength))
+			values := readChunk(t, file[start:end], pf.schema[i].typ, fieldInt(t, cm, 4))
+			require.Len(t, values, int(rows))
+			pf.columns[i] = append(pf.columns[i], values...)
+		}
+	}
+	return pf
+}
+
+// readChunk decodes the data pages of a column chunk
+func readChunk(t *testing.T, chunk []byte, typ, codec int64) []interface{} {
+	t.Helper()
+	require.Equal(t, int64(2), codec, "only GZIP chunks are read")
+
+	var values []interface{}
+	for len(chunk) > 0 {
+		r := &thriftReader{buf: chunk}
+		header, err := r.readStruct()
+		require.NoError(t, err)
+		require.Equal(t, int64(0), fieldInt(t, header, 1), "page type")
+		compressedSize := int(fieldInt(t, header, 3))
+		require.LessOrEqual(t, r.pos+compressedSize, len(chunk))
+
+		zr, err := gzip.NewReader(bytes.NewReader(chunk[r.pos : r.pos+compressedSize]))
+		require.NoError(t, err)
+		body, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		require.Len(t, body, int(fieldInt(t, header, 2)), "uncompressed page size")
+
+		data := fieldStruct(t, header, 5)
+		require.Equal(t, int64(0), fieldInt(t, data, 2), "value encoding")
+		require.Equal(t, int64(3), fieldInt(t, data, 3), "definition level encoding")
+		values = append(values, decodePage(t, body, typ, int(fieldInt(t, data, 1)))...)
+		chunk = chunk[r.pos+compressedSize:]
+	}
+	return values
+}
+
+// decodePage decodes the definition levels and PLAIN values of a page body
+func decodePage(t *testing.T, body []byte, typ int64, count int) []interface{} {
+	t.Helper()
+	require.GreaterOrEqual(t, len(body), 4)
+	levelsLength := int(binary.LittleEndian.Uint32(body))
+	require.LessOrEqual(t, 4+levelsLength, len(body))
+	defined := decodeLevels(t, body[4:4+levelsLength], count)
+	plain := body[4+levelsLength:]
+
+	values := make([]interface{}, count)
+	var bit int
+	for i := range values {
+		if !defined[i] {
+			continue
+		}
+		switch typ {
+		case 0:
+			require.Less(t, bit/8, len(plain))
+			values[i] = plain[bit/8]&(1<<(bit%8)) != 0
+			bit++
+		case 2:
+			require.GreaterOrEqual(t, len(plain), 8)
+			values[i] = int64(binary.LittleEndian.Uint64(plain))
+			plain = plain[8:]
+		case 5:
+			require.GreaterOrEqual(t, len(plain), 8)
+			values[i] = math.Float64frombits(binary.LittleEndian.Uint64(plain))
+			plain = plain[8:]
+		case 6:
+			require.GreaterOrEqual(t, len(plain), 4)
+			n := int(binary.LittleEndian.Uint32(plain))
+			require.GreaterOrEqual(t, len(plain), 4+n)
+			values[i] = string(plain[4 : 4+n])
+			plain = plain[4+n:]
+		default:
+			t.Fatalf("unsupported physical type %d", typ)
+		}
+	}
+	if typ == 0 {
+		plain = plain[(bit+7)/8:]
+	}
+	assert.Empty(t, plain, "page has trailing values")
+	return values
+}
+
+// decodeLevels decodes count definition levels of bit width 1 from the RLE /
+// bit-packing hybrid encoding
+func decodeLevels(t *testing.T, encoded []byte, count int) []bool {
+	t.Helper()
+	var levels []bool
+	for len(levels) < count {
+		header, n := binary.Uvarint(encoded)
+		require.Greater(t, n, 0)
+		encoded = encoded[n:]
+		if header&1 == 0 {
+			require.NotEmpty(t, encoded)
+			for i := uint64(0); i < header>>1; i++ {
+				levels = append(levels, encoded[0] == 1)
+			}
+			encoded = encoded[1:]
+		} else {
+			groups := int(header >> 1)
+			require.GreaterOrEqual(t, len(encoded), groups)
+			for i := 0; i < groups*8; i++ {
+				levels = append(levels, encoded[i/8]&(1<<(i%8)) != 0)
+			}
+			encoded = encoded[groups:]
+		}
+	}
+	assert.Empty(t, encoded, "levels have trailing bytes")
+	// Bit-packed runs are padded to groups of 8 values
+	return levels[:count]
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	base := time.Date(2025, 3, 1, 12, 30, 15, 123456000, time.UTC)
+	rowCount := 500
+	row := func(i int) []interface{} {
+		values := []interface{}{fmt.Sprintf("row-%d", i), nil, int64(i) - 250, nil, nil, nil}
+		if i%11 == 0 {
+			values[0] = nil
+		}
+		if i%3 != 0 {
+			values[1] = float64(i) / 4
+		}
+		if i%5 != 0 {
+			values[3] = i%2 == 0
+		}
+		if i%7 != 0 {
+			values[4] = base.Add(time.Duration(i) * time.Hour).UnixMicro()
+		}
+		if i%2 == 0 {
+			values[5] = fmt.Sprintf(`{"n":%d}`, i)
+		}
+		return values
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testColumns)
+	require.NoError(t, err)
+	w.pageSize, w.rowGroupSize = 64, 1024
+	for i := 0; i < rowCount; i++ {
+		require.NoError(t, w.Write(row(i)))
+	}
+	require.NoError(t, w.Close())
+
+	file := readParquet(t, buf.Bytes())
+
+	t.Run("Schema", func(t *testing.T) {
+		require.Len(t, file.schema, len(testColumns))
+		for i, c := range testColumns {
+			assert.Equal(t, c.Name, file.schema[i].name)
+			assert.Equal(t, int64(c.Type), file.schema[i].typ, c.Name)
+			assert.Equal(t, int64(1), file.schema[i].repetition, "%s is OPTIONAL", c.Name)
+		}
+
+		// Converted types: UTF8 = 0, TIMESTAMP_MICROS = 10, JSON = 19
+		assert.Equal(t, int64(0), file.schema[0].converted)
+		assert.Contains(t, file.schema[0].logical, int16(1), "id is a STRING")
+		assert.Equal(t, int64(-1), file.schema[1].converted)
+		assert.Nil(t, file.schema[1].logical)
+		assert.Equal(t, int64(-1), file.schema[2].converted)
+		assert.Equal(t, int64(-1), file.schema[3].converted)
+		assert.Equal(t, int64(10), file.schema[4].converted)
+		assert.Equal(t, int64(19), file.schema[5].converted)
+		assert.Contains(t, file.schema[5].logical, int16(12), "metadata is JSON")
+
+		timestamp := fieldStruct(t, file.schema[4].logical, 8)
+		assert.Equal(t, true, timestamp[1], "isAdjustedToUTC")
+		assert.Contains(t, fieldStruct(t, timestamp, 2), int16(2), "unit is MICROS")
+	})
+
+	t.Run("Pages are GZIP compressed", func(t *testing.T) {
+		assert.Greater(t, file.rowGroups, 1)
+		require.NotEmpty(t, file.codecs)
+		for _, codec := range file.codecs {
+			assert.Equal(t, int64(2), codec)
+		}
+	})
+
+	t.Run("Values and nulls", func(t *testing.T) {
+		assert.Equal(t, int64(rowCount), file.numRows)
+		for i := 0; i < rowCount; i++ {
+			want := row(i)
+			for c := range testColumns {
+				require.Len(t, file.columns[c], rowCount)
+				assert.Equal(t, want[c], file.columns[c][i], "row %d, column %s", i, testColumns[c].Name)
+			}
+		}
+	})
+
+	t.Run("Timestamps", func(t *testing.T) {
+		for i := 0; i < rowCount; i++ {
+			got := file.columns[4][i]
+			if i%7 == 0 {
+				assert.Nil(t, got)
+				continue
+			}
+			micros, ok := got.(int64)
+			require.True(t, ok)
+			assert.True(t, time.UnixMicro(micros).UTC().Equal(base.Add(time.Duration(i)*time.Hour)), "row %d", i)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package parquet
+
+import "encoding/binary"
+
+// Types of the Thrift compact protocol used by the file and page metadata
+const (
+	thriftTrue   = 1
+	thriftFalse  = 2
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes Thrift structs with the compact protocol. Fields must be
+// written in ascending order of their IDs within each struct.
+type thriftWriter struct {
+	buf []byte
+	// last holds the ID of the last field written in each open struct
+	last []int16
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// field writes the header of field id of the innermost open struct
+func (t *thriftWriter) field(id int16, typ byte) {
+	top := len(t.last) - 1
+	delta := id - t.last[top]
+	if delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.buf = binary.AppendUvarint(t.buf, zigzag(int64(id)))
+	}
+	t.last[top] = id
+}
+
+// begin opens a struct, the top-level one, a list element or, after field, a field value
+func (t *thriftWriter) begin() {
+	t.last = append(t.last, 0)
+}
+
+// end closes the innermost open struct
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.buf = binary.AppendUvarint(t.buf, zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.buf = binary.AppendUvarint(t.buf, zigzag(v))
+}
+
+func (t *thriftWriter) bool(id int16, v bool) {
+	if v {
+		t.field(id, thriftTrue)
+	} else {
+		t.field(id, thriftFalse)
+	}
+}
+
+func (t *thriftWriter) string(id int16, v string) {
+	t.field(id, thriftBinary)
+	t.appendString(v)
+}
+
+func (t *thriftWriter) appendString(v string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
+
+// structField opens a struct valued field, closed by end
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, thriftStruct)
+	t.begin()
+}
+
+// list writes the header of a list field of size elements of type elem,
+// which follow it
+func (t *thriftWriter) list(id int16, elem byte, size int) {
+	t.field(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(size))
+}
+
+// i32List writes a list field of i32 values
+func (t *thriftWriter) i32List(id int16, values ...int32) {
+	t.list(id, thriftI32, len(values))
+	for _, v := range values {
+		t.buf = binary.AppendUvarint(t.buf, zigzag(int64(v)))
+	}
+}
+
+// stringList writes a list field of strings
+func (t *thriftWriter) stringList(id int16, values ...string) {
+	t.list(id, thriftBinary, len(values))
+	for _, v := range values {
+		t.appendString(v)
+	}
+}
@@ -0,0 +1,443 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Physical types of column values
+type Type int32
+
+const (
+	Boolean   Type = 0
+	Int64     Type = 2
+	Double    Type = 5
+	ByteArray Type = 6
+)
+
+// Logical annotates how the values of a column are to be read
+type Logical int
+
+const (
+	Plain           Logical = iota // No annotation
+	String                         // UTF-8 text in a ByteArray column
+	JSON                           // A JSON document in a ByteArray column
+	TimestampMicros                // Microseconds since the Unix epoch, UTC, in an Int64 column
+)
+
+// Column describes a column of a flat schema. Every column is optional.
+type Column struct {
+	Name    string
+	Type    Type
+	Logical Logical
+}
+
+// Parquet metadata enums written by the Writer
+const (
+	repetitionOptional   = 1
+	convertedUTF8        = 0
+	convertedTimestampUs = 10
+	convertedJSON        = 19
+	encodingPlain        = 0
+	encodingRLE          = 3
+	codecGzip            = 2
+	pageTypeData         = 0
+)
+
+// Default sizes of the pages and row groups the Writer cuts
+const (
+	defaultPageSize     = 1 << 20
+	defaultRowGroupSize = 64 << 20
+)
+
+const magic = "PAR1"
+
+// createdBy identifies the writer in the file metadata
+const createdBy = "formbricks-hub"
+
+// ErrClosed is returned when writing to a closed Writer
+var ErrClosed = errors.New("parquet writer is closed")
+
+// column buffers the values of a column in the current row group
+type column struct {
+	Column
+
+	// levels holds the definition level of every value of the current page,
+	// false for null
+	levels []bool
+	// values holds the PLAIN encoded values of the current page, except booleans
+	values []byte
+	bools  []bool
+
+	// pages holds the completed pages of the current row group
+	pages        []byte
+	uncompressed int64
+}
+
+// Writer writes rows to a Parquet file with a flat schema of optional columns
+//
+// Values are PLAIN encoded, definition levels RLE encoded, and pages GZIP
+// compressed. Rows are buffered in memory until their row group is full, so
+// memory use is bounded by the row group size rather than the file size.
+type Writer struct {
+	w       io.Writer
+	offset  int64
+	columns []*column
+	gzip    *gzip.Writer
+
+	pageSize     int
+	rowGroupSize int
+
+	groupRows  int64
+	totalRows  int64
+	groups     []rowGroup
+	closed     bool
+	writeError error
+}
+
+// columnChunk records where a column of a row group was written
+type columnChunk struct {
+	offset       int64
+	uncompressed int64
+	compressed   int64
+}
+
+type rowGroup struct {
+	rows   int64
+	chunks []columnChunk
+}
+
+// NewWriter starts a Parquet file with the given columns on w
+func NewWriter(w io.Writer, columns []Column) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("parquet schema needs at least one column")
+	}
+
+	pw := &Writer{w: w, pageSize: defaultPageSize, rowGroupSize: defaultRowGroupSize}
+	for _, c := range columns {
+		switch {
+		case c.Logical == String || c.Logical == JSON:
+			if c.Type != ByteArray {
+				return nil, fmt.Errorf("column %s: text and JSON columns must be ByteArray", c.Name)
+			}
+		case c.Logical == TimestampMicros:
+			if c.Type != Int64 {
+				return nil, fmt.Errorf("column %s: timestamp columns must be Int64", c.Name)
+			}
+		}
+		pw.columns = append(pw.columns, &column{Column: c})
+	}
+
+	var err error
+	if pw.gzip, err = gzip.NewWriterLevel(io.Discard, gzip.BestSpeed); err != nil {
+		return nil, err
+	}
+	if err := pw.write([]byte(magic)); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// write writes b to the file, remembering the first error
+func (pw *Writer) write(b []byte) error {
+	if pw.writeError != nil {
+		return pw.writeError
+	}
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	if err != nil {
+		pw.writeError = fmt.Errorf("failed to write parquet file: %w", err)
+	}
+	return pw.writeError
+}
+
+// Write adds a row holding a value for every column, in schema order. Values
+// are nil for null, or a bool, int64, float64, or string or []byte, following
+// the column type.
+func (pw *Writer) Write(row []interface{}) error {
+	if pw.closed {
+		return ErrClosed
+	}
+	if len(row) != len(pw.columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(row), len(pw.columns))
+	}
+
+	for i, c := range pw.columns {
+		if err := c.add(row[i]); err != nil {
+			return err
+		}
+	}
+	pw.groupRows++
+
+	var buffered int
+	for _, c := range pw.columns {
+		if c.pageBytes() >= pw.pageSize {
+			if err := c.flushPage(pw.gzip); err != nil {
+				return err
+			}
+		}
+		buffered += len(c.pages) + c.pageBytes()
+	}
+	if buffered >= pw.rowGroupSize {
+		return pw.flushRowGroup()
+	}
+	return nil
+}
+
+// add buffers v, the next value of the column
+func (c *column) add(v interface{}) error {
+	if v == nil {
+		c.levels = append(c.levels, false)
+		return nil
+	}
+
+	switch c.Type {
+	case Boolean:
+		b, ok := v.(bool)
+		if !ok {
+			return c.typeError(v)
+		}
+		c.bools = append(c.bools, b)
+	case Int64:
+		n, ok := v.(int64)
+		if !ok {
+			return c.typeError(v)
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, uint64(n))
+	case Double:
+		f, ok := v.(float64)
+		if !ok {
+			return c.typeError(v)
+		}
+		c.values = binary.LittleEndian.AppendUint64(c.values, math.Float64bits(f))
+	case ByteArray:
+		switch b := v.(type) {
+		case string:
+			c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(b)))
+			c.values = append(c.values, b...)
+		case []byte:
+			c.values = binary.LittleEndian.AppendUint32(c.values, uint32(len(b)))
+			c.values = append(c.values, b...)
+		default:
+			return c.typeError(v)
+		}
+	}
+
+	c.levels = append(c.levels, true)
+	return nil
+}
+
+func (c *column) typeError(v interface{}) error {
+	return fmt.Errorf("column %s: unsupported value of type %T", c.Name, v)
+}
+
+// pageBytes estimates the encoded size of the current page
+func (c *column) pageBytes() int {
+	return len(c.values) + len(c.bools)/8 + len(c.levels)/8
+}
+
+// flushPage appends the current page, if it holds any value, to the pages of
+// the row group
+func (c *column) flushPage(zw *gzip.Writer) error {
+	if len(c.levels) == 0 {
+		return nil
+	}
+
+	levels := encodeLevels(c.levels)
+	body := binary.LittleEndian.AppendUint32(nil, uint32(len(levels)))
+	body = append(body, levels...)
+	if c.Type == Boolean {
+		body = append(body, packBools(c.bools)...)
+	} else {
+		body = append(body, c.values...)
+	}
+
+	var compressed bytes.Buffer
+	zw.Reset(&compressed)
+	if _, err := zw.Write(body); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	t := &thriftWriter{}
+	t.begin()
+	t.i32(1, pageTypeData)
+	t.i32(2, int32(len(body)))
+	t.i32(3, int32(compressed.Len()))
+	t.structField(5)
+	t.i32(1, int32(len(c.levels)))
+	t.i32(2, encodingPlain)
+	t.i32(3, encodingRLE)
+	t.i32(4, encodingRLE)
+	t.end()
+	t.end()
+
+	c.pages = append(c.pages, t.buf...)
+	c.pages = append(c.pages, compressed.Bytes()...)
+	c.uncompressed += int64(len(t.buf) + len(body))
+
+	c.levels, c.values, c.bools = c.levels[:0], c.values[:0], c.bools[:0]
+	return nil
+}
+
+// encodeLevels encodes definition levels of bit width 1 as runs of the RLE /
+// bit-packing hybrid encoding
+func encodeLevels(levels []bool) []byte {
+	var encoded []byte
+	for i := 0; i < len(levels); {
+		j := i
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		encoded = binary.AppendUvarint(encoded, uint64(j-i)<<1)
+		if levels[i] {
+			encoded = append(encoded, 1)
+		} else {
+			encoded = append(encoded, 0)
+		}
+		i = j
+	}
+	return encoded
+}
+
+// packBools PLAIN encodes booleans as bits, least significant first
+func packBools(bools []bool) []byte {
+	packed := make([]byte, (len(bools)+7)/8)
+	for i, b := range bools {
+		if b {
+			packed[i/8] |= 1 << (i % 8)
+		}
+	}
+	return packed
+}
+
+// flushRowGroup writes the buffered rows as a row group
+func (pw *Writer) flushRowGroup() error {
+	if pw.groupRows == 0 {
+		return nil
+	}
+
+	group := rowGroup{rows: pw.groupRows}
+	for _, c := range pw.columns {
+		if err := c.flushPage(pw.gzip); err != nil {
+			return err
+		}
+		chunk := columnChunk{offset: pw.offset, uncompressed: c.uncompressed, compressed: int64(len(c.pages))}
+		if err := pw.write(c.pages); err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		c.pages, c.uncompressed = c.pages[:0], 0
+	}
+
+	pw.groups = append(pw.groups, group)
+	pw.totalRows += pw.groupRows
+	pw.groupRows = 0
+	return nil
+}
+
+// Close writes the remaining rows and the file footer. It does not close the
+// underlying writer.
+func (pw *Writer) Close() error {
+	if pw.closed {
+		return ErrClosed
+	}
+	pw.closed = true
+
+	if err := pw.flushRowGroup(); err != nil {
+		return err
+	}
+
+	footer := pw.footer()
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, magic...)
+	return pw.write(footer)
+}
+
+// footer encodes the FileMetaData of the file
+func (pw *Writer) footer() []byte {
+	t := &thriftWriter{}
+	t.begin()
+	t.i32(1, 1)
+
+	t.list(2, thriftStruct, len(pw.columns)+1)
+	t.begin()
+	t.string(4, "schema")
+	t.i32(5, int32(len(pw.columns)))
+	t.end()
+	for _, c := range pw.columns {
+		t.begin()
+		t.i32(1, int32(c.Type))
+		t.i32(3, repetitionOptional)
+		t.string(4, c.Name)
+		switch c.Logical {
+		case String:
+			t.i32(6, convertedUTF8)
+			t.structField(10)
+			t.structField(1)
+			t.end()
+			t.end()
+		case JSON:
+			t.i32(6, convertedJSON)
+			t.structField(10)
+			t.structField(12)
+			t.end()
+			t.end()
+		case TimestampMicros:
+			t.i32(6, convertedTimestampUs)
+			t.structField(10)
+			t.structField(8)
+			t.bool(1, true)
+			t.structField(2)
+			t.structField(2)
+			t.end()
+			t.end()
+			t.end()
+			t.end()
+		}
+		t.end()
+	}
+
+	t.i64(3, pw.totalRows)
+
+	t.list(4, thriftStruct, len(pw.groups))
+	for _, group := range pw.groups {
+		var uncompressed, compressed int64
+		t.begin()
+		t.list(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			c := pw.columns[i]
+			t.begin()
+			t.i64(2, chunk.offset)
+			t.structField(3)
+			t.i32(1, int32(c.Type))
+			t.i32List(2, encodingPlain, encodingRLE)
+			t.stringList(3, c.Name)
+			t.i32(4, codecGzip)
+			t.i64(5, group.rows)
+			t.i64(6, chunk.uncompressed)
+			t.i64(7, chunk.compressed)
+			t.i64(9, chunk.offset)
+			t.end()
+			t.end()
+			uncompressed += chunk.uncompressed
+			compressed += chunk.compressed
+		}
+		t.i64(2, uncompressed)
+		t.i64(3, group.rows)
+		t.i64(5, group.chunks[0].offset)
+		t.i64(6, compressed)
+		t.end()
+	}
+
+	t.string(6, createdBy)
+	t.end()
+	return t.buf
+}
@@ -0,0 +1,99 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testColumns = []Column{
+	{Name: "id", Type: ByteArray, Logical: String},
+	{Name: "score", Type: Double},
+	{Name: "version", Type: Int64},
+	{Name: "flag", Type: Boolean},
+	{Name: "at", Type: Int64, Logical: TimestampMicros},
+	{Name: "metadata", Type: ByteArray, Logical: JSON},
+}
+
+func TestThriftCompactEncoding(t *testing.T) {
+	tw := &thriftWriter{}
+	tw.begin()
+	tw.i32(1, -1)
+	tw.i64(3, 300)
+	tw.string(20, "ab")
+	tw.bool(21, true)
+	tw.i32List(22, 0, 3)
+	tw.end()
+
+	assert.Equal(t, []byte{
+		0x15, 0x01, // field 1, i32, zigzag(-1)
+		0x26, 0xd8, 0x04, // field 3 (delta 2), i64, zigzag(300)
+		0x08, 0x28, 0x02, 'a', 'b', // field 20 (delta 17, long form), binary
+		0x11,                   // field 21, true
+		0x19, 0x25, 0x00, 0x06, // field 22, list of 2 i32
+		0x00, // stop
+	}, tw.buf)
+}
+
+func TestEncodeLevels(t *testing.T) {
+	levels := []bool{true, true, true, false, true}
+	assert.Equal(t, []byte{3 << 1, 1, 1 << 1, 0, 1 << 1, 1}, encodeLevels(levels))
+	assert.Empty(t, encodeLevels(nil))
+}
+
+func TestPackBools(t *testing.T) {
+	assert.Equal(t, []byte{0b00000101, 0b1}, packBools([]bool{true, false, true, false, false, false, false, false, true}))
+}
+
+func TestWriterFileLayout(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, testColumns)
+	require.NoError(t, err)
+	w.pageSize, w.rowGroupSize = 64, 1024
+
+	for i := 0; i < 500; i++ {
+		var metadata interface{}
+		if i%2 == 0 {
+			metadata = `{"plan":"pro"}`
+		}
+		require.NoError(t, w.Write([]interface{}{"row", float64(i), int64(i), i%3 == 0, int64(i) * 1e6, metadata}))
+	}
+	require.NoError(t, w.Close())
+	assert.Greater(t, len(w.groups), 1)
+	assert.Equal(t, int64(500), w.totalRows)
+
+	file := buf.Bytes()
+	assert.Equal(t, magic, string(file[:4]))
+	assert.Equal(t, magic, string(file[len(file)-4:]))
+	footerLength := binary.LittleEndian.Uint32(file[len(file)-8:])
+	assert.Equal(t, w.footer(), file[len(file)-8-int(footerLength):len(file)-8])
+
+	// Column chunks are laid out one after the other, starting after the magic
+	offset := int64(len(magic))
+	for _, group := range w.groups {
+		for _, chunk := range group.chunks {
+			assert.Equal(t, offset, chunk.offset)
+			offset += chunk.compressed
+		}
+	}
+	assert.Equal(t, int64(len(file)-8-int(footerLength)), offset)
+}
+
+func TestWriterRejectsInvalidRows(t *testing.T) {
+	_, err := NewWriter(&bytes.Buffer{}, nil)
+	assert.Error(t, err)
+	_, err = NewWriter(&bytes.Buffer{}, []Column{{Name: "at", Type: Double, Logical: TimestampMicros}})
+	assert.Error(t, err)
+
+	w, err := NewWriter(&bytes.Buffer{}, testColumns)
+	require.NoError(t, err)
+	assert.Error(t, w.Write([]interface{}{"row"}))
+	assert.Error(t, w.Write([]interface{}{"row", "9", nil, nil, nil, nil}))
+	require.NoError(t, w.Write([]interface{}{nil, nil, nil, nil, nil, nil}))
+
+	require.NoError(t, w.Close())
+	assert.ErrorIs(t, w.Write([]interface{}{nil, nil, nil, nil, nil, nil}), ErrClosed)
+}
@@ -0,0 +1,418 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestAnalyticsMetrics(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// Create NPS answers: 3 promoters, 1 passive and 1 detractor
+	for _, score := range []float64{10, 9, 9, 7, 3} {
+		reqBody := map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    "metrics_survey",
+			"field_id":     "metrics_nps",
+			"field_type":   "number",
+			"value_number": score,
+		}
+		body, _ := json.Marshal(reqBody)
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	t.Run("NPS metrics", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/metrics?field_id=metrics_nps&source_id=metrics_survey&metric=nps", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.MetricsResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		require.GreaterOrEqual(t, result.Count, 5)
+		require.NotNil(t, result.Mean)
+		require.NotNil(t, result.Median)
+		assert.Contains(t, result.Percentiles, "p90")
+		assert.NotEmpty(t, result.Distribution)
+
+		require.NotNil(t, result.NPS)
+		assert.Equal(t, result.Count, result.NPS.Promoters+result.NPS.Passives+result.NPS.Detractors)
+		assert.Nil(t, result.CSAT)
+	})
+
+	t.Run("Wide value ranges are binned", func(t *testing.T) {
+		// 150 distinct answers from 0 to 74.5: 132 promoters, 4 passives and 14 detractors
+		sourceID := "metrics_wide_" + uuid.NewString()
+		for i := 0; i < 150; i++ {
+			body, _ := json.Marshal(map[string]interface{}{
+				"source_type":  "formbricks",
+				"source_id":    sourceID,
+				"field_id":     "metrics_wide",
+				"field_type":   "number",
+				"value_number": float64(i) / 2,
+			})
+			req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+testAPIKey)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+		}
+
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/metrics?field_id=metrics_wide&metric=nps&source_id="+sourceID, nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.MetricsResponse
+		require.NoError(t, decodeData(resp, &result))
+
+		assert.Equal(t, 150, result.Count)
+		assert.True(t, result.DistributionBinned)
+		require.Len(t, result.Distribution, 20)
+		var counted int
+		for _, bucket := range result.Distribution {
+			require.NotNil(t, bucket.UpperBound)
+			counted += bucket.Count
+		}
+		assert.Equal(t, 150, counted)
+		assert.Equal(t, 0.0, result.Distribution[0].Value)
+		assert.Equal(t, 74.5, *result.Distribution[19].UpperBound)
+
+		require.NotNil(t, result.NPS)
+		assert.Equal(t, 132, result.NPS.Promoters)
+		assert.Equal(t, 4, result.NPS.Passives)
+		assert.Equal(t, 14, result.NPS.Detractors)
+	})
+
+	t.Run("No matching records", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/metrics?field_id=metrics_missing_field&metric=csat", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.MetricsResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, result.Count)
+		assert.Nil(t, result.Mean)
+		assert.Empty(t, result.Distribution)
+		require.NotNil(t, result.CSAT)
+		assert.Equal(t, 0.0, result.CSAT.Score)
+	})
+
+	t.Run("Missing field_id", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/metrics?metric=nps", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Invalid metric", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/metrics?field_id=metrics_nps&metric=roi", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestAnalyticsTimeSeries(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// Create NPS answers on the first and third day of a three day range, leaving a gap
+	day := time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)
+	answers := []struct {
+		collectedAt time.Time
+		score       float64
+		channel     string
+	}{
+		{day, 10, "email"},
+		{day, 2, "web"},
+		{day.AddDate(0, 0, 2), 9, "email"},
+	}
+	for _, answer := range answers {
+		reqBody := map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    "timeseries_survey",
+			"field_id":     "timeseries_nps",
+			"field_type":   "number",
+			"value_number": answer.score,
+			"collected_at": answer.collectedAt.Format(time.RFC3339),
+			"metadata":     map[string]interface{}{"channel": answer.channel},
+		}
+		body, _ := json.Marshal(reqBody)
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	rangeParams := "&field_id=timeseries_nps&start_date=2025-06-02T00:00:00Z&end_date=2025-06-04T23:59:59Z"
+
+	t.Run("Daily NPS with gap filling", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/timeseries?interval=day&metric=nps"+rangeParams, nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.TimeSeriesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		require.Len(t, result.Series, 1)
+		points := result.Series[0].Points
+		require.Len(t, points, 3)
+
+		assert.GreaterOrEqual(t, points[0].Count, 2)
+		require.NotNil(t, points[0].Value)
+
+		// The middle day has no answers
+		assert.Equal(t, 0, points[1].Count)
+		assert.Nil(t, points[1].Value)
+
+		assert.GreaterOrEqual(t, points[2].Count, 1)
+	})
+
+	t.Run("Value metrics count only numeric answers", func(t *testing.T) {
+		// A skipped answer and a numeric one on the same day, in a source unique to this run
+		sourceID := "timeseries_skipped_" + uuid.NewString()
+		for _, answer := range []map[string]interface{}{
+			{"field_type": "number", "value_number": 4},
+			{"field_type": "text", "value_text": "skipped"},
+		} {
+			answer["source_type"] = "formbricks"
+			answer["source_id"] = sourceID
+			answer["field_id"] = "timeseries_rating"
+			answer["collected_at"] = day.Format(time.RFC3339)
+			body, _ := json.Marshal(answer)
+			req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+testAPIKey)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		for metric, want := range map[string]int{"count": 2, "mean": 1, "csat": 1} {
+			req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/timeseries?interval=day&start_date=2025-06-02T00:00:00Z&end_date=2025-06-02T23:59:59Z&source_id="+sourceID+"&metric="+metric, nil)
+			req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+			var result models.TimeSeriesResponse
+			require.NoError(t, decodeData(resp, &result))
+			require.Len(t, result.Series, 1)
+			require.Len(t, result.Series[0].Points, 1)
+			assert.Equal(t, want, result.Series[0].Points[0].Count, metric)
+		}
+	})
+
+	t.Run("Buckets follow the requested time zone", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/timeseries?interval=day&time_zone=America/New_York"+rangeParams, nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.TimeSeriesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		assert.Equal(t, "America/New_York", result.TimeZone)
+		require.Len(t, result.Series, 1)
+		newYork, _ := time.LoadLocation("America/New_York")
+		for _, point := range result.Series[0].Points {
+			local := point.Bucket.In(newYork)
+			assert.Equal(t, 0, local.Hour())
+		}
+	})
+
+	t.Run("Group by metadata key", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/timeseries?interval=day&group_by=metadata.channel"+rangeParams, nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.TimeSeriesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		groups := map[string]bool{}
+		for _, series := range result.Series {
+			require.NotNil(t, series.Group)
+			groups[*series.Group] = true
+			assert.Len(t, series.Points, 3)
+		}
+		assert.True(t, groups["email"])
+		assert.True(t, groups["web"])
+	})
+
+	t.Run("Invalid parameters", func(t *testing.T) {
+		for _, params := range []string{
+			"interval=minute",
+			"interval=day&time_zone=Mars/Olympus",
+			"interval=day&group_by=value_text",
+			"interval=day&metric=median",
+			"interval=hour&start_date=2000-01-01T00:00:00Z&end_date=2025-01-01T00:00:00Z",
+		} {
+			req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/timeseries?"+params, nil)
+			req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, params)
+		}
+	})
+}
+
+func TestAnalyticsCrosstab(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// Create responses answering a plan and an NPS question, in a source unique to this run
+	sourceID := "crosstab_survey_" + uuid.NewString()
+	responses := []struct {
+		plan  string
+		score float64
+	}{
+		{"pro", 10},
+		{"pro", 10},
+		{"pro", 9},
+		{"free", 3},
+	}
+	for _, response := range responses {
+		responseID := uuid.NewString()
+		answers := []map[string]interface{}{
+			{"field_id": "crosstab_plan", "field_type": "single_choice", "value_text": response.plan},
+			{"field_id": "crosstab_nps", "field_type": "number", "value_number": response.score},
+		}
+		for _, answer := range answers {
+			answer["source_type"] = "formbricks"
+			answer["source_id"] = sourceID
+			answer["response_id"] = responseID
+			body, _ := json.Marshal(answer)
+			req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+			req.Header.Set("Authorization", "Bearer "+testAPIKey)
+			req.Header.Set("Content-Type", "application/json")
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+	}
+
+	t.Run("NPS by plan", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/crosstab?row_field=crosstab_plan&column_field=crosstab_nps&source_id="+sourceID, nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.CrosstabResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		assert.Equal(t, 4, result.Total)
+		require.Len(t, result.Columns, 3)
+		assert.Equal(t, "3", result.Columns[0].Value)
+		assert.Equal(t, "10", result.Columns[2].Value)
+
+		require.Len(t, result.Rows, 2)
+		pro := result.Rows[1]
+		assert.Equal(t, "pro", pro.Value)
+		assert.Equal(t, 3, pro.Total)
+		assert.Equal(t, 2, pro.Cells[2].Count)
+		assert.InDelta(t, 66.67, pro.Cells[2].RowPercentage, 0.01)
+
+		require.NotNil(t, result.ChiSquare)
+		assert.Equal(t, 2, result.ChiSquare.DegreesOfFreedom)
+	})
+
+	t.Run("Same field twice", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/crosstab?row_field=crosstab_plan&column_field=crosstab_plan", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Missing column_field", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/analytics/crosstab?row_field=crosstab_plan", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
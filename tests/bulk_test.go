@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/api/handlers"
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+)
+
+func TestBulkExperiences(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// createRecords creates n answers to fieldID of a new source and returns the source_id
+	createRecords := func(fieldID string, n int) string {
+		sourceID := "bulk_" + uuid.NewString()
+		for i := 0; i < n; i++ {
+			resp := do("POST", "/v1/experiences", map[string]interface{}{
+				"source_type":  "formbricks",
+				"source_id":    sourceID,
+				"field_id":     fieldID,
+				"field_type":   "nps",
+				"value_number": i % 11,
+				"metadata":     map[string]interface{}{"sentiment": "neutral", "tags": []string{"imported"}},
+			})
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+			var exp models.ExperienceData
+			require.NoError(t, decodeData(resp, &exp))
+			resp.Body.Close()
+			t.Cleanup(func() { do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil).Body.Close() })
+		}
+		return sourceID
+	}
+
+	list := func(sourceID string) []models.ExperienceData {
+		resp := do("GET", "/v1/experiences?source_id="+sourceID, nil)
+		defer resp.Body.Close()
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(resp, &experiences))
+		return experiences
+	}
+
+	bulk := func(path string, body map[string]interface{}, status int) models.BulkResult {
+		resp := do("POST", path, body)
+		defer resp.Body.Close()
+		require.Equal(t, status, resp.StatusCode)
+		var result models.BulkResult
+		require.NoError(t, decodeData(resp, &result))
+		return result
+	}
+
+	t.Run("Dry run counts without changing records", func(t *testing.T) {
+		sourceID := createRecords("nps_scroe", 3)
+		result := bulk("/v1/experiences/bulk-update", map[string]interface{}{
+			"source_id": sourceID,
+			"field_id":  "nps_scroe",
+			"patch":     map[string]interface{}{"field_id": "nps_score"},
+			"dry_run":   true,
+		}, http.StatusOK)
+		assert.True(t, result.DryRun)
+		assert.Equal(t, 3, result.Matched)
+		assert.Equal(t, 0, result.Affected)
+
+		for _, exp := range list(sourceID) {
+			assert.Equal(t, "nps_scroe", exp.FieldID)
+		}
+	})
+
+	t.Run("Bulk update renames a field and merges metadata", func(t *testing.T) {
+		sourceID := createRecords("nps_scroe", 3)
+		result := bulk("/v1/experiences/bulk-update", map[string]interface{}{
+			"source_id": sourceID,
+			"field_id":  "nps_scroe",
+			"patch": map[string]interface{}{
+				"field_id": "nps_score",
+				"metadata": map[string]interface{}{"sentiment": nil, "relabelled": true},
+			},
+		}, http.StatusOK)
+		assert.Equal(t, 3, result.Matched)
+		assert.Equal(t, 3, result.Affected)
+		assert.Nil(t, result.Job)
+
+		experiences := list(sourceID)
+		require.Len(t, experiences, 3)
+		for _, exp := range experiences {
+			assert.Equal(t, "nps_score", exp.FieldID)
+			assert.Equal(t, 2, exp.Version)
+			assert.JSONEq(t, `{"tags": ["imported"], "relabelled": true}`, string(exp.Metadata))
+		}
+
+		resp := do("GET", fmt.Sprintf("/v1/experiences/%s/history", experiences[0].ID), nil)
+		defer resp.Body.Close()
+		var history []models.ExperienceHistoryEntry
+		require.NoError(t, decodeData(resp, &history))
+		require.Len(t, history, 2)
+		assert.Equal(t, models.HistoryActionUpdate, history[1].Action)
+	})
+
+	t.Run("Bulk delete moves matching records to the trash", func(t *testing.T) {
+		sourceID := createRecords("spam", 2)
+		result := bulk("/v1/experiences/bulk-delete", map[string]interface{}{"source_id": sourceID}, http.StatusOK)
+		assert.Equal(t, 2, result.Affected)
+		assert.Empty(t, list(sourceID))
+	})
+
+	t.Run("More matches than max_affected change nothing", func(t *testing.T) {
+		sourceID := createRecords("spam", 3)
+		resp := do("POST", "/v1/experiences/bulk-delete", map[string]interface{}{"source_id": sourceID, "max_affected": 2})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		require.Len(t, errResp.Details, 1)
+		assert.Equal(t, "max_affected", errResp.Details[0].Field)
+		assert.Len(t, list(sourceID), 3)
+	})
+
+	t.Run("Requests without filters or patch are rejected", func(t *testing.T) {
+		resp := do("POST", "/v1/experiences/bulk-delete", map[string]interface{}{"dry_run": true})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = do("POST", "/v1/experiences/bulk-update", map[string]interface{}{"source_type": "formbricks"})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Records cannot move into a source scanning for PII differently", func(t *testing.T) {
+		sourceID := createRecords("comment", 2)
+		target := "bulk_reject_" + uuid.NewString()
+		resp := do("POST", "/v1/sources", map[string]interface{}{
+			"source_type": "formbricks",
+			"external_id": target,
+			"pii_mode":    "reject",
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var source models.Source
+		require.NoError(t, decodeData(resp, &source))
+		resp.Body.Close()
+		defer do("DELETE", fmt.Sprintf("/v1/sources/%s", source.ID), nil).Body.Close()
+
+		resp = do("POST", "/v1/experiences/bulk-update", map[string]interface{}{
+			"source_id": sourceID,
+			"patch":     map[string]interface{}{"source_id": target},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		assert.Len(t, list(sourceID), 2)
+		assert.Empty(t, list(target))
+	})
+
+	t.Run("Async requests run as a job in the worker", func(t *testing.T) {
+		sourceID := createRecords("spam", 2)
+		result := bulk("/v1/experiences/bulk-delete", map[string]interface{}{"source_id": sourceID, "async": true}, http.StatusAccepted)
+		require.NotNil(t, result.Job)
+		assert.Equal(t, models.BulkJobPending, result.Job.Status)
+		assert.Equal(t, 2, result.Job.Matched)
+		assert.Len(t, list(sourceID), 2)
+
+		// The worker runs the jobs through the service
+		ctx := context.Background()
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+		require.NoError(t, err)
+		defer db.Close()
+		bulkService := service.NewBulkService(repository.NewExperienceRepository(db), repository.NewSourceRepository(db),
+			repository.NewBulkJobRepository(db), nil, nil)
+
+		_, err = bulkService.RunJobs(ctx)
+		require.NoError(t, err)
+		assert.Empty(t, list(sourceID))
+
+		resp := do("GET", fmt.Sprintf("/v1/bulk-jobs/%s", result.Job.ID), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var job models.BulkJob
+		require.NoError(t, decodeData(resp, &job))
+		assert.Equal(t, models.BulkJobCompleted, job.Status)
+		assert.Equal(t, 2, job.Affected)
+		assert.NotNil(t, job.FinishedAt)
+	})
+
+	t.Run("Unknown jobs are not found", func(t *testing.T) {
+		resp := do("GET", fmt.Sprintf("/v1/bulk-jobs/%s", uuid.New()), nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
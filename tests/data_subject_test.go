@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+)
+
+func TestDataSubjects(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// createRecords creates a rating and a free text answer for a person
+	createRecords := func(userIdentifier string) []models.ExperienceData {
+		var created []models.ExperienceData
+		for _, record := range []map[string]interface{}{
+			{"field_id": "subject_rating", "field_type": "rating", "value_number": 4},
+			{"field_id": "subject_comment", "field_type": "text", "value_text": "Call me at 555-0100", "metadata": map[string]string{"sentiment": "positive"}},
+		} {
+			record["source_type"] = "formbricks"
+			record["user_identifier"] = userIdentifier
+			resp := do("POST", "/v1/experiences", record)
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+			var exp models.ExperienceData
+			require.NoError(t, decodeData(resp, &exp))
+			resp.Body.Close()
+			created = append(created, exp)
+		}
+		return created
+	}
+
+	userIdentifier := "subject+" + uuid.NewString() + "@example.com"
+	subjectPath := "/v1/data-subjects/" + url.PathEscape(userIdentifier)
+	created := createRecords(userIdentifier)
+
+	// Records in the trash are part of the data subject's data
+	resp := do("DELETE", fmt.Sprintf("/v1/experiences/%s", created[0].ID), nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	t.Run("Export as JSON", func(t *testing.T) {
+		resp := do("GET", subjectPath+"/export", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), "attachment")
+
+		var export models.DataSubjectExport
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&export))
+		assert.Equal(t, userIdentifier, export.UserIdentifier)
+		assert.Len(t, export.Experiences, 2)
+		// Two creates and one delete
+		assert.Len(t, export.History, 3)
+	})
+
+	t.Run("Export as ZIP", func(t *testing.T) {
+		resp := do("GET", subjectPath+"/export?format=zip", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/zip", resp.Header.Get("Content-Type"))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		require.NoError(t, err)
+		require.Len(t, archive.File, 1)
+
+		file, err := archive.File[0].Open()
+		require.NoError(t, err)
+		defer file.Close()
+
+		var export models.DataSubjectExport
+		require.NoError(t, json.NewDecoder(file).Decode(&export))
+		assert.Len(t, export.Experiences, 2)
+	})
+
+	t.Run("Export with invalid format", func(t *testing.T) {
+		resp := do("GET", subjectPath+"/export?format=xml", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Erase with invalid mode", func(t *testing.T) {
+		resp := do("DELETE", subjectPath+"?mode=shred", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Erase by deleting", func(t *testing.T) {
+		resp := do("DELETE", subjectPath, nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var receipt models.ErasureReceipt
+		require.NoError(t, decodeData(resp, &receipt))
+		assert.Equal(t, models.ErasureModeDelete, receipt.Mode)
+		assert.Equal(t, service.HashUserIdentifier(newTestKeyring(t, 1), userIdentifier), receipt.UserIdentifierHash)
+		assert.Equal(t, int64(2), receipt.ExperiencesAffected)
+		assert.Equal(t, int64(3), receipt.HistoryEntriesDeleted)
+		assert.NotNil(t, receipt.APIKeyID)
+
+		// Nothing is left to export, and the receipt is kept
+		exportResp := do("GET", subjectPath+"/export", nil)
+		exportResp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, exportResp.StatusCode)
+
+		receiptResp := do("GET", fmt.Sprintf("/v1/erasure-receipts/%s", receipt.ID), nil)
+		defer receiptResp.Body.Close()
+		assert.Equal(t, http.StatusOK, receiptResp.StatusCode)
+
+		var stored models.ErasureReceipt
+		require.NoError(t, decodeData(receiptResp, &stored))
+		assert.Equal(t, receipt.ID, stored.ID)
+		assert.Equal(t, receipt.UserIdentifierHash, stored.UserIdentifierHash)
+	})
+
+	t.Run("Erase by anonymizing", func(t *testing.T) {
+		anonymous := "subject_" + uuid.NewString()
+		records := createRecords(anonymous)
+
+		resp := do("DELETE", "/v1/data-subjects/"+anonymous+"?mode=anonymize", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var receipt models.ErasureReceipt
+		require.NoError(t, decodeData(resp, &receipt))
+		assert.Equal(t, models.ErasureModeAnonymize, receipt.Mode)
+		assert.Equal(t, int64(2), receipt.ExperiencesAffected)
+
+		// The rating is kept for aggregates, the free text answer is cleared
+		for _, record := range records {
+			getResp := do("GET", fmt.Sprintf("/v1/experiences/%s", record.ID), nil)
+			require.Equal(t, http.StatusOK, getResp.StatusCode)
+			var exp models.ExperienceData
+			require.NoError(t, decodeData(getResp, &exp))
+			getResp.Body.Close()
+
+			assert.Nil(t, exp.UserIdentifier)
+			assert.Nil(t, exp.Metadata)
+			if exp.FieldType == models.FieldTypeText {
+				assert.Nil(t, exp.ValueText)
+			} else {
+				assert.Equal(t, record.ValueNumber, exp.ValueNumber)
+			}
+		}
+	})
+
+	t.Run("Get missing erasure receipt", func(t *testing.T) {
+		resp := do("GET", "/v1/erasure-receipts/00000000-0000-0000-0000-000000000000", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
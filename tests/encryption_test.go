@@ -0,0 +1,327 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+)
+
+func TestFieldEncryption(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// The stored columns and the re-encryption job are checked on the database directly
+	ctx := context.Background()
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+	require.NoError(t, err)
+	defer db.Close()
+
+	externalID := "encrypted-" + uuid.NewString()
+	resp := do("POST", "/v1/sources", map[string]interface{}{
+		"source_type": "formbricks",
+		"external_id": externalID,
+		"encrypt":     true,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var source models.Source
+	require.NoError(t, decodeData(resp, &source))
+	resp.Body.Close()
+	assert.True(t, source.Encrypt)
+	defer do("DELETE", fmt.Sprintf("/v1/sources/%s", source.ID), nil).Body.Close()
+
+	userIdentifier := "encrypted_user_" + uuid.NewString()
+	resp = do("POST", "/v1/experiences", map[string]interface{}{
+		"source_type":     "formbricks",
+		"source_id":       externalID,
+		"field_id":        "encrypted_comment",
+		"field_type":      "text",
+		"value_text":      "Confidential feedback",
+		"user_identifier": userIdentifier,
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created models.ExperienceData
+	require.NoError(t, decodeData(resp, &created))
+	resp.Body.Close()
+	defer do("DELETE", fmt.Sprintf("/v1/experiences/%s", created.ID), nil).Body.Close()
+
+	t.Run("Values are returned decrypted", func(t *testing.T) {
+		assert.True(t, created.Encrypted)
+		require.NotNil(t, created.ValueText)
+		assert.Equal(t, "Confidential feedback", *created.ValueText)
+		require.NotNil(t, created.UserIdentifier)
+		assert.Equal(t, userIdentifier, *created.UserIdentifier)
+	})
+
+	t.Run("Values are stored encrypted", func(t *testing.T) {
+		var valueText, storedUser *string
+		var ciphertext []byte
+		var keyVersion int
+		err := db.QueryRow(ctx, `SELECT value_text, user_identifier, encrypted_values, key_version FROM experience_data WHERE id = $1`, created.ID).
+			Scan(&valueText, &storedUser, &ciphertext, &keyVersion)
+		require.NoError(t, err)
+		assert.Nil(t, valueText)
+		assert.Nil(t, storedUser)
+		assert.NotEmpty(t, ciphertext)
+		assert.Equal(t, 1, keyVersion)
+	})
+
+	t.Run("Values copied to another record do not decrypt", func(t *testing.T) {
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type": "formbricks",
+			"source_id":   externalID,
+			"field_id":    "encrypted_comment",
+			"field_type":  "text",
+			"value_text":  "Other feedback",
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var other models.ExperienceData
+		require.NoError(t, decodeData(resp, &other))
+		resp.Body.Close()
+		defer do("DELETE", fmt.Sprintf("/v1/experiences/%s", other.ID), nil).Body.Close()
+
+		_, err := db.Exec(ctx, `
+			UPDATE experience_data o
+			SET encrypted_values = c.encrypted_values, encrypted_data_key = c.encrypted_data_key, key_version = c.key_version
+			FROM experience_data c
+			WHERE o.id = $1 AND c.id = $2`, other.ID, created.ID)
+		require.NoError(t, err)
+
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s", other.ID), nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+
+	t.Run("Replaced records keep values bound to their ID", func(t *testing.T) {
+		upsert := func(text string) models.UpsertExperienceResult {
+			resp := do("PUT", "/v1/experiences", []map[string]interface{}{{
+				"source_type": "formbricks",
+				"source_id":   externalID,
+				"field_id":    "encrypted_upserted",
+				"field_type":  "text",
+				"value_text":  text,
+				"response_id": "upsert_" + externalID,
+			}})
+			defer resp.Body.Close()
+			require.Equal(t, http.StatusOK, resp.StatusCode)
+			var result models.UpsertExperiencesResponse
+			require.NoError(t, decodeData(resp, &result))
+			require.Len(t, result.Data, 1)
+			return result.Data[0]
+		}
+
+		first := upsert("First sync")
+		defer do("DELETE", fmt.Sprintf("/v1/experiences/%s", first.ID), nil).Body.Close()
+		second := upsert("Second sync")
+		assert.Equal(t, models.UpsertResultUpdated, second.Result)
+		assert.Equal(t, first.ID, second.ID)
+
+		resp := do("GET", fmt.Sprintf("/v1/experiences/%s", first.ID), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		require.NotNil(t, exp.ValueText)
+		assert.Equal(t, "Second sync", *exp.ValueText)
+	})
+
+	t.Run("Filter by user_identifier matches encrypted records", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences?user_identifier="+userIdentifier, nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(resp, &experiences))
+		require.Len(t, experiences, 1)
+		assert.Equal(t, created.ID, experiences[0].ID)
+	})
+
+	t.Run("Search reports encrypted records it could not search", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences/search?query=confidential&source_id="+externalID, nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result models.SearchExperiencesResponse
+		require.NoError(t, decodeData(resp, &result))
+		assert.Empty(t, result.Data)
+		assert.Equal(t, 1, result.EncryptedNotSearched)
+	})
+
+	t.Run("Responses and crosstabs do not lose encrypted answers", func(t *testing.T) {
+		responseID := "encrypted_response_" + uuid.NewString()
+		responseUser := "encrypted_respondent_" + uuid.NewString()
+		for _, answer := range []map[string]interface{}{
+			{"field_id": "encrypted_plan", "field_type": "text", "value_text": "Pro"},
+			{"field_id": "encrypted_nps", "field_type": "number", "value_number": 9},
+		} {
+			answer["source_type"] = "formbricks"
+			answer["source_id"] = externalID
+			answer["response_id"] = responseID
+			answer["user_identifier"] = responseUser
+			resp := do("POST", "/v1/experiences", answer)
+			require.Equal(t, http.StatusCreated, resp.StatusCode)
+			var exp models.ExperienceData
+			require.NoError(t, decodeData(resp, &exp))
+			resp.Body.Close()
+			defer do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil).Body.Close()
+		}
+
+		resp := do("GET", "/v1/responses?source_id="+externalID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var responses models.ListResponsesResponse
+		require.NoError(t, decodeData(resp, &responses))
+		resp.Body.Close()
+		require.Len(t, responses.Data, 1)
+		row := responses.Data[0]
+		assert.Equal(t, responseID, row.ID)
+		require.NotNil(t, row.UserIdentifier)
+		assert.Equal(t, responseUser, *row.UserIdentifier)
+		assert.JSONEq(t, `"Pro"`, string(row.Values["encrypted_plan"]))
+		assert.JSONEq(t, `9`, string(row.Values["encrypted_nps"]))
+
+		resp = do("GET", "/v1/analytics/crosstab?row_field=encrypted_plan&column_field=encrypted_nps&source_id="+externalID, nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var crosstab models.CrosstabResponse
+		require.NoError(t, decodeData(resp, &crosstab))
+		resp.Body.Close()
+		assert.Equal(t, 0, crosstab.Total)
+		assert.Equal(t, 1, crosstab.EncryptedNotCounted)
+	})
+
+	t.Run("Update re-encrypts the merged values", func(t *testing.T) {
+		resp := do("PATCH", fmt.Sprintf("/v1/experiences/%s", created.ID), map[string]interface{}{
+			"value_text": "Updated confidential feedback",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.True(t, updated.Encrypted)
+		assert.Equal(t, "Updated confidential feedback", *updated.ValueText)
+		require.NotNil(t, updated.UserIdentifier)
+		assert.Equal(t, userIdentifier, *updated.UserIdentifier)
+	})
+
+	t.Run("Re-encryption moves records to the current key version", func(t *testing.T) {
+		rotated := service.NewExperienceService(
+			repository.NewExperienceRepository(db), repository.NewSourceRepository(db), nil, newTestKeyring(t, 2), nil)
+
+		reencrypted, err := rotated.ReencryptRecords(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, reencrypted, int64(1))
+
+		var keyVersion int
+		err = db.QueryRow(ctx, `SELECT key_version FROM experience_data WHERE id = $1`, created.ID).Scan(&keyVersion)
+		require.NoError(t, err)
+		assert.Equal(t, 2, keyVersion)
+
+		// Records sealed with any configured version stay readable
+		resp := do("GET", fmt.Sprintf("/v1/experiences/%s", created.ID), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		assert.Equal(t, "Updated confidential feedback", *exp.ValueText)
+	})
+
+	t.Run("Data subject export includes encrypted records", func(t *testing.T) {
+		resp := do("GET", fmt.Sprintf("/v1/data-subjects/%s/export", userIdentifier), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var export models.DataSubjectExport
+		require.NoError(t, decodeData(resp, &export))
+		require.Len(t, export.Experiences, 1)
+		assert.Equal(t, "Updated confidential feedback", *export.Experiences[0].ValueText)
+	})
+
+	t.Run("Concurrent updates keep each other's changes", func(t *testing.T) {
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type":     "formbricks",
+			"source_id":       externalID,
+			"field_id":        "encrypted_concurrent",
+			"field_type":      "text",
+			"value_text":      "Original feedback",
+			"user_identifier": "concurrent_user_" + uuid.NewString(),
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		resp.Body.Close()
+		defer do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil).Body.Close()
+
+		// Hold the row so both updates read the same version and then wait to write it
+		tx, err := db.Begin(ctx)
+		require.NoError(t, err)
+		defer tx.Rollback(ctx)
+		_, err = tx.Exec(ctx, `SELECT 1 FROM experience_data WHERE id = $1 FOR UPDATE`, exp.ID)
+		require.NoError(t, err)
+
+		newUser := "concurrent_user_" + uuid.NewString()
+		statuses := make(chan int, 2)
+		for _, body := range []map[string]interface{}{
+			{"value_text": "Concurrently edited feedback"},
+			{"user_identifier": newUser},
+		} {
+			payload, _ := json.Marshal(body)
+			req, _ := http.NewRequest("PATCH", fmt.Sprintf("%s/v1/experiences/%s", server.URL, exp.ID), bytes.NewReader(payload))
+			req.Header.Set("Authorization", "Bearer "+testAPIKey)
+			req.Header.Set("Content-Type", "application/json")
+			go func() {
+				resp, err := client.Do(req)
+				if err != nil {
+					statuses <- 0
+					return
+				}
+				resp.Body.Close()
+				statuses <- resp.StatusCode
+			}()
+		}
+
+		require.Eventually(t, func() bool {
+			var waiting int
+			err := db.QueryRow(ctx, `SELECT COUNT(*) FROM pg_stat_activity WHERE wait_event_type = 'Lock' AND query LIKE '%FOR UPDATE%'`).Scan(&waiting)
+			return err == nil && waiting == 2
+		}, 5*time.Second, 10*time.Millisecond)
+		require.NoError(t, tx.Rollback(ctx))
+
+		assert.Equal(t, http.StatusOK, <-statuses)
+		assert.Equal(t, http.StatusOK, <-statuses)
+
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.Equal(t, "Concurrently edited feedback", *updated.ValueText)
+		require.NotNil(t, updated.UserIdentifier)
+		assert.Equal(t, newUser, *updated.UserIdentifier)
+		assert.Equal(t, exp.Version+2, updated.Version)
+	})
+}
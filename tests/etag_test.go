@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestExperienceETags(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// do sends a request with the given conditional headers
+	do := func(method, path string, body interface{}, headers map[string]string) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := do("POST", "/v1/experiences", map[string]interface{}{
+		"source_type": "formbricks",
+		"source_id":   "etag_" + uuid.NewString(),
+		"field_id":    "etag_comment",
+		"field_type":  "text",
+		"value_text":  "First version",
+	}, nil)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created models.ExperienceData
+	require.NoError(t, decodeData(resp, &created))
+	resp.Body.Close()
+	path := fmt.Sprintf("/v1/experiences/%s", created.ID)
+	defer do("DELETE", path, nil, nil).Body.Close()
+
+	assert.Equal(t, 1, created.Version)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+
+	t.Run("Get returns the ETag", func(t *testing.T) {
+		resp := do("GET", path, nil, nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+	})
+
+	t.Run("If-None-Match returns 304 while unchanged", func(t *testing.T) {
+		resp := do("GET", path, nil, map[string]string{"If-None-Match": `"1"`})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	})
+
+	t.Run("Update with the current ETag applies", func(t *testing.T) {
+		resp := do("PATCH", path, map[string]interface{}{"value_text": "Second version"}, map[string]string{"If-Match": `"1"`})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.Equal(t, 2, updated.Version)
+	})
+
+	t.Run("Update with a stale ETag fails", func(t *testing.T) {
+		resp := do("PATCH", path, map[string]interface{}{"value_text": "Lost update"}, map[string]string{"If-Match": `"1"`})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+
+		get := do("GET", path, nil, nil)
+		defer get.Body.Close()
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(get, &exp))
+		assert.Equal(t, "Second version", *exp.ValueText)
+	})
+
+	t.Run("If-None-Match with an old ETag returns the record", func(t *testing.T) {
+		resp := do("GET", path, nil, map[string]string{"If-None-Match": `"1"`})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Delete with a stale ETag fails", func(t *testing.T) {
+		resp := do("DELETE", path, nil, map[string]string{"If-Match": `"1"`})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	})
+
+	t.Run("Delete with the current ETag applies", func(t *testing.T) {
+		resp := do("DELETE", path, nil, map[string]string{"If-Match": `"2"`})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("If-Match on a missing record fails the precondition", func(t *testing.T) {
+		for _, ifMatch := range []string{"*", `"2"`} {
+			resp := do("PATCH", path, map[string]interface{}{"value_text": "Recreated"}, map[string]string{"If-Match": ifMatch})
+			assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode, ifMatch)
+			resp.Body.Close()
+
+			resp = do("DELETE", path, nil, map[string]string{"If-Match": ifMatch})
+			assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode, ifMatch)
+			resp.Body.Close()
+		}
+
+		resp := do("DELETE", path, nil, nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -0,0 +1,207 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+)
+
+func TestExports(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	sourceID := "export_" + uuid.NewString()
+	for i, meta := range []map[string]interface{}{{"plan": "pro"}, {"plan": "free", "tags": []string{"beta"}}, {"region": "eu"}} {
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    sourceID,
+			"field_id":     "nps",
+			"field_type":   "nps",
+			"value_number": 8 + i,
+			"metadata":     meta,
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		resp.Body.Close()
+		t.Cleanup(func() { do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil).Body.Close() })
+	}
+
+	// The worker writes the exports through the service
+	ctx := context.Background()
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+	require.NoError(t, err)
+	defer db.Close()
+	exportService := service.NewExportService(repository.NewExperienceRepository(db), repository.NewExportRepository(db),
+		newTestExportStore(t), newTestKeyring(t, 1), newTestPseudonymizer(t), 0)
+
+	create := func(body map[string]interface{}) models.Export {
+		resp := do("POST", "/v1/exports", body)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusAccepted, resp.StatusCode)
+		var exp models.Export
+		require.NoError(t, decodeData(resp, &exp))
+		assert.Equal(t, models.ExportPending, exp.Status)
+		return exp
+	}
+
+	get := func(id uuid.UUID) models.Export {
+		resp := do("GET", fmt.Sprintf("/v1/exports/%s", id), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var exp models.Export
+		require.NoError(t, decodeData(resp, &exp))
+		return exp
+	}
+
+	// download fetches a signed URL without the API key
+	download := func(url string) *http.Response {
+		resp, err := http.Get(server.URL + url)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// run writes the waiting exports, keeping their files for retention, and
+	// returns the completed export with the given id
+	run := func(id uuid.UUID, retention time.Duration) models.Export {
+		_, err := exportService.RunJobs(ctx, retention)
+		require.NoError(t, err)
+		exp := get(id)
+		require.Equal(t, models.ExportCompleted, exp.Status, "export error: %v", exp.Error)
+		require.NotNil(t, exp.DownloadURL)
+		require.NotNil(t, exp.DownloadExpiresAt)
+		assert.Equal(t, int64(3), exp.Rows)
+		assert.Positive(t, exp.Size)
+		return exp
+	}
+
+	t.Run("CSV exports flatten metadata", func(t *testing.T) {
+		exp := run(create(map[string]interface{}{"source_id": sourceID, "format": "csv"}).ID, time.Hour)
+
+		resp := download(*exp.DownloadURL)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+		assert.Contains(t, resp.Header.Get("Content-Disposition"), exp.ID.String()+".csv")
+
+		rows, err := csv.NewReader(resp.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		assert.Contains(t, rows[0], "metadata.plan")
+		assert.Contains(t, rows[0], "metadata.tags")
+		assert.Contains(t, rows[0], "metadata.region")
+	})
+
+	t.Run("NDJSON exports hold the requested fields", func(t *testing.T) {
+		exp := run(create(map[string]interface{}{"source_id": sourceID, "format": "ndjson", "fields": []string{"value_number"}}).ID, time.Hour)
+		assert.Equal(t, []string{"value_number"}, exp.Fields)
+
+		resp := download(*exp.DownloadURL)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+		var lines int
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var object map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &object))
+			assert.Len(t, object, 2)
+			assert.Contains(t, object, "value_number")
+			lines++
+		}
+		assert.Equal(t, 3, lines)
+	})
+
+	t.Run("Parquet exports are served as files", func(t *testing.T) {
+		exp := run(create(map[string]interface{}{"source_id": sourceID, "format": "parquet"}).ID, time.Hour)
+
+		resp := download(*exp.DownloadURL)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/vnd.apache.parquet", resp.Header.Get("Content-Type"))
+
+		file, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, exp.Size, int64(len(file)))
+		assert.Equal(t, "PAR1", string(file[:4]))
+		assert.Equal(t, "PAR1", string(file[len(file)-4:]))
+	})
+
+	t.Run("Download URLs must be signed", func(t *testing.T) {
+		exp := run(create(map[string]interface{}{"source_id": sourceID, "format": "csv"}).ID, time.Hour)
+
+		resp := download(strings.Replace(*exp.DownloadURL, "signature=", "signature=0", 1))
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		resp = download(strings.Replace(*exp.DownloadURL, exp.ID.String(), uuid.NewString(), 1))
+		resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+	})
+
+	t.Run("Expired exports no longer have a file", func(t *testing.T) {
+		exp := run(create(map[string]interface{}{"source_id": sourceID, "format": "ndjson"}).ID, -time.Minute)
+
+		expired, err := exportService.ExpireExports(ctx)
+		require.NoError(t, err)
+		assert.Positive(t, expired)
+
+		after := get(exp.ID)
+		assert.Equal(t, models.ExportExpired, after.Status)
+		assert.Nil(t, after.DownloadURL)
+	})
+
+	t.Run("Invalid exports are rejected", func(t *testing.T) {
+		for _, body := range []map[string]interface{}{
+			{"source_id": sourceID},
+			{"source_id": sourceID, "format": "xlsx"},
+			{"source_id": sourceID, "format": "csv", "fields": []string{"value_blob"}},
+			{"format": "csv", "query": "slow", "mode": "exact"},
+		} {
+			resp := do("POST", "/v1/exports", body)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+		}
+	})
+
+	t.Run("Unknown exports are not found", func(t *testing.T) {
+		resp := do("GET", fmt.Sprintf("/v1/exports/%s", uuid.New()), nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
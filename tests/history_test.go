@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestExperienceHistory(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// Create, update, delete and restore a record
+	resp := do("POST", "/v1/experiences", map[string]interface{}{
+		"source_type": "formbricks",
+		"field_id":    "history_feedback",
+		"field_type":  "text",
+		"value_text":  "Original feedback",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created models.ExperienceData
+	require.NoError(t, decodeData(resp, &created))
+	resp.Body.Close()
+
+	path := fmt.Sprintf("/v1/experiences/%s", created.ID)
+
+	resp = do("PATCH", path, map[string]interface{}{"value_text": "Edited feedback"})
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	resp = do("DELETE", path, nil)
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp.Body.Close()
+
+	t.Run("History of a record in the trash", func(t *testing.T) {
+		resp := do("GET", path+"/history", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var history []models.ExperienceHistoryEntry
+		require.NoError(t, decodeData(resp, &history))
+		require.Len(t, history, 3)
+		assert.Equal(t, models.HistoryActionDelete, history[2].Action)
+	})
+
+	resp = do("POST", path+"/restore", nil)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+
+	t.Run("Get history", func(t *testing.T) {
+		resp := do("GET", path+"/history", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var history []models.ExperienceHistoryEntry
+		require.NoError(t, decodeData(resp, &history))
+		require.Len(t, history, 4)
+
+		actions := make([]string, len(history))
+		for i, entry := range history {
+			actions[i] = entry.Action
+			assert.Equal(t, created.ID, entry.ExperienceID)
+			require.NotNil(t, entry.APIKeyID, "changes should be attributed to the API key")
+			assert.Equal(t, *history[0].APIKeyID, *entry.APIKeyID)
+			assert.NotNil(t, entry.After)
+		}
+		assert.Equal(t, []string{
+			models.HistoryActionCreate, models.HistoryActionUpdate,
+			models.HistoryActionDelete, models.HistoryActionRestore,
+		}, actions)
+
+		// Create has no before snapshot, update captures both sides of the change
+		assert.Nil(t, history[0].Before)
+
+		var before, after models.ExperienceData
+		require.NoError(t, json.Unmarshal(history[1].Before, &before))
+		require.NoError(t, json.Unmarshal(history[1].After, &after))
+		require.NotNil(t, before.ValueText)
+		require.NotNil(t, after.ValueText)
+		assert.Equal(t, "Original feedback", *before.ValueText)
+		assert.Equal(t, "Edited feedback", *after.ValueText)
+
+		// Delete sets deleted_at, restore clears it
+		var deleted, restored models.ExperienceData
+		require.NoError(t, json.Unmarshal(history[2].After, &deleted))
+		require.NoError(t, json.Unmarshal(history[3].After, &restored))
+		assert.NotNil(t, deleted.DeletedAt)
+		assert.Nil(t, restored.DeletedAt)
+	})
+
+	t.Run("History of a missing record", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences/00000000-0000-0000-0000-000000000000/history", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("History with invalid ID", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences/not-a-uuid/history", nil)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
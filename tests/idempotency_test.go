@@ -0,0 +1,162 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/api/handlers"
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+)
+
+func TestIdempotencyKeys(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// post sends a POST request with an Idempotency-Key header
+	post := func(path, idempotencyKey string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		req, _ := http.NewRequest("POST", server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(models.IdempotencyKeyHeader, idempotencyKey)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	do := func(method, path string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+path, nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	sourceID := "idempotency_" + uuid.NewString()
+	record := func(text string) map[string]interface{} {
+		return map[string]interface{}{
+			"source_type": "formbricks",
+			"source_id":   sourceID,
+			"field_id":    "idempotency_comment",
+			"field_type":  "text",
+			"value_text":  text,
+		}
+	}
+
+	// create posts a record and removes it when the test ends
+	create := func(idempotencyKey, text string) (*http.Response, models.ExperienceData) {
+		resp := post("/v1/experiences", idempotencyKey, record(text))
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		resp.Body.Close()
+		t.Cleanup(func() {
+			do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID)).Body.Close()
+		})
+		return resp, exp
+	}
+
+	count := func() int {
+		resp := do("GET", "/v1/experiences?source_id="+sourceID)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(resp, &experiences))
+		return len(experiences)
+	}
+
+	t.Run("Retry replays the original response", func(t *testing.T) {
+		key := uuid.NewString()
+
+		first, original := create(key, "Retried feedback")
+		assert.Empty(t, first.Header.Get(models.IdempotentReplayedHeader))
+
+		replay, replayed := create(key, "Retried feedback")
+		assert.Equal(t, "true", replay.Header.Get(models.IdempotentReplayedHeader))
+		assert.Equal(t, original.ID, replayed.ID)
+		assert.NotEmpty(t, first.Header.Get("ETag"))
+		assert.Equal(t, first.Header.Get("ETag"), replay.Header.Get("ETag"))
+
+		assert.Equal(t, 1, count())
+	})
+
+	t.Run("Reusing a key for a different request is rejected", func(t *testing.T) {
+		key := uuid.NewString()
+		create(key, "First request")
+
+		resp := post("/v1/experiences", key, record("Different request"))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		var problem handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, "idempotency_key_reused", problem.Error)
+	})
+
+	t.Run("Different keys create separate records", func(t *testing.T) {
+		before := count()
+		_, first := create(uuid.NewString(), "Same body")
+		_, second := create(uuid.NewString(), "Same body")
+		assert.NotEqual(t, first.ID, second.ID)
+		assert.Equal(t, before+2, count())
+	})
+
+	t.Run("Keys are limited to 255 characters", func(t *testing.T) {
+		resp := post("/v1/experiences", strings.Repeat("k", 256), record("Too long key"))
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Stored responses are sealed and erased with their records", func(t *testing.T) {
+		// The stored responses are checked on the database directly
+		ctx := context.Background()
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+		require.NoError(t, err)
+		defer db.Close()
+
+		key := uuid.NewString()
+		userIdentifier := "idempotency_user_" + uuid.NewString()
+		body := record("Personal feedback")
+		body["user_identifier"] = userIdentifier
+		resp := post("/v1/experiences", key, body)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		resp.Body.Close()
+
+		var stored []byte
+		var keyVersion *int
+		var experienceIDs []uuid.UUID
+		err = db.QueryRow(ctx, `SELECT response_body, key_version, experience_ids FROM idempotency_keys WHERE key = $1`, key).
+			Scan(&stored, &keyVersion, &experienceIDs)
+		require.NoError(t, err)
+		assert.NotContains(t, string(stored), "Personal feedback")
+		assert.NotContains(t, string(stored), userIdentifier)
+		require.NotNil(t, keyVersion)
+		assert.Equal(t, 1, *keyVersion)
+		assert.Equal(t, []uuid.UUID{exp.ID}, experienceIDs)
+
+		resp = do("DELETE", "/v1/data-subjects/"+url.PathEscape(userIdentifier))
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var remaining int
+		require.NoError(t, db.QueryRow(ctx, `SELECT COUNT(*) FROM idempotency_keys WHERE key = $1`, key).Scan(&remaining))
+		assert.Equal(t, 0, remaining)
+	})
+}
@@ -8,7 +8,10 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -18,7 +21,9 @@ import (
 	"github.com/xernobyl/formbricks_worktrial/internal/models"
 	"github.com/xernobyl/formbricks_worktrial/internal/repository"
 	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/blob"
 	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+	"github.com/xernobyl/formbricks_worktrial/pkg/encryption"
 )
 
 const testAPIKey = "test-api-key-12345"
@@ -35,36 +40,108 @@ func setupTestServer(t *testing.T) (*httptest.Server, func()) {
 	db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
 	require.NoError(t, err, "Failed to connect to database")
 
+	// Sources keeping a PII vault seal masked originals with a fixed test key
+	vault, err := encryption.NewCipher(bytes.Repeat([]byte{7}, encryption.KeySize))
+	require.NoError(t, err)
+
+	// Encrypting sources seal their records with key version 1 of the test keyring
+	keys := newTestKeyring(t, 1)
+
+	// Pseudonymizing sources hash user identifiers with a fixed test key
+	pseudonyms := newTestPseudonymizer(t)
+
 	// Initialize repository, service, and handler layers
 	experienceRepo := repository.NewExperienceRepository(db)
-	experienceService := service.NewExperienceService(experienceRepo)
+	sourceRepo := repository.NewSourceRepository(db)
+	experienceService := service.NewExperienceService(experienceRepo, sourceRepo, vault, keys, pseudonyms)
 	experienceHandler := handlers.NewExperienceHandler(experienceService)
+	sourceService := service.NewSourceService(sourceRepo, vault, keys, pseudonyms)
+	sourceHandler := handlers.NewSourceHandler(sourceService)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+	analyticsService := service.NewAnalyticsService(analyticsRepo, keys, pseudonyms)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
+	responseRepo := repository.NewResponseRepository(db)
+	responseService := service.NewResponseService(responseRepo, keys, pseudonyms)
+	responseHandler := handlers.NewResponseHandler(responseService)
+	dataSubjectRepo := repository.NewDataSubjectRepository(db)
+	dataSubjectService := service.NewDataSubjectService(dataSubjectRepo, keys, pseudonyms)
+	dataSubjectHandler := handlers.NewDataSubjectHandler(dataSubjectService)
+	retentionRepo := repository.NewRetentionRepository(db)
+	retentionService := service.NewRetentionService(retentionRepo)
+	retentionHandler := handlers.NewRetentionHandler(retentionService)
+	bulkJobRepo := repository.NewBulkJobRepository(db)
+	bulkService := service.NewBulkService(experienceRepo, sourceRepo, bulkJobRepo, keys, pseudonyms)
+	bulkHandler := handlers.NewBulkHandler(bulkService)
+	exportStore := newTestExportStore(t)
+	exportService := service.NewExportService(experienceRepo, repository.NewExportRepository(db), exportStore, keys, pseudonyms, time.Hour)
+	exportHandler := handlers.NewExportHandler(exportService, exportStore)
 	healthHandler := handlers.NewHealthHandler()
 
 	// Initialize API key repository for authentication
 	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	idempotencyRepo := repository.NewIdempotencyRepository(db)
 
 	// Set up public endpoints
 	publicMux := http.NewServeMux()
 	publicMux.HandleFunc("GET /health", healthHandler.Check)
+	publicMux.HandleFunc("GET /v1/downloads/{key...}", exportHandler.Download)
 
 	var publicHandler http.Handler = publicMux
 
 	// Set up protected endpoints
 	protectedMux := http.NewServeMux()
 	protectedMux.HandleFunc("POST /v1/experiences", experienceHandler.Create)
+	protectedMux.HandleFunc("PUT /v1/experiences", experienceHandler.Upsert)
+	protectedMux.HandleFunc("POST /v1/experiences/lookup", experienceHandler.Lookup)
 	protectedMux.HandleFunc("GET /v1/experiences", experienceHandler.List)
 	protectedMux.HandleFunc("GET /v1/experiences/{id}", experienceHandler.Get)
 	protectedMux.HandleFunc("PATCH /v1/experiences/{id}", experienceHandler.Update)
 	protectedMux.HandleFunc("DELETE /v1/experiences/{id}", experienceHandler.Delete)
+	protectedMux.HandleFunc("POST /v1/experiences/{id}/restore", experienceHandler.Restore)
+	protectedMux.HandleFunc("GET /v1/experiences/trash", experienceHandler.Trash)
+	protectedMux.HandleFunc("GET /v1/experiences/{id}/history", experienceHandler.History)
+	protectedMux.HandleFunc("GET /v1/experiences/{id}/vault", experienceHandler.Vault)
+	protectedMux.HandleFunc("POST /v1/experiences/bulk-update", bulkHandler.Update)
+	protectedMux.HandleFunc("POST /v1/experiences/bulk-delete", bulkHandler.Delete)
+	protectedMux.HandleFunc("GET /v1/bulk-jobs/{id}", bulkHandler.GetJob)
+	protectedMux.HandleFunc("POST /v1/exports", exportHandler.Create)
+	protectedMux.HandleFunc("GET /v1/exports/{id}", exportHandler.Get)
 	protectedMux.HandleFunc("GET /v1/experiences/search", experienceHandler.Search)
+	protectedMux.HandleFunc("GET /v1/analytics/metrics", analyticsHandler.Metrics)
+	protectedMux.HandleFunc("GET /v1/analytics/timeseries", analyticsHandler.TimeSeries)
+	protectedMux.HandleFunc("GET /v1/analytics/crosstab", analyticsHandler.Crosstab)
+	protectedMux.HandleFunc("GET /v1/responses", responseHandler.List)
+	protectedMux.HandleFunc("GET /v1/responses/{id}", responseHandler.Get)
+
+	protectedMux.HandleFunc("GET /v1/data-subjects/{user_identifier}/export", dataSubjectHandler.Export)
+	protectedMux.HandleFunc("DELETE /v1/data-subjects/{user_identifier}", dataSubjectHandler.Erase)
+	protectedMux.HandleFunc("GET /v1/erasure-receipts/{id}", dataSubjectHandler.GetErasureReceipt)
+
+	protectedMux.HandleFunc("POST /v1/retention-policies", retentionHandler.Create)
+	protectedMux.HandleFunc("GET /v1/retention-policies", retentionHandler.List)
+	protectedMux.HandleFunc("GET /v1/retention-policies/{id}", retentionHandler.Get)
+	protectedMux.HandleFunc("PATCH /v1/retention-policies/{id}", retentionHandler.Update)
+	protectedMux.HandleFunc("DELETE /v1/retention-policies/{id}", retentionHandler.Delete)
+	protectedMux.HandleFunc("POST /v1/retention-policies/{id}/dry-run", retentionHandler.DryRun)
+
+	protectedMux.HandleFunc("POST /v1/sources", sourceHandler.Create)
+	protectedMux.HandleFunc("GET /v1/sources", sourceHandler.List)
+	protectedMux.HandleFunc("GET /v1/sources/{id}", sourceHandler.Get)
+	protectedMux.HandleFunc("PATCH /v1/sources/{id}", sourceHandler.Update)
+	protectedMux.HandleFunc("DELETE /v1/sources/{id}", sourceHandler.Delete)
+	protectedMux.HandleFunc("GET /v1/sources/{id}/fields", sourceHandler.ListFields)
+	protectedMux.HandleFunc("PUT /v1/sources/{id}/fields/{field_id}", sourceHandler.PutField)
+	protectedMux.HandleFunc("DELETE /v1/sources/{id}/fields/{field_id}", sourceHandler.DeleteField)
+	protectedMux.HandleFunc("POST /v1/sources/import/formbricks", sourceHandler.ImportFormbricks)
 
 	var protectedHandler http.Handler = protectedMux
+	protectedHandler = middleware.Idempotency(idempotencyRepo, keys, time.Hour)(protectedHandler)
 	protectedHandler = middleware.Auth(apiKeyRepo)(protectedHandler)
 
 	// Combine both handlers
 	mainMux := http.NewServeMux()
 	mainMux.Handle("/v1/", protectedHandler)
+	mainMux.Handle("/v1/downloads/", publicHandler)
 	mainMux.Handle("/", publicHandler)
 
 	// Create test server
@@ -79,6 +156,32 @@ func setupTestServer(t *testing.T) (*httptest.Server, func()) {
 	return server, cleanup
 }
 
+// newTestKeyring returns a keyring with the fixed test keys of versions 1 and 2,
+// sealing new records with version current
+func newTestKeyring(t *testing.T, current int) *encryption.Keyring {
+	keys, err := encryption.NewKeyring(map[int][]byte{
+		1: bytes.Repeat([]byte{11}, encryption.KeySize),
+		2: bytes.Repeat([]byte{12}, encryption.KeySize),
+	}, current, bytes.Repeat([]byte{13}, encryption.KeySize))
+	require.NoError(t, err)
+	return keys
+}
+
+// newTestPseudonymizer returns a pseudonymizer with the fixed test key
+func newTestPseudonymizer(t *testing.T) *encryption.Pseudonymizer {
+	pseudonyms, err := encryption.NewPseudonymizer(bytes.Repeat([]byte{14}, encryption.KeySize))
+	require.NoError(t, err)
+	return pseudonyms
+}
+
+// newTestExportStore returns the local export store shared by the test server
+// and the worker run by the tests, which signs download URLs with a fixed key
+func newTestExportStore(t *testing.T) *blob.FileStore {
+	store, err := blob.NewFileStore(filepath.Join(os.TempDir(), "formbricks-hub-test-exports"), "/v1/downloads", bytes.Repeat([]byte{15}, 32))
+	require.NoError(t, err)
+	return store
+}
+
 // decodeData decodes the {"data": ...} wrapper from API responses
 func decodeData(resp *http.Response, v interface{}) error {
 	var wrapper struct {
@@ -125,6 +228,7 @@ func TestCreateExperience(t *testing.T) {
 		defer resp.Body.Close()
 
 		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
 	})
 
 	// Test with valid authentication
@@ -178,6 +282,58 @@ func TestCreateExperience(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
 	})
+
+	// Test values that do not match the field type
+	t.Run("Unprocessable entity with NPS out of range", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"source_type":  "formbricks",
+			"field_id":     "nps",
+			"field_type":   "nps",
+			"value_number": 11,
+		}
+		body, _ := json.Marshal(reqBody)
+
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		err = json.NewDecoder(resp.Body).Decode(&errResp)
+		require.NoError(t, err)
+
+		assert.Equal(t, "validation_failed", errResp.Error)
+		require.Len(t, errResp.Details, 1)
+		assert.Equal(t, "value_number", errResp.Details[0].Field)
+		assert.Equal(t, models.ValidationOutOfRange, errResp.Details[0].Code)
+	})
+
+	t.Run("Unprocessable entity with missing value column", func(t *testing.T) {
+		reqBody := map[string]interface{}{
+			"source_type": "formbricks",
+			"field_id":    "score",
+			"field_type":  "number",
+			"value_text":  "five",
+		}
+		body, _ := json.Marshal(reqBody)
+
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+
+		client := &http.Client{}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
 }
 
 func TestListExperiences(t *testing.T) {
@@ -291,6 +447,13 @@ func TestGetExperience(t *testing.T) {
 		defer resp.Body.Close()
 
 		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+
+		var problem handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&problem))
+		assert.Equal(t, http.StatusNotFound, problem.Status)
+		assert.Equal(t, "not_found", problem.Error)
+		assert.Equal(t, "experience not found", problem.Detail)
 	})
 }
 
@@ -394,6 +557,95 @@ func TestDeleteExperience(t *testing.T) {
 
 		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 	})
+
+	t.Run("Deleted experience is hidden from list", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences?field_id=temp&limit=1000", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var experiences []models.ExperienceData
+		decodeData(resp, &experiences)
+		for _, exp := range experiences {
+			assert.NotEqual(t, created.ID, exp.ID)
+		}
+	})
+
+	t.Run("Deleted experience is listed in the trash", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/trash?field_id=temp&limit=1000", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var trash []models.ExperienceData
+		decodeData(resp, &trash)
+
+		var found *models.ExperienceData
+		for i := range trash {
+			if trash[i].ID == created.ID {
+				found = &trash[i]
+			}
+		}
+		require.NotNil(t, found, "deleted experience should be in the trash")
+		assert.NotNil(t, found.DeletedAt)
+	})
+
+	t.Run("Delete experience in the trash", func(t *testing.T) {
+		req, _ := http.NewRequest("DELETE", fmt.Sprintf("%s/v1/experiences/%s", server.URL, created.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("Restore experience", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/v1/experiences/%s/restore", server.URL, created.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var restored models.ExperienceData
+		decodeData(resp, &restored)
+		assert.Equal(t, created.ID, restored.ID)
+		assert.Nil(t, restored.DeletedAt)
+	})
+
+	t.Run("Restored experience is found again", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/v1/experiences/%s", server.URL, created.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Restore experience not in the trash", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", fmt.Sprintf("%s/v1/experiences/%s/restore", server.URL, created.ID), nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
 }
 
 func TestSearchExperiences(t *testing.T) {
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestLookupAndSparseFieldsets(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	sourceID := "lookup_" + uuid.NewString()
+	ids := make([]uuid.UUID, 0, 3)
+	for score := 7; score <= 9; score++ {
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    sourceID,
+			"field_id":     "nps",
+			"field_type":   "nps",
+			"value_number": score,
+			"metadata":     map[string]interface{}{"payload": "large"},
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		resp.Body.Close()
+		ids = append(ids, exp.ID)
+		t.Cleanup(func() { do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil).Body.Close() })
+	}
+
+	t.Run("Lookup returns records in request order and reports missing IDs", func(t *testing.T) {
+		missing := uuid.New()
+		resp := do("POST", "/v1/experiences/lookup", map[string]interface{}{
+			"ids": []uuid.UUID{ids[2], missing, ids[0]},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.LookupExperiencesResponse
+		require.NoError(t, decodeData(resp, &result))
+		require.Len(t, result.Data, 2)
+		assert.Equal(t, ids[2], result.Data[0].ID)
+		assert.Equal(t, ids[0], result.Data[1].ID)
+		assert.Equal(t, []uuid.UUID{missing}, result.Missing)
+	})
+
+	t.Run("Lookup returns only the requested fields", func(t *testing.T) {
+		resp := do("POST", "/v1/experiences/lookup", map[string]interface{}{
+			"ids":    ids,
+			"fields": []string{"value_number"},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result struct {
+			Data []map[string]interface{} `json:"data"`
+		}
+		require.NoError(t, decodeData(resp, &result))
+		require.Len(t, result.Data, 3)
+		for _, record := range result.Data {
+			assert.Len(t, record, 2)
+			assert.Contains(t, record, "id")
+			assert.Contains(t, record, "value_number")
+		}
+	})
+
+	t.Run("Lookup limits the number of IDs", func(t *testing.T) {
+		tooMany := make([]uuid.UUID, models.MaxLookupIDs+1)
+		for i := range tooMany {
+			tooMany[i] = uuid.New()
+		}
+		resp := do("POST", "/v1/experiences/lookup", map[string]interface{}{"ids": tooMany})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("List and get return sparse fieldsets", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences?fields=field_id,value_number&source_id="+sourceID, nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var records []map[string]interface{}
+		require.NoError(t, decodeData(resp, &records))
+		require.Len(t, records, 3)
+		for _, record := range records {
+			assert.Len(t, record, 3)
+			assert.NotContains(t, record, "metadata")
+		}
+
+		get := do("GET", fmt.Sprintf("/v1/experiences/%s?fields=metadata", ids[0]), nil)
+		defer get.Body.Close()
+		require.Equal(t, http.StatusOK, get.StatusCode)
+		var record map[string]interface{}
+		require.NoError(t, decodeData(get, &record))
+		assert.Len(t, record, 2)
+		assert.Equal(t, map[string]interface{}{"payload": "large"}, record["metadata"])
+	})
+
+	t.Run("Unknown fields are rejected", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences?fields=id,value_blob", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		resp = do("POST", "/v1/experiences/lookup", map[string]interface{}{"ids": ids, "fields": []string{"value_blob"}})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestMergePatchExperience(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// do sends body as is with the given Content-Type
+	do := func(method, path, contentType, body string) *http.Response {
+		req, _ := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := do("POST", "/v1/experiences", "application/json", fmt.Sprintf(`{
+		"source_type": "formbricks",
+		"source_id": "merge_patch_%s",
+		"source_name": "Onboarding survey",
+		"field_id": "merge_patch_comment",
+		"field_type": "text",
+		"value_text": "Setup was easy",
+		"language": "en",
+		"metadata": {"sentiment": "positive", "tags": {"topic": "setup", "team": "growth"}}
+	}`, uuid.NewString()))
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var created models.ExperienceData
+	require.NoError(t, decodeData(resp, &created))
+	resp.Body.Close()
+	path := fmt.Sprintf("/v1/experiences/%s", created.ID)
+	defer do("DELETE", path, "", "").Body.Close()
+
+	patch := func(body string) *http.Response {
+		return do("PATCH", path, "application/merge-patch+json", body)
+	}
+
+	t.Run("null clears a field", func(t *testing.T) {
+		resp := patch(`{"source_name": null, "language": null}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.Nil(t, updated.SourceName)
+		assert.Nil(t, updated.Language)
+		assert.Equal(t, "Setup was easy", *updated.ValueText)
+	})
+
+	t.Run("metadata is merged recursively", func(t *testing.T) {
+		resp := patch(`{"metadata": {"sentiment": null, "tags": {"team": "support"}, "reviewed": true}}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.JSONEq(t, `{"tags": {"topic": "setup", "team": "support"}, "reviewed": true}`, string(updated.Metadata))
+	})
+
+	t.Run("application/json still replaces metadata and ignores null", func(t *testing.T) {
+		resp := do("PATCH", path, "application/json", `{"metadata": {"reviewed": false}, "value_text": null}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.JSONEq(t, `{"reviewed": false}`, string(updated.Metadata))
+		assert.Equal(t, "Setup was easy", *updated.ValueText)
+	})
+
+	t.Run("clearing the value the field type requires is rejected", func(t *testing.T) {
+		resp := patch(`{"value_text": null}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("required fields cannot be cleared", func(t *testing.T) {
+		resp := patch(`{"field_id": null}`)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("fields that cannot be cleared are rejected", func(t *testing.T) {
+		for _, body := range []string{`{"collected_at": null}`, `{"id": null}`, `{"value_txt": null}`} {
+			resp := patch(body)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode, body)
+			resp.Body.Close()
+		}
+	})
+
+	t.Run("value columns can be cleared when switching type", func(t *testing.T) {
+		resp := patch(`{"field_type": "rating", "value_number": 4, "value_text": null}`)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.Nil(t, updated.ValueText)
+		assert.Equal(t, 4.0, *updated.ValueNumber)
+	})
+}
@@ -0,0 +1,168 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/api/handlers"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestPIIScanning(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// createSource registers a source with a PII mode and removes it when the test ends
+	createSource := func(mode string, vault bool) string {
+		externalID := "pii-" + uuid.NewString()
+		resp := do("POST", "/v1/sources", map[string]interface{}{
+			"source_type": "formbricks",
+			"external_id": externalID,
+			"pii_mode":    mode,
+			"pii_vault":   vault,
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var source models.Source
+		require.NoError(t, decodeData(resp, &source))
+		assert.Equal(t, mode, source.PIIMode)
+		t.Cleanup(func() {
+			do("DELETE", fmt.Sprintf("/v1/sources/%s", source.ID), nil).Body.Close()
+		})
+		return externalID
+	}
+
+	create := func(sourceID, text string) *http.Response {
+		return do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type": "formbricks",
+			"source_id":   sourceID,
+			"field_id":    "pii_comment",
+			"field_type":  "text",
+			"value_text":  text,
+		})
+	}
+
+	const comment = "Reach me at jane@example.com or +49 30 1234567"
+
+	t.Run("flag stores the text and the detected types", func(t *testing.T) {
+		sourceID := createSource(models.PIIModeFlag, false)
+
+		resp := create(sourceID, comment)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		assert.Equal(t, comment, *exp.ValueText)
+		assert.Equal(t, []string{models.PIITypeEmail, models.PIITypePhone}, exp.PIITypes)
+
+		listResp := do("GET", "/v1/experiences?source_id="+sourceID+"&pii_type=email", nil)
+		defer listResp.Body.Close()
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(listResp, &experiences))
+		require.Len(t, experiences, 1)
+		assert.Equal(t, exp.ID, experiences[0].ID)
+
+		listResp = do("GET", "/v1/experiences?source_id="+sourceID+"&pii_type=iban", nil)
+		defer listResp.Body.Close()
+		require.NoError(t, decodeData(listResp, &experiences))
+		assert.Empty(t, experiences)
+	})
+
+	t.Run("mask replaces the values irreversibly", func(t *testing.T) {
+		sourceID := createSource(models.PIIModeMask, false)
+
+		resp := create(sourceID, comment)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		assert.Equal(t, "Reach me at [EMAIL] or [PHONE]", *exp.ValueText)
+
+		vaultResp := do("GET", fmt.Sprintf("/v1/experiences/%s/vault", exp.ID), nil)
+		defer vaultResp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, vaultResp.StatusCode)
+	})
+
+	t.Run("mask keeps the original in the vault", func(t *testing.T) {
+		sourceID := createSource(models.PIIModeMask, true)
+
+		resp := create(sourceID, comment)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		assert.Equal(t, "Reach me at [EMAIL] or [PHONE]", *exp.ValueText)
+
+		vaultResp := do("GET", fmt.Sprintf("/v1/experiences/%s/vault", exp.ID), nil)
+		defer vaultResp.Body.Close()
+		require.Equal(t, http.StatusOK, vaultResp.StatusCode)
+		var vault models.ExperienceVault
+		require.NoError(t, decodeData(vaultResp, &vault))
+		assert.Equal(t, comment, vault.ValueText)
+
+		// Updating value_text scans it again and replaces the vaulted original
+		updateResp := do("PATCH", fmt.Sprintf("/v1/experiences/%s", exp.ID), map[string]interface{}{
+			"value_text": "No contact details this time",
+		})
+		defer updateResp.Body.Close()
+		require.Equal(t, http.StatusOK, updateResp.StatusCode)
+		require.NoError(t, decodeData(updateResp, &exp))
+		assert.Empty(t, exp.PIITypes)
+
+		vaultResp = do("GET", fmt.Sprintf("/v1/experiences/%s/vault", exp.ID), nil)
+		defer vaultResp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, vaultResp.StatusCode)
+	})
+
+	t.Run("reject refuses records with personal data", func(t *testing.T) {
+		sourceID := createSource(models.PIIModeReject, false)
+
+		resp := create(sourceID, "IBAN DE89 3704 0044 0532 0130 00")
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		require.Len(t, errResp.Details, 1)
+		assert.Equal(t, "value_text", errResp.Details[0].Field)
+		assert.Equal(t, models.ValidationPIIDetected, errResp.Details[0].Code)
+
+		okResp := create(sourceID, "Nothing personal here")
+		defer okResp.Body.Close()
+		assert.Equal(t, http.StatusCreated, okResp.StatusCode)
+	})
+
+	t.Run("unknown pii_mode", func(t *testing.T) {
+		resp := do("POST", "/v1/sources", map[string]interface{}{
+			"source_type": "formbricks",
+			"external_id": "pii-" + uuid.NewString(),
+			"pii_mode":    "hide",
+		})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+}
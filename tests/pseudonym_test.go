@@ -0,0 +1,201 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+)
+
+func TestPseudonymousUserIdentifiers(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// createSource registers a source and removes it when the test ends
+	createSource := func(pseudonymize bool) models.Source {
+		resp := do("POST", "/v1/sources", map[string]interface{}{
+			"source_type":  "formbricks",
+			"external_id":  "pseudonym-" + uuid.NewString(),
+			"pseudonymize": pseudonymize,
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var source models.Source
+		require.NoError(t, decodeData(resp, &source))
+		assert.Equal(t, pseudonymize, source.Pseudonymize)
+		t.Cleanup(func() {
+			do("DELETE", fmt.Sprintf("/v1/sources/%s", source.ID), nil).Body.Close()
+		})
+		return source
+	}
+
+	// createRecord creates an answer of userIdentifier and removes it when the test ends
+	createRecord := func(sourceID, userIdentifier string) models.ExperienceData {
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type":     "formbricks",
+			"source_id":       sourceID,
+			"field_id":        "pseudonym_rating",
+			"field_type":      "rating",
+			"value_number":    4,
+			"user_identifier": userIdentifier,
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		t.Cleanup(func() {
+			do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil).Body.Close()
+		})
+		return exp
+	}
+
+	list := func(userIdentifier string) []models.ExperienceData {
+		resp := do("GET", "/v1/experiences?user_identifier="+url.QueryEscape(userIdentifier), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(resp, &experiences))
+		return experiences
+	}
+
+	pseudonyms := newTestPseudonymizer(t)
+
+	t.Run("Records of pseudonymizing sources store the pseudonym", func(t *testing.T) {
+		source := createSource(true)
+		email := "jane-" + uuid.NewString() + "@example.com"
+		pseudonym := pseudonyms.Pseudonym(email)
+
+		exp := createRecord(source.ExternalID, email)
+		require.NotNil(t, exp.UserIdentifier)
+		assert.Equal(t, pseudonym, *exp.UserIdentifier)
+
+		// Filters hash the identifier transparently and accept the pseudonym itself
+		for _, filter := range []string{email, pseudonym} {
+			experiences := list(filter)
+			require.Len(t, experiences, 1, filter)
+			assert.Equal(t, exp.ID, experiences[0].ID)
+		}
+
+		resp := do("GET", fmt.Sprintf("/v1/data-subjects/%s/export", url.PathEscape(email)), nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var export models.DataSubjectExport
+		require.NoError(t, decodeData(resp, &export))
+		assert.Len(t, export.Experiences, 1)
+	})
+
+	t.Run("Pseudonymize command converts existing records and their history", func(t *testing.T) {
+		source := createSource(false)
+		email := "john-" + uuid.NewString() + "@example.com"
+		pseudonym := pseudonyms.Pseudonym(email)
+
+		exp := createRecord(source.ExternalID, email)
+		assert.Equal(t, email, *exp.UserIdentifier)
+
+		resp := do("PATCH", fmt.Sprintf("/v1/sources/%s", source.ID), map[string]interface{}{"pseudonymize": true})
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		// The command runs the conversion through the service
+		ctx := context.Background()
+		cfg, err := config.Load()
+		require.NoError(t, err)
+		db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+		require.NoError(t, err)
+		defer db.Close()
+		experienceService := service.NewExperienceService(
+			repository.NewExperienceRepository(db), repository.NewSourceRepository(db), nil, nil, pseudonyms)
+
+		converted, err := experienceService.PseudonymizeRecords(ctx)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, converted, int64(1))
+
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		resp.Body.Close()
+		assert.Equal(t, pseudonym, *updated.UserIdentifier)
+
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s/history", exp.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var history []models.ExperienceHistoryEntry
+		require.NoError(t, decodeData(resp, &history))
+		resp.Body.Close()
+		require.NotEmpty(t, history)
+		for _, entry := range history {
+			assert.NotContains(t, string(entry.After), email)
+			assert.NotContains(t, string(entry.Before), email)
+		}
+
+		// Filtering by the raw identifier still finds the record
+		experiences := list(email)
+		require.Len(t, experiences, 1)
+		assert.Equal(t, exp.ID, experiences[0].ID)
+
+		// Converted records are skipped on the next run
+		_, err = experienceService.PseudonymizeRecords(ctx)
+		require.NoError(t, err)
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s", exp.ID), nil)
+		require.NoError(t, decodeData(resp, &updated))
+		resp.Body.Close()
+		assert.Equal(t, pseudonym, *updated.UserIdentifier)
+	})
+
+	t.Run("Identifiers that only look like pseudonyms are rejected", func(t *testing.T) {
+		source := createSource(false)
+		lookalike := "hmac:jane-" + uuid.NewString() + "@example.com"
+
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type":     "formbricks",
+			"source_id":       source.ExternalID,
+			"field_id":        "pseudonym_rating",
+			"field_type":      "rating",
+			"value_number":    4,
+			"user_identifier": lookalike,
+		})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		exp := createRecord(source.ExternalID, "jane-"+uuid.NewString()+"@example.com")
+		resp = do("PATCH", fmt.Sprintf("/v1/experiences/%s", exp.ID), map[string]interface{}{"user_identifier": lookalike})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		// Real pseudonyms are accepted as sent
+		pseudonym := pseudonyms.Pseudonym("jane@example.com")
+		resp = do("PATCH", fmt.Sprintf("/v1/experiences/%s", exp.ID), map[string]interface{}{"user_identifier": pseudonym})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated models.ExperienceData
+		require.NoError(t, decodeData(resp, &updated))
+		assert.Equal(t, pseudonym, *updated.UserIdentifier)
+	})
+}
@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestResponses(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// Create three answers of one submission
+	responseID := "response_" + uuid.NewString()
+	answers := []map[string]interface{}{
+		{"field_id": "response_nps", "field_type": "number", "value_number": 9},
+		{"field_id": "response_reason", "field_type": "text", "value_text": "Fast support"},
+		{"field_id": "response_recommend", "field_type": "boolean", "value_boolean": true},
+	}
+	for _, answer := range answers {
+		answer["source_type"] = "formbricks"
+		answer["source_id"] = "responses_survey"
+		answer["response_id"] = responseID
+		body, _ := json.Marshal(answer)
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	t.Run("Get response", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", fmt.Sprintf("%s/v1/responses/%s", server.URL, responseID), nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.Response
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		assert.Equal(t, responseID, result.ID)
+		assert.Equal(t, "formbricks", result.SourceType)
+		require.Len(t, result.Answers, 3)
+		for _, answer := range result.Answers {
+			require.NotNil(t, answer.ResponseID)
+			assert.Equal(t, responseID, *answer.ResponseID)
+		}
+	})
+
+	t.Run("Get missing response", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/responses/response_missing", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+
+	t.Run("List responses pivoted by field", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/responses?source_id=responses_survey", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.ListResponsesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		assert.Subset(t, result.Fields, []string{"response_nps", "response_reason", "response_recommend"})
+
+		var row *models.ResponseRow
+		for i := range result.Data {
+			if result.Data[i].ID == responseID {
+				row = &result.Data[i]
+			}
+		}
+		require.NotNil(t, row)
+		assert.JSONEq(t, `9`, string(row.Values["response_nps"]))
+		assert.JSONEq(t, `"Fast support"`, string(row.Values["response_reason"]))
+		assert.JSONEq(t, `true`, string(row.Values["response_recommend"]))
+	})
+
+	t.Run("Filter experiences by response_id", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences?response_id="+responseID, nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result []models.ExperienceData
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+		assert.Len(t, result, 3)
+	})
+}
@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/config"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+	"github.com/xernobyl/formbricks_worktrial/internal/repository"
+	"github.com/xernobyl/formbricks_worktrial/internal/service"
+	"github.com/xernobyl/formbricks_worktrial/pkg/database"
+)
+
+func TestRetentionPolicies(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	// createRecord creates a free text answer for a source collected days ago
+	createRecord := func(sourceID string, days int) models.ExperienceData {
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type":     "formbricks",
+			"source_id":       sourceID,
+			"field_id":        "retention_comment",
+			"field_type":      "text",
+			"value_text":      "Feedback from user",
+			"user_identifier": "retention_user",
+			"collected_at":    time.Now().AddDate(0, 0, -days),
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		return exp
+	}
+
+	// createPolicy creates a policy and removes it when the test ends
+	createPolicy := func(body map[string]interface{}) models.RetentionPolicy {
+		resp := do("POST", "/v1/retention-policies", body)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var policy models.RetentionPolicy
+		require.NoError(t, decodeData(resp, &policy))
+		t.Cleanup(func() {
+			do("DELETE", fmt.Sprintf("/v1/retention-policies/%s", policy.ID), nil).Body.Close()
+		})
+		return policy
+	}
+
+	// Enforcement runs in the worker, so it is driven through the service
+	ctx := context.Background()
+	cfg, err := config.Load()
+	require.NoError(t, err)
+	db, err := database.NewPostgresPool(ctx, cfg.DatabaseURL)
+	require.NoError(t, err)
+	defer db.Close()
+	retentionService := service.NewRetentionService(repository.NewRetentionRepository(db))
+
+	t.Run("Create policy with invalid values", func(t *testing.T) {
+		for _, body := range []map[string]interface{}{
+			{"max_age_days": 30, "action": "delete"},
+			{"source_type": "formbricks", "max_age_days": 0, "action": "delete"},
+			{"source_type": "formbricks", "max_age_days": 30, "action": "archive"},
+		} {
+			resp := do("POST", "/v1/retention-policies", body)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		}
+	})
+
+	t.Run("Delete expired records", func(t *testing.T) {
+		sourceID := "retention_" + uuid.NewString()
+		expired := createRecord(sourceID, 400)
+		kept := createRecord(sourceID, 10)
+
+		policy := createPolicy(map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    sourceID,
+			"max_age_days": 365,
+			"action":       "delete",
+		})
+		assert.True(t, policy.Enabled)
+
+		resp := do("POST", fmt.Sprintf("/v1/retention-policies/%s/dry-run", policy.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var dryRun models.RetentionRun
+		require.NoError(t, decodeData(resp, &dryRun))
+		resp.Body.Close()
+		assert.True(t, dryRun.DryRun)
+		assert.Equal(t, int64(1), dryRun.Affected)
+
+		// A dry run leaves the records alone
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s", expired.ID), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		_, err := retentionService.EnforcePolicies(ctx, false)
+		require.NoError(t, err)
+
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s", expired.ID), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+		resp = do("GET", fmt.Sprintf("/v1/experiences/%s", kept.ID), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do("GET", fmt.Sprintf("/v1/retention-policies/%s", policy.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var ran models.RetentionPolicy
+		require.NoError(t, decodeData(resp, &ran))
+		resp.Body.Close()
+		assert.NotNil(t, ran.LastRunAt)
+		require.NotNil(t, ran.LastRunAffected)
+		assert.Equal(t, int64(1), *ran.LastRunAffected)
+	})
+
+	t.Run("Anonymize expired records", func(t *testing.T) {
+		sourceID := "retention_" + uuid.NewString()
+		expired := createRecord(sourceID, 40)
+
+		policy := createPolicy(map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    sourceID,
+			"max_age_days": 30,
+			"action":       "anonymize",
+		})
+
+		_, err := retentionService.EnforcePolicies(ctx, false)
+		require.NoError(t, err)
+
+		resp := do("GET", fmt.Sprintf("/v1/experiences/%s", expired.ID), nil)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		resp.Body.Close()
+		assert.Nil(t, exp.UserIdentifier)
+		assert.Nil(t, exp.ValueText)
+
+		// Anonymized records are not counted again
+		resp = do("POST", fmt.Sprintf("/v1/retention-policies/%s/dry-run", policy.ID), nil)
+		var dryRun models.RetentionRun
+		require.NoError(t, decodeData(resp, &dryRun))
+		resp.Body.Close()
+		assert.Equal(t, int64(0), dryRun.Affected)
+	})
+
+	t.Run("Disabled policies are not enforced", func(t *testing.T) {
+		sourceID := "retention_" + uuid.NewString()
+		expired := createRecord(sourceID, 40)
+
+		policy := createPolicy(map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    sourceID,
+			"max_age_days": 30,
+			"action":       "delete",
+			"enabled":      false,
+		})
+		assert.False(t, policy.Enabled)
+
+		_, err := retentionService.EnforcePolicies(ctx, false)
+		require.NoError(t, err)
+
+		resp := do("GET", fmt.Sprintf("/v1/experiences/%s", expired.ID), nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("Update policy", func(t *testing.T) {
+		policy := createPolicy(map[string]interface{}{
+			"source_type":  "formbricks",
+			"max_age_days": 730,
+			"action":       "delete",
+			"enabled":      false,
+		})
+
+		resp := do("PATCH", fmt.Sprintf("/v1/retention-policies/%s", policy.ID), map[string]interface{}{
+			"max_age_days": 365,
+			"action":       "anonymize",
+		})
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var updated models.RetentionPolicy
+		require.NoError(t, decodeData(resp, &updated))
+		resp.Body.Close()
+		assert.Equal(t, 365, updated.MaxAgeDays)
+		assert.Equal(t, models.RetentionActionAnonymize, updated.Action)
+		assert.False(t, updated.Enabled)
+
+		resp = do("PATCH", fmt.Sprintf("/v1/retention-policies/%s", policy.ID), map[string]interface{}{"max_age_days": -1})
+		resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Get missing policy", func(t *testing.T) {
+		resp := do("GET", "/v1/retention-policies/00000000-0000-0000-0000-000000000000", nil)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"testing"
 	"time"
 
@@ -358,6 +359,322 @@ func TestSearchFullText(t *testing.T) {
 	})
 }
 
+func TestSearchFullTextSyntax(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	// Create test experiences in different languages
+	testData := []map[string]interface{}{
+		{
+			"source_type": "formbricks",
+			"field_id":    "fts_feedback",
+			"field_type":  "text",
+			"value_text":  "The customers loved the onboarding experience",
+			"language":    "en",
+		},
+		{
+			"source_type": "formbricks",
+			"field_id":    "fts_feedback",
+			"field_type":  "text",
+			"value_text":  "Onboarding was slow and the customer support was unhelpful",
+			"language":    "en",
+		},
+		{
+			"source_type": "formbricks",
+			"field_id":    "fts_feedback",
+			"field_type":  "text",
+			"value_text":  "Die Kundenbetreuung war hervorragend",
+			"language":    "de",
+		},
+	}
+
+	for _, data := range testData {
+		body, _ := json.Marshal(data)
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		_, _ = client.Do(req)
+	}
+
+	search := func(t *testing.T, query string) models.SearchExperiencesResponse {
+		params := url.Values{}
+		params.Set("query", query)
+		params.Set("field_id", "fts_feedback")
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?"+params.Encode(), nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.SearchExperiencesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+		return result
+	}
+
+	t.Run("Stemming matches word variants", func(t *testing.T) {
+		result := search(t, "customer")
+
+		assert.GreaterOrEqual(t, len(result.Data), 2)
+		for _, exp := range result.Data {
+			require.NotNil(t, exp.Rank)
+			require.NotNil(t, exp.Highlight)
+			assert.Contains(t, *exp.Highlight, "<mark>")
+		}
+	})
+
+	t.Run("Results are ordered by rank", func(t *testing.T) {
+		result := search(t, "onboarding OR customer")
+
+		require.GreaterOrEqual(t, len(result.Data), 2)
+		for i := 1; i < len(result.Data); i++ {
+			assert.GreaterOrEqual(t, *result.Data[i-1].Rank, *result.Data[i].Rank)
+		}
+	})
+
+	t.Run("Phrase search", func(t *testing.T) {
+		result := search(t, `"customer support"`)
+
+		require.GreaterOrEqual(t, len(result.Data), 1)
+		for _, exp := range result.Data {
+			assert.Contains(t, *exp.ValueText, "customer support")
+		}
+	})
+
+	t.Run("Negated term", func(t *testing.T) {
+		result := search(t, "onboarding -slow")
+
+		require.GreaterOrEqual(t, len(result.Data), 1)
+		for _, exp := range result.Data {
+			assert.NotContains(t, *exp.ValueText, "slow")
+		}
+	})
+
+	t.Run("Prefix term", func(t *testing.T) {
+		result := search(t, "unhelp*")
+
+		assert.GreaterOrEqual(t, len(result.Data), 1)
+	})
+
+	t.Run("Language specific stemming", func(t *testing.T) {
+		result := search(t, "hervorragende")
+
+		require.GreaterOrEqual(t, len(result.Data), 1)
+		assert.Equal(t, "de", *result.Data[0].Language)
+	})
+}
+
+func TestSearchFuzzy(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	testData := []map[string]interface{}{
+		{
+			"source_type": "formbricks",
+			"field_id":    "fuzzy_feedback",
+			"field_label": "What could we improve?",
+			"field_type":  "text",
+			"value_text":  "The onboarding was confusing",
+		},
+		{
+			"source_type": "formbricks",
+			"field_id":    "fuzzy_feedback",
+			"field_label": "What could we improve?",
+			"field_type":  "text",
+			"value_text":  "Pricing is too high",
+		},
+	}
+
+	for _, data := range testData {
+		body, _ := json.Marshal(data)
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		_, _ = client.Do(req)
+	}
+
+	t.Run("Misspelled query matches", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?mode=fuzzy&field_id=fuzzy_feedback&query=onbaording", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.SearchExperiencesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		require.GreaterOrEqual(t, len(result.Data), 1)
+		assert.Contains(t, *result.Data[0].ValueText, "onboarding")
+		for i, exp := range result.Data {
+			require.NotNil(t, exp.Similarity)
+			assert.GreaterOrEqual(t, *exp.Similarity, 0.4)
+			if i > 0 {
+				assert.GreaterOrEqual(t, *result.Data[i-1].Similarity, *exp.Similarity)
+			}
+		}
+	})
+
+	t.Run("Higher threshold excludes weak matches", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?mode=fuzzy&field_id=fuzzy_feedback&query=onbaording&min_similarity=0.99", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.SearchExperiencesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		assert.Equal(t, 0, result.TotalCount)
+	})
+
+	t.Run("Invalid mode parameter", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?mode=regex&query=test", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Invalid min_similarity parameter", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?mode=fuzzy&query=test&min_similarity=1.5", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestSearchFacets(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	testData := []map[string]interface{}{
+		{
+			"source_type": "formbricks",
+			"source_id":   "facet_survey",
+			"field_id":    "facet_q1",
+			"field_type":  "text",
+			"value_text":  "Love it",
+			"language":    "en",
+			"metadata":    map[string]interface{}{"sentiment": "positive"},
+		},
+		{
+			"source_type": "formbricks",
+			"source_id":   "facet_survey",
+			"field_id":    "facet_q1",
+			"field_type":  "text",
+			"value_text":  "Hate it",
+			"language":    "en",
+			"metadata":    map[string]interface{}{"sentiment": "negative"},
+		},
+		{
+			"source_type": "formbricks",
+			"source_id":   "facet_survey",
+			"field_id":    "facet_q2",
+			"field_type":  "text",
+			"value_text":  "Gefällt mir",
+			"language":    "de",
+			"metadata":    map[string]interface{}{"sentiment": "positive"},
+		},
+	}
+
+	for _, data := range testData {
+		body, _ := json.Marshal(data)
+		req, _ := http.NewRequest("POST", server.URL+"/v1/experiences", bytes.NewBuffer(body))
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		_, _ = client.Do(req)
+	}
+
+	countOf := func(values []models.FacetValue, value string) int {
+		for _, v := range values {
+			if v.Value != nil && *v.Value == value {
+				return v.Count
+			}
+		}
+		return 0
+	}
+
+	t.Run("Facet counts under current filters", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?source_id=facet_survey&facets=field_id,language,sentiment", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		var result models.SearchExperiencesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		require.Len(t, result.Facets, 3)
+		assert.GreaterOrEqual(t, countOf(result.Facets["field_id"], "facet_q1"), 2)
+		assert.GreaterOrEqual(t, countOf(result.Facets["field_id"], "facet_q2"), 1)
+		assert.GreaterOrEqual(t, countOf(result.Facets["language"], "de"), 1)
+		assert.GreaterOrEqual(t, countOf(result.Facets["sentiment"], "positive"), 2)
+
+		// Facet counts never exceed the total
+		for _, values := range result.Facets {
+			sum := 0
+			for _, v := range values {
+				sum += v.Count
+			}
+			assert.LessOrEqual(t, sum, result.TotalCount)
+		}
+	})
+
+	t.Run("Facets are omitted when not requested", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?source_id=facet_survey", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var result models.SearchExperiencesResponse
+		err = decodeData(resp, &result)
+		require.NoError(t, err)
+
+		assert.Nil(t, result.Facets)
+	})
+
+	t.Run("Invalid facet", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", server.URL+"/v1/experiences/search?facets=field_id,value_text", nil)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
 func TestSearchDateRange(t *testing.T) {
 	server, cleanup := setupTestServer(t)
 	defer cleanup()
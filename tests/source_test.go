@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/api/handlers"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestSources(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var reader *bytes.Buffer
+		if body != nil {
+			data, _ := json.Marshal(body)
+			reader = bytes.NewBuffer(data)
+		} else {
+			reader = &bytes.Buffer{}
+		}
+		req, _ := http.NewRequest(method, server.URL+path, reader)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	externalID := "registry_survey_" + uuid.NewString()
+
+	resp := do("POST", "/v1/sources", map[string]interface{}{
+		"source_type": "formbricks",
+		"external_id": externalID,
+		"name":        "Registry Survey",
+	})
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var source models.Source
+	require.NoError(t, decodeData(resp, &source))
+	resp.Body.Close()
+
+	t.Run("Duplicate source", func(t *testing.T) {
+		resp := do("POST", "/v1/sources", map[string]interface{}{
+			"source_type": "formbricks",
+			"external_id": externalID,
+		})
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+
+	t.Run("Register fields", func(t *testing.T) {
+		resp := do("PUT", fmt.Sprintf("/v1/sources/%s/fields/plan", source.ID), map[string]interface{}{
+			"label":      "What plan are you on?",
+			"field_type": "single_choice",
+			"choices":    []map[string]interface{}{{"id": "free", "label": "Free"}, {"id": "pro", "label": "Pro"}},
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do("GET", fmt.Sprintf("/v1/sources/%s/fields", source.ID), nil)
+		defer resp.Body.Close()
+
+		var fields []models.SourceField
+		require.NoError(t, decodeData(resp, &fields))
+		require.Len(t, fields, 1)
+		assert.Equal(t, "plan", fields[0].FieldID)
+		assert.Len(t, fields[0].Choices, 2)
+	})
+
+	t.Run("Unknown field type", func(t *testing.T) {
+		resp := do("PUT", fmt.Sprintf("/v1/sources/%s/fields/slider", source.ID), map[string]interface{}{
+			"field_type": "slider",
+		})
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("Registered label fills field_label", func(t *testing.T) {
+		resp := do("POST", "/v1/experiences", map[string]interface{}{
+			"source_type": "formbricks",
+			"source_id":   externalID,
+			"field_id":    "plan",
+			"field_type":  "single_choice",
+			"value_text":  "pro",
+		})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		require.NotNil(t, exp.FieldLabel)
+		assert.Equal(t, "What plan are you on?", *exp.FieldLabel)
+	})
+
+	t.Run("Strict mode rejects unknown fields", func(t *testing.T) {
+		record := map[string]interface{}{
+			"source_type": "formbricks",
+			"source_id":   externalID,
+			"field_id":    "plna",
+			"field_type":  "single_choice",
+			"value_text":  "pro",
+		}
+
+		resp := do("POST", "/v1/experiences?strict=true", record)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		require.Len(t, errResp.Details, 1)
+		assert.Equal(t, models.ValidationUnknownField, errResp.Details[0].Code)
+
+		// Without strict mode the record is accepted
+		resp = do("POST", "/v1/experiences", record)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+		// Strict sources always validate
+		resp = do("PATCH", fmt.Sprintf("/v1/sources/%s", source.ID), map[string]interface{}{"strict": true})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp = do("POST", "/v1/experiences", record)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	})
+
+	t.Run("Delete source", func(t *testing.T) {
+		resp := do("DELETE", fmt.Sprintf("/v1/sources/%s", source.ID), nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		resp = do("GET", fmt.Sprintf("/v1/sources/%s", source.ID), nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestImportFormbricksSurvey(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	surveyID := "import_survey_" + uuid.NewString()
+	survey := map[string]interface{}{
+		"id":   surveyID,
+		"name": "Imported Survey",
+		"questions": []map[string]interface{}{
+			{"id": "nps", "type": "nps", "headline": map[string]string{"default": "How likely are you to recommend us?"}},
+			{"id": "reason", "type": "openText", "headline": map[string]string{"default": "Why?", "de": "Warum?"}},
+			{"id": "book", "type": "cta", "headline": map[string]string{"default": "Book a call"}},
+		},
+	}
+	body, _ := json.Marshal(survey)
+
+	req, _ := http.NewRequest("POST", server.URL+"/v1/sources/import/formbricks?strict=true", bytes.NewBuffer(body))
+	req.Header.Set("Authorization", "Bearer "+testAPIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result models.ImportSurveyResponse
+	require.NoError(t, decodeData(resp, &result))
+
+	assert.Equal(t, "formbricks", result.Source.SourceType)
+	assert.Equal(t, surveyID, result.Source.ExternalID)
+	assert.True(t, result.Source.Strict)
+	require.Len(t, result.Fields, 2)
+	assert.Equal(t, "nps", result.Fields[0].FieldID)
+	assert.Equal(t, map[string]string{"de": "Warum?"}, result.Fields[1].Translations)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "book", result.Skipped[0].ID)
+}
@@ -0,0 +1,127 @@
+package tests
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestStreamedResponses(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path, accept string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	sourceID := "stream_" + uuid.NewString()
+	metadata := []map[string]interface{}{
+		{"plan": "pro"},
+		{"plan": "free", "tags": []string{"beta"}},
+		{"region": "eu"},
+	}
+	for i, meta := range metadata {
+		resp := do("POST", "/v1/experiences", "", map[string]interface{}{
+			"source_type": "formbricks",
+			"source_id":   sourceID,
+			"field_id":    "feedback",
+			"field_type":  "text",
+			"value_text":  fmt.Sprintf("Checkout was slow, attempt %d", i),
+			"metadata":    meta,
+		})
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+		var exp models.ExperienceData
+		require.NoError(t, decodeData(resp, &exp))
+		resp.Body.Close()
+		t.Cleanup(func() { do("DELETE", fmt.Sprintf("/v1/experiences/%s", exp.ID), "", nil).Body.Close() })
+	}
+
+	t.Run("List streams CSV with flattened metadata", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences?source_id="+sourceID, "text/csv", nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv; charset=utf-8", resp.Header.Get("Content-Type"))
+
+		rows, err := csv.NewReader(resp.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		header := rows[0]
+		assert.Equal(t, "id", header[0])
+		assert.Contains(t, header, "metadata.plan")
+		assert.Contains(t, header, "metadata.region")
+		assert.Contains(t, header, "metadata.tags")
+		assert.NotContains(t, header, "metadata")
+		for _, row := range rows[1:] {
+			assert.Len(t, row, len(header))
+		}
+	})
+
+	t.Run("List streams NDJSON with sparse fieldsets", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences?fields=value_text&source_id="+sourceID, "application/x-ndjson", nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/x-ndjson", resp.Header.Get("Content-Type"))
+
+		lines := 0
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			assert.Len(t, record, 2)
+			assert.Contains(t, record["value_text"], "Checkout was slow")
+			lines++
+		}
+		require.NoError(t, scanner.Err())
+		assert.Equal(t, 3, lines)
+	})
+
+	t.Run("Search streams every match without pagination", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences/search?query=checkout&pageSize=1&fields=field_id&source_id="+sourceID, "text/csv", nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		rows, err := csv.NewReader(resp.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		assert.Equal(t, []string{"id", "field_id", "rank", "highlight", "similarity"}, rows[0])
+		assert.NotEmpty(t, rows[1][2])
+	})
+
+	t.Run("JSON stays the default", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences?source_id="+sourceID, "application/json, text/csv;q=0.5", nil)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(resp, &experiences))
+		assert.Len(t, experiences, 3)
+	})
+
+	t.Run("Invalid parameters are reported before streaming", func(t *testing.T) {
+		resp := do("GET", "/v1/experiences?fields=value_blob", "text/csv", nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xernobyl/formbricks_worktrial/internal/api/handlers"
+	"github.com/xernobyl/formbricks_worktrial/internal/models"
+)
+
+func TestUpsertExperiences(t *testing.T) {
+	server, cleanup := setupTestServer(t)
+	defer cleanup()
+
+	client := &http.Client{}
+
+	do := func(method, path string, body interface{}) *http.Response {
+		var buf bytes.Buffer
+		if body != nil {
+			require.NoError(t, json.NewEncoder(&buf).Encode(body))
+		}
+		req, _ := http.NewRequest(method, server.URL+path, &buf)
+		req.Header.Set("Authorization", "Bearer "+testAPIKey)
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	sourceID := "upsert_" + uuid.NewString()
+	answer := func(fieldID, responseID string, score float64) map[string]interface{} {
+		return map[string]interface{}{
+			"source_type":  "formbricks",
+			"source_id":    sourceID,
+			"field_id":     fieldID,
+			"field_type":   "nps",
+			"value_number": score,
+			"response_id":  responseID,
+		}
+	}
+
+	// upsert puts records and removes the created ones when the test ends
+	upsert := func(path string, records ...map[string]interface{}) models.UpsertExperiencesResponse {
+		resp := do("PUT", path, records)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		var result models.UpsertExperiencesResponse
+		require.NoError(t, decodeData(resp, &result))
+		for _, record := range result.Data {
+			if record.Result == models.UpsertResultCreated {
+				t.Cleanup(func() { do("DELETE", fmt.Sprintf("/v1/experiences/%s", record.ID), nil).Body.Close() })
+			}
+		}
+		return result
+	}
+
+	first := upsert("/v1/experiences", answer("upsert_nps", "resp-1", 7), answer("upsert_nps", "resp-2", 9))
+
+	t.Run("New natural keys create records", func(t *testing.T) {
+		assert.Equal(t, 2, first.Created)
+		assert.Equal(t, 0, first.Updated)
+		require.Len(t, first.Data, 2)
+		assert.Equal(t, models.UpsertResultCreated, first.Data[0].Result)
+		assert.Equal(t, models.DefaultNaturalKey, first.Data[0].NaturalKey)
+		assert.Equal(t, "resp-2", *first.Data[1].ResponseID)
+	})
+
+	t.Run("Re-syncing updates the same records", func(t *testing.T) {
+		result := upsert("/v1/experiences", answer("upsert_nps", "resp-1", 8), answer("upsert_nps", "resp-3", 10))
+		assert.Equal(t, 1, result.Created)
+		assert.Equal(t, 1, result.Updated)
+		require.Len(t, result.Data, 2)
+		assert.Equal(t, models.UpsertResultUpdated, result.Data[0].Result)
+		assert.Equal(t, first.Data[0].ID, result.Data[0].ID)
+		assert.Equal(t, 8.0, *result.Data[0].ValueNumber)
+		assert.Equal(t, models.UpsertResultCreated, result.Data[1].Result)
+
+		resp := do("GET", "/v1/experiences?source_id="+sourceID, nil)
+		defer resp.Body.Close()
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(resp, &experiences))
+		assert.Len(t, experiences, 3)
+	})
+
+	t.Run("Replacing a record is recorded in its history", func(t *testing.T) {
+		resp := do("GET", fmt.Sprintf("/v1/experiences/%s/history", first.Data[0].ID), nil)
+		defer resp.Body.Close()
+		var history []models.ExperienceHistoryEntry
+		require.NoError(t, decodeData(resp, &history))
+		require.Len(t, history, 2)
+		assert.Equal(t, models.HistoryActionUpdate, history[1].Action)
+	})
+
+	t.Run("A configured key matches on its fields only", func(t *testing.T) {
+		path := "/v1/experiences?key=field_id,source_id,source_type"
+		created := upsert(path, answer("upsert_latest", "resp-1", 3))
+		assert.Equal(t, 1, created.Created)
+		assert.Equal(t, []string{"source_type", "source_id", "field_id"}, created.Data[0].NaturalKey)
+
+		updated := upsert(path, answer("upsert_latest", "resp-2", 5))
+		assert.Equal(t, 1, updated.Updated)
+		assert.Equal(t, created.Data[0].ID, updated.Data[0].ID)
+		assert.Equal(t, "resp-2", *updated.Data[0].ResponseID)
+	})
+
+	t.Run("Records missing a natural key value are rejected", func(t *testing.T) {
+		record := answer("upsert_nps", "", 5)
+		delete(record, "response_id")
+		resp := do("PUT", "/v1/experiences", []interface{}{answer("upsert_nps", "resp-4", 5), record})
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+
+		var errResp handlers.ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+		require.Len(t, errResp.Details, 1)
+		assert.Equal(t, "[1].response_id", errResp.Details[0].Field)
+		assert.Equal(t, models.ValidationRequired, errResp.Details[0].Code)
+
+		// No record of the batch was written
+		list := do("GET", "/v1/experiences?response_id=resp-4&source_id="+sourceID, nil)
+		defer list.Body.Close()
+		var experiences []models.ExperienceData
+		require.NoError(t, decodeData(list, &experiences))
+		assert.Empty(t, experiences)
+	})
+
+	t.Run("Unsupported key fields are rejected", func(t *testing.T) {
+		resp := do("PUT", "/v1/experiences?key=source_type,field_id,user_identifier", []interface{}{answer("upsert_nps", "resp-1", 5)})
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+
+	t.Run("Restoring a record whose natural key was upserted again conflicts", func(t *testing.T) {
+		path := "/v1/experiences?key=source_type,source_id,field_id"
+		deleted := upsert(path, answer("upsert_restore", "resp-1", 1))
+		resp := do("DELETE", fmt.Sprintf("/v1/experiences/%s", deleted.Data[0].ID), nil)
+		resp.Body.Close()
+		require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		replacement := upsert(path, answer("upsert_restore", "resp-2", 2))
+		assert.Equal(t, 1, replacement.Created)
+
+		resp = do("POST", fmt.Sprintf("/v1/experiences/%s/restore", deleted.Data[0].ID), nil)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+	})
+}